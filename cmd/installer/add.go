@@ -0,0 +1,215 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/colors"
+	"github.com/Abhaythakor/dev-tools-installer/internal/config"
+	"github.com/Abhaythakor/dev-tools-installer/internal/installer"
+	"golang.org/x/term"
+)
+
+// methodTemplates lists the templates offered by add
+var methodTemplates = []string{"go", "apt", "brew", "github", "custom"}
+
+// stringList is a repeatable string flag
+type stringList []string
+
+func (l *stringList) String() string     { return strings.Join(*l, ", ") }
+func (l *stringList) Set(s string) error { *l = append(*l, s); return nil }
+
+// prompter asks for missing values when stdin is a terminal
+type prompter struct {
+	reader      *bufio.Reader
+	interactive bool
+}
+
+// newPrompter creates a prompter reading from stdin
+func newPrompter() *prompter {
+	return &prompter{
+		reader:      bufio.NewReader(os.Stdin),
+		interactive: term.IsTerminal(int(os.Stdin.Fd())),
+	}
+}
+
+// ask returns value, or prompts for one when it is empty and stdin is interactive
+func (p *prompter) ask(value *string, label, fallback string) {
+	if *value != "" || !p.interactive {
+		if *value == "" {
+			*value = fallback
+		}
+		return
+	}
+
+	if fallback != "" {
+		fmt.Printf("%s?%s %s [%s]: ", colors.Blue, colors.Reset, label, fallback)
+	} else {
+		fmt.Printf("%s?%s %s: ", colors.Blue, colors.Reset, label)
+	}
+	line, _ := p.reader.ReadString('\n')
+	if *value = strings.TrimSpace(line); *value == "" {
+		*value = fallback
+	}
+}
+
+// splitName separates a leading positional name from the flags that follow it
+func splitName(args []string) (string, []string) {
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		return args[0], args[1:]
+	}
+	return "", args
+}
+
+// runAdd appends a tool to the config file, preserving the rest of the file
+func runAdd(inst *installer.Installer, args []string) error {
+	name, args := splitName(args)
+
+	flags := flag.NewFlagSet("add", flag.ExitOnError)
+	template := flags.String("template", "", "method template: "+strings.Join(methodTemplates, ", "))
+	module := flags.String("module", "", "module path for the go template (e.g. github.com/owner/tool/cmd/tool)")
+	pkg := flags.String("package", "", "package name for the apt and brew templates")
+	repo := flags.String("repo", "", "owner/name for the github template")
+	asset := flags.String("asset", "", "release asset glob for the github template (supports ${version}, ${os}, ${arch})")
+	var commands stringList
+	flags.Var(&commands, "command", "install command for the custom template (repeatable)")
+	versionFlag := flags.String("version-flag", "", "flag that prints the tool's version")
+	pin := flags.String("version", "", "pin the tool to a version")
+	install := flags.Bool("install", false, "install the tool after adding it")
+	fromRecipe := flags.String("from-recipe", "", "copy the `tool`'s recipe from the recipe index")
+	flags.Parse(args)
+	if name == "" {
+		name = flags.Arg(0)
+	}
+	if name == "" {
+		name = *fromRecipe
+	}
+
+	p := newPrompter()
+	p.ask(&name, "Tool name", "")
+	if name == "" {
+		return configError{fmt.Errorf("usage: installer add <tool> [flags]")}
+	}
+	// Tool names are lowercase, as the config requires
+	name = strings.ToLower(name)
+
+	doc, err := config.LoadDocument(configPath)
+	if err != nil {
+		return err
+	}
+	if doc.HasTool(name) {
+		return fmt.Errorf("tool %s already exists in %s", name, configPath)
+	}
+
+	var tool *config.ToolConfig
+	if *fromRecipe != "" {
+		if tool, err = inst.Recipe(*fromRecipe); err != nil {
+			return err
+		}
+		// Flags given on the command line override the recipe
+		if *versionFlag != "" {
+			tool.VersionFlag = *versionFlag
+		}
+		if *pin != "" {
+			tool.Version = strings.TrimPrefix(*pin, "v")
+		}
+	} else {
+		tool = &config.ToolConfig{}
+		p.ask(template, "Method template ("+strings.Join(methodTemplates, "/")+")", "custom")
+		switch *template {
+		case "go":
+			p.ask(module, "Go module path", "")
+			if *module == "" {
+				return fmt.Errorf("the go template needs --module")
+			}
+			version := "latest"
+			if *pin != "" {
+				version = "v${version}"
+			}
+			tool.Dependencies = []string{"go"}
+			tool.Methods = []config.InstallMethod{{Name: "go", Commands: config.RunCommands("go install -v " + *module + "@" + version)}}
+		case "apt":
+			p.ask(pkg, "apt package", name)
+			tool.Methods = []config.InstallMethod{{Name: "apt", Commands: config.RunCommands("sudo apt-get update", "sudo apt-get install -y "+*pkg)}}
+		case "brew":
+			p.ask(pkg, "Homebrew formula", name)
+			tool.Methods = []config.InstallMethod{{Name: "brew", Commands: config.RunCommands("brew install " + *pkg)}}
+		case "github":
+			p.ask(repo, "GitHub repository (owner/name)", "")
+			p.ask(asset, "Release asset glob (empty to pick the asset matching the platform)", "")
+			tool.Methods = []config.InstallMethod{{Name: "github release", Type: config.MethodGithubRelease, Repo: *repo, Asset: *asset}}
+		case "custom":
+			if len(commands) == 0 {
+				var command string
+				p.ask(&command, "Install command", "")
+				if command != "" {
+					commands = append(commands, command)
+				}
+			}
+			tool.Methods = []config.InstallMethod{{Name: "custom", Commands: config.RunCommands(commands...)}}
+		default:
+			return fmt.Errorf("unknown template %q (choose from %s)", *template, strings.Join(methodTemplates, ", "))
+		}
+
+		p.ask(versionFlag, "Version flag (empty to auto-detect)", "")
+		p.ask(pin, "Pinned version (empty for none)", "")
+		tool.VersionFlag = *versionFlag
+		tool.Version = strings.TrimPrefix(*pin, "v")
+	}
+
+	if err := doc.AddTool(name, tool); err != nil {
+		return err
+	}
+	cfg, err := doc.Config()
+	if err != nil {
+		return err
+	}
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("%v (config not modified)", err)
+	}
+	if err := doc.Save(); err != nil {
+		return err
+	}
+	fmt.Printf("%s✓ Added %s to %s%s\n", colors.Green, name, configPath, colors.Reset)
+
+	if !*install {
+		return nil
+	}
+	cfg, err = config.LoadConfig(configPath)
+	if err != nil {
+		return err
+	}
+	inst = installer.New(cfg)
+	inst.Options.Only = []string{name}
+	return inst.Run()
+}
+
+// runRemove deletes a tool and its tool_list entries from the config file
+func runRemove(inst *installer.Installer, args []string) error {
+	if len(args) != 1 {
+		return configError{fmt.Errorf("usage: installer remove <tool>")}
+	}
+
+	doc, err := config.LoadDocument(configPath)
+	if err != nil {
+		return err
+	}
+	if err := doc.RemoveTool(args[0]); err != nil {
+		return err
+	}
+	cfg, err := doc.Config()
+	if err != nil {
+		return err
+	}
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("%v (config not modified)", err)
+	}
+	if err := doc.Save(); err != nil {
+		return err
+	}
+	fmt.Printf("%s✓ Removed %s from %s%s\n", colors.Green, args[0], configPath, colors.Reset)
+	return nil
+}
@@ -0,0 +1,427 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/colors"
+	"github.com/Abhaythakor/dev-tools-installer/internal/config"
+	"github.com/Abhaythakor/dev-tools-installer/internal/installer"
+)
+
+// runInstall checks all tools and installs the missing ones
+func runInstall(inst *installer.Installer, args []string) error {
+	flags := flag.NewFlagSet("install", flag.ExitOnError)
+	flags.BoolVar(&inst.Options.Fix, "fix", false, "reinstall pinned tools whose installed version drifted")
+	flags.StringVar(&inst.Options.ReportPath, "report", "", "write a JSON report to `file`")
+	dryRun := flags.Bool("dry-run", false, "print the plan and commands without installing")
+	flags.BoolVar(&inst.Options.ShowScripts, "show-scripts", false, "print the content of script methods in the --dry-run plan")
+	concurrency := flags.Int("concurrency", 1, "number of tools to install at once")
+	verbosityFlags(flags, &inst.Options.Verbosity)
+	flags.BoolVar(&inst.Options.Force, "force", false, "install present tools again, only the named ones when tools follow, even when there is not enough disk space")
+	flags.BoolVar(&inst.Options.SkipPreflight, "skip-preflight", false, "skip the connectivity check before installing")
+	flags.BoolVar(&inst.Options.KeepTemp, "keep-temp", false, "keep the temporary directory of the run and print its path")
+	flags.StringVar(&inst.Options.Record, "record", "", "record the commands of the run into `file`")
+	flags.StringVar(&inst.Options.Replay, "replay", "", "replay the commands recorded in `file` instead of running them")
+	prefer := flags.String("prefer", "", "comma-separated method names or types to try first, ahead of preferred_methods")
+	flags.StringVar(&inst.Options.Channel, "channel", "", "install from the methods of this `channel`, e.g. nightly, falling back to unlabeled ones; overrides default_channel")
+	flags.BoolVar(&inst.Options.IncludeDisabled, "include-disabled", false, "process tools marked disabled")
+	flags.BoolVar(&inst.Options.NoCache, "no-cache", false, "query the GitHub API without using cached responses")
+	flags.DurationVar(&inst.Options.Budget, "budget", 0, "stop starting installs after this `duration`, e.g. 10m")
+	flags.BoolVar(&inst.Options.BudgetHard, "budget-hard", false, "also cancel in-flight installs when the budget runs out")
+	maxDownload := flags.String("max-download-size", "", "refuse to start when the downloads of the run are estimated above this `size`, e.g. 500MB, and stop downloading once it is reached; overrides downloads.max_size")
+	flags.StringVar(&inst.Options.PathSnippet, "path-snippet", "", "write the PATH line for installed tools to `file` to source")
+	flags.BoolVar(&inst.Options.Porcelain, "porcelain", false, "print one tab-separated line per tool: name, status, version, method")
+	flags.BoolVar(&inst.Options.PrintFailed, "print-failed", false, "print the tools that failed on stdout, one per line, and everything else on stderr")
+	flags.BoolVar(&inst.Options.InsecureConfig, "insecure-config", false, "install although other users can change the config or its scripts and the run uses root")
+	flags.BoolVar(&inst.Options.RetryFailed, "retry-failed", false, "install only the tools that failed at their last install, plus tools added to tool_list since; named tools narrow it down")
+	flags.BoolVar(&inst.Options.NoBatch, "no-batch", false, "run the package manager command of each tool instead of one command for several tools")
+	flags.Var(progressFlag{&inst.Options.Progress}, "progress", "show progress as `box`, the check table, or as line, a single status line")
+	group := flags.String("group", "", "limit the run to the tools of this `group`")
+	flags.Parse(args)
+	inst.Options.Prefer = splitList(*prefer)
+	if *maxDownload != "" {
+		size, err := config.ParseSize(*maxDownload)
+		if err != nil {
+			return configError{fmt.Errorf("--max-download-size: %v", err)}
+		}
+		inst.Options.MaxDownloadSize = size
+	}
+	opts := []installer.Option{installer.WithConcurrency(*concurrency)}
+	if *dryRun {
+		opts = append(opts, installer.WithDryRun())
+	}
+	if err := inst.Apply(opts...); err != nil {
+		return configError{err}
+	}
+	if inst.Options.Porcelain && inst.Options.DryRun {
+		return configError{fmt.Errorf("--porcelain cannot be combined with --dry-run")}
+	}
+	if inst.Options.Porcelain && inst.Options.Progress == installer.ProgressLine {
+		return configError{fmt.Errorf("--porcelain cannot be combined with --progress=line")}
+	}
+	if inst.Options.Porcelain && inst.Options.PrintFailed {
+		return configError{fmt.Errorf("--porcelain cannot be combined with --print-failed")}
+	}
+	// Named tools limit the run to them, or with --force are the ones installed again
+	if patterns := selectionArgs(flags.Args(), *group); config.IsSelection(patterns) {
+		sel, err := inst.Select(patterns)
+		if err != nil {
+			return err
+		}
+		if inst.Options.Force {
+			inst.Options.Reinstall = sel.Entries
+		}
+	} else if flags.NArg() > 0 {
+		if err := inst.AddEntries(flags.Args()); err != nil {
+			return err
+		}
+		if inst.Options.Force {
+			inst.Options.Reinstall = flags.Args()
+		} else {
+			inst.Options.Only = flags.Args()
+		}
+	}
+	return inst.Run()
+}
+
+// runReinstall installs tools again although they are present, running their
+// uninstall_commands first
+func runReinstall(inst *installer.Installer, args []string) error {
+	flags := flag.NewFlagSet("reinstall", flag.ExitOnError)
+	flags.StringVar(&inst.Options.ReportPath, "report", "", "write a JSON report to `file`")
+	concurrency := flags.Int("concurrency", 1, "number of tools to install at once")
+	flags.StringVar(&inst.Options.Channel, "channel", "", "install from the methods of this `channel`, e.g. nightly, falling back to unlabeled ones; overrides default_channel")
+	verbosityFlags(flags, &inst.Options.Verbosity)
+	flags.Var(progressFlag{&inst.Options.Progress}, "progress", "show progress as `box`, the check table, or as line, a single status line")
+	flags.BoolVar(&inst.Options.PrintFailed, "print-failed", false, "print the tools that failed on stdout, one per line, and everything else on stderr")
+	flags.BoolVar(&inst.Options.InsecureConfig, "insecure-config", false, "install although other users can change the config or its scripts and the run uses root")
+	flags.Parse(args)
+	if flags.NArg() == 0 {
+		return configError{fmt.Errorf("usage: installer reinstall [flags] <tool>...")}
+	}
+	if err := inst.Apply(installer.WithConcurrency(*concurrency)); err != nil {
+		return configError{err}
+	}
+	if err := inst.CheckEntries(flags.Args()); err != nil {
+		return err
+	}
+	inst.Options.Force, inst.Options.UninstallFirst = true, true
+	inst.Options.Reinstall, inst.Options.Only = flags.Args(), flags.Args()
+	return inst.Run()
+}
+
+// runVerify checks all tools without installing and fails on missing or drifted tools
+func runVerify(inst *installer.Installer, args []string) error {
+	flags := flag.NewFlagSet("verify", flag.ExitOnError)
+	flags.StringVar(&inst.Options.ReportPath, "report", "", "write a JSON report to `file`")
+	flags.BoolVar(&inst.Options.Integrity, "integrity", false, "fail when a binary changed since it was installed")
+	flags.StringVar(&inst.Options.Record, "record", "", "record the commands of the run into `file`")
+	flags.StringVar(&inst.Options.Replay, "replay", "", "replay the commands recorded in `file` instead of running them")
+	flags.BoolVar(&inst.Options.IncludeDisabled, "include-disabled", false, "verify tools marked disabled")
+	flags.BoolVar(&inst.Options.Porcelain, "porcelain", false, "print one tab-separated line per tool: name, status, version, method")
+	flags.BoolVar(&inst.Options.PrintFailed, "print-failed", false, "print the tools that are missing or drifted on stdout, one per line, and everything else on stderr")
+	verbosityFlags(flags, &inst.Options.Verbosity)
+	flags.Parse(args)
+	if inst.Options.Porcelain && inst.Options.PrintFailed {
+		return configError{fmt.Errorf("--porcelain cannot be combined with --print-failed")}
+	}
+	return inst.Verify()
+}
+
+// runStatus prints a one-word summary of the tools for shell prompts, exiting like verify when
+// tools are missing or drifted and with exitStale when the last full check cannot be trusted
+func runStatus(inst *installer.Installer, args []string) error {
+	flags := flag.NewFlagSet("status", flag.ExitOnError)
+	fast := flags.Bool("fast", false, "report from the state file alone, without checking any tool")
+	maxAge := flags.Duration("max-age", 0, "report stale when the last full verify or install is older than this `duration`, e.g. 24h")
+	details := flags.Bool("details", false, "also print the config and git revision the last check and the last successful install used")
+	flags.Parse(args)
+	if !*fast {
+		started := time.Now()
+		inst.Options.Verbosity = installer.VerbositySilent
+		err := inst.Verify()
+		if checked := inst.Status(0).Checked; err != nil && (checked == nil || checked.Before(started)) {
+			return err
+		}
+	}
+	status := inst.Status(*maxAge)
+	fmt.Println(status.Token())
+	if *details {
+		printStatusDetails(status)
+	}
+	switch {
+	case status.Stale != "":
+		os.Exit(exitStale)
+	case len(status.Missing) > 0:
+		os.Exit(exitVerify)
+	case len(status.Drifted) > 0:
+		os.Exit(exitDrift)
+	}
+	return nil
+}
+
+// printStatusDetails prints why the status is stale and the configs behind it, one
+// "label: value" line each after the status word
+func printStatusDetails(status installer.Status) {
+	if status.Stale != "" {
+		fmt.Printf("stale: %s\n", status.Stale)
+	}
+	for _, check := range []struct {
+		label  string
+		record *installer.CheckRecord
+	}{{"last check", status.Check}, {"last successful install", status.LastInstall}} {
+		if check.record == nil {
+			fmt.Printf("%s: none recorded\n", check.label)
+			continue
+		}
+		source := check.record.ConfigSource
+		fmt.Printf("%s: %s %s\n", check.label, check.record.Command, check.record.Time.Local().Format("2006-01-02 15:04:05"))
+		fmt.Printf("  config: %s\n", orUnknown(source.ConfigPath))
+		fmt.Printf("  sha256: %s\n", orUnknown(source.ConfigSHA256))
+		switch {
+		case source.ConfigCommit == "":
+			fmt.Printf("  commit: none (not in a git work tree)\n")
+		case source.ConfigDirty:
+			fmt.Printf("  commit: %s (dirty)\n", source.ConfigCommit)
+		default:
+			fmt.Printf("  commit: %s\n", source.ConfigCommit)
+		}
+	}
+}
+
+// orUnknown returns s, or "unknown" when s is empty, as for records older than the field
+func orUnknown(s string) string {
+	if s == "" {
+		return "unknown"
+	}
+	return s
+}
+
+// runUse switches the active version of a side-by-side tool
+func runUse(inst *installer.Installer, args []string) error {
+	if len(args) != 2 {
+		return configError{fmt.Errorf("usage: installer use <tool> <version>")}
+	}
+	return inst.Use(args[0], args[1])
+}
+
+// runUninstall removes a tool or one of its versions
+func runUninstall(inst *installer.Installer, args []string) error {
+	if len(args) != 1 {
+		return configError{fmt.Errorf("usage: installer uninstall <tool>[@version]")}
+	}
+	return inst.Uninstall(args[0])
+}
+
+// runRollback restores the previous binary of a managed tool
+func runRollback(inst *installer.Installer, args []string) error {
+	if len(args) != 1 {
+		return configError{fmt.Errorf("usage: installer rollback <tool>")}
+	}
+	_, err := inst.Rollback(args[0])
+	return err
+}
+
+// runPrune removes installs that are no longer referenced by the config
+func runPrune(inst *installer.Installer, args []string) error {
+	pruned, err := inst.Prune()
+	if err != nil {
+		return err
+	}
+	if len(pruned) == 0 {
+		fmt.Println("Nothing to prune")
+	}
+	return nil
+}
+
+// runWatch verifies the toolset periodically, loading the config itself so an invalid one is
+// retried instead of ending the process
+func runWatch(_ *installer.Installer, args []string) error {
+	flags := flag.NewFlagSet("watch", flag.ExitOnError)
+	interval := flags.Duration("interval", 6*time.Hour, "time between verification cycles")
+	autoFix := flags.Bool("auto-fix", false, "install missing tools and reinstall drifted ones")
+	flags.Parse(args)
+
+	load := func() (*config.InstallerConfig, error) { return config.LoadConfig(configPath) }
+	cfg, err := load()
+	for backoff := installer.WatchBackoff; err != nil; backoff = min(backoff*2, *interval) {
+		fmt.Printf("%s⚠ %v; retrying in %s%s\n", colors.Yellow, err, backoff, colors.Reset)
+		time.Sleep(backoff)
+		cfg, err = load()
+	}
+	// A cycle coinciding with a manual run waits for it rather than failing
+	inst := installer.New(cfg)
+	inst.Options.WaitLock = true
+	return inst.Watch(*interval, *autoFix, load)
+}
+
+// runPlan prints what an install run would do for each tool without running it
+func runPlan(inst *installer.Installer, args []string) error {
+	flags := flag.NewFlagSet("plan", flag.ExitOnError)
+	asJSON := flags.Bool("json", false, "print the plan as JSON")
+	flags.BoolVar(&inst.Options.Fix, "fix", false, "plan upgrades for drifted pinned tools")
+	prefer := flags.String("prefer", "", "comma-separated method names or types to try first, ahead of preferred_methods")
+	flags.StringVar(&inst.Options.Channel, "channel", "", "install from the methods of this `channel`, e.g. nightly, falling back to unlabeled ones; overrides default_channel")
+	flags.BoolVar(&inst.Options.IncludeDisabled, "include-disabled", false, "plan tools marked disabled")
+	group := flags.String("group", "", "plan only the tools of this `group`")
+	explain := flags.Bool("explain", false, "show the tools the selection resolved to and the rule that left out each other tool")
+	flags.Parse(args)
+	inst.Options.Prefer = splitList(*prefer)
+	if *asJSON && *explain {
+		return configError{fmt.Errorf("--explain cannot be combined with --json")}
+	}
+	patterns := selectionArgs(flags.Args(), *group)
+	if len(patterns) > 0 || *explain {
+		if len(patterns) == 0 {
+			patterns = []string{"@" + config.AllGroup}
+		}
+		sel, err := inst.Select(patterns)
+		if err != nil {
+			return err
+		}
+		if *explain {
+			inst.PrintSelection(patterns, sel)
+		}
+	}
+
+	if *asJSON {
+		plan := inst.BuildPlan()
+		inst.EstimateDownloads(plan)
+		data, err := json.MarshalIndent(plan, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+	inst.PrintPlan()
+	return nil
+}
+
+// selectionArgs returns the tools a command names, with the group of --group first
+func selectionArgs(args []string, group string) []string {
+	if group == "" {
+		return args
+	}
+	return append([]string{"@" + group}, args...)
+}
+
+// splitList splits a comma-separated flag value, dropping empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// runDoctor checks that the machine can run the configured installs
+func runDoctor(inst *installer.Installer, args []string) error {
+	return inst.Doctor()
+}
+
+// runWhy explains the status of one tool
+func runWhy(inst *installer.Installer, args []string) error {
+	if len(args) != 1 {
+		return configError{fmt.Errorf("usage: installer why <tool>")}
+	}
+	return inst.Why(args[0])
+}
+
+// runInfo prints everything known about one tool
+func runInfo(inst *installer.Installer, args []string) error {
+	flags := flag.NewFlagSet("info", flag.ExitOnError)
+	asJSON := flags.Bool("json", false, "print the info as JSON")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		return configError{fmt.Errorf("usage: installer info [--json] <tool>")}
+	}
+	if *asJSON {
+		return inst.InfoJSON(flags.Arg(0))
+	}
+	return inst.Info(flags.Arg(0))
+}
+
+// runEnv prints the expansion context of method commands, for all tools or one of them
+func runEnv(inst *installer.Installer, args []string) error {
+	flags := flag.NewFlagSet("env", flag.ExitOnError)
+	asJSON := flags.Bool("json", false, "print the context as JSON")
+	flags.Parse(args)
+	if flags.NArg() > 1 {
+		return configError{fmt.Errorf("usage: installer env [--json] [tool]")}
+	}
+	if *asJSON {
+		return inst.ContextJSON(flags.Arg(0))
+	}
+	return inst.PrintContext(flags.Arg(0))
+}
+
+// runSearch finds tools in the config and the recipe index by name, command or description
+func runSearch(inst *installer.Installer, args []string) error {
+	if len(args) != 1 {
+		return configError{fmt.Errorf("usage: installer search <query>")}
+	}
+	return inst.PrintSearch(args[0])
+}
+
+// runList prints the configured tools and the commands they provide
+func runList(inst *installer.Installer, args []string) error {
+	if len(args) > 1 {
+		return configError{fmt.Errorf("usage: installer list [command]")}
+	}
+	filter := ""
+	if len(args) == 1 {
+		filter = args[0]
+	}
+	return inst.List(filter)
+}
+
+// runHistory prints recent runs or one tool's timeline
+func runHistory(inst *installer.Installer, args []string) error {
+	flags := flag.NewFlagSet("history", flag.ExitOnError)
+	limit := flags.Int("n", 20, "number of entries to show")
+	flags.Parse(args)
+	if flags.NArg() > 1 {
+		return configError{fmt.Errorf("usage: installer history [-n count] [tool]")}
+	}
+	return inst.PrintHistory(flags.Arg(0), *limit)
+}
+
+// runDiff compares another config against the loaded one and the installed state
+func runDiff(inst *installer.Installer, args []string) error {
+	flags := flag.NewFlagSet("diff", flag.ExitOnError)
+	asJSON := flags.Bool("json", false, "print the diff as JSON")
+	flags.BoolVar(&inst.Options.Fix, "fix", false, "plan upgrades for drifted pinned tools")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		return configError{fmt.Errorf("usage: installer diff [--json] <config>")}
+	}
+
+	other, err := config.LoadConfig(flags.Arg(0))
+	if err != nil {
+		return configError{err}
+	}
+	diff := inst.Diff(other)
+	if *asJSON {
+		return diff.PrintJSON(os.Stdout)
+	}
+	diff.Print(os.Stdout)
+	return nil
+}
+
+// runSchema prints the JSON Schema for the config file
+func runSchema(_ *installer.Installer, args []string) error {
+	schema, err := config.Schema()
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(schema)
+	return err
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/colors"
+	"github.com/Abhaythakor/dev-tools-installer/internal/config"
+	"github.com/Abhaythakor/dev-tools-installer/internal/installer"
+)
+
+// runDiscover finds the tools installed on this machine and writes a config for them
+func runDiscover(_ *installer.Installer, args []string) error {
+	flags := flag.NewFlagSet("discover", flag.ExitOnError)
+	output := flags.String("o", "", "write the config to `file` instead of stdout")
+	merge := flags.Bool("merge", false, "add the discovered tools to the --config file instead")
+	flags.Parse(args)
+
+	// The config goes to stdout, so progress and the summary go to stderr
+	log := os.Stderr
+	fmt.Fprintf(log, "%sLooking for installed tools...%s\n", colors.Gray, colors.Reset)
+	found := installer.New(&config.InstallerConfig{}).Discover()
+
+	var toolList, unknown []string
+	tools := map[string]*config.ToolConfig{}
+	for _, tool := range found {
+		toolConfig := tool.ToolConfig()
+		if toolConfig == nil {
+			unknown = append(unknown, fmt.Sprintf("%s (%s)", tool.Name, tool.Path))
+			continue
+		}
+		toolList = append(toolList, tool.Name)
+		tools[tool.Name] = toolConfig
+		version := tool.Version
+		if version == "" {
+			version = "version unknown"
+		}
+		fmt.Fprintf(log, "%s✓ %-16s%s %s, %s %s\n", colors.Green, tool.Name, colors.Reset, version, tool.Method, tool.Package)
+	}
+
+	if *merge {
+		if err := mergeTools(toolList, tools, log); err != nil {
+			return err
+		}
+	} else {
+		data, err := config.MarshalTools(toolList, tools)
+		if err != nil {
+			return err
+		}
+		if *output == "" {
+			_, err = os.Stdout.Write(data)
+		} else if err = writeConfigFile(*output, data); err == nil {
+			fmt.Fprintf(log, "%s✓ Wrote %d tools to %s%s\n", colors.Green, len(toolList), *output, colors.Reset)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(unknown) > 0 {
+		fmt.Fprintf(log, "\n%sFound but not recognized, add these by hand:%s\n", colors.Yellow, colors.Reset)
+		for _, tool := range unknown {
+			fmt.Fprintf(log, "  %s\n", tool)
+		}
+	}
+	return nil
+}
+
+// mergeTools adds the tools missing from the --config file to it
+func mergeTools(toolList []string, tools map[string]*config.ToolConfig, log io.Writer) error {
+	doc, err := config.LoadDocument(configPath)
+	if err != nil {
+		return err
+	}
+	added := 0
+	for _, name := range toolList {
+		if doc.HasTool(name) {
+			continue
+		}
+		if err := doc.AddTool(name, tools[name]); err != nil {
+			return err
+		}
+		added++
+	}
+	cfg, err := doc.Config()
+	if err != nil {
+		return err
+	}
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("%v (config not modified)", err)
+	}
+	if err := doc.Save(); err != nil {
+		return err
+	}
+	fmt.Fprintf(log, "%s✓ Added %d tools to %s (%d already present)%s\n", colors.Green, added, configPath, len(toolList)-added, colors.Reset)
+	return nil
+}
+
+// writeConfigFile writes a new config, creating its directory when missing
+func writeConfigFile(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/installer"
+)
+
+// Exit statuses of the installer
+const (
+	exitOK          = 0
+	exitFailure     = 1
+	exitConfig      = 2
+	exitVerify      = 3
+	exitDrift       = 4
+	exitStale       = 5
+	exitBudget      = 6
+	exitInsecure    = 7
+	exitInterrupted = 130
+)
+
+// exitStatusInfo describes an exit status for help exit-codes
+type exitStatusInfo struct {
+	code    int
+	meaning string
+	cause   error // Errors wrapping cause exit with code; nil for statuses set otherwise
+}
+
+// exitStatuses is the source of both the statuses commands exit with and the table help
+// exit-codes prints, so the two cannot drift apart
+var exitStatuses = []exitStatusInfo{
+	{exitOK, "success", nil},
+	{exitFailure, "a tool failed to install, or the command failed", installer.ErrInstallFailed},
+	{exitConfig, "the config or the command line is invalid", errConfig},
+	{exitVerify, "verify or status found missing tools, or binaries changed since they were installed", installer.ErrVerifyFailed},
+	{exitDrift, "verify or status found tools whose version differs from the pin, and none missing", installer.ErrDrift},
+	{exitStale, "status cannot trust the last full check", nil},
+	{exitBudget, "the time budget ran out and tools were deferred", installer.ErrBudgetExhausted},
+	{exitInsecure, "other users can change the config or its scripts and the install would use root; see --insecure-config", installer.ErrInsecureConfig},
+	{exitInterrupted, "interrupted with Ctrl-C or SIGTERM", installer.ErrInterrupted},
+}
+
+// errConfig is the cause of configError
+var errConfig = errors.New("config error")
+
+// configError marks an invalid config or command line, so that it exits with exitConfig
+type configError struct{ err error }
+
+func (e configError) Error() string        { return e.err.Error() }
+func (e configError) Unwrap() error        { return e.err }
+func (e configError) Is(target error) bool { return target == errConfig }
+
+// exitStatus returns the status a command failing with err exits with
+func exitStatus(err error) int {
+	for _, status := range exitStatuses {
+		if status.cause != nil && errors.Is(err, status.cause) {
+			return status.code
+		}
+	}
+	return exitFailure
+}
+
+// runHelp prints the usage, or the exit status table with exit-codes
+func runHelp(_ *installer.Installer, args []string) error {
+	switch {
+	case len(args) == 0:
+		showUsage()
+	case args[0] == "exit-codes":
+		fmt.Println("Exit statuses:")
+		for _, status := range exitStatuses {
+			fmt.Printf("  %3d  %s\n", status.code, status.meaning)
+		}
+	default:
+		return configError{fmt.Errorf("unknown help topic %q; try installer help exit-codes", args[0])}
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/installer"
+)
+
+func TestExitStatus(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		err  error
+		want int
+	}{
+		{"install failed", fmt.Errorf("%w: 1 of 3 tools failed", installer.ErrInstallFailed), exitFailure},
+		{"config error", configError{errors.New("tool_list: unknown tool")}, exitConfig},
+		{"wrapped config error", fmt.Errorf("add: %w", configError{errors.New("no such method")}), exitConfig},
+		{"verify failed", fmt.Errorf("%w: 2 tools missing", installer.ErrVerifyFailed), exitVerify},
+		{"drift", fmt.Errorf("%w: 1 tool drifted", installer.ErrDrift), exitDrift},
+		{"budget exhausted", fmt.Errorf("%w: 4 tools deferred", installer.ErrBudgetExhausted), exitBudget},
+		{"insecure config", fmt.Errorf("%w: installer.yaml is world-writable", installer.ErrInsecureConfig), exitInsecure},
+		{"interrupted", installer.ErrInterrupted, exitInterrupted},
+		{"install failure reported with a finish error", fmt.Errorf("%w; %v", fmt.Errorf("%w: 1 of 1 tools failed", installer.ErrInstallFailed), "replay: 1 invocations were not recorded"), exitFailure},
+		{"other error", errors.New("failed to write report"), exitFailure},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := exitStatus(tc.err); got != tc.want {
+				t.Errorf("exitStatus(%v) = %d, want %d", tc.err, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestExitStatusesAreDistinct(t *testing.T) {
+	seen := map[int]bool{}
+	for _, status := range exitStatuses {
+		if seen[status.code] {
+			t.Errorf("exit status %d is listed twice", status.code)
+		}
+		seen[status.code] = true
+		if status.cause != nil && exitStatus(status.cause) != status.code {
+			t.Errorf("%v exits with %d, but help exit-codes lists %d", status.cause, exitStatus(status.cause), status.code)
+		}
+	}
+}
+
+func TestHelpExitCodes(t *testing.T) {
+	stdout := os.Stdout
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Stdout = w
+	helpErr := runHelp(nil, []string{"exit-codes"})
+	os.Stdout = stdout
+	w.Close()
+	output, _ := io.ReadAll(r)
+	if helpErr != nil {
+		t.Fatal(helpErr)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(string(output), "\n"), "\n")
+	if lines[0] != "Exit statuses:" || len(lines) != len(exitStatuses)+1 {
+		t.Fatalf("help exit-codes printed %q, want a header and one line per status", output)
+	}
+	for n, status := range exitStatuses {
+		if want := fmt.Sprintf("  %3d  %s", status.code, status.meaning); lines[n+1] != want {
+			t.Errorf("line %d = %q, want %q", n+1, lines[n+1], want)
+		}
+	}
+	if err := runHelp(nil, []string{"exit-status"}); exitStatus(err) != exitConfig {
+		t.Errorf("help exit-status = %v, want a config error", err)
+	}
+}
+
+func TestInvalidCommandLinesExitWithTheConfigStatus(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "installer.yaml")
+	if err := os.WriteFile(path, []byte("tool_list: []\ntools: {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{
+		{"use", "go"},
+		{"uninstall"},
+		{"rollback"},
+		{"why"},
+		{"info"},
+		{"env", "go", "kubectl"},
+		{"search"},
+		{"list", "a", "b"},
+		{"history", "a", "b"},
+		{"diff"},
+		{"diff", filepath.Join(t.TempDir(), "missing.yaml")},
+		{"reinstall"},
+		{"install", "--porcelain", "--dry-run"},
+		{"verify", "--porcelain", "--print-failed"},
+		{"install", "--max-download-size=abc"},
+		{"install", "--concurrency=0"},
+		{"reinstall", "--concurrency=0", "go"},
+		{"plan", "--json", "--explain"},
+		{"add"},
+		{"remove"},
+		{"recipe"},
+		{"recipe", "import"},
+		{"shellenv", "--apply", "--remove"},
+		{"--var", "novalue", "list"},
+		{"--root", filepath.Join(t.TempDir(), "missing"), "list"},
+	} {
+		output, status := runMain(t, append([]string{"--config", path}, args...)...)
+		if status != exitConfig {
+			t.Errorf("installer %s exited with %d, want %d:\n%s", strings.Join(args, " "), status, exitConfig, output)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/colors"
+	"github.com/Abhaythakor/dev-tools-installer/internal/installer"
+	"golang.org/x/term"
+)
+
+// configSearchPaths returns where the config is looked for without --config: installer.yaml
+// in the current directory, then in the user's config directory
+func configSearchPaths() []string {
+	paths := []string{"installer.yaml"}
+	if dir, err := os.UserConfigDir(); err == nil {
+		paths = append(paths, filepath.Join(dir, "dev-tools-installer", "installer.yaml"))
+	}
+	return paths
+}
+
+// findConfig sets configPath to the first of the search paths that exists, unless --config
+// chose it, and returns the paths looked at
+func findConfig(explicit bool) []string {
+	if explicit {
+		return []string{configPath}
+	}
+	paths := configSearchPaths()
+	for _, path := range paths {
+		if _, err := os.Stat(path); err == nil {
+			configPath = path
+			return paths
+		}
+	}
+	// Configs are created where the first search path points
+	configPath = paths[0]
+	return paths
+}
+
+// guidesFirstRun reports whether a missing config should be set up interactively: stdin and
+// stdout are terminals and nothing parses the output
+func guidesFirstRun() bool {
+	return term.IsTerminal(int(os.Stdin.Fd())) && term.IsTerminal(int(os.Stdout.Fd())) &&
+		!porcelain && !splitStreams && verbosity >= installer.VerbosityNormal
+}
+
+// firstRun explains that no config was found at the paths tried. When interactive, it offers
+// to discover the tools installed here or write a starter config, reading the choice from in,
+// and reports whether a config was written; otherwise it prints the commands that do so.
+func firstRun(tried []string, explicit, interactive bool, in io.Reader, out io.Writer) (bool, error) {
+	fmt.Fprintf(out, "%sNo installer config found.%s Looked for:\n", colors.Yellow, colors.Reset)
+	for _, path := range tried {
+		if abs, err := filepath.Abs(path); err == nil {
+			path = abs
+		}
+		fmt.Fprintf(out, "  %s\n", path)
+	}
+
+	prefix := "installer"
+	if explicit {
+		prefix += " --config " + shellQuote(configPath)
+	}
+	choices := []struct{ command, help string }{
+		{"installer discover -o " + shellQuote(configPath), "write a config for the tools installed on this machine"},
+		{prefix + " init", "write a starter config to edit"},
+	}
+	if !interactive {
+		width := max(len(choices[0].command), len(choices[1].command))
+		fmt.Fprintf(out, "\nTo create one, run either of:\n")
+		for _, choice := range choices {
+			fmt.Fprintf(out, "  %s%-*s%s  %s# %s%s\n", colors.Bold, width, choice.command, colors.Reset, colors.Gray, choice.help, colors.Reset)
+		}
+		return false, nil
+	}
+
+	fmt.Fprintf(out, "\nSet one up now?\n")
+	for n, choice := range choices {
+		fmt.Fprintf(out, "  %s%d)%s %s %s(%s)%s\n", colors.Bold, n+1, colors.Reset, choice.help, colors.Gray, choice.command, colors.Reset)
+	}
+	fmt.Fprintf(out, "  %s3)%s quit\n", colors.Bold, colors.Reset)
+	reader := bufio.NewReader(in)
+	for {
+		fmt.Fprintf(out, "%s?%s Choice [1]: ", colors.Blue, colors.Reset)
+		line, readErr := reader.ReadString('\n')
+		answer := strings.TrimSpace(line)
+		if answer == "" && readErr != nil {
+			fmt.Fprintln(out)
+			return false, nil
+		}
+		var err error
+		switch answer {
+		case "", "1":
+			err = runDiscover(nil, []string{"-o", configPath})
+		case "2":
+			err = runInit(nil, nil)
+		case "3", "q", "quit":
+			return false, nil
+		default:
+			fmt.Fprintf(out, "%sPlease answer 1, 2 or 3%s\n", colors.Yellow, colors.Reset)
+			continue
+		}
+		if err != nil {
+			return false, err
+		}
+		fmt.Fprintf(out, "\nReview %s, then run %s%s%s to install its tools\n", configPath, colors.Bold, prefix, colors.Reset)
+		return true, nil
+	}
+}
+
+// shellQuote quotes a path for a command line shown to be copied, when it needs quoting
+func shellQuote(path string) string {
+	if path != "" && !strings.ContainsAny(path, " \t\n'\"\\$`&|;<>()*?[]#~!{}") {
+		return path
+	}
+	return "'" + strings.ReplaceAll(path, "'", `'\''`) + "'"
+}
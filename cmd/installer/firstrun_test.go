@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/colors"
+	"github.com/Abhaythakor/dev-tools-installer/internal/config"
+)
+
+// inTempDir runs a test in a temp directory without a config, with plain output and the
+// home and config directories of the user inside it
+func inTempDir(t *testing.T) string {
+	dir := t.TempDir()
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("HOME", dir)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(dir, "config"))
+	t.Setenv("AppData", filepath.Join(dir, "config"))
+	saved := configPath
+	colors.Set(false)
+	t.Cleanup(func() {
+		os.Chdir(wd)
+		configPath = saved
+		colors.Set(true)
+	})
+	return dir
+}
+
+// userConfig returns the config in the user's config directory
+func userConfig(t *testing.T) string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return filepath.Join(dir, "dev-tools-installer", "installer.yaml")
+}
+
+func TestFindConfig(t *testing.T) {
+	inTempDir(t)
+	user := userConfig(t)
+	tried := findConfig(false)
+	if want := []string{"installer.yaml", user}; strings.Join(tried, "\n") != strings.Join(want, "\n") || configPath != "installer.yaml" {
+		t.Errorf("findConfig = %q with config %q, want %q and the first path", tried, configPath, want)
+	}
+
+	if err := writeConfigFile(user, []byte("tool_list: []\n")); err != nil {
+		t.Fatal(err)
+	}
+	if findConfig(false); configPath != user {
+		t.Errorf("config = %q, want the one in the user's config directory", configPath)
+	}
+	configPath = "elsewhere.yaml"
+	if tried := findConfig(true); len(tried) != 1 || configPath != "elsewhere.yaml" {
+		t.Errorf("findConfig = %q with config %q, want only the --config file", tried, configPath)
+	}
+}
+
+func TestFirstRunWithoutATerminal(t *testing.T) {
+	dir := inTempDir(t)
+	tried := findConfig(false)
+	var out bytes.Buffer
+	created, err := firstRun(tried, false, false, strings.NewReader("1\n"), &out)
+	if err != nil || created {
+		t.Fatalf("firstRun = %v, %v; want nothing created", created, err)
+	}
+	want := "No installer config found. Looked for:\n" +
+		"  " + filepath.Join(dir, "installer.yaml") + "\n" +
+		"  " + userConfig(t) + "\n" +
+		"\nTo create one, run either of:\n" +
+		"  installer discover -o installer.yaml  # write a config for the tools installed on this machine\n" +
+		"  installer init                        # write a starter config to edit\n"
+	if out.String() != want {
+		t.Errorf("output:\n%s\nwant:\n%s", out.String(), want)
+	}
+	if _, err := os.Stat("installer.yaml"); err == nil {
+		t.Error("a config was written without asking")
+	}
+}
+
+func TestFirstRunWithoutATerminalQuotesTheConfigFlag(t *testing.T) {
+	inTempDir(t)
+	configPath = "my configs/installer.yaml"
+	var out bytes.Buffer
+	if _, err := firstRun(findConfig(true), true, false, nil, &out); err != nil {
+		t.Fatal(err)
+	}
+	for _, command := range []string{"installer discover -o 'my configs/installer.yaml'", "installer --config 'my configs/installer.yaml' init"} {
+		if !strings.Contains(out.String(), command) {
+			t.Errorf("output = %q, want the command %s", out.String(), command)
+		}
+	}
+}
+
+func TestFirstRunOnATerminal(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		input   string
+		created bool
+		config  string // Expected config content; "" when none is written
+		prompts int
+	}{
+		{name: "init", input: "2\n", created: true, config: starterConfig, prompts: 1},
+		{name: "discover by default", input: "\n", created: true, config: "tool_list: []\ntools: {}\n", prompts: 1},
+		{name: "quit", input: "3\n", prompts: 1},
+		{name: "end of input", input: "", prompts: 1},
+		{name: "invalid answer", input: "yes\n2\n", created: true, config: starterConfig, prompts: 2},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := inTempDir(t)
+			// Nothing to discover, so that the discovered config does not depend on this machine
+			t.Setenv("PATH", filepath.Join(dir, "bin"))
+			var out bytes.Buffer
+			created, err := firstRun(findConfig(false), false, true, strings.NewReader(tc.input), &out)
+			if err != nil || created != tc.created {
+				t.Fatalf("firstRun = %v, %v; want %v", created, err, tc.created)
+			}
+			for _, line := range []string{
+				"Set one up now?",
+				"  1) write a config for the tools installed on this machine (installer discover -o installer.yaml)",
+				"  2) write a starter config to edit (installer init)",
+				"  3) quit",
+			} {
+				if !strings.Contains(out.String(), line+"\n") {
+					t.Errorf("output = %q, want the line %q", out.String(), line)
+				}
+			}
+			if prompts := strings.Count(out.String(), "? Choice [1]: "); prompts != tc.prompts {
+				t.Errorf("asked %d times, want %d", prompts, tc.prompts)
+			}
+
+			data, err := os.ReadFile("installer.yaml")
+			switch {
+			case tc.config == "" && err == nil:
+				t.Errorf("wrote a config:\n%s", data)
+			case tc.config != "" && string(data) != tc.config:
+				t.Errorf("config = %q, %v; want %q", data, err, tc.config)
+			case tc.config != "" && !strings.Contains(out.String(), "Review installer.yaml, then run installer to install its tools"):
+				t.Errorf("output = %q, want what to do next", out.String())
+			}
+		})
+	}
+}
+
+func TestInit(t *testing.T) {
+	inTempDir(t)
+	configPath = filepath.Join("new", "installer.yaml")
+	if err := runInit(nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(configPath); err != nil || string(data) != starterConfig {
+		t.Fatalf("config = %q, %v; want the starter config", data, err)
+	}
+	if _, err := config.LoadConfig(configPath); err != nil {
+		t.Errorf("the starter config does not load: %v", err)
+	}
+
+	if err := os.WriteFile(configPath, []byte("tool_list: [mine]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := runInit(nil, nil); err == nil || !strings.Contains(err.Error(), "already exists; use --force to overwrite it") {
+		t.Errorf("runInit = %v, want it to refuse overwriting the config", err)
+	}
+	if err := runInit(nil, []string{"--force"}); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(configPath); string(data) != starterConfig {
+		t.Errorf("config = %q after init --force, want the starter config", data)
+	}
+}
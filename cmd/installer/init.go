@@ -0,0 +1,50 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/colors"
+	"github.com/Abhaythakor/dev-tools-installer/internal/installer"
+)
+
+// starterConfig is the config init writes: a few system packages and a commented recipe
+const starterConfig = `# Tools to check and install, in order. Entries without a tools entry below are
+# installed with the system package manager under the same name.
+tool_list:
+  - git
+  - curl
+  - jq
+
+# Recipes for tools that need more than a system package, tried method by method until
+# one succeeds. See https://github.com/Abhaythakor/dev-tools-installer#-configuration
+tools:
+#  subfinder:
+#    methods:
+#      - name: go
+#        commands:
+#          - go install -v github.com/projectdiscovery/subfinder/v2/cmd/subfinder@latest
+#      - name: brew
+#        commands:
+#          - brew install subfinder
+`
+
+// runInit writes a starter config to the --config file
+func runInit(_ *installer.Installer, args []string) error {
+	flags := flag.NewFlagSet("init", flag.ExitOnError)
+	force := flags.Bool("force", false, "overwrite the config file when it exists")
+	flags.Parse(args)
+	if flags.NArg() > 0 {
+		return configError{fmt.Errorf("usage: installer init [--force]")}
+	}
+
+	if _, err := os.Stat(configPath); err == nil && !*force {
+		return fmt.Errorf("%s already exists; use --force to overwrite it", configPath)
+	}
+	if err := writeConfigFile(configPath, []byte(starterConfig)); err != nil {
+		return err
+	}
+	fmt.Fprintf(messages(), "%s✓ Wrote a starter config to %s%s\n", colors.Green, configPath, colors.Reset)
+	return nil
+}
@@ -1,25 +1,483 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
+	"io/fs"
+	"net"
 	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 
+	"github.com/Abhaythakor/dev-tools-installer/internal/colors"
 	"github.com/Abhaythakor/dev-tools-installer/internal/config"
+	"github.com/Abhaythakor/dev-tools-installer/internal/debug"
 	"github.com/Abhaythakor/dev-tools-installer/internal/installer"
 )
 
+// command is a CLI subcommand
+type command struct {
+	name     string
+	args     string
+	help     string
+	run      func(inst *installer.Installer, args []string) error
+	noConfig bool // The command runs without loading the config; inst is nil
+}
+
+// usesHome reports whether a command finds the config, resolves default paths or runs
+// methods, which all need HOME; help and schema do none of that
+func (c *command) usesHome() bool {
+	return c.name != "help" && c.name != "schema"
+}
+
+// commands lists the subcommands in help order
+var commands = []command{
+	{"install", "[flags] [tool[@version]...]", "Check tools and install missing ones (default)", runInstall, false},
+	{"verify", "[flags]", "Check tools without installing; fail on missing or drifted tools", runVerify, false},
+	{"status", "[--fast] [--max-age duration] [--details]", "Print a one-word summary of the tools for shell prompts, e.g. devtools:ok", runStatus, false},
+	{"watch", "[flags]", "Verify tools periodically and optionally repair drift", runWatch, true},
+	{"plan", "[--json] [--fix] [tool...]", "Show whether a run would install, upgrade or skip each tool, and why", runPlan, false},
+	{"diff", "[--json] <config>", "Show what would change when switching to another config", runDiff, false},
+	{"doctor", "", "Check disk space and other prerequisites for the pending installs", runDoctor, false},
+	{"list", "[command]", "List tools, the commands they provide and their descriptions", runList, false},
+	{"search", "<query>", "Find tools by name, command or description", runSearch, false},
+	{"info", "[--json] <tool>", "Show a tool's effective config, methods, installed version and history", runInfo, false},
+	{"why", "<tool>", "Explain a tool's status and what a run would do with it", runWhy, false},
+	{"history", "[-n count] [tool]", "Show recent runs or the timeline of one tool", runHistory, false},
+	{"add", "<tool> [flags]", "Add a tool to the config, prompting for missing details", runAdd, false},
+	{"discover", "[-o file] [--merge]", "Write a config for the tools installed on this machine", runDiscover, true},
+	{"init", "[--force]", "Write a starter config to edit", runInit, true},
+	{"recipe", "export|import <tool|file>", "Share a tool as a recipe file, or merge one into the config", runRecipe, false},
+	{"remove", "<tool>", "Remove a tool from the config", runRemove, false},
+	{"schema", "", "Print a JSON Schema for installer.yaml", runSchema, true},
+	{"use", "<tool> <version>", "Switch the active side-by-side version of a tool", runUse, false},
+	{"uninstall", "<tool>[@version]", "Remove a tool or one of its versions", runUninstall, false},
+	{"reinstall", "[flags] <tool>...", "Install tools again although present, running their uninstall_commands first", runReinstall, false},
+	{"rollback", "<tool>", "Restore the binary the last install of a managed tool replaced", runRollback, false},
+	{"prune", "", "Remove versions and binaries no longer in tool_list", runPrune, false},
+	{"env", "[--json] [tool]", "Print what ${name} references expand to on this machine, and where each value comes from", runEnv, false},
+	{"shellenv", "[--shell sh] [--apply|--remove]", "Print the PATH and shell_init lines of installed tools, or persist them in the rc file", runShellenv, false},
+	{"help", "[exit-codes]", "Show this help, or the exit statuses and what they mean", runHelp, true},
+}
+
+// showUsage prints the global flags and the subcommand list
+var showUsage func()
+
+// configPath is the configuration file selected with --config
+var configPath string
+
+// porcelain is set when the command prints porcelain output; warnings and errors then go
+// to stderr without colors so that stdout holds nothing else
+var porcelain bool
+
+// splitStreams is set when the command prints results on stdout, with --print-failed, --json
+// or --policy-report; warnings and errors then go to stderr, colored as usual
+var splitStreams bool
+
+// verbosity is the level the --quiet and -qq flags of a run request; quiet runs skip config
+// warnings and silent ones print no errors either
+var verbosity installer.Verbosity
+
+// debugFlag is --debug, optionally limited to components as in --debug=exec,version
+type debugFlag struct {
+	set        bool
+	components []string
+}
+
+func (f *debugFlag) String() string   { return "" }
+func (f *debugFlag) IsBoolFlag() bool { return true }
+
+func (f *debugFlag) Set(value string) error {
+	switch value {
+	case "false":
+		f.set = false
+	case "true":
+		f.set, f.components = true, nil
+	default:
+		f.set, f.components = true, debug.Components(value)
+	}
+	return nil
+}
+
+// colorFlag is --color, checked while parsing so that a bad value fails like other flag errors
+type colorFlag struct{ mode string }
+
+func (f *colorFlag) String() string { return f.mode }
+
+func (f *colorFlag) Set(value string) error {
+	if _, err := colors.Enabled(value, false, os.Getenv); err != nil {
+		return errors.New("must be auto, always or never")
+	}
+	f.mode = value
+	return nil
+}
+
+// progressFlag is --progress, setting how an install shows its progress
+type progressFlag struct{ target *string }
+
+func (f progressFlag) String() string {
+	if f.target == nil || *f.target == "" {
+		return installer.ProgressBox
+	}
+	return *f.target
+}
+
+func (f progressFlag) Set(value string) error {
+	if value != installer.ProgressBox && value != installer.ProgressLine {
+		return errors.New("must be box or line")
+	}
+	*f.target = value
+	return nil
+}
+
+// verbosityFlag sets the verbosity of a run to level, for --verbose, --quiet and -qq
+type verbosityFlag struct {
+	target *installer.Verbosity
+	level  installer.Verbosity
+}
+
+func (f verbosityFlag) String() string   { return "" }
+func (f verbosityFlag) IsBoolFlag() bool { return true }
+
+func (f verbosityFlag) Set(value string) error {
+	on, err := strconv.ParseBool(value)
+	if err != nil || !on {
+		return err
+	}
+	// --verbose must not lower the debug level of --debug
+	if f.level > installer.VerbosityNormal {
+		*f.target = max(*f.target, f.level)
+	} else {
+		*f.target = f.level
+	}
+	return nil
+}
+
+// verbosityFlags adds --verbose, --quiet, -q and -qq to the flags of a run
+func verbosityFlags(flags *flag.FlagSet, target *installer.Verbosity) {
+	flags.Var(verbosityFlag{target, installer.VerbosityVerbose}, "verbose", "print each command of a method as it runs")
+	flags.Var(verbosityFlag{target, installer.VerbosityQuiet}, "quiet", "print only failures and the final summary")
+	flags.Var(verbosityFlag{target, installer.VerbosityQuiet}, "q", "shorthand for --quiet")
+	flags.Var(verbosityFlag{target, installer.VerbositySilent}, "qq", "print nothing; the exit status tells how the run went")
+}
+
 func main() {
+	// A panic must not leave a spinner drawing or the cursor hidden
+	defer func() {
+		if r := recover(); r != nil {
+			installer.RestoreTerminal()
+			panic(r)
+		}
+	}()
+
+	var debugOpt debugFlag
+	var logFile, root, eventSocket string
+	var waitLock, policyReport bool
+	var eventFD int
+	var vars stringList
+	colorOpt := colorFlag{colors.Auto}
+	flags := flag.NewFlagSet("installer", flag.ExitOnError)
+	flags.StringVar(&configPath, "config", "installer.yaml", "path to the configuration file")
+	flags.Var(&debugOpt, "debug", "write debug logs to stderr, optionally only for components (--debug=exec,version); also INSTALLER_DEBUG=1")
+	flags.StringVar(&logFile, "log-file", "", "also append debug logs to this file")
+	flags.BoolVar(&waitLock, "wait-lock", false, "wait for another run holding the state directory lock instead of failing")
+	flags.BoolVar(&policyReport, "policy-report", false, "print how the config complies with its security policy as JSON and exit")
+	flags.StringVar(&root, "root", "", "install into the system mounted at this `directory`, e.g. /mnt/target")
+	flags.BoolVar(&config.Lenient, "lenient", false, "warn instead of failing on config problems a run can work around, such as ${version} without a version")
+	flags.IntVar(&eventFD, "event-fd", 0, "write the progress events of runs as JSON lines to this open file `descriptor`")
+	flags.StringVar(&eventSocket, "event-socket", "", "write the progress events of runs as JSON lines to the Unix socket at `path`")
+	flags.Var(&vars, "var", "set the installer variable `name=value` for ${name} references, overriding detected values and the config's vars; repeatable")
+	flags.Var(&colorOpt, "color", "`when` to color output: auto, always or never; auto honors NO_COLOR, CLICOLOR and CLICOLOR_FORCE")
+	flags.Usage = usage(flags)
+	showUsage = flags.Usage
+	flags.Parse(os.Args[1:])
+	if args := flags.Args(); len(args) > 0 {
+		switch args[0] {
+		case "install", "verify", "reinstall":
+			splitStreams = flagRequested(args[1:], "print-failed")
+		case "plan", "diff", "info", "env":
+			splitStreams = flagRequested(args[1:], "json")
+		}
+	}
+	splitStreams = splitStreams || policyReport
+	// Applied before anything is printed, errors included, for the stream output goes to
+	colorOut := os.Stdout
+	if splitStreams {
+		colorOut = os.Stderr
+	}
+	if err := colors.Configure(colorOpt.mode, colorOut); err != nil {
+		fail(err)
+	}
+
+	var logOut io.Writer = os.Stderr
+	if logFile != "" {
+		f, err := os.OpenFile(logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			fail(err)
+		}
+		defer f.Close()
+		logOut = io.MultiWriter(os.Stderr, f)
+	}
+	debugging := debugOpt.set
+	if debugging {
+		debug.Enable(logOut, debugOpt.components...)
+	} else {
+		debugging = debug.EnableFromEnv(logOut)
+	}
+
+	name, args := "install", flags.Args()
+	if len(args) > 0 {
+		name, args = args[0], args[1:]
+	}
+
+	var cmd *command
+	for i := range commands {
+		if commands[i].name == name {
+			cmd = &commands[i]
+		}
+	}
+	if cmd == nil {
+		fmt.Fprintf(messages(), "%sError: unknown command %q%s\n", colors.Red, name, colors.Reset)
+		flags.Usage()
+		os.Exit(exitConfig)
+	}
+
+	porcelain = (name == "install" || name == "verify") && flagRequested(args, "porcelain") || name == "status"
+	switch {
+	case name == "status":
+		verbosity = installer.VerbosityQuiet
+	case name != "install" && name != "verify" && name != "reinstall":
+	case flagRequested(args, "qq"):
+		verbosity = installer.VerbositySilent
+	case flagRequested(args, "quiet", "q"):
+		verbosity = installer.VerbosityQuiet
+	}
+
+	// Default paths and commands need HOME, which systemd units and minimal containers may not set
+	for _, warning := range installer.FillEnvironment() {
+		if cmd.usesHome() {
+			warn(warning)
+		}
+	}
+
+	explicitConfig := false
+	flags.Visit(func(f *flag.Flag) { explicitConfig = explicitConfig || f.Name == "config" })
+	searched := findConfig(explicitConfig)
+
+	if cmd.noConfig {
+		if err := cmd.run(nil, args); err != nil {
+			fail(err)
+		}
+		return
+	}
+
+	// Without a config, explain where it was looked for and how to create one
+	if _, err := os.Stat(configPath); errors.Is(err, fs.ErrNotExist) {
+		out := messages()
+		if porcelain {
+			out = os.Stderr
+		}
+		if verbosity == installer.VerbositySilent {
+			out = io.Discard
+		}
+		created, err := firstRun(searched, explicitConfig, guidesFirstRun(), os.Stdin, out)
+		if err != nil {
+			fail(err)
+		}
+		if !created {
+			os.Exit(exitConfig)
+		}
+		return
+	}
+
 	// Load configuration
-	cfg, err := config.LoadConfig("installer.yaml")
+	cfg, err := config.LoadConfig(configPath)
+	var policy *config.PolicyError
+	if policyReport && errors.As(err, &policy) {
+		err = nil
+	}
 	if err != nil {
-		fmt.Printf("\033[31mError: %v\033[0m\n", err)
-		os.Exit(1)
+		fail(configError{err})
+	}
+	if policyReport {
+		if err := printPolicyReport(cfg); err != nil {
+			fail(err)
+		}
+		return
 	}
+	for _, warning := range cfg.Warnings() {
+		warn(warning)
+	}
+	for _, problem := range cfg.InsecureFiles() {
+		securityWarning(problem + "; whoever can change it can run commands through the installer")
+	}
+
+	// Create installer and run the command. Porcelain, quiet and --print-failed runs choose
+	// what reaches each writer.
+	opts := []installer.Option{installer.WithOutput(os.Stdout), installer.WithDiagnostics(os.Stderr)}
+	if root != "" {
+		if info, err := os.Stat(root); err != nil || !info.IsDir() {
+			fail(configError{fmt.Errorf("--root %s is not a directory", root)})
+		}
+		abs, _ := filepath.Abs(root)
+		opts = append(opts, installer.WithRoot(abs))
+	}
+	inst := installer.New(cfg, opts...)
+	inst.Options.WaitLock = waitLock
+	for _, kv := range vars {
+		name, value, ok := strings.Cut(kv, "=")
+		if !ok {
+			fail(configError{fmt.Errorf("--var %s: expected name=value", kv)})
+		}
+		if err := config.CheckVarName(name); err != nil {
+			fail(configError{fmt.Errorf("--var: %v", err)})
+		}
+		if inst.Options.Vars == nil {
+			inst.Options.Vars = map[string]string{}
+		}
+		inst.Options.Vars[name] = value
+	}
+	if debugging {
+		inst.Options.Verbosity = installer.VerbosityDebug
+	}
+	closeEvents, err := openEventStream(inst, eventFD, eventSocket)
+	if err != nil {
+		fail(err)
+	}
+	err = cmd.run(inst, args)
+	closeEvents()
+	if err != nil {
+		fail(err)
+	}
+}
 
-	// Create and run installer
-	inst := installer.New(cfg)
-	if err := inst.Run(); err != nil {
-		fmt.Printf("\033[31mError: %v\033[0m\n", err)
-		os.Exit(1)
+// openEventStream sends the events of inst to the file descriptor or Unix socket given with
+// --event-fd or --event-socket, returning a function that closes the stream and warns about
+// events the consumer missed
+func openEventStream(inst *installer.Installer, fd int, socket string) (func(), error) {
+	var out io.WriteCloser
+	switch {
+	case fd != 0 && socket != "":
+		return nil, configError{errors.New("--event-fd cannot be combined with --event-socket")}
+	case fd < 0:
+		return nil, configError{fmt.Errorf("--event-fd %d is not a file descriptor", fd)}
+	case fd != 0:
+		f := os.NewFile(uintptr(fd), "event-fd")
+		if _, err := f.Stat(); err != nil {
+			return nil, configError{fmt.Errorf("--event-fd %d is not open", fd)}
+		}
+		out = f
+	case socket != "":
+		conn, err := net.Dial("unix", socket)
+		if err != nil {
+			return nil, fmt.Errorf("--event-socket: %v", err)
+		}
+		out = conn
+	default:
+		return func() {}, nil
+	}
+	stream := installer.NewEventStream(out)
+	if err := inst.Apply(installer.WithEvents(stream)); err != nil {
+		return nil, err
+	}
+	return func() {
+		if dropped := stream.Close(); dropped > 0 {
+			warn(fmt.Sprintf("%d progress events were dropped because the event consumer fell behind or disconnected", dropped))
+		}
+		out.Close()
+	}, nil
+}
+
+// warn prints a warning unless output is quiet, on stderr for porcelain output
+func warn(warning string) {
+	if verbosity < installer.VerbosityNormal {
+		return
+	}
+	if porcelain {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", warning)
+		return
+	}
+	fmt.Fprintf(messages(), "%s⚠ %s%s\n", colors.Yellow, warning, colors.Reset)
+}
+
+// securityWarning prints a warning about a risk to this machine, in quiet runs too
+func securityWarning(warning string) {
+	switch {
+	case verbosity == installer.VerbositySilent:
+	case porcelain:
+		fmt.Fprintf(os.Stderr, "warning: %s\n", warning)
+	default:
+		fmt.Fprintf(messages(), "%s%s⚠ SECURITY: %s%s\n", colors.Red, colors.Bold, warning, colors.Reset)
+	}
+}
+
+// fail restores the terminal, prints err and exits. It is used instead of returning from
+// main because os.Exit skips deferred calls.
+func fail(err error) {
+	installer.RestoreTerminal()
+	switch {
+	case verbosity == installer.VerbositySilent:
+	case porcelain:
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	default:
+		fmt.Fprintf(messages(), "%sError: %v%s\n", colors.Red, err, colors.Reset)
+	}
+	os.Exit(exitStatus(err))
+}
+
+// messages returns the stream warnings and errors are printed on: stdout, unless it holds the
+// results of --print-failed
+func messages() io.Writer {
+	if splitStreams {
+		return os.Stderr
+	}
+	return os.Stdout
+}
+
+// flagRequested reports whether the flags of a command set one of the named boolean flags
+func flagRequested(args []string, names ...string) bool {
+	for _, arg := range args {
+		if arg == "--" {
+			break
+		}
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !strings.HasPrefix(arg, "-") || !slices.Contains(names, name) {
+			continue
+		}
+		on, err := strconv.ParseBool(value)
+		return !hasValue || err == nil && on
+	}
+	return false
+}
+
+// usage prints the global flags and the subcommand list
+func usage(flags *flag.FlagSet) func() {
+	return func() {
+		fmt.Fprintf(flags.Output(), "Usage: installer [--config file] [--root dir] [--color when] <command> [args]\n\nCommands:\n")
+		for _, cmd := range commands {
+			fmt.Fprintf(flags.Output(), "  %-32s %s\n", cmd.name+" "+cmd.args, cmd.help)
+		}
+		fmt.Fprintf(flags.Output(), "\nFlags:\n")
+		flags.PrintDefaults()
+	}
+}
+
+// printPolicyReport prints the compliance of the config with its security policy as JSON,
+// failing like loading the config does when the policy is enforced and broken
+func printPolicyReport(cfg *config.InstallerConfig) error {
+	report := cfg.PolicyReport()
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	if report.Enforced && !report.Compliant {
+		os.Exit(exitConfig)
 	}
+	return nil
 }
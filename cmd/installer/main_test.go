@@ -0,0 +1,65 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// runMain runs the installer's main with args in a child process of the test binary, without
+// HOME and USER, and returns what it printed and its exit status
+func runMain(t *testing.T, args ...string) (string, int) {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], append([]string{"-test.run=^TestMainProcess$", "--"}, args...)...)
+	for _, kv := range os.Environ() {
+		if !strings.HasPrefix(kv, "HOME=") && !strings.HasPrefix(kv, "USER=") {
+			cmd.Env = append(cmd.Env, kv)
+		}
+	}
+	cmd.Env = append(cmd.Env, "INSTALLER_TEST_MAIN=1", "NO_COLOR=1")
+	output, err := cmd.CombinedOutput()
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		t.Fatal(err)
+	}
+	return string(output), cmd.ProcessState.ExitCode()
+}
+
+// TestMainProcess runs main in the child processes of runMain
+func TestMainProcess(t *testing.T) {
+	if os.Getenv("INSTALLER_TEST_MAIN") == "" {
+		t.Skip("only runs as the child process of runMain")
+	}
+	os.Args = append([]string{"installer"}, flag.Args()...)
+	main()
+	os.Exit(0)
+}
+
+func TestHomeWarningsOnlyForCommandsUsingHome(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "installer.yaml")
+	if err := os.WriteFile(path, []byte("tool_list: []\ntools: {}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		args []string
+		warn bool
+	}{
+		{[]string{"help"}, false},
+		{[]string{"help", "exit-codes"}, false},
+		{[]string{"schema"}, false},
+		{[]string{"--config", path, "verify"}, true},
+		{[]string{"--config", path, "list"}, true},
+	} {
+		output, _ := runMain(t, tc.args...)
+		if output == "" {
+			t.Fatalf("installer %s printed nothing", strings.Join(tc.args, " "))
+		}
+		if warned := strings.Contains(output, "HOME is not set"); warned != tc.warn {
+			t.Errorf("installer %s printed:\n%s\nwant a HOME warning %v", strings.Join(tc.args, " "), output, tc.warn)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/colors"
+	"github.com/Abhaythakor/dev-tools-installer/internal/config"
+	"github.com/Abhaythakor/dev-tools-installer/internal/installer"
+)
+
+// runRecipe exports a tool as a recipe file or imports one into the config
+func runRecipe(inst *installer.Installer, args []string) error {
+	if len(args) == 0 {
+		return configError{fmt.Errorf("usage: installer recipe export <tool> | import [--dry-run] <file|url>")}
+	}
+	switch args[0] {
+	case "export":
+		return recipeExport(args[1:])
+	case "import":
+		return recipeImport(inst, args[1:])
+	}
+	return fmt.Errorf("unknown recipe command %q (choose from export, import)", args[0])
+}
+
+// recipeExport prints a tool's config entry as a recipe
+func recipeExport(args []string) error {
+	if len(args) != 1 {
+		return configError{fmt.Errorf("usage: installer recipe export <tool>")}
+	}
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		return err
+	}
+	tool := cfg.Tools[args[0]]
+	if tool == nil {
+		return fmt.Errorf("tool %s is not in %s", args[0], configPath)
+	}
+
+	recipe := &config.Recipe{Name: args[0], ToolConfig: *tool}
+	recipe.Source, recipe.Disabled = "", false
+	data, err := config.MarshalRecipe(recipe)
+	if err != nil {
+		return err
+	}
+	_, err = os.Stdout.Write(data)
+	return err
+}
+
+// recipeImport merges a recipe into the config, showing how it changes an existing entry
+func recipeImport(inst *installer.Installer, args []string) error {
+	flags := flag.NewFlagSet("recipe import", flag.ExitOnError)
+	dryRun := flags.Bool("dry-run", false, "show the changes without writing the config")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		return configError{fmt.Errorf("usage: installer recipe import [--dry-run] <file|url>")}
+	}
+
+	recipe, err := inst.LoadRecipe(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	doc, err := config.LoadDocument(configPath)
+	if err != nil {
+		return err
+	}
+	current, err := doc.Config()
+	if err != nil {
+		return err
+	}
+
+	tool := &recipe.ToolConfig
+	if existing := current.Tools[recipe.Name]; existing != nil {
+		// Whether the tool is disabled is a local decision the recipe doesn't carry
+		tool.Disabled = existing.Disabled
+		before, err := config.MarshalTool(existing)
+		if err != nil {
+			return err
+		}
+		after, err := config.MarshalTool(tool)
+		if err != nil {
+			return err
+		}
+		if string(before) == string(after) {
+			fmt.Printf("%s✓ %s is already up to date%s\n", colors.Green, recipe.Name, colors.Reset)
+			return nil
+		}
+		fmt.Printf("%s%s%s in %s:\n", colors.Bold, recipe.Name, colors.Reset, configPath)
+		printLineDiff(before, after)
+		err = doc.ReplaceTool(recipe.Name, tool)
+	} else {
+		fmt.Printf("%s%s%s is new to %s\n", colors.Bold, recipe.Name, colors.Reset, configPath)
+		err = doc.AddTool(recipe.Name, tool)
+	}
+	if err != nil {
+		return err
+	}
+
+	cfg, err := doc.Config()
+	if err != nil {
+		return err
+	}
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("%v (config not modified)", err)
+	}
+	if *dryRun {
+		return nil
+	}
+	if err := doc.Save(); err != nil {
+		return err
+	}
+	fmt.Printf("%s✓ Imported %s into %s%s\n", colors.Green, recipe.Name, configPath, colors.Reset)
+	return nil
+}
+
+// printLineDiff prints the lines removed from before in red and those added in after in green
+func printLineDiff(before, after []byte) {
+	a := strings.Split(strings.TrimSuffix(string(before), "\n"), "\n")
+	b := strings.Split(strings.TrimSuffix(string(after), "\n"), "\n")
+
+	// Longest common subsequence table, filled from the end
+	lcs := make([][]int, len(a)+1)
+	for n := range lcs {
+		lcs[n] = make([]int, len(b)+1)
+	}
+	for x := len(a) - 1; x >= 0; x-- {
+		for y := len(b) - 1; y >= 0; y-- {
+			if a[x] == b[y] {
+				lcs[x][y] = lcs[x+1][y+1] + 1
+			} else {
+				lcs[x][y] = max(lcs[x+1][y], lcs[x][y+1])
+			}
+		}
+	}
+
+	x, y := 0, 0
+	for x < len(a) || y < len(b) {
+		switch {
+		case x < len(a) && y < len(b) && a[x] == b[y]:
+			fmt.Printf("    %s\n", a[x])
+			x, y = x+1, y+1
+		case x < len(a) && (y == len(b) || lcs[x+1][y] >= lcs[x][y+1]):
+			fmt.Printf("%s  - %s%s\n", colors.Red, a[x], colors.Reset)
+			x++
+		default:
+			fmt.Printf("%s  + %s%s\n", colors.Green, b[y], colors.Reset)
+			y++
+		}
+	}
+}
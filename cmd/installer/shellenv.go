@@ -0,0 +1,47 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/colors"
+	"github.com/Abhaythakor/dev-tools-installer/internal/installer"
+)
+
+// runShellenv prints the shell environment of the installed tools, or with --apply or
+// --remove adds it to or takes it out of the shell's rc file
+func runShellenv(inst *installer.Installer, args []string) error {
+	flags := flag.NewFlagSet("shellenv", flag.ExitOnError)
+	shell := flags.String("shell", installer.DefaultShell(), "shell to generate for: bash, zsh or fish")
+	apply := flags.Bool("apply", false, "write the environment file and source it from the shell's rc file")
+	remove := flags.Bool("remove", false, "take the block added by --apply back out of the rc file")
+	flags.Parse(args)
+
+	switch {
+	case *apply && *remove:
+		return configError{fmt.Errorf("--apply and --remove are mutually exclusive")}
+	case *apply:
+		rc, err := inst.ApplyShellEnv(*shell)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s✓ Updated %s; open a new shell to use it%s\n", colors.Green, rc, colors.Reset)
+	case *remove:
+		rc, err := inst.RemoveShellEnv(*shell)
+		if err != nil {
+			return err
+		}
+		if rc == "" {
+			fmt.Printf("%sNo dev-tools-installer block to remove%s\n", colors.Gray, colors.Reset)
+		} else {
+			fmt.Printf("%s✓ Removed the dev-tools-installer block from %s%s\n", colors.Green, rc, colors.Reset)
+		}
+	default:
+		env, err := inst.ShellEnv(*shell)
+		if err != nil {
+			return err
+		}
+		fmt.Print(env)
+	}
+	return nil
+}
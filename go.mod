@@ -2,4 +2,8 @@ module github.com/Abhaythakor/dev-tools-installer
 
 go 1.23.3
 
-require gopkg.in/yaml.v3 v3.0.1
+require (
+	golang.org/x/sys v0.26.0
+	golang.org/x/term v0.25.0
+	gopkg.in/yaml.v3 v3.0.1
+)
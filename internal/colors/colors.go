@@ -0,0 +1,76 @@
+// Package colors holds the escape sequences of the installer's colored output and decides
+// whether they are used. Output is colored by default; Set(false) turns every sequence into
+// an empty string so that the same format strings print plain text.
+package colors
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// Escape sequences of the colors and styles used in output; empty while colors are off
+var (
+	Reset  = "\033[0m"
+	Bold   = "\033[1m"
+	Red    = "\033[31m"
+	Green  = "\033[32m"
+	Yellow = "\033[33m"
+	Blue   = "\033[34m"
+	Gray   = "\033[37m"
+)
+
+// Values of --color
+const (
+	Auto   = "auto"   // Colors when stdout is a terminal, unless the environment says otherwise
+	Always = "always" // Colors also when stdout is redirected
+	Never  = "never"  // No colors
+)
+
+// Enabled decides whether output is colored for a --color mode. always and never win over
+// the environment. With auto, a non-empty NO_COLOR turns colors off, then CLICOLOR=0 turns
+// them off, then a CLICOLOR_FORCE other than empty or 0 turns them on; otherwise output is
+// colored when it goes to a terminal.
+func Enabled(mode string, terminal bool, getenv func(string) string) (bool, error) {
+	switch mode {
+	case Always:
+		return true, nil
+	case Never:
+		return false, nil
+	case Auto, "":
+	default:
+		return false, fmt.Errorf("--color must be auto, always or never, not %q", mode)
+	}
+	switch {
+	case getenv("NO_COLOR") != "":
+		return false, nil
+	case strings.TrimSpace(getenv("CLICOLOR")) == "0":
+		return false, nil
+	case getenv("CLICOLOR_FORCE") != "" && strings.TrimSpace(getenv("CLICOLOR_FORCE")) != "0":
+		return true, nil
+	}
+	return terminal, nil
+}
+
+// Configure applies a --color mode to output going to out, usually os.Stdout, and the
+// process environment
+func Configure(mode string, out *os.File) error {
+	on, err := Enabled(mode, term.IsTerminal(int(out.Fd())), os.Getenv)
+	if err != nil {
+		return err
+	}
+	Set(on)
+	return nil
+}
+
+// Set turns colored output on or off
+func Set(on bool) {
+	if on {
+		Reset, Bold, Red, Green, Yellow = "\033[0m", "\033[1m", "\033[31m", "\033[32m", "\033[33m"
+		Blue, Gray = "\033[34m", "\033[37m"
+		return
+	}
+	Reset, Bold, Red, Green, Yellow, Blue, Gray = "", "", "", "", "", "", ""
+}
@@ -0,0 +1,71 @@
+package colors
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestEnabled(t *testing.T) {
+	for _, tc := range []struct {
+		mode     string
+		env      map[string]string
+		terminal bool
+		want     bool
+	}{
+		// Without a say from the environment, auto follows the terminal
+		{mode: Auto, terminal: true, want: true},
+		{mode: Auto, terminal: false, want: false},
+		{mode: "", terminal: true, want: true},
+
+		// NO_COLOR wins over CLICOLOR and CLICOLOR_FORCE
+		{mode: Auto, env: map[string]string{"NO_COLOR": "1"}, terminal: true, want: false},
+		{mode: Auto, env: map[string]string{"NO_COLOR": "1", "CLICOLOR_FORCE": "1"}, want: false},
+		{mode: Auto, env: map[string]string{"NO_COLOR": "0"}, terminal: true, want: false},
+		{mode: Auto, env: map[string]string{"NO_COLOR": ""}, terminal: true, want: true},
+
+		// CLICOLOR=0 turns colors off, also against CLICOLOR_FORCE
+		{mode: Auto, env: map[string]string{"CLICOLOR": "0"}, terminal: true, want: false},
+		{mode: Auto, env: map[string]string{"CLICOLOR": " 0 "}, terminal: true, want: false},
+		{mode: Auto, env: map[string]string{"CLICOLOR": "0", "CLICOLOR_FORCE": "1"}, want: false},
+		{mode: Auto, env: map[string]string{"CLICOLOR": "1"}, terminal: false, want: false},
+		{mode: Auto, env: map[string]string{"CLICOLOR": "1"}, terminal: true, want: true},
+
+		// CLICOLOR_FORCE other than empty or 0 colors redirected output
+		{mode: Auto, env: map[string]string{"CLICOLOR_FORCE": "1"}, terminal: false, want: true},
+		{mode: Auto, env: map[string]string{"CLICOLOR_FORCE": "yes"}, terminal: false, want: true},
+		{mode: Auto, env: map[string]string{"CLICOLOR_FORCE": "0"}, terminal: false, want: false},
+		{mode: Auto, env: map[string]string{"CLICOLOR_FORCE": "0"}, terminal: true, want: true},
+		{mode: Auto, env: map[string]string{"CLICOLOR_FORCE": ""}, terminal: false, want: false},
+
+		// always and never win over the environment
+		{mode: Always, env: map[string]string{"NO_COLOR": "1", "CLICOLOR": "0"}, terminal: false, want: true},
+		{mode: Never, env: map[string]string{"CLICOLOR_FORCE": "1"}, terminal: true, want: false},
+	} {
+		t.Run(fmt.Sprintf("%s %v terminal=%v", tc.mode, tc.env, tc.terminal), func(t *testing.T) {
+			got, err := Enabled(tc.mode, tc.terminal, func(key string) string { return tc.env[key] })
+			if err != nil || got != tc.want {
+				t.Errorf("Enabled = %v, %v; want %v", got, err, tc.want)
+			}
+		})
+	}
+}
+
+func TestEnabledRejectsUnknownModes(t *testing.T) {
+	if _, err := Enabled("sometimes", true, func(string) string { return "" }); err == nil {
+		t.Error("Enabled accepted --color sometimes")
+	}
+}
+
+func TestSet(t *testing.T) {
+	defer Set(true)
+	Set(false)
+	for _, sequence := range []string{Reset, Bold, Red, Green, Yellow, Blue, Gray} {
+		if sequence != "" {
+			t.Errorf("sequence %q left while colors are off", sequence)
+		}
+	}
+	Set(true)
+	if Red != "\033[31m" || Reset != "\033[0m" {
+		t.Errorf("Red = %q and Reset = %q after colors are turned back on", Red, Reset)
+	}
+}
@@ -1,33 +1,489 @@
 package config
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"maps"
 	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/Abhaythakor/dev-tools-installer/internal/debug"
+	"github.com/Abhaythakor/dev-tools-installer/internal/shellwords"
 	"gopkg.in/yaml.v3"
 )
 
 // InstallerConfig represents the YAML configuration structure
 type InstallerConfig struct {
-	ToolList []string               `yaml:"tool_list"`
-	Tools    map[string]*ToolConfig `yaml:"tools"`
+	BinDir              string                 `yaml:"bindir"`                // Directory managed binaries are installed into
+	StateDir            string                 `yaml:"state_dir"`             // Directory holding the installer's state file
+	TempDir             string                 `yaml:"temp_dir"`              // Directory the per-run temp directory is created in; defaults to TMPDIR
+	Integrity           string                 `yaml:"integrity"`             // "managed" limits binary hashing to installs in bindir; defaults to "all"
+	HistoryLimit        int                    `yaml:"history_limit"`         // Number of runs kept in the history file; defaults to 200
+	BackupLimit         int                    `yaml:"backup_limit"`          // Replaced managed binaries kept per tool for rollback; defaults to 3
+	LockWait            string                 `yaml:"lock_wait"`             // How long to retry apt/dnf commands while another process holds their lock; defaults to 5m
+	Patterns            OutputPatterns         `yaml:"output_patterns"`       // Extra matches for localized output of method commands and version banners
+	Hints               []Hint                 `yaml:"hints"`                 // Hints printed when the output of any failed method matches, after tool and method hints
+	OutputLimit         string                 `yaml:"output_limit"`          // Output kept per command for logs and the report, e.g. "64KB"; the middle of longer output is dropped
+	EnvMode             string                 `yaml:"env_mode"`              // Environment of method commands: inherit (default), clean or custom
+	EnvAllow            []string               `yaml:"env_allow"`             // Variables passed through in clean mode besides PATH and HOME
+	Env                 map[string]string      `yaml:"env"`                   // Variables set for every method command
+	Vars                map[string]string      `yaml:"vars"`                  // Installer variables method commands and URLs reference as ${name}, overriding detected ones such as distro
+	Secrets             map[string]*Secret     `yaml:"secrets"`               // Named secrets referenced as ${secret:name}
+	Mirrors             []Mirror               `yaml:"mirrors"`               // URL rewrites for download and github_release methods, tried in order
+	Downloads           Downloads              `yaml:"downloads"`             // Limits shared by all downloads of a run
+	Security            Security               `yaml:"security"`              // Policies the methods must satisfy, such as require_checksums
+	Tracing             Tracing                `yaml:"tracing"`               // OTLP export of run traces
+	GitHub              GitHub                 `yaml:"github"`                // Caching and concurrency of GitHub API lookups
+	Recipes             Recipes                `yaml:"recipes"`               // Remote recipe index searched by search and add --from-recipe
+	Preferred           []string               `yaml:"preferred_methods"`     // Method names or types tried first, in this order
+	AssetPreferences    []string               `yaml:"asset_preferences"`     // Variants such as musl or gnu that github_release methods without an asset prefer, best first
+	SudoUserMethods     bool                   `yaml:"sudo_user_methods"`     // Under sudo, run go, cargo and pipx methods as the user who invoked sudo
+	StrictConfig        bool                   `yaml:"strict_config"`         // Require a tools entry for every tool_list entry instead of installing the package of the same name
+	SkipPermissionCheck bool                   `yaml:"skip_permission_check"` // Trust the config and its scripts although other users can change them, e.g. in containers
+	VersionsFile        string                 `yaml:"versions_file"`         // asdf/mise .tool-versions file whose versions override the pins of tools, looked up upward from the working directory when relative
+	VersionsNames       map[string]string      `yaml:"versions_names"`        // Tool names of versions_file entries named differently, e.g. golang: go
+	Groups              map[string][]string    `yaml:"groups"`                // Named selections of tools, e.g. slim: ["@all", "!metasploit"]
+	ToolList            []string               `yaml:"tool_list"`
+	Tools               map[string]*ToolConfig `yaml:"tools"`
+
+	// Set by LoadConfig
+	Path         string `yaml:"-"` // Absolute path of the loaded file
+	SHA256       string `yaml:"-"` // Digest of the loaded file
+	VersionsPath string `yaml:"-"` // versions_file the pins were read from, when one was found
+
+	corrections []string // Problems LoadConfig corrected, reported by Warnings
+	lenient     []string // Errors Lenient turned into warnings, reported by Warnings
 }
 
+// Lenient makes Validate report problems a run can work around, such as ${version}
+// references of tools without a version, as warnings instead of failing
+var Lenient bool
+
 // ToolConfig represents a tool's configuration
 type ToolConfig struct {
-	Dependencies []string        `yaml:"dependencies"`
-	Version      string         `yaml:"version"`
-	VersionFlag  string         `yaml:"version_flag"`
-	Methods      []InstallMethod `yaml:"methods"`
+	Description    string              `yaml:"description,omitempty"`  // One-line summary shown by list and info
+	Homepage       string              `yaml:"homepage,omitempty"`     // Shown by info and after failed installs
+	Docs           string              `yaml:"docs,omitempty"`         // Documentation URL shown by info
+	Dependencies   []string            `yaml:"dependencies,omitempty"` // Tools or provided commands that must be installed first
+	Provides       []string            `yaml:"provides,omitempty"`     // Commands the tool makes available, defaults to the tool name
+	SameAs         string              `yaml:"same_as,omitempty"`      // Tool whose binary this one is, e.g. python for python3
+	Version        string              `yaml:"version,omitempty"`
+	VersionFrom    string              `yaml:"version_from,omitempty"` // Command whose output is the pinned version, run once per run
+	VersionFlag    string              `yaml:"version_flag,omitempty"`
+	Methods        []InstallMethod     `yaml:"methods,omitempty"`
+	ShellInit      string              `yaml:"shell_init,omitempty"`         // Shell lines shellenv adds once the tool is installed; ${shell} is the shell's name
+	InstallDir     string              `yaml:"install_dir,omitempty"`        // Where managed methods place the binary and ${bindir}, defaults to bindir
+	Uninstall      []string            `yaml:"uninstall_commands,omitempty"` // Commands removing a tool not managed by the installer
+	DiskEstimate   string              `yaml:"disk_estimate,omitempty"`      // Space the install needs, e.g. "500MB"
+	InstallTimeout string              `yaml:"install_timeout,omitempty"`    // Fail the tool once its methods have taken this long together, e.g. "15m"
+	RunAs          string              `yaml:"run_as,omitempty"`             // User the commands of the tool's methods run as, through sudo -u
+	Disabled       bool                `yaml:"disabled,omitempty"`           // Keep the tool in tool_list but skip it
+	Source         string              `yaml:"source,omitempty"`             // URL of the recipe the entry was imported from
+	Hints          []Hint              `yaml:"hints,omitempty"`              // Hints printed when the output of a failed method matches
+	DefaultChannel string              `yaml:"default_channel,omitempty"`    // Channel of the methods tried when --channel is not given, defaults to stable
+	Detect         *Detect             `yaml:"detect,omitempty"`             // How the tool is found installed, for tools that are not a command
+	SystemPackages map[string][]string `yaml:"system_packages,omitempty"`    // Libraries and headers installed before the methods run, by package manager
+	VersionsFile   string              `yaml:"-"`                            // file:line of the versions_file entry the version comes from
+
+	// Set by the installer for tool_list entries without a tools entry, which install the
+	// package of the same name through the default method
+	Default bool `yaml:"-"`
+}
+
+// Detect kinds
+const (
+	DetectCommand       = "command"        // The commands the tool provides are on PATH
+	DetectFile          = "file"           // A file exists, such as a CLI plugin or a font
+	DetectCommandOutput = "command_output" // The output of a command matches a regex
+)
+
+// Detect is how a tool that is not a command on PATH is found installed
+type Detect struct {
+	Kind    string `yaml:"kind" schema:"required"` // command, file or command_output
+	Path    string `yaml:"path,omitempty"`         // File that exists once the tool is installed, for file; ${home} and ~ expand
+	Command string `yaml:"command,omitempty"`      // Command whose output is matched, for command_output, e.g. docker buildx version
+	Match   string `yaml:"match,omitempty"`        // Regex the output must match; its first group, when it has one, is the version
 }
 
 // InstallMethod represents an installation method
 type InstallMethod struct {
-	Name     string   `yaml:"name"`
-	Commands []string `yaml:"commands"`
+	Name         string            `yaml:"name" schema:"required"`
+	Priority     int               `yaml:"priority,omitempty"`        // Higher priorities are tried first among equally preferred methods
+	Type         string            `yaml:"type,omitempty"`            // Typed method (cargo, pipx, npm, download, github_release, script); empty for plain commands
+	Package      string            `yaml:"package,omitempty"`         // Package name for typed methods
+	Plugin       string            `yaml:"plugin,omitempty"`          // Tool of mise methods or plugin of asdf methods, e.g. node or aqua:cli/cli; defaults to the tool name
+	Version      string            `yaml:"version,omitempty"`         // Package version for typed methods, defaults to the tool version
+	Bootstrap    bool              `yaml:"bootstrap,omitempty"`       // Install the toolchain when it is missing
+	URL          string            `yaml:"url,omitempty"`             // Artifact URL for download methods
+	Repo         string            `yaml:"repo,omitempty"`            // owner/name for github_release methods
+	Tag          string            `yaml:"tag,omitempty"`             // Release tag for github_release methods, defaults to v${version}
+	TagPattern   string            `yaml:"tag_pattern,omitempty"`     // Glob the tag of an unpinned github_release must match, e.g. nightly-*
+	Channel      string            `yaml:"channel,omitempty"`         // Channel the method installs from, e.g. stable, latest or nightly; empty for every channel
+	Asset        string            `yaml:"asset,omitempty"`           // Glob matching the release asset name; without it the asset best matching the platform is picked
+	Binary       string            `yaml:"binary,omitempty"`          // Binary name inside the artifact, defaults to the tool name
+	SHA256       string            `yaml:"sha256,omitempty"`          // Expected checksum of the downloaded artifact
+	MinisignKey  string            `yaml:"minisign_pubkey,omitempty"` // minisign public key the artifact must be signed with
+	Cosign       *Cosign           `yaml:"cosign,omitempty"`          // cosign verification of the artifact
+	Signature    string            `yaml:"signature,omitempty"`       // URL of the artifact's signature, defaults to ${url}.minisig or ${url}.sig
+	Commands     []Command         `yaml:"commands,omitempty"`
+	Cleanup      []string          `yaml:"cleanup,omitempty"`            // Commands run after the method whether it succeeded, failed or was cancelled
+	File         string            `yaml:"file,omitempty"`               // Script run by script methods, relative to the config file
+	Content      string            `yaml:"content,omitempty"`            // Inline script run by script methods instead of a file
+	Interpreter  string            `yaml:"interpreter,omitempty"`        // Command running the script of script methods, defaults to sh
+	Requires     []string          `yaml:"requires,omitempty"`           // Commands the method needs; tools providing them are installed first
+	InTarget     bool              `yaml:"in_target,omitempty"`          // With --root, run the commands inside the target through chroot
+	RunAs        string            `yaml:"run_as,omitempty"`             // User the method's commands run as, overriding the tool's run_as
+	StallTimeout string            `yaml:"stall_timeout,omitempty"`      // Kill a command that prints nothing for this long, e.g. "10m", and try the next method
+	SuccessCodes []int             `yaml:"success_exit_codes,omitempty"` // Exit codes of the method's commands that count as success, defaults to [0]
+	WarnCodes    []int             `yaml:"warn_exit_codes,omitempty"`    // Exit codes that count as success but print the end of the output
+	EnvMode      string            `yaml:"env_mode,omitempty"`           // Overrides the config's env_mode for this method
+	EnvAllow     []string          `yaml:"env_allow,omitempty"`          // Added to the config's env_allow
+	Env          map[string]string `yaml:"env,omitempty"`                // Added to the config's env, overriding it
+	Headers      map[string]string `yaml:"headers,omitempty"`            // HTTP headers sent by download and github_release methods
+	Hints        []Hint            `yaml:"hints,omitempty"`              // Hints printed when the method fails with matching output, before the tool's
+	Batch        *bool             `yaml:"batch,omitempty"`              // false keeps the method out of package batches; true with batch_command puts it in one
+	BatchCommand string            `yaml:"batch_command,omitempty"`      // Command installing the ${packages} of several tools at once, for batch: true
+	Taps         []string          `yaml:"taps,omitempty"`               // Homebrew taps added before the method runs, e.g. projectdiscovery/tap
+	AptRepo      *AptRepo          `yaml:"apt_repo,omitempty"`           // apt repository, with its signing key, added before the method runs
+}
+
+// Command is a command of a method. In YAML it is the command line, or a mapping with the
+// command line under run and the name under which its output is captured.
+type Command struct {
+	Run     string `yaml:"run" schema:"required"`
+	Capture string `yaml:"capture,omitempty"` // Later commands of the method see the trimmed output as ${capture:name}
+	Secret  bool   `yaml:"secret,omitempty"`  // Redact the captured value in output and logs
+}
+
+// UnmarshalYAML accepts a command line as well as a run/capture mapping
+func (c *Command) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*c = Command{}
+		return node.Decode(&c.Run)
+	}
+	type plain Command
+	return node.Decode((*plain)(c))
+}
+
+// MarshalYAML writes commands without a capture as plain command lines
+func (c Command) MarshalYAML() (interface{}, error) {
+	if c.Capture == "" && !c.Secret {
+		return c.Run, nil
+	}
+	type plain Command
+	return plain(c), nil
+}
+
+// RunCommands returns commands running each of lines without capturing their output
+func RunCommands(lines ...string) []Command {
+	commands := make([]Command, len(lines))
+	for n, line := range lines {
+		commands[n].Run = line
+	}
+	return commands
+}
+
+// Runs returns the command lines of a method's commands
+func (m InstallMethod) Runs() []string {
+	lines := make([]string, len(m.Commands))
+	for n, command := range m.Commands {
+		lines[n] = command.Run
+	}
+	return lines
+}
+
+// captureName matches valid capture names
+var captureName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// captureRef matches ${capture:name} references
+var captureRef = regexp.MustCompile(`\$\{capture:([^}]*)\}`)
+
+// validateCaptures checks that commands capture under valid, distinct names and only
+// reference values that an earlier command of the method captured
+func validateCaptures(method InstallMethod) error {
+	captured := map[string]bool{}
+	for n, command := range method.Commands {
+		if strings.TrimSpace(command.Run) == "" {
+			return fmt.Errorf("command %d has nothing to run", n+1)
+		}
+		for _, ref := range captureRef.FindAllStringSubmatch(command.Run, -1) {
+			if !captured[ref[1]] {
+				return fmt.Errorf("command %d references ${capture:%s}, which no earlier command of the method captures", n+1, ref[1])
+			}
+		}
+		switch {
+		case command.Capture == "" && command.Secret:
+			return fmt.Errorf("command %d is secret but captures nothing", n+1)
+		case command.Capture == "":
+		case !captureName.MatchString(command.Capture):
+			return fmt.Errorf("command %d: capture %q must be letters, digits and underscores", n+1, command.Capture)
+		case captured[command.Capture]:
+			return fmt.Errorf("command %d captures %s again", n+1, command.Capture)
+		}
+		captured[command.Capture] = command.Capture != ""
+	}
+	for _, field := range [][]string{method.Cleanup, {method.URL, method.Asset, method.Tag}} {
+		for _, value := range field {
+			if ref := captureRef.FindStringSubmatch(value); ref != nil {
+				return fmt.Errorf("${capture:%s} is only available to the commands of a method", ref[1])
+			}
+		}
+	}
+	return nil
+}
+
+// Hint is a known fix for a failure, printed when a failed method's output matches
+type Hint struct {
+	Match string `yaml:"match" schema:"required"` // Regular expression matched against the output and error of the failed method
+	Hint  string `yaml:"hint" schema:"required"`
+
+	re *regexp.Regexp // Match compiled by Validate
+}
+
+// Matches reports whether the hint applies to the output of a failed method
+func (h Hint) Matches(output string) bool {
+	re := h.re
+	if re == nil {
+		var err error
+		if re, err = regexp.Compile(h.Match); err != nil {
+			return false
+		}
+	}
+	return re.MatchString(output)
+}
+
+// compileHints compiles the patterns of hints in place
+func compileHints(hints []Hint) error {
+	for n := range hints {
+		if hints[n].Hint == "" {
+			return fmt.Errorf("hints: hint for %q is empty", hints[n].Match)
+		}
+		re, err := regexp.Compile(hints[n].Match)
+		if err != nil {
+			return fmt.Errorf("hints: %v", err)
+		}
+		hints[n].re = re
+	}
+	return nil
+}
+
+// Cosign verifies a downloaded artifact with cosign verify-blob, against a public key or,
+// keyless, against the identity in a certificate
+type Cosign struct {
+	Key         string `yaml:"key,omitempty"`         // Public key file or URL
+	Identity    string `yaml:"identity,omitempty"`    // Certificate identity for keyless verification, e.g. an email or workflow URL
+	Issuer      string `yaml:"issuer,omitempty"`      // OIDC issuer of the identity, e.g. https://token.actions.githubusercontent.com
+	Certificate string `yaml:"certificate,omitempty"` // URL of the signing certificate, defaults to ${url}.pem
+}
+
+// Secret is a value resolved at runtime and never printed
+type Secret struct {
+	Env     string `yaml:"env,omitempty"`     // Environment variable holding the value
+	Command string `yaml:"command,omitempty"` // Command printing the value, e.g. "op read op://vault/item/token"
+}
+
+// Downloads limits the downloads of a run, independently of the install concurrency
+type Downloads struct {
+	Concurrency int    `yaml:"concurrency"` // Downloads and toolchain bootstraps running at once; defaults to 3
+	Bandwidth   string `yaml:"bandwidth"`   // Aggregate transfer rate such as "10MB/s"; unlimited when empty
+	MaxSize     string `yaml:"max_size"`    // Total a run may download, such as "500MB"; unlimited when empty
+}
+
+// OutputPatterns extend the installer's matching of command output, for tools whose
+// messages are localized even though the installer runs its own probes in the C locale
+type OutputPatterns struct {
+	Lock    []string `yaml:"lock"`    // Case-insensitive substrings of a "package manager lock is held" error
+	Version []string `yaml:"version"` // Regular expressions tried before the built-in ones; the first group is the version
+}
+
+// GitHub configures the client shared by GitHub API lookups. GITHUB_TOKEN authenticates them
+// when set.
+type GitHub struct {
+	CacheDir    string `yaml:"cache_dir"`   // Directory API responses are cached in; defaults to the user cache directory
+	CacheTTL    string `yaml:"cache_ttl"`   // How long a cached response is used before it is revalidated; defaults to 1h
+	Concurrency int    `yaml:"concurrency"` // API requests in flight at once; defaults to 4
+}
+
+// Tracing configures the export of run traces to an OTLP/HTTP collector. The standard
+// OTEL_EXPORTER_OTLP_* variables are used when Endpoint is empty.
+type Tracing struct {
+	Endpoint string            `yaml:"endpoint"` // Collector base URL such as http://localhost:4318, or its /v1/traces URL
+	Headers  map[string]string `yaml:"headers"`  // Headers sent with the export, e.g. for authentication
 }
 
-// LoadConfig loads the installer configuration from a YAML file
+// Mirror rewrites URLs starting with Prefix to start with URL instead
+type Mirror struct {
+	Prefix string `yaml:"prefix" schema:"required"` // e.g. "https://github.com/"
+	URL    string `yaml:"url" schema:"required"`    // e.g. "https://ghmirror.internal/"
+}
+
+// Supported typed method kinds
+const (
+	MethodCargo         = "cargo"
+	MethodPipx          = "pipx"
+	MethodNpm           = "npm"
+	MethodMise          = "mise"
+	MethodAsdf          = "asdf"
+	MethodDownload      = "download"
+	MethodGithubRelease = "github_release"
+	MethodScript        = "script"
+)
+
+// Environment modes of method commands
+const (
+	EnvInherit = "inherit" // The installer's full environment
+	EnvClean   = "clean"   // Only PATH, HOME and env_allow
+	EnvCustom  = "custom"  // Only PATH and the configured env
+)
+
+// ChannelStable is the channel of tools without default_channel when --channel is not given
+const ChannelStable = "stable"
+
+// OnChannel reports whether a method is tried on channel: methods labeled with it, and
+// unlabeled ones as the fallback
+func (m InstallMethod) OnChannel(channel string) bool {
+	if channel == "" {
+		channel = ChannelStable
+	}
+	return m.Channel == "" || m.Channel == channel
+}
+
+// Integrity checking scopes
+const (
+	IntegrityAll     = "all"
+	IntegrityManaged = "managed"
+)
+
+// configLog is the debug logger of config loading
+var configLog = debug.Logger("config")
+
+// secretRef matches ${secret:name} references
+var secretRef = regexp.MustCompile(`\$\{secret:([A-Za-z0-9_.-]+)\}`)
+
+// SecretRefs returns the names of the secrets referenced in s
+func SecretRefs(s string) []string {
+	var names []string
+	for _, match := range secretRef.FindAllStringSubmatch(s, -1) {
+		names = append(names, match[1])
+	}
+	return names
+}
+
+// ExpandSecrets replaces ${secret:name} references in s with the values returned by lookup
+func ExpandSecrets(s string, lookup func(name string) (string, error)) (string, error) {
+	var err error
+	expanded := secretRef.ReplaceAllStringFunc(s, func(ref string) string {
+		value, lookupErr := lookup(secretRef.FindStringSubmatch(ref)[1])
+		if lookupErr != nil && err == nil {
+			err = lookupErr
+		}
+		return value
+	})
+	return expanded, err
+}
+
+// DetectKind returns how the tool is found installed, command unless its detect block says otherwise
+func (t *ToolConfig) DetectKind() string {
+	if t == nil || t.Detect == nil {
+		return DetectCommand
+	}
+	return t.Detect.Kind
+}
+
+// Commands returns the commands the tool named name makes available
+func (t *ToolConfig) Commands(name string) []string {
+	if t == nil || len(t.Provides) == 0 {
+		return []string{name}
+	}
+	return t.Provides
+}
+
+// Provider returns the tool that makes command available, or "" when no tool does
+func (c *InstallerConfig) Provider(command string) string {
+	if _, ok := c.Tools[command]; ok {
+		return command
+	}
+	for name, tool := range c.Tools {
+		if tool != nil && slices.Contains(tool.Provides, command) {
+			return name
+		}
+	}
+	return ""
+}
+
+// Warnings returns problems in the config that do not prevent using it
+func (c *InstallerConfig) Warnings() []string {
+	warnings := slices.Concat(c.corrections, c.lenient)
+	for _, entry := range c.ToolList {
+		name, version := ParseToolEntry(entry)
+		if note := c.InlinePinNote(name, version); note != "" {
+			warnings = append(warnings, fmt.Sprintf("tool_list entry %s %s", entry, note))
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(c.Tools)) {
+		tool := c.Tools[name]
+		if tool == nil || tool.Disabled {
+			continue
+		}
+		needs := append([]string{}, tool.Dependencies...)
+		for _, method := range tool.Methods {
+			needs = append(needs, method.Requires...)
+		}
+		for _, need := range needs {
+			if provider := c.Provider(need); provider != "" && c.Tools[provider] != nil && c.Tools[provider].Disabled {
+				warnings = append(warnings, fmt.Sprintf("tool %s depends on %s, which is disabled", name, provider))
+			}
+		}
+	}
+	return slices.Compact(warnings)
+}
+
+// Fields returns a config value as a map keyed by its YAML field names, for output formats
+// such as JSON that should use the same names as the config file
+func Fields(v any) (map[string]any, error) {
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	fields := map[string]any{}
+	if err := yaml.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+// InlinePinNote describes how the inline version of a name@version entry overrides the
+// version its tools entry pins, or returns nothing when they do not conflict
+func (c *InstallerConfig) InlinePinNote(name, version string) string {
+	tool := c.Tools[name]
+	switch {
+	case version == "" || tool == nil:
+	case tool.Version != "" && tool.Version != version:
+		return fmt.Sprintf("pins %s while tools.%s.version is %s; the inline pin wins for this entry", version, name, tool.Version)
+	case tool.VersionFrom != "":
+		return fmt.Sprintf("pins %s while tools.%s sets version_from; the inline pin wins for this entry", version, name)
+	}
+	return ""
+}
+
+// ParseToolEntry splits a tool_list entry of the form name@version
+func ParseToolEntry(entry string) (name, version string) {
+	if i := strings.LastIndex(entry, "@"); i > 0 {
+		return entry[:i], entry[i+1:]
+	}
+	return entry, ""
+}
+
+// LoadConfig loads the installer configuration from a YAML file. When the config is valid
+// but breaks the security policy, the config is returned along with a *PolicyError.
 func LoadConfig(filename string) (*InstallerConfig, error) {
 	data, err := os.ReadFile(filename)
 	if err != nil {
@@ -39,5 +495,627 @@ func LoadConfig(filename string) (*InstallerConfig, error) {
 		return nil, fmt.Errorf("failed to parse config file: %v", err)
 	}
 
+	sum := sha256.Sum256(data)
+	config.SHA256 = hex.EncodeToString(sum[:])
+	if config.Path, err = filepath.Abs(filename); err != nil {
+		config.Path = filename
+	}
+
+	configLog.Debug("loaded", "path", config.Path, "sha256", config.SHA256, "tools", len(config.Tools), "tool_list", len(config.ToolList))
+	config.normalizeToolList()
+	if err := config.applyVersionsFile(); err != nil {
+		return nil, err
+	}
+	if err := config.Validate(); err != nil {
+		configLog.Debug("invalid", "path", config.Path, "error", err)
+		// A config only breaking the security policy is returned with the error, for reports
+		var policy *PolicyError
+		if errors.As(err, &policy) {
+			return &config, err
+		}
+		return nil, err
+	}
+
 	return &config, nil
 }
+
+// normalizeToolList lowercases the tool names of tool_list entries, which must match the
+// lowercase tools keys, and drops entries listed more than once, recording a warning for each
+func (c *InstallerConfig) normalizeToolList() {
+	seen := map[string][]string{}
+	var entries, duplicates []string
+	for _, entry := range c.ToolList {
+		name, version := ParseToolEntry(strings.TrimSpace(entry))
+		normalized := strings.ToLower(name)
+		if version != "" {
+			normalized += "@" + version
+		}
+		if _, ok := seen[normalized]; ok {
+			if len(seen[normalized]) == 1 {
+				duplicates = append(duplicates, normalized)
+			}
+		} else {
+			entries = append(entries, normalized)
+		}
+		seen[normalized] = append(seen[normalized], entry)
+	}
+	for _, normalized := range duplicates {
+		c.corrections = append(c.corrections, fmt.Sprintf("tool_list lists %s more than once (as %s); it is processed once",
+			normalized, strings.Join(seen[normalized], ", ")))
+	}
+	for _, normalized := range entries {
+		if original := seen[normalized][0]; len(seen[normalized]) == 1 && original != normalized {
+			c.corrections = append(c.corrections, fmt.Sprintf("tool_list entry %s is treated as %s; tool names are lowercase", original, normalized))
+		}
+	}
+	c.ToolList = entries
+}
+
+// Validate checks the configuration for errors that would only surface at install time
+func (c *InstallerConfig) Validate() error {
+	switch c.Integrity {
+	case "", IntegrityAll, IntegrityManaged:
+	default:
+		return fmt.Errorf("integrity must be %q or %q, got %q", IntegrityAll, IntegrityManaged, c.Integrity)
+	}
+	if err := validateEnvMode(c.EnvMode); err != nil {
+		return err
+	}
+	for name, secret := range c.Secrets {
+		if secret == nil || (secret.Env == "") == (secret.Command == "") {
+			return fmt.Errorf("secret %s: set exactly one of env or command", name)
+		}
+		if _, err := shellwords.Split(secret.Command); err != nil {
+			return fmt.Errorf("secret %s: command %q: %v", name, secret.Command, err)
+		}
+	}
+	if err := c.validateSecretRefs("env", c.Env); err != nil {
+		return err
+	}
+	if err := c.validateSecretRefs("tracing.headers", c.Tracing.Headers); err != nil {
+		return err
+	}
+	if c.Downloads.Concurrency < 0 {
+		return fmt.Errorf("downloads.concurrency must not be negative")
+	}
+	if c.Downloads.Bandwidth != "" {
+		if _, err := ParseRate(c.Downloads.Bandwidth); err != nil {
+			return fmt.Errorf("downloads.bandwidth: %v", err)
+		}
+	}
+	if c.Downloads.MaxSize != "" {
+		if _, err := ParseSize(c.Downloads.MaxSize); err != nil {
+			return fmt.Errorf("downloads.max_size: %v", err)
+		}
+	}
+	for n, mirror := range c.Mirrors {
+		if mirror.Prefix == "" || mirror.URL == "" {
+			return fmt.Errorf("mirrors[%d]: prefix and url are required", n)
+		}
+	}
+	if c.OutputLimit != "" {
+		if _, err := ParseSize(c.OutputLimit); err != nil {
+			return fmt.Errorf("output_limit: %v", err)
+		}
+	}
+	if c.GitHub.CacheTTL != "" {
+		if _, err := time.ParseDuration(c.GitHub.CacheTTL); err != nil {
+			return fmt.Errorf("github.cache_ttl: %v", err)
+		}
+	}
+	if c.GitHub.Concurrency < 0 {
+		return fmt.Errorf("github.concurrency must not be negative")
+	}
+	if slices.Contains(c.Patterns.Lock, "") {
+		return fmt.Errorf("output_patterns.lock must not contain empty patterns")
+	}
+	for _, pattern := range c.Patterns.Version {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("output_patterns.version: %v", err)
+		}
+	}
+	if err := compileHints(c.Hints); err != nil {
+		return err
+	}
+	if c.LockWait != "" {
+		if _, err := time.ParseDuration(c.LockWait); err != nil {
+			return fmt.Errorf("lock_wait: %v", err)
+		}
+	}
+
+	if err := c.validateVars(); err != nil {
+		return err
+	}
+	if err := c.validateGroups(); err != nil {
+		return err
+	}
+	for _, entry := range c.ToolList {
+		name, version := ParseToolEntry(entry)
+		if c.StrictConfig && c.Provider(name) == "" {
+			return fmt.Errorf("tool_list entry %s has no tools entry, which strict_config requires", entry)
+		}
+		if version == "" {
+			continue
+		}
+		if tool := c.Tools[name]; tool == nil || len(tool.Methods) == 0 {
+			return fmt.Errorf("tool_list entry %s: versioned entries need a tools entry for %s", entry, name)
+		}
+	}
+
+	// tool_list entries are lowercased when loaded, so mixed-case keys would never match
+	for _, name := range slices.Sorted(maps.Keys(c.Tools)) {
+		if lower := strings.ToLower(name); lower != name {
+			return fmt.Errorf("tool %s: tool names must be lowercase; rename it to %s", name, lower)
+		}
+	}
+
+	providers := map[string]string{}
+	for _, name := range slices.Sorted(maps.Keys(c.Tools)) {
+		if c.Tools[name] == nil {
+			continue
+		}
+		for _, command := range c.Tools[name].Provides {
+			if command == "" || strings.ContainsAny(command, `/\`) {
+				return fmt.Errorf("tool %s: provides entry %q must be a command name", name, command)
+			}
+			if other, ok := providers[command]; ok {
+				return fmt.Errorf("tool %s: %s is already provided by %s", name, command, other)
+			}
+			providers[command] = name
+		}
+	}
+
+	for name, tool := range c.Tools {
+		if tool == nil {
+			continue
+		}
+		if tool.DiskEstimate != "" {
+			if _, err := ParseSize(tool.DiskEstimate); err != nil {
+				return fmt.Errorf("tool %s: disk_estimate: %v", name, err)
+			}
+		}
+		if tool.VersionFrom != "" && tool.Version != "" {
+			return fmt.Errorf("tool %s: version and version_from are mutually exclusive", name)
+		}
+		if tool.VersionFrom != "" && strings.TrimSpace(tool.VersionFrom) == "" {
+			return fmt.Errorf("tool %s: version_from must not be blank", name)
+		}
+		if _, err := shellwords.Split(tool.VersionFrom); err != nil {
+			return fmt.Errorf("tool %s: version_from %q: %v", name, tool.VersionFrom, err)
+		}
+		if tool.InstallTimeout != "" {
+			if timeout, err := time.ParseDuration(tool.InstallTimeout); err != nil || timeout <= 0 {
+				return fmt.Errorf("tool %s: install_timeout must be a positive duration such as 15m", name)
+			}
+		}
+		if tool.SameAs != "" {
+			target := c.Tools[tool.SameAs]
+			switch {
+			case tool.SameAs == name || target == nil:
+				return fmt.Errorf("tool %s: same_as %q must name another configured tool", name, tool.SameAs)
+			case target.SameAs != "":
+				return fmt.Errorf("tool %s: same_as %q is itself the same as %s", name, tool.SameAs, target.SameAs)
+			case len(tool.Methods) > 0:
+				return fmt.Errorf("tool %s: tools with same_as install through %s and have no methods", name, tool.SameAs)
+			}
+		}
+		if err := compileHints(tool.Hints); err != nil {
+			return fmt.Errorf("tool %s: %v", name, err)
+		}
+		if err := validateDetect(tool.Detect); err != nil {
+			return fmt.Errorf("tool %s: detect: %v", name, err)
+		}
+		if err := validateSystemPackages(tool.SystemPackages); err != nil {
+			return fmt.Errorf("tool %s: system_packages: %v", name, err)
+		}
+		for _, method := range tool.Methods {
+			if err := compileHints(method.Hints); err != nil {
+				return fmt.Errorf("tool %s: method %q: %v", name, method.Name, err)
+			}
+			if err := validateEnvMode(method.EnvMode); err != nil {
+				return fmt.Errorf("tool %s: method %q: %v", name, method.Name, err)
+			}
+			if slices.Contains(method.Requires, "") {
+				return fmt.Errorf("tool %s: method %q: requires entries must not be empty", name, method.Name)
+			}
+			if method.StallTimeout != "" {
+				if timeout, err := time.ParseDuration(method.StallTimeout); err != nil || timeout <= 0 {
+					return fmt.Errorf("tool %s: method %q: stall_timeout must be a positive duration such as 10m", name, method.Name)
+				}
+			}
+			for _, code := range slices.Concat(method.SuccessCodes, method.WarnCodes) {
+				if code < 0 || code > 255 {
+					return fmt.Errorf("tool %s: method %q: exit code %d is out of range 0-255", name, method.Name, code)
+				}
+				if slices.Contains(method.SuccessCodes, code) && slices.Contains(method.WarnCodes, code) {
+					return fmt.Errorf("tool %s: method %q: exit code %d is in both success_exit_codes and warn_exit_codes", name, method.Name, code)
+				}
+			}
+			for field, values := range map[string]map[string]string{"env": method.Env, "headers": method.Headers} {
+				if err := c.validateSecretRefs(field, values); err != nil {
+					return fmt.Errorf("tool %s: method %q: %v", name, method.Name, err)
+				}
+			}
+			if err := validateSignature(method); err != nil {
+				return fmt.Errorf("tool %s: method %q: %v", name, method.Name, err)
+			}
+			if err := validateRunAs(tool, method); err != nil {
+				return fmt.Errorf("tool %s: method %q: %v", name, method.Name, err)
+			}
+			if err := validateCaptures(method); err != nil {
+				return fmt.Errorf("tool %s: method %q: %v", name, method.Name, err)
+			}
+			if err := validateTagPattern(method); err != nil {
+				return fmt.Errorf("tool %s: method %q: %v", name, method.Name, err)
+			}
+			if err := validateBatch(method); err != nil {
+				return fmt.Errorf("tool %s: method %q: %v", name, method.Name, err)
+			}
+			if err := validateCommandLines(method); err != nil {
+				return fmt.Errorf("tool %s: method %q: %v", name, method.Name, err)
+			}
+			if err := validateRepositories(method); err != nil {
+				return fmt.Errorf("tool %s: method %q: %v", name, method.Name, err)
+			}
+			switch method.Type {
+			case "":
+				if len(method.Commands) == 0 {
+					return fmt.Errorf("tool %s: method %q has no commands", name, method.Name)
+				}
+			case MethodCargo, MethodPipx, MethodNpm:
+				if method.Package == "" {
+					return fmt.Errorf("tool %s: %s method %q requires a package", name, method.Type, method.Name)
+				}
+			case MethodMise, MethodAsdf:
+				if method.Package != "" {
+					return fmt.Errorf("tool %s: %s method %q takes a plugin, not a package", name, method.Type, method.Name)
+				}
+				if strings.ContainsAny(method.Plugin, " \t@") {
+					return fmt.Errorf("tool %s: %s method %q: plugin %q must not contain spaces or @; set the version with version", name, method.Type, method.Name, method.Plugin)
+				}
+			case MethodDownload:
+				if method.URL == "" {
+					return fmt.Errorf("tool %s: download method %q requires a url", name, method.Name)
+				}
+			case MethodGithubRelease:
+				if method.Repo == "" {
+					return fmt.Errorf("tool %s: github_release method %q requires a repo", name, method.Name)
+				}
+			case MethodScript:
+				sources := 0
+				for _, set := range []bool{method.File != "", method.Content != "", method.URL != ""} {
+					if set {
+						sources++
+					}
+				}
+				if sources != 1 {
+					return fmt.Errorf("tool %s: script method %q requires exactly one of file, content and url", name, method.Name)
+				}
+				if method.URL != "" && method.SHA256 == "" {
+					return fmt.Errorf("tool %s: script method %q: a script fetched from url requires its sha256", name, method.Name)
+				}
+				if method.InTarget {
+					return fmt.Errorf("tool %s: script method %q cannot run in_target", name, method.Name)
+				}
+				if method.File != "" {
+					if info, err := os.Stat(c.ScriptPath(method)); err != nil || info.IsDir() {
+						return fmt.Errorf("tool %s: script method %q: %s is not a file", name, method.Name, c.ScriptPath(method))
+					}
+				}
+			default:
+				return fmt.Errorf("tool %s: method %q has unknown type %q", name, method.Name, method.Type)
+			}
+		}
+	}
+	if err := c.validateDependencies(); err != nil {
+		return err
+	}
+	if err := c.validatePolicy(); err != nil {
+		return err
+	}
+	c.lenient = nil
+	for _, problem := range c.versionProblems() {
+		if !Lenient {
+			return errors.New(problem)
+		}
+		c.lenient = append(c.lenient, problem)
+	}
+	return nil
+}
+
+// versionRef matches references to the ${version} variable
+var versionRef = regexp.MustCompile(`\$(\{version\}|version\b)`)
+
+// versionProblems lists the methods referencing ${version} of tools that tool_list installs
+// without a version: no version or version_from, and an entry without an inline pin
+func (c *InstallerConfig) versionProblems() []string {
+	unpinned := map[string]bool{}
+	for _, entry := range c.ToolList {
+		if name, version := ParseToolEntry(entry); version == "" {
+			unpinned[name] = true
+			if tool := c.Tools[name]; tool != nil && tool.SameAs != "" {
+				unpinned[tool.SameAs] = true
+			}
+		}
+	}
+	var problems []string
+	for _, name := range slices.Sorted(maps.Keys(unpinned)) {
+		tool := c.Tools[name]
+		if tool == nil || tool.Version != "" || tool.VersionFrom != "" {
+			continue
+		}
+		for _, method := range tool.Methods {
+			if fields := versionRefs(method); len(fields) > 0 {
+				problems = append(problems, fmt.Sprintf("tool %s: method %q references ${version} in %s, but the tool has no version, version_from or name@version entry",
+					name, method.Name, strings.Join(fields, ", ")))
+			}
+		}
+	}
+	return problems
+}
+
+// versionRefs returns the fields of a method that reference ${version}. github_release methods
+// take it from the release they install, except in their tag.
+func versionRefs(method InstallMethod) []string {
+	fields := map[string][]string{
+		"commands": method.Runs(),
+		"cleanup":  method.Cleanup,
+		"url":      {method.URL},
+		"tag":      {method.Tag},
+		"version":  {method.Version},
+		"env":      slices.Collect(maps.Values(method.Env)),
+	}
+	if method.Type != MethodGithubRelease {
+		fields["asset"], fields["signature"] = []string{method.Asset}, []string{method.Signature}
+	}
+	var refs []string
+	for _, field := range slices.Sorted(maps.Keys(fields)) {
+		if slices.ContainsFunc(fields[field], versionRef.MatchString) {
+			refs = append(refs, field)
+		}
+	}
+	return refs
+}
+
+// validateDependencies fails when tools depend on each other in a cycle, which would leave
+// them waiting for one another
+func (c *InstallerConfig) validateDependencies() error {
+	const (
+		visiting = 1
+		visited  = 2
+	)
+	marks := map[string]int{}
+	var visit func(name string, path []string) error
+	visit = func(name string, path []string) error {
+		switch marks[name] {
+		case visiting:
+			cycle := append(path[slices.Index(path, name):], name)
+			return fmt.Errorf("tool %s: dependency cycle %s", name, strings.Join(cycle, " -> "))
+		case visited:
+			return nil
+		}
+		marks[name] = visiting
+		if tool := c.Tools[name]; tool != nil {
+			for _, dep := range tool.Dependencies {
+				if provider := c.Provider(dep); provider != "" {
+					if err := visit(provider, append(path, name)); err != nil {
+						return err
+					}
+				}
+			}
+		}
+		marks[name] = visited
+		return nil
+	}
+	for _, name := range slices.Sorted(maps.Keys(c.Tools)) {
+		if err := visit(name, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ScriptPath returns the path of a script method's file, resolving it relative to the
+// config file
+func (c *InstallerConfig) ScriptPath(method InstallMethod) string {
+	path := method.File
+	if filepath.IsAbs(path) || c.Path == "" {
+		return path
+	}
+	return filepath.Join(filepath.Dir(c.Path), path)
+}
+
+// validateSignature checks the signature fields of a method
+func validateSignature(method InstallMethod) error {
+	signed := method.MinisignKey != "" || method.Cosign != nil
+	switch {
+	case !signed && method.Signature != "":
+		return fmt.Errorf("signature needs minisign_pubkey or cosign")
+	case !signed:
+		return nil
+	case method.Type != MethodDownload && method.Type != MethodGithubRelease:
+		return fmt.Errorf("signatures are only verified for download and github_release methods")
+	case method.MinisignKey != "" && method.Cosign != nil:
+		return fmt.Errorf("minisign_pubkey and cosign are mutually exclusive")
+	case method.Cosign == nil:
+		return nil
+	case method.Cosign.Key != "" && method.Cosign.Identity != "":
+		return fmt.Errorf("cosign takes a key or an identity, not both")
+	case method.Cosign.Key == "" && (method.Cosign.Identity == "" || method.Cosign.Issuer == ""):
+		return fmt.Errorf("cosign requires a key, or an identity and issuer")
+	}
+	return nil
+}
+
+// validateDetect checks the detect block of a tool
+func validateDetect(detect *Detect) error {
+	if detect == nil {
+		return nil
+	}
+	switch detect.Kind {
+	case DetectCommand:
+		if detect.Path != "" || detect.Command != "" || detect.Match != "" {
+			return fmt.Errorf("kind command takes no path, command or match; it checks the commands the tool provides")
+		}
+	case DetectFile:
+		if detect.Path == "" {
+			return fmt.Errorf("kind file requires path")
+		}
+		if detect.Command != "" || detect.Match != "" {
+			return fmt.Errorf("kind file takes no command or match")
+		}
+	case DetectCommandOutput:
+		if strings.TrimSpace(detect.Command) == "" || detect.Match == "" {
+			return fmt.Errorf("kind command_output requires command and match")
+		}
+		if detect.Path != "" {
+			return fmt.Errorf("kind command_output takes no path")
+		}
+		if _, err := shellwords.Split(detect.Command); err != nil {
+			return fmt.Errorf("command %q: %v", detect.Command, err)
+		}
+		if _, err := regexp.Compile(detect.Match); err != nil {
+			return fmt.Errorf("match: %v", err)
+		}
+	default:
+		return fmt.Errorf("unknown kind %q; use %s, %s or %s", detect.Kind, DetectCommand, DetectFile, DetectCommandOutput)
+	}
+	return nil
+}
+
+// validateTagPattern checks the tag_pattern of a method
+func validateTagPattern(method InstallMethod) error {
+	switch {
+	case method.TagPattern == "":
+		return nil
+	case method.Type != MethodGithubRelease:
+		return fmt.Errorf("tag_pattern only applies to github_release methods")
+	case method.Tag != "":
+		return fmt.Errorf("tag and tag_pattern are mutually exclusive")
+	}
+	if _, err := path.Match(method.TagPattern, ""); err != nil {
+		return fmt.Errorf("tag_pattern %q: %v", method.TagPattern, err)
+	}
+	return nil
+}
+
+// SystemPackageManagers are the package managers system_packages can name
+var SystemPackageManagers = []string{"apt", "dnf", "yum", "pacman", "zypper", "apk", "brew"}
+
+// validateSystemPackages checks the system_packages of a tool
+func validateSystemPackages(packages map[string][]string) error {
+	for manager, names := range packages {
+		if !slices.Contains(SystemPackageManagers, manager) {
+			return fmt.Errorf("unknown package manager %q, expected one of %s", manager, strings.Join(SystemPackageManagers, ", "))
+		}
+		if len(names) == 0 || slices.Contains(names, "") {
+			return fmt.Errorf("%s lists no packages or an empty one", manager)
+		}
+	}
+	return nil
+}
+
+// validateCommandLines checks that the commands, cleanup and batch_command of a method split
+// into words: quotes are closed and no invisible characters were pasted into them
+func validateCommandLines(method InstallMethod) error {
+	lines := append(append(method.Runs(), method.Cleanup...), method.BatchCommand)
+	for _, line := range lines {
+		if _, err := shellwords.Split(line); err != nil {
+			return fmt.Errorf("command %q: %v", line, err)
+		}
+	}
+	return nil
+}
+
+// validateBatch checks the batch settings of a method
+func validateBatch(method InstallMethod) error {
+	batch := method.Batch != nil && *method.Batch
+	switch {
+	case method.Batch == nil && method.BatchCommand == "":
+		return nil
+	case method.Type != "":
+		return fmt.Errorf("batch only applies to methods running commands")
+	case method.BatchCommand != "" && !batch:
+		return fmt.Errorf("batch_command requires batch: true")
+	case batch && method.BatchCommand == "":
+		return fmt.Errorf("batch: true requires a batch_command installing ${packages}")
+	case batch && !strings.Contains(method.BatchCommand, "${packages}"):
+		return fmt.Errorf("batch_command must contain ${packages}")
+	case batch && method.Package == "":
+		return fmt.Errorf("batch: true requires the package the method installs")
+	}
+	return nil
+}
+
+// validateRunAs checks the user a method's commands run as
+func validateRunAs(tool *ToolConfig, method InstallMethod) error {
+	switch {
+	case method.RunAs != "" && (method.Type == MethodDownload || method.Type == MethodGithubRelease):
+		return fmt.Errorf("run_as only applies to methods running commands")
+	case (method.RunAs != "" || tool.RunAs != "") && method.InTarget:
+		return fmt.Errorf("run_as cannot be combined with in_target")
+	}
+	return nil
+}
+
+// SuccessExitCodes returns the exit codes that count as success for a method's commands
+func (m InstallMethod) SuccessExitCodes() []int {
+	if len(m.SuccessCodes) == 0 {
+		return []int{0}
+	}
+	return m.SuccessCodes
+}
+
+// validateEnvMode checks an env_mode value
+func validateEnvMode(mode string) error {
+	switch mode {
+	case "", EnvInherit, EnvClean, EnvCustom:
+		return nil
+	}
+	return fmt.Errorf("env_mode must be %q, %q or %q, got %q", EnvInherit, EnvClean, EnvCustom, mode)
+}
+
+// validateSecretRefs checks that every secret referenced in values is defined
+func (c *InstallerConfig) validateSecretRefs(field string, values map[string]string) error {
+	for key, value := range values {
+		for _, ref := range SecretRefs(value) {
+			if c.Secrets[ref] == nil {
+				return fmt.Errorf("%s %s references undefined secret %q", field, key, ref)
+			}
+		}
+	}
+	return nil
+}
+
+// sizeUnits maps size suffixes to their multiplier
+var sizeUnits = map[string]int64{
+	"":   1,
+	"B":  1,
+	"KB": 1e3, "MB": 1e6, "GB": 1e9, "TB": 1e12,
+	"KIB": 1 << 10, "MIB": 1 << 20, "GIB": 1 << 30, "TIB": 1 << 40,
+	"K": 1 << 10, "M": 1 << 20, "G": 1 << 30, "T": 1 << 40,
+}
+
+// ParseSize parses a size such as "500MB", "1.5GiB" or "200M" into bytes
+func ParseSize(s string) (int64, error) {
+	value := strings.TrimSpace(s)
+	n := strings.IndexFunc(value, func(r rune) bool { return (r < '0' || r > '9') && r != '.' })
+	if n < 0 {
+		n = len(value)
+	}
+	number, err := strconv.ParseFloat(value[:n], 64)
+	unit, ok := sizeUnits[strings.ToUpper(strings.TrimSpace(value[n:]))]
+	if err != nil || !ok || number < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return int64(number * float64(unit)), nil
+}
+
+// ParseRate parses a transfer rate such as "10MB/s" or "512KiB" into bytes per second
+func ParseRate(s string) (int64, error) {
+	rate, err := ParseSize(strings.TrimSuffix(strings.TrimSpace(s), "/s"))
+	if err != nil || rate == 0 {
+		return 0, fmt.Errorf("invalid rate %q", s)
+	}
+	return rate, nil
+}
@@ -0,0 +1,99 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// loadTestConfig writes a config into a temp directory and loads it
+func loadTestConfig(t *testing.T, yaml string) (*InstallerConfig, error) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "installer.yaml")
+	if err := os.WriteFile(path, []byte(yaml), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return LoadConfig(path)
+}
+
+func TestLoadConfigDeduplicatesMixedCaseToolListEntries(t *testing.T) {
+	config, err := loadTestConfig(t, `
+tool_list: [Nuclei, jq, nuclei, NUCLEI, Httpx@1.3.0, httpx@1.3.0, FFUF]
+tools:
+  nuclei:
+    methods:
+      - name: go
+        commands: ["go install nuclei"]
+  httpx:
+    methods:
+      - name: go
+        commands: ["go install httpx@v${version}"]
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"nuclei", "jq", "httpx@1.3.0", "ffuf"}; !slices.Equal(config.ToolList, want) {
+		t.Errorf("tool_list = %q, want %q", config.ToolList, want)
+	}
+	want := []string{
+		"tool_list lists nuclei more than once (as Nuclei, nuclei, NUCLEI); it is processed once",
+		"tool_list lists httpx@1.3.0 more than once (as Httpx@1.3.0, httpx@1.3.0); it is processed once",
+		"tool_list entry FFUF is treated as ffuf; tool names are lowercase",
+	}
+	if warnings := config.Warnings(); !slices.Equal(warnings[:min(len(warnings), len(want))], want) {
+		t.Errorf("warnings = %q, want them to start with %q", warnings, want)
+	}
+}
+
+func TestLoadConfigRejectsMixedCaseToolNames(t *testing.T) {
+	_, err := loadTestConfig(t, `
+tool_list: [nuclei]
+tools:
+  Nuclei:
+    methods:
+      - name: go
+        commands: ["go install nuclei"]
+`)
+	if err == nil || !strings.Contains(err.Error(), "tool Nuclei: tool names must be lowercase; rename it to nuclei") {
+		t.Errorf("LoadConfig error = %v, want the mixed-case tool name rejected", err)
+	}
+}
+
+// FuzzParseToolEntry checks that splitting a tool_list entry loses nothing. Its seed corpus
+// is in testdata/fuzz/FuzzParseToolEntry.
+func FuzzParseToolEntry(f *testing.F) {
+	f.Fuzz(func(t *testing.T, entry string) {
+		name, version := ParseToolEntry(entry)
+		switch {
+		case version == "" && name != entry && name+"@" != entry:
+			t.Fatalf("ParseToolEntry(%q) = %q without a version", entry, name)
+		case version != "" && (name+"@"+version != entry || name == "" || strings.Contains(version, "@")):
+			t.Fatalf("ParseToolEntry(%q) = %q, %q", entry, name, version)
+		}
+	})
+}
+
+// FuzzLoadConfig checks that LoadConfig never panics and that the configs it accepts have
+// a lowercase tool_list without duplicates. Its seed corpus is in testdata/fuzz/FuzzLoadConfig.
+func FuzzLoadConfig(f *testing.F) {
+	f.Fuzz(func(t *testing.T, data []byte) {
+		path := filepath.Join(t.TempDir(), "installer.yaml")
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		config, err := LoadConfig(path)
+		if err != nil {
+			return
+		}
+		seen := map[string]bool{}
+		for _, entry := range config.ToolList {
+			name, _ := ParseToolEntry(entry)
+			if seen[entry] || name != strings.ToLower(name) {
+				t.Fatalf("tool_list = %q, want lowercase entries listed once", config.ToolList)
+			}
+			seen[entry] = true
+		}
+	})
+}
@@ -0,0 +1,503 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Document is a config file loaded for editing. Edits are applied as text splices located
+// through the parsed yaml.Node tree, so comments, anchors, key order and formatting outside
+// the edited entry are preserved byte for byte.
+type Document struct {
+	path string
+	data []byte
+	root yaml.Node
+	bare map[string]bool // tools and tool_list keys loaded without a value, which removals keep
+}
+
+// LoadDocument loads a config file for editing
+func LoadDocument(path string) (*Document, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %v", err)
+	}
+	doc := &Document{path: path}
+	if err := doc.load(data); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// ParseDocument parses config data for editing without a backing file
+func ParseDocument(data []byte) (*Document, error) {
+	doc := &Document{}
+	if err := doc.load(data); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// load parses the document as read, noting the keys it has without a value. Carriage
+// returns ending lines alone would make the lines edits count differ from the parser's.
+func (d *Document) load(data []byte) error {
+	if bytes.Count(data, []byte("\r")) != bytes.Count(data, []byte("\r\n")) {
+		return fmt.Errorf("config file %s ends lines with carriage returns alone; convert them to newlines to edit it", d.path)
+	}
+	if err := d.setData(data); err != nil {
+		return err
+	}
+	d.bare = map[string]bool{}
+	for _, key := range []string{"tools", "tool_list"} {
+		d.bare[key] = d.emptyKeyLine(key) >= 0
+	}
+	return nil
+}
+
+// setData replaces the document text and re-parses it
+func (d *Document) setData(data []byte) error {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return fmt.Errorf("failed to parse config file: %v", err)
+	}
+	if root.Kind == 0 {
+		root = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}}}
+	}
+	if root.Content[0].Kind != yaml.MappingNode {
+		return fmt.Errorf("config file %s is not a mapping", d.path)
+	}
+	d.data, d.root = data, root
+	return nil
+}
+
+// Config decodes the document into an InstallerConfig
+func (d *Document) Config() (*InstallerConfig, error) {
+	var config InstallerConfig
+	if err := d.root.Decode(&config); err != nil {
+		return nil, fmt.Errorf("failed to decode config: %v", err)
+	}
+	return &config, nil
+}
+
+// HasTool reports whether the tools map contains name
+func (d *Document) HasTool(name string) bool {
+	_, value := lookupPair(lookup(d.mapping(), "tools"), name)
+	return value != nil
+}
+
+// AddTool appends a tool to the tools map and to tool_list. The document is left unchanged
+// when the edited text would not decode with the tool, as with unusual indentation.
+func (d *Document) AddTool(name string, tool *ToolConfig) error {
+	if d.HasTool(name) {
+		return fmt.Errorf("tool %s already exists", name)
+	}
+	data, root := d.data, d.root
+	err := d.insertTool(name, tool)
+	if err == nil {
+		err = d.appendToolList(name)
+	}
+	if err == nil {
+		if config, decodeErr := d.Config(); decodeErr != nil || config.Tools[name] == nil || !slices.Contains(config.ToolList, name) {
+			err = fmt.Errorf("cannot add tool %s to this config automatically; add it by hand", name)
+		}
+	}
+	if err != nil {
+		d.data, d.root = data, root
+	}
+	return err
+}
+
+// RemoveTool deletes a tool from the tools map and every tool_list entry referencing it
+func (d *Document) RemoveTool(name string) error {
+	removedList, err := d.removeToolList(name)
+	if err != nil {
+		return err
+	}
+	removedTool, err := d.deleteTool(name)
+	if err != nil {
+		return err
+	}
+	if !removedList && !removedTool {
+		return fmt.Errorf("tool %s is not in the config", name)
+	}
+	// Drop the keys the removal left without a value, as adding the first tool created them,
+	// unless the file was written that way
+	for _, key := range []string{"tools", "tool_list"} {
+		if line := d.emptyKeyLine(key); line >= 0 && !d.bare[key] {
+			lines := splitLines(d.data)
+			if err := d.splice(lineOffset(lines, line), lineOffset(lines, line+1), "", false); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ReplaceTool rewrites a tool's entry in the tools map in place. Comments inside the entry are
+// lost; the rest of the document is left as it was.
+func (d *Document) ReplaceTool(name string, tool *ToolConfig) error {
+	key, tools := lookupPair(d.mapping(), "tools")
+	start, end, err := d.toolLines(name)
+	if err != nil {
+		return err
+	}
+	if start < 0 {
+		return fmt.Errorf("tool %s is not in the config", name)
+	}
+	indent := tools.Content[0].Column - 1
+	block, err := renderBlock(map[string]*ToolConfig{name: tool}, indent, indent-(key.Column-1))
+	if err != nil {
+		return fmt.Errorf("failed to encode tool %s: %v", name, err)
+	}
+	lines := splitLines(d.data)
+	return d.splice(lineOffset(lines, start), lineOffset(lines, end), block, false)
+}
+
+// Bytes returns the current document text
+func (d *Document) Bytes() []byte {
+	return d.data
+}
+
+// Save writes the document back to its file atomically
+func (d *Document) Save() error {
+	info, err := os.Stat(d.path)
+	mode := os.FileMode(0644)
+	if err == nil {
+		mode = info.Mode().Perm()
+	}
+	tmp := d.path + ".tmp"
+	if err := os.WriteFile(tmp, d.data, mode); err != nil {
+		return fmt.Errorf("failed to write config file: %v", err)
+	}
+	return os.Rename(tmp, d.path)
+}
+
+// insertTool adds the tool's block after the last entry of the tools mapping
+func (d *Document) insertTool(name string, tool *ToolConfig) error {
+	root := d.mapping()
+	key, tools := lookupPair(root, "tools")
+	lines := splitLines(d.data)
+
+	if tools != nil && tools.Kind == yaml.MappingNode && tools.Style&yaml.FlowStyle != 0 {
+		return fmt.Errorf("tools is written in flow style and cannot be edited automatically")
+	}
+
+	// Indentation of tool keys and the step used for nested blocks
+	indent, step := d.rootIndent()+2, 2
+	if tools != nil && tools.Kind == yaml.MappingNode && len(tools.Content) > 0 {
+		indent = tools.Content[0].Column - 1
+		step = indent - (key.Column - 1)
+	}
+
+	block, err := renderBlock(map[string]*ToolConfig{name: tool}, indent, step)
+	if err != nil {
+		return fmt.Errorf("failed to encode tool %s: %v", name, err)
+	}
+
+	if key == nil {
+		return d.splice(len(d.data), len(d.data), strings.Repeat(" ", d.rootIndent())+"tools:\n"+block, true)
+	}
+	end := d.sectionEnd(key, lines)
+	return d.splice(lineOffset(lines, end), lineOffset(lines, end), block, true)
+}
+
+// appendToolList adds name as the last tool_list entry
+func (d *Document) appendToolList(name string) error {
+	key, list := lookupPair(d.mapping(), "tool_list")
+	lines := splitLines(d.data)
+
+	switch {
+	case key == nil:
+		indent := strings.Repeat(" ", d.rootIndent())
+		return d.splice(len(d.data), len(d.data), indent+"tool_list:\n"+indent+"  - "+name+"\n", true)
+	case list.Kind == yaml.SequenceNode && list.Style&yaml.FlowStyle != 0:
+		if len(list.Content) == 0 {
+			open := nodeOffset(lines, list) + 1
+			return d.splice(open, open, name, false)
+		}
+		end := scalarEnd(d.data, lines, list.Content[len(list.Content)-1])
+		return d.splice(end, end, ", "+name, false)
+	case list.Kind == yaml.SequenceNode && len(list.Content) > 0:
+		last := list.Content[len(list.Content)-1]
+		line := lines[last.Line-1]
+		prefix := line[:runeOffset(line, last.Column-1)]
+		at := lineOffset(lines, last.Line)
+		return d.splice(at, at, prefix+name+"\n", true)
+	default:
+		// Empty "tool_list:" with no items
+		at := lineOffset(lines, key.Line)
+		return d.splice(at, at, strings.Repeat(" ", key.Column+1)+"- "+name+"\n", true)
+	}
+}
+
+// deleteTool removes the tool's block from the tools mapping
+func (d *Document) deleteTool(name string) (bool, error) {
+	start, end, err := d.toolLines(name)
+	if err != nil || start < 0 {
+		return false, err
+	}
+	lines := splitLines(d.data)
+	return true, d.splice(lineOffset(lines, start), lineOffset(lines, end), "", false)
+}
+
+// toolLines returns the line range of the tool's block in the tools mapping, or -1 when the
+// tool is not in it
+func (d *Document) toolLines(name string) (start, end int, err error) {
+	key, tools := lookupPair(d.mapping(), "tools")
+	if tools == nil || tools.Kind != yaml.MappingNode {
+		return -1, -1, nil
+	}
+	if tools.Style&yaml.FlowStyle != 0 {
+		return -1, -1, fmt.Errorf("tools is written in flow style and cannot be edited automatically")
+	}
+
+	lines := splitLines(d.data)
+	for i := 0; i+1 < len(tools.Content); i += 2 {
+		if tools.Content[i].Value != name {
+			continue
+		}
+		start := tools.Content[i].Line - 1
+		end := d.sectionEnd(key, lines)
+		if i+2 < len(tools.Content) {
+			next := tools.Content[i+2]
+			end = trimTrailing(lines, start+1, next.Line-1, next.Column-1)
+		}
+		return start, end, nil
+	}
+	return -1, -1, nil
+}
+
+// removeToolList removes every tool_list entry naming the tool, with or without a version suffix
+func (d *Document) removeToolList(name string) (bool, error) {
+	removed := false
+	for {
+		_, list := lookupPair(d.mapping(), "tool_list")
+		if list == nil || list.Kind != yaml.SequenceNode {
+			return removed, nil
+		}
+
+		index := -1
+		for i, item := range list.Content {
+			if entry, _ := ParseToolEntry(item.Value); entry == name {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			return removed, nil
+		}
+		removed = true
+
+		lines := splitLines(d.data)
+		item := list.Content[index]
+		var err error
+		switch {
+		case list.Style&yaml.FlowStyle == 0:
+			err = d.splice(lineOffset(lines, item.Line-1), lineOffset(lines, item.Line), "", false)
+		case index > 0:
+			err = d.splice(scalarEnd(d.data, lines, list.Content[index-1]), scalarEnd(d.data, lines, item), "", false)
+		case len(list.Content) > 1:
+			err = d.splice(nodeOffset(lines, item), nodeOffset(lines, list.Content[1]), "", false)
+		default:
+			err = d.splice(nodeOffset(lines, item), scalarEnd(d.data, lines, item), "", false)
+		}
+		if err != nil {
+			return removed, err
+		}
+	}
+}
+
+// emptyKeyLine returns the line index of a top-level key written on its own line without a
+// value, or -1 when the key is missing or has one
+func (d *Document) emptyKeyLine(name string) int {
+	key, value := lookupPair(d.mapping(), name)
+	if key == nil || value.Kind != yaml.ScalarNode || value.Tag != "!!null" || value.Value != "" {
+		return -1
+	}
+	if strings.TrimSpace(splitLines(d.data)[key.Line-1]) != name+":" {
+		return -1
+	}
+	return key.Line - 1
+}
+
+// sectionEnd returns the line index just past the last content line of a top-level key's block
+func (d *Document) sectionEnd(key *yaml.Node, lines []string) int {
+	root := d.mapping()
+	next := len(lines)
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i] == key && i+2 < len(root.Content) {
+			next = root.Content[i+2].Line - 1
+		}
+	}
+	return trimTrailing(lines, key.Line, next, key.Column-1)
+}
+
+// trimTrailing walks back from end over blank lines and comments indented at most indent columns,
+// which belong to whatever follows, and returns the new end
+func trimTrailing(lines []string, start, end, indent int) int {
+	for end > start {
+		line := strings.TrimRight(lines[end-1], "\r\n")
+		trimmed := strings.TrimLeft(line, " \t")
+		if trimmed == "" || (strings.HasPrefix(trimmed, "#") && len(line)-len(trimmed) <= indent) {
+			end--
+			continue
+		}
+		break
+	}
+	return end
+}
+
+// splice replaces data[start:end] with text and re-parses the document. When newline is set
+// and text is inserted after a final line without a trailing newline, one is added first.
+// Lines of text end in \r\n when the document's first line does, and a document without a
+// final newline keeps ending without one.
+func (d *Document) splice(start, end int, text string, newline bool) error {
+	prefix := d.data[:start]
+	if newline && len(prefix) > 0 && prefix[len(prefix)-1] != '\n' {
+		text = "\n" + text
+	}
+	if lines := splitLines(d.data); len(lines) > 0 && strings.HasSuffix(lines[0], "\r\n") {
+		text = strings.ReplaceAll(text, "\n", "\r\n")
+	}
+
+	var buf bytes.Buffer
+	buf.Write(prefix)
+	buf.WriteString(text)
+	buf.Write(d.data[end:])
+	data := buf.Bytes()
+	if len(d.data) > 0 && d.data[len(d.data)-1] != '\n' {
+		data = bytes.TrimSuffix(data, []byte("\n"))
+		if bytes.HasSuffix(buf.Bytes(), []byte("\r\n")) {
+			data = bytes.TrimSuffix(data, []byte("\r"))
+		}
+	}
+	return d.setData(data)
+}
+
+// rootIndent returns the column the document's top-level keys start at, where new ones go
+func (d *Document) rootIndent() int {
+	if root := d.mapping(); len(root.Content) > 0 {
+		return root.Content[0].Column - 1
+	}
+	return 0
+}
+
+// mapping returns the document's top-level mapping
+func (d *Document) mapping() *yaml.Node {
+	return d.root.Content[0]
+}
+
+// MarshalTools renders a config file holding tools, listed in tool_list in the given order
+func MarshalTools(toolList []string, tools map[string]*ToolConfig) ([]byte, error) {
+	text, err := renderBlock(struct {
+		ToolList []string               `yaml:"tool_list"`
+		Tools    map[string]*ToolConfig `yaml:"tools"`
+	}{toolList, tools}, 0, 2)
+	return []byte(text), err
+}
+
+// renderBlock encodes value as block YAML indented by indent spaces, nesting by step
+func renderBlock(value interface{}, indent, step int) (string, error) {
+	if step <= 0 {
+		step = 2
+	}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(step)
+	if err := enc.Encode(value); err != nil {
+		return "", err
+	}
+	if err := enc.Close(); err != nil {
+		return "", err
+	}
+
+	pad := strings.Repeat(" ", indent)
+	var out strings.Builder
+	for _, line := range strings.SplitAfter(buf.String(), "\n") {
+		if line != "" {
+			out.WriteString(pad + line)
+		}
+	}
+	return out.String(), nil
+}
+
+// splitLines splits data into lines that keep their line endings
+func splitLines(data []byte) []string {
+	lines := strings.SplitAfter(string(data), "\n")
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// lineOffset returns the byte offset of the start of line index n (0-based)
+func lineOffset(lines []string, n int) int {
+	offset := 0
+	for i := 0; i < n && i < len(lines); i++ {
+		offset += len(lines[i])
+	}
+	return offset
+}
+
+// runeOffset converts a 0-based column in runes to a byte offset within line
+func runeOffset(line string, column int) int {
+	for i := range line {
+		if column == 0 {
+			return i
+		}
+		column--
+	}
+	return len(line)
+}
+
+// nodeOffset returns the byte offset where a node starts
+func nodeOffset(lines []string, node *yaml.Node) int {
+	return lineOffset(lines, node.Line-1) + runeOffset(lines[node.Line-1], node.Column-1)
+}
+
+// scalarEnd returns the byte offset just past a single-line scalar's source text
+func scalarEnd(data []byte, lines []string, node *yaml.Node) int {
+	start := nodeOffset(lines, node)
+	switch node.Style {
+	case yaml.DoubleQuotedStyle, yaml.SingleQuotedStyle:
+		quote := data[start]
+		for i := start + 1; i < len(data); i++ {
+			if data[i] == '\\' && quote == '"' {
+				i++
+				continue
+			}
+			if data[i] == quote {
+				if quote == '\'' && i+1 < len(data) && data[i+1] == '\'' {
+					i++
+					continue
+				}
+				return i + 1
+			}
+		}
+		return len(data)
+	}
+	return start + len(node.Value)
+}
+
+// lookup returns the value for key in a mapping node
+func lookup(mapping *yaml.Node, key string) *yaml.Node {
+	_, value := lookupPair(mapping, key)
+	return value
+}
+
+// lookupPair returns the key and value nodes for key in a mapping node
+func lookupPair(mapping *yaml.Node, key string) (*yaml.Node, *yaml.Node) {
+	if mapping == nil || mapping.Kind != yaml.MappingNode {
+		return nil, nil
+	}
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i], mapping.Content[i+1]
+		}
+	}
+	return nil, nil
+}
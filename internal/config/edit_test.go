@@ -0,0 +1,117 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// checkGolden compares got with the golden file at path, or rewrites the file when
+// UPDATE_GOLDEN is set
+func checkGolden(t *testing.T, path string, got []byte) {
+	t.Helper()
+	if os.Getenv("UPDATE_GOLDEN") != "" {
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run the test with UPDATE_GOLDEN=1 to create it)", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("output differs from %s:\n got %q\nwant %q", path, got, want)
+	}
+}
+
+func TestDocumentAddThenRemoveRestoresTheFile(t *testing.T) {
+	inputs, err := filepath.Glob(filepath.Join("testdata", "edit", "*.yaml"))
+	if err != nil || len(inputs) == 0 {
+		t.Fatalf("no inputs in testdata/edit (%v)", err)
+	}
+	tool := &ToolConfig{Methods: []InstallMethod{{Name: "apt", Commands: []Command{{Run: "sudo apt-get install -y evtool"}}}}}
+	for _, input := range inputs {
+		t.Run(filepath.Base(input), func(t *testing.T) {
+			original, err := os.ReadFile(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			doc, err := ParseDocument(original)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := doc.AddTool("evtool", tool); err != nil {
+				t.Fatalf("AddTool: %v", err)
+			}
+			checkGolden(t, strings.TrimSuffix(input, ".yaml")+".golden", doc.Bytes())
+			config, err := doc.Config()
+			if err != nil || config.Tools["evtool"] == nil || config.ToolList[len(config.ToolList)-1] != "evtool" {
+				t.Fatalf("the added tool does not decode as the last tool_list entry (%v)", err)
+			}
+
+			if err := doc.RemoveTool("evtool"); err != nil {
+				t.Fatalf("RemoveTool: %v", err)
+			}
+			if !bytes.Equal(doc.Bytes(), original) {
+				t.Errorf("after add and remove:\n%q\nwant the original:\n%q", doc.Bytes(), original)
+			}
+		})
+	}
+}
+
+func TestDocumentAddToolLeavesLayoutsItCannotExtendUnchanged(t *testing.T) {
+	// The value of notes sits left of its key, where a new top-level key would not decode
+	original := []byte("  bindir: /opt/tools/bin\n  notes:\n\"kept at the margin\"\n")
+	doc, err := ParseDocument(original)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tool := &ToolConfig{Methods: []InstallMethod{{Name: "apt", Commands: []Command{{Run: "sudo apt-get install -y evtool"}}}}}
+	if err := doc.AddTool("evtool", tool); err == nil || !strings.Contains(err.Error(), "add it by hand") {
+		t.Fatalf("AddTool error = %v, want a request to add the tool by hand", err)
+	}
+	if !bytes.Equal(doc.Bytes(), original) || doc.HasTool("evtool") {
+		t.Errorf("after the refused add:\n%q\nwant the original:\n%q", doc.Bytes(), original)
+	}
+}
+
+func TestParseDocumentRejectsLoneCarriageReturns(t *testing.T) {
+	for _, data := range []string{"bindir: /opt/tools/bin\rtool_list: []\r", "tool_list:\r\n- jq\rtools: {}\r\n"} {
+		if _, err := ParseDocument([]byte(data)); err == nil || !strings.Contains(err.Error(), "carriage returns alone") {
+			t.Errorf("ParseDocument(%q) error = %v, want the lone carriage returns reported", data, err)
+		}
+	}
+}
+
+// FuzzParseDocument checks that ParseDocument never panics, and that adding a tool to a
+// document and removing it again restores the document. Its seed corpus is in
+// testdata/fuzz/FuzzParseDocument.
+func FuzzParseDocument(f *testing.F) {
+	tool := &ToolConfig{Methods: []InstallMethod{{Name: "apt", Commands: []Command{{Run: "sudo apt-get install -y fuzztool"}}}}}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		doc, err := ParseDocument(data)
+		if err != nil {
+			return
+		}
+		if _, err := doc.Config(); err != nil {
+			return
+		}
+		if err := doc.AddTool("fuzztool", tool); err != nil {
+			return
+		}
+		config, err := doc.Config()
+		if err != nil || config.Tools["fuzztool"] == nil || !slices.Contains(config.ToolList, "fuzztool") {
+			t.Fatalf("after AddTool the document does not decode with the tool (%v):\n%s", err, doc.Bytes())
+		}
+		if err := doc.RemoveTool("fuzztool"); err != nil {
+			t.Fatalf("RemoveTool of the added tool: %v", err)
+		}
+		if !bytes.Equal(doc.Bytes(), data) {
+			t.Fatalf("after add and remove:\n%q\nwant the original:\n%q", doc.Bytes(), data)
+		}
+	})
+}
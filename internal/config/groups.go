@@ -0,0 +1,155 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// AllGroup is the group of every tool_list entry
+const AllGroup = "all"
+
+// Selection is the tool_list entries a list of selection patterns resolved to
+type Selection struct {
+	Entries  []string          // Selected entries, in tool_list order, then other configured tools in the order named
+	Excluded map[string]string // Rule that excluded each entry an include selected, e.g. "!metasploit in group slim"
+}
+
+// IsSelection reports whether args hold group references or exclusions, which
+// ResolveSelection has to expand, rather than only tool names
+func IsSelection(args []string) bool {
+	return slices.ContainsFunc(args, func(arg string) bool {
+		return strings.HasPrefix(arg, "@") || strings.HasPrefix(arg, "!")
+	})
+}
+
+// ResolveSelection expands selection patterns: tool_list entries or tool names, @all and
+// @group references, and exclusions prefixed with "!", such as !metasploit or !@huge. All
+// includes are expanded first and the exclusions applied after, both within each group and
+// for the patterns themselves; patterns with only exclusions exclude from @all.
+func (c *InstallerConfig) ResolveSelection(patterns []string) (Selection, error) {
+	sel := Selection{Excluded: map[string]string{}}
+	if !slices.ContainsFunc(patterns, func(p string) bool { return !strings.HasPrefix(p, "!") }) {
+		patterns = append([]string{"@" + AllGroup}, patterns...)
+	}
+	entries, err := c.expandSelection(patterns, "", nil, sel.Excluded)
+	if err != nil {
+		return Selection{}, err
+	}
+	sel.Entries = entries
+	// An entry an inner group excluded may be selected again, e.g. by [@slim, metasploit]
+	for _, entry := range entries {
+		delete(sel.Excluded, entry)
+	}
+	return sel, nil
+}
+
+// expandSelection resolves the patterns of group, "" for those given on the command line.
+// stack holds the groups being expanded, to catch groups that reference themselves.
+func (c *InstallerConfig) expandSelection(patterns []string, group string, stack []string, excluded map[string]string) ([]string, error) {
+	var included []string
+	add := func(entries ...string) {
+		for _, entry := range entries {
+			if !slices.Contains(included, entry) {
+				included = append(included, entry)
+			}
+		}
+	}
+	var exclusions []string
+	for _, pattern := range patterns {
+		if rest, ok := strings.CutPrefix(pattern, "!"); ok {
+			exclusions = append(exclusions, rest)
+			continue
+		}
+		entries, err := c.expandPattern(pattern, stack, excluded)
+		if err != nil {
+			return nil, err
+		}
+		add(entries...)
+	}
+	for _, exclusion := range exclusions {
+		entries, err := c.expandPattern(exclusion, stack, map[string]string{})
+		if err != nil {
+			return nil, err
+		}
+		rule := "!" + exclusion
+		if group != "" {
+			rule += " in group " + group
+		}
+		included = slices.DeleteFunc(included, func(entry string) bool {
+			if slices.Contains(entries, entry) {
+				excluded[entry] = rule
+				return true
+			}
+			return false
+		})
+	}
+	return c.inListOrder(included), nil
+}
+
+// expandPattern resolves one include or the target of one exclusion to entries
+func (c *InstallerConfig) expandPattern(pattern string, stack []string, excluded map[string]string) ([]string, error) {
+	name, isGroup := strings.CutPrefix(pattern, "@")
+	switch {
+	case isGroup && name == AllGroup:
+		return slices.Clone(c.ToolList), nil
+	case isGroup:
+		members, ok := c.Groups[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown group @%s", name)
+		}
+		if slices.Contains(stack, name) {
+			return nil, fmt.Errorf("includes itself through %s", strings.Join(append(stack[slices.Index(stack, name):], name), " → "))
+		}
+		return c.expandSelection(members, name, append(stack, name), excluded)
+	}
+	var entries []string
+	for _, entry := range c.ToolList {
+		if tool, _ := ParseToolEntry(entry); entry == pattern || tool == pattern {
+			entries = append(entries, entry)
+		}
+	}
+	if len(entries) == 0 {
+		tool, _ := ParseToolEntry(pattern)
+		if c.Tools[tool] == nil {
+			return nil, fmt.Errorf("%s is not configured under tools or in tool_list", pattern)
+		}
+		entries = []string{pattern}
+	}
+	return entries, nil
+}
+
+// inListOrder sorts entries in tool_list order, keeping entries outside it last
+func (c *InstallerConfig) inListOrder(entries []string) []string {
+	var ordered []string
+	for _, entry := range c.ToolList {
+		if slices.Contains(entries, entry) {
+			ordered = append(ordered, entry)
+		}
+	}
+	for _, entry := range entries {
+		if !slices.Contains(c.ToolList, entry) {
+			ordered = append(ordered, entry)
+		}
+	}
+	return ordered
+}
+
+// validateGroups checks that the groups resolve: their names, references and exclusions
+func (c *InstallerConfig) validateGroups() error {
+	for _, name := range slices.Sorted(maps.Keys(c.Groups)) {
+		switch {
+		case name == AllGroup:
+			return fmt.Errorf("group %s: the name is reserved for every tool_list entry", name)
+		case name == "" || strings.ContainsAny(name, "@! \t"):
+			return fmt.Errorf("group %q: names must not be empty or contain @, ! or spaces", name)
+		case len(c.Groups[name]) == 0:
+			return fmt.Errorf("group %s lists no tools", name)
+		}
+		if _, err := c.expandSelection(c.Groups[name], name, []string{name}, map[string]string{}); err != nil {
+			return fmt.Errorf("group %s: %v", name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package config
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// groupsConfig has groups built from @all, other groups and exclusions
+const groupsConfig = `
+tool_list: [subfinder, httpx, nuclei, metasploit, burpsuite, jq]
+groups:
+  slim: ["@all", "!metasploit", "!burpsuite"]
+  recon: [subfinder, httpx, nuclei]
+  ci: ["!@recon", "@slim"]
+tools:
+  ffuf:
+    methods: [{name: go, commands: ["go install ffuf"]}]
+`
+
+func TestResolveSelection(t *testing.T) {
+	config, err := loadTestConfig(t, groupsConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		patterns []string
+		entries  []string
+		excluded map[string]string
+	}{
+		{[]string{"@all"}, []string{"subfinder", "httpx", "nuclei", "metasploit", "burpsuite", "jq"}, map[string]string{}},
+		{[]string{"@slim"}, []string{"subfinder", "httpx", "nuclei", "jq"}, map[string]string{"metasploit": "!metasploit in group slim", "burpsuite": "!burpsuite in group slim"}},
+		{[]string{"@ci"}, []string{"jq"}, map[string]string{
+			"metasploit": "!metasploit in group slim", "burpsuite": "!burpsuite in group slim",
+			"subfinder": "!@recon in group ci", "httpx": "!@recon in group ci", "nuclei": "!@recon in group ci",
+		}},
+		{[]string{"!nuclei", "@recon"}, []string{"subfinder", "httpx"}, map[string]string{"nuclei": "!nuclei"}},
+		{[]string{"!@recon", "!jq"}, []string{"metasploit", "burpsuite"}, map[string]string{
+			"subfinder": "!@recon", "httpx": "!@recon", "nuclei": "!@recon", "jq": "!jq",
+		}},
+		// An entry a group excluded can be selected again, and configured tools join the end
+		{[]string{"ffuf", "@slim", "metasploit"}, []string{"subfinder", "httpx", "nuclei", "metasploit", "jq", "ffuf"}, map[string]string{"burpsuite": "!burpsuite in group slim"}},
+	} {
+		t.Run(strings.Join(tc.patterns, " "), func(t *testing.T) {
+			sel, err := config.ResolveSelection(tc.patterns)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(sel.Entries, tc.entries) {
+				t.Errorf("entries = %q, want %q", sel.Entries, tc.entries)
+			}
+			if !maps.Equal(sel.Excluded, tc.excluded) {
+				t.Errorf("excluded = %q, want %q", sel.Excluded, tc.excluded)
+			}
+		})
+	}
+
+	if _, err := config.ResolveSelection([]string{"@missing"}); err == nil || err.Error() != "unknown group @missing" {
+		t.Errorf("ResolveSelection(@missing) = %v", err)
+	}
+	if _, err := config.ResolveSelection([]string{"amass"}); err == nil || err.Error() != "amass is not configured under tools or in tool_list" {
+		t.Errorf("ResolveSelection(amass) = %v", err)
+	}
+}
+
+func TestLoadConfigValidatesGroups(t *testing.T) {
+	for _, tc := range []struct {
+		groups string
+		err    string
+	}{
+		{`{all: [jq]}`, "group all: the name is reserved for every tool_list entry"},
+		{`{"a@b": [jq]}`, `group "a@b": names must not be empty or contain @, ! or spaces`},
+		{`{empty: []}`, "group empty lists no tools"},
+		{`{slim: ["@huge"]}`, "group slim: unknown group @huge"},
+		{`{slim: [amass]}`, "group slim: amass is not configured under tools or in tool_list"},
+		{`{ci: ["@slim"], slim: ["@all", "!@ci"]}`, "group ci: includes itself through ci → slim → ci"},
+	} {
+		_, err := loadTestConfig(t, "tool_list: [jq]\ngroups: "+tc.groups+"\n")
+		if err == nil || !strings.Contains(err.Error(), tc.err) {
+			t.Errorf("groups %s: LoadConfig = %v, want %q", tc.groups, err, tc.err)
+		}
+	}
+}
+
+func TestIsSelection(t *testing.T) {
+	for _, tc := range []struct {
+		args []string
+		want bool
+	}{
+		{[]string{"jq", "httpx@1.3.0"}, false},
+		{[]string{"@recon"}, true},
+		{[]string{"jq", "!nuclei"}, true},
+		{nil, false},
+	} {
+		if got := IsSelection(tc.args); got != tc.want {
+			t.Errorf("IsSelection(%q) = %v, want %v", tc.args, got, tc.want)
+		}
+	}
+}
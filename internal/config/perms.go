@@ -0,0 +1,37 @@
+package config
+
+import (
+	"maps"
+	"slices"
+)
+
+// InsecureFiles returns why the files a run takes commands from can be changed by users
+// other than the one running the installer, root and the user who invoked sudo: the config
+// itself, its versions_file, whose versions end up in commands, and the scripts of script
+// methods. skip_permission_check turns the check off.
+func (c *InstallerConfig) InsecureFiles() []string {
+	if c.SkipPermissionCheck {
+		return nil
+	}
+	files := []string{c.Path}
+	if c.VersionsPath != "" {
+		files = append(files, c.VersionsPath)
+	}
+	for _, name := range slices.Sorted(maps.Keys(c.Tools)) {
+		if c.Tools[name] == nil {
+			continue
+		}
+		for _, method := range c.Tools[name].Methods {
+			if method.Type == MethodScript && method.File != "" && !slices.Contains(files, c.ScriptPath(method)) {
+				files = append(files, c.ScriptPath(method))
+			}
+		}
+	}
+	var problems []string
+	for _, path := range files {
+		if problem := fileInsecurity(path); problem != "" {
+			problems = append(problems, path+" "+problem)
+		}
+	}
+	return problems
+}
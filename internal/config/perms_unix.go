@@ -0,0 +1,61 @@
+//go:build !windows
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"syscall"
+)
+
+// fileInsecurity returns how users other than the trusted ones can change a file, or ""
+// when they cannot or the file does not exist
+func fileInsecurity(path string) string {
+	info, err := os.Stat(path)
+	if err != nil {
+		return ""
+	}
+	st, _ := info.Sys().(*syscall.Stat_t)
+	switch mode := info.Mode().Perm(); {
+	case mode&0002 != 0:
+		return "is writable by every user"
+	// Groups named after their only user, as many distributions create them, are that user
+	case mode&0020 != 0 && st != nil && !privateGroup(st.Uid, st.Gid):
+		return "is writable by its group"
+	}
+	if st != nil && !trustedOwner(st.Uid) {
+		owner := strconv.FormatUint(uint64(st.Uid), 10)
+		if u, err := user.LookupId(owner); err == nil {
+			owner = u.Username
+		}
+		return fmt.Sprintf("is owned by %s, another user", owner)
+	}
+	// Anyone can replace a file in a directory every user can write, unless it is sticky
+	if dir, err := os.Stat(filepath.Dir(path)); err == nil && dir.Mode().Perm()&0002 != 0 && dir.Mode()&os.ModeSticky == 0 {
+		return fmt.Sprintf("is in %s, where every user can replace it", filepath.Dir(path))
+	}
+	return ""
+}
+
+// trustedOwner reports whether the owner of a file may change the commands of this run: root,
+// the user running the installer and the user who ran it through sudo
+func trustedOwner(uid uint32) bool {
+	if uid == 0 || int(uid) == os.Geteuid() {
+		return true
+	}
+	sudoUID, err := strconv.ParseUint(os.Getenv("SUDO_UID"), 10, 32)
+	return err == nil && os.Geteuid() == 0 && uint32(sudoUID) == uid
+}
+
+// privateGroup reports whether gid is the group of the same name as the user owning a file
+func privateGroup(uid, gid uint32) bool {
+	u, err := user.LookupId(strconv.FormatUint(uint64(uid), 10))
+	if err != nil {
+		return false
+	}
+	g, err := user.LookupGroupId(strconv.FormatUint(uint64(gid), 10))
+	return err == nil && g.Name == u.Username
+}
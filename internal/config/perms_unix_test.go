@@ -0,0 +1,123 @@
+//go:build !windows
+
+package config
+
+import (
+	"os"
+	"os/user"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// otherUser returns a user other than the one running the test, and its group
+func otherUser(t *testing.T) (uid, gid int, name string) {
+	t.Helper()
+	if os.Geteuid() != 0 {
+		t.Skip("giving files to other users needs root")
+	}
+	for _, candidate := range []string{"daemon", "bin", "nobody"} {
+		if u, err := user.Lookup(candidate); err == nil && u.Uid != "0" {
+			uid, _ = strconv.Atoi(u.Uid)
+			gid, _ = strconv.Atoi(u.Gid)
+			return uid, gid, u.Username
+		}
+	}
+	t.Skip("no user to give files to")
+	return 0, 0, ""
+}
+
+func TestFileInsecurity(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		mode    os.FileMode
+		dirMode os.FileMode
+		owner   bool // Owned by another user
+		group   bool // In a group of another user, rather than the owner's own group
+		sudo    bool // The other user ran the installer through sudo
+		want    string
+	}{
+		{name: "owner only", mode: 0644, want: ""},
+		{name: "read-only", mode: 0400, want: ""},
+		{name: "world-writable", mode: 0666, want: "is writable by every user"},
+		{name: "world-writable and owned by another user", mode: 0646, owner: true, want: "is writable by every user"},
+		{name: "group-writable in the owner's own group", mode: 0664, want: ""},
+		{name: "group-writable in another group", mode: 0664, group: true, want: "is writable by its group"},
+		{name: "group-readable in another group", mode: 0644, group: true, want: ""},
+		{name: "owned by another user", mode: 0644, owner: true, want: "is owned by @USER@, another user"},
+		{name: "owned by the sudo user", mode: 0644, owner: true, sudo: true, want: ""},
+		{name: "in a world-writable directory", mode: 0644, dirMode: 0777, want: "is in @DIR@, where every user can replace it"},
+		{name: "in a sticky world-writable directory", mode: 0644, dirMode: 0777 | os.ModeSticky, want: ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "installer.yaml")
+			if err := os.WriteFile(path, []byte("tool_list: []\n"), 0600); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Chmod(path, tc.mode); err != nil {
+				t.Fatal(err)
+			}
+			if tc.dirMode != 0 {
+				if err := os.Chmod(dir, tc.dirMode); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want := tc.want
+			if tc.owner || tc.group {
+				uid, gid, name := otherUser(t)
+				if !tc.owner {
+					uid = os.Geteuid()
+				}
+				if err := os.Chown(path, uid, gid); err != nil {
+					t.Fatal(err)
+				}
+				want = strings.ReplaceAll(want, "@USER@", name)
+				t.Setenv("SUDO_UID", "")
+				if tc.sudo {
+					t.Setenv("SUDO_UID", strconv.Itoa(uid))
+				}
+			}
+			want = strings.ReplaceAll(want, "@DIR@", dir)
+			if got := fileInsecurity(path); got != want {
+				t.Errorf("fileInsecurity = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestInsecureFiles(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string, mode os.FileMode) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("echo install\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chmod(path, mode); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	config := &InstallerConfig{
+		Path:         write("installer.yaml", 0666),
+		VersionsPath: write("versions.yaml", 0666),
+		Tools: map[string]*ToolConfig{
+			"evtool": {Methods: []InstallMethod{{Name: "script", Type: MethodScript, File: write("install.sh", 0666)}}},
+			"other":  {Methods: []InstallMethod{{Name: "script", Type: MethodScript, File: write("safe.sh", 0644)}}},
+		},
+	}
+	want := []string{
+		filepath.Join(dir, "installer.yaml") + " is writable by every user",
+		filepath.Join(dir, "versions.yaml") + " is writable by every user",
+		filepath.Join(dir, "install.sh") + " is writable by every user",
+	}
+	if problems := config.InsecureFiles(); !slices.Equal(problems, want) {
+		t.Errorf("InsecureFiles = %q, want %q", problems, want)
+	}
+	config.SkipPermissionCheck = true
+	if problems := config.InsecureFiles(); problems != nil {
+		t.Errorf("InsecureFiles = %q with skip_permission_check, want nothing", problems)
+	}
+}
@@ -0,0 +1,8 @@
+//go:build windows
+
+package config
+
+// fileInsecurity reports nothing on Windows, whose ACLs the check does not read
+func fileInsecurity(path string) string {
+	return ""
+}
@@ -0,0 +1,297 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Security holds the policies a config must satisfy to load
+type Security struct {
+	RequireChecksums  bool `yaml:"require_checksums,omitempty"`  // Reject methods that run or install what they fetch without a pinned sha256
+	RequireSignatures bool `yaml:"require_signatures,omitempty"` // Also require a minisign or cosign signature on download and github_release methods, with require_checksums
+}
+
+// Rules of the security policy
+const (
+	RuleChecksum    = "checksum"      // download and github_release methods pin a sha256
+	RuleSignature   = "signature"     // download and github_release methods verify a signature
+	RulePipeToShell = "pipe-to-shell" // Commands do not run what curl or wget fetch
+	RuleBootstrap   = "bootstrap"     // Toolchains are not bootstrapped from unpinned downloads
+)
+
+// PolicyViolation is a method breaking a rule of the security policy
+type PolicyViolation struct {
+	Tool   string `json:"tool"`
+	Method string `json:"method"`
+	Rule   string `json:"rule"`
+	Detail string `json:"detail"`
+}
+
+func (v PolicyViolation) String() string {
+	return fmt.Sprintf("tool %s: method %q: %s", v.Tool, v.Method, v.Detail)
+}
+
+// PolicyError is returned by Validate when methods break the security policy. It lists
+// every violation so that they can be fixed in one pass.
+type PolicyError struct {
+	Violations []PolicyViolation
+}
+
+func (e *PolicyError) Error() string {
+	lines := []string{fmt.Sprintf("security.require_checksums: %d violations:", len(e.Violations))}
+	for _, v := range e.Violations {
+		lines = append(lines, "  "+v.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// PolicyReport is the compliance of a config with the security policy
+type PolicyReport struct {
+	Config            string            `json:"config"`
+	RequireChecksums  bool              `json:"require_checksums"`
+	RequireSignatures bool              `json:"require_signatures"`
+	Enforced          bool              `json:"enforced"`  // Whether the config fails to load while it has violations
+	Compliant         bool              `json:"compliant"` // Whether no method breaks a rule
+	Methods           int               `json:"methods"`   // Methods checked
+	Violations        []PolicyViolation `json:"violations"`
+}
+
+// PolicyReport checks every method against the security policy. The checksum, pipe-to-shell
+// and bootstrap rules are checked whether or not require_checksums is set, so that a config
+// can be checked before turning it on; the signature rule only with require_signatures.
+func (c *InstallerConfig) PolicyReport() PolicyReport {
+	report := PolicyReport{
+		Config:            c.Path,
+		RequireChecksums:  c.Security.RequireChecksums,
+		RequireSignatures: c.Security.RequireSignatures,
+		Enforced:          c.Security.RequireChecksums,
+		Violations:        []PolicyViolation{},
+	}
+	for _, name := range slices.Sorted(maps.Keys(c.Tools)) {
+		if c.Tools[name] == nil {
+			continue
+		}
+		for _, method := range c.Tools[name].Methods {
+			report.Methods++
+			for _, v := range methodViolations(method, c.Security.RequireSignatures) {
+				v.Tool, v.Method = name, method.Name
+				report.Violations = append(report.Violations, v)
+			}
+		}
+	}
+	report.Compliant = len(report.Violations) == 0
+	return report
+}
+
+// pipeToShell matches commands running what curl or wget fetch: piped into a shell, or
+// through command or process substitution, e.g. sh -c "$(curl -fsSL ...)"
+var pipeToShell = []*regexp.Regexp{
+	regexp.MustCompile(`\b(curl|wget)\b[^|;&]*\|\s*(sudo\s+(-\S+\s+)*)?(env\s+(\S+=\S*\s+)*)?(ba|da|k|z|fi)?sh\b`),
+	regexp.MustCompile(`\b(ba|da|k|z|fi)?sh\b[^;&|]*(\$\(|<\(|` + "`" + `)\s*(curl|wget)\b`),
+}
+
+// methodViolations returns the rules a method breaks, without its tool and name
+func methodViolations(method InstallMethod, signatures bool) []PolicyViolation {
+	var violations []PolicyViolation
+	switch method.Type {
+	case MethodDownload, MethodGithubRelease:
+		if method.SHA256 == "" {
+			violations = append(violations, PolicyViolation{Rule: RuleChecksum, Detail: method.Type + " method without sha256"})
+		}
+		if signatures && method.MinisignKey == "" && method.Cosign == nil {
+			violations = append(violations, PolicyViolation{Rule: RuleSignature, Detail: method.Type + " method without minisign_pubkey or cosign"})
+		}
+	case MethodCargo, MethodNpm, MethodMise:
+		if method.Bootstrap {
+			violations = append(violations, PolicyViolation{Rule: RuleBootstrap,
+				Detail: fmt.Sprintf("bootstrap: true installs %s from an unpinned download; install it before the run", toolchainOf(method.Type))})
+		}
+	}
+	for _, command := range append(method.Runs(), method.Cleanup...) {
+		if slices.ContainsFunc(pipeToShell, func(pattern *regexp.Regexp) bool { return pattern.MatchString(command) }) {
+			violations = append(violations, PolicyViolation{Rule: RulePipeToShell,
+				Detail: fmt.Sprintf("command %q runs a download; use a script method with url and sha256", command)})
+		}
+	}
+	if command, file := runsDownload(append(method.Runs(), method.Cleanup...)); command != "" {
+		violations = append(violations, PolicyViolation{Rule: RulePipeToShell,
+			Detail: fmt.Sprintf("command %q runs %s, which curl or wget downloaded; use a script method with url and sha256", command, file)})
+	}
+	return violations
+}
+
+// shells are the commands running a script file given as their argument
+var shells = []string{"sh", "bash", "dash", "ksh", "zsh", "fish", "source", "."}
+
+// runsDownload finds the first command executing a file that curl or wget wrote in an earlier
+// command of the method, or earlier in the same command, e.g. curl -o /tmp/i.sh URL then
+// sh /tmp/i.sh. It returns the command and the file, or empty strings.
+func runsDownload(commands []string) (string, string) {
+	downloaded := map[string]bool{}
+	for _, command := range commands {
+		for _, words := range simpleCommands(command) {
+			switch path.Base(words[0]) {
+			case "curl", "wget":
+				for _, file := range downloadTargets(words) {
+					downloaded[path.Clean(file)] = true
+				}
+			default:
+				if file := executedFile(words); file != "" && downloaded[path.Clean(file)] {
+					return command, file
+				}
+			}
+		}
+	}
+	return "", ""
+}
+
+// simpleCommands splits a command into the words of each command it chains with ;, &&, || or
+// |, with quotes dropped so that the script of sh -c '...' is split too. Leading sudo, env and
+// sh -c are dropped.
+func simpleCommands(command string) [][]string {
+	var commands [][]string
+	var words []string
+	end := func() {
+		if words = commandWords(words); len(words) > 0 {
+			commands = append(commands, words)
+		}
+		words = nil
+	}
+	for _, word := range strings.Fields(strings.NewReplacer(`"`, " ", "'", " ").Replace(command)) {
+		switch {
+		case word == ";" || word == "&&" || word == "||" || word == "|" || word == "&":
+			end()
+		case strings.HasSuffix(word, ";"):
+			words = append(words, strings.TrimSuffix(word, ";"))
+			end()
+		default:
+			words = append(words, word)
+		}
+	}
+	end()
+	return commands
+}
+
+// commandWords drops the sudo, env and sh -c prefixes of a command, with their options and
+// variable assignments
+func commandWords(words []string) []string {
+	for len(words) > 0 {
+		switch name := path.Base(words[0]); {
+		case name == "sudo" || name == "env":
+			words = words[1:]
+			for len(words) > 0 && (strings.HasPrefix(words[0], "-") || strings.Contains(words[0], "=")) {
+				words = words[1:]
+			}
+		case slices.Contains(shells, name) && len(words) > 1 && strings.HasPrefix(words[1], "-") && strings.Contains(words[1], "c"):
+			words = words[2:]
+		default:
+			return words
+		}
+	}
+	return words
+}
+
+// downloadTargets returns the files a curl or wget command writes: the -o, --output, -O and
+// --output-document arguments, and the base of the URLs fetched with curl -O or wget without -O,
+// under the wget -P directory
+func downloadTargets(words []string) []string {
+	curl := path.Base(words[0]) == "curl"
+	var files, urls []string
+	dir := "."
+	remoteName := !curl
+	for n := 1; n < len(words); n++ {
+		word := words[n]
+		next := func() string {
+			if n+1 < len(words) {
+				n++
+				return words[n]
+			}
+			return ""
+		}
+		switch {
+		case strings.HasPrefix(word, "http://") || strings.HasPrefix(word, "https://"):
+			urls = append(urls, word)
+		case curl && (word == "--output" || word == "-o"), !curl && (word == "--output-document" || word == "-O"):
+			files = append(files, next())
+		case curl && strings.HasPrefix(word, "--output="), !curl && strings.HasPrefix(word, "--output-document="):
+			files = append(files, word[strings.Index(word, "=")+1:])
+		case curl && word == "--remote-name":
+			remoteName = true
+		case !curl && (word == "-P" || word == "--directory-prefix"):
+			dir = next()
+		case !curl && strings.HasPrefix(word, "--directory-prefix="):
+			dir = word[strings.Index(word, "=")+1:]
+		case strings.HasPrefix(word, "-") && !strings.HasPrefix(word, "--"):
+			// A cluster of short options such as -fsSLo FILE, whose last option may take the next word
+			flag := "o"
+			if !curl {
+				flag = "O"
+			}
+			if at := strings.Index(word, flag); at > 0 {
+				if file := word[at+1:]; file != "" {
+					files = append(files, file)
+				} else {
+					files = append(files, next())
+				}
+			} else if curl && strings.Contains(word, "O") {
+				remoteName = true
+			}
+		}
+	}
+	if len(files) > 0 && !curl {
+		remoteName = false
+	}
+	if remoteName {
+		for _, url := range urls {
+			url, _, _ = strings.Cut(url, "?")
+			files = append(files, path.Join(dir, path.Base(url)))
+		}
+	}
+	return slices.DeleteFunc(files, func(file string) bool { return file == "" || file == "-" })
+}
+
+// executedFile returns the file a command executes: the script a shell runs, or the command
+// itself when it is a path
+func executedFile(words []string) string {
+	if slices.Contains(shells, path.Base(words[0])) {
+		for _, word := range words[1:] {
+			if !strings.HasPrefix(word, "-") {
+				return word
+			}
+		}
+		return ""
+	}
+	if strings.Contains(words[0], "/") {
+		return words[0]
+	}
+	return ""
+}
+
+// toolchainOf names what the bootstrap of a typed method installs
+func toolchainOf(methodType string) string {
+	switch methodType {
+	case MethodCargo:
+		return "rustup"
+	case MethodNpm:
+		return "Node.js"
+	}
+	return methodType
+}
+
+// validatePolicy fails when require_checksums is set and methods break the security policy
+func (c *InstallerConfig) validatePolicy() error {
+	if c.Security.RequireSignatures && !c.Security.RequireChecksums {
+		return fmt.Errorf("security.require_signatures requires require_checksums")
+	}
+	if !c.Security.RequireChecksums {
+		return nil
+	}
+	if report := c.PolicyReport(); !report.Compliant {
+		return &PolicyError{Violations: report.Violations}
+	}
+	return nil
+}
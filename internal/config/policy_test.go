@@ -0,0 +1,47 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMethodViolationsRunningDownloads(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		commands []string
+		cleanup  []string
+		flagged  string // the file the violation names, or empty for none
+	}{
+		{"curl -o then sh", []string{"curl -fsSL -o /tmp/i.sh https://example.com/install.sh", "sh /tmp/i.sh"}, nil, "/tmp/i.sh"},
+		{"short option cluster", []string{"curl -fsSLo /tmp/i.sh https://example.com/install.sh", "sudo bash /tmp/i.sh --yes"}, nil, "/tmp/i.sh"},
+		{"wget -O then bash", []string{"wget -qO /tmp/i.sh https://example.com/install.sh", "bash -e /tmp/i.sh"}, nil, "/tmp/i.sh"},
+		{"chmod then run", []string{"curl --output=install.sh https://example.com/install.sh", "chmod +x install.sh", "./install.sh"}, nil, "./install.sh"},
+		{"same command", []string{"sh -c 'curl -o /tmp/i.sh https://example.com/i.sh && . /tmp/i.sh'"}, nil, "/tmp/i.sh"},
+		{"remote name", []string{"curl -fsSLO https://example.com/install.sh?v=1", "sh install.sh"}, nil, "install.sh"},
+		{"wget directory prefix", []string{"wget -P /tmp https://example.com/install.sh", "sh /tmp/install.sh"}, nil, "/tmp/install.sh"},
+		{"in cleanup", []string{"curl -o /tmp/u.sh https://example.com/u.sh"}, []string{"sh /tmp/u.sh"}, "/tmp/u.sh"},
+		{"download only", []string{"curl -fsSL -o /tmp/tool.tar.gz https://example.com/tool.tar.gz", "tar -xzf /tmp/tool.tar.gz -C /opt"}, nil, ""},
+		{"another file", []string{"curl -o /tmp/i.sh https://example.com/install.sh", "sh /tmp/other.sh"}, nil, ""},
+		{"run before download", []string{"sh /tmp/i.sh", "curl -o /tmp/i.sh https://example.com/install.sh"}, nil, ""},
+		{"wget to stdout", []string{"wget -qO- https://example.com/version", "sh ./-"}, nil, ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			method := InstallMethod{Name: "fake", Cleanup: tc.cleanup}
+			for _, command := range tc.commands {
+				method.Commands = append(method.Commands, Command{Run: command})
+			}
+			var details []string
+			for _, v := range methodViolations(method, false) {
+				if v.Rule == RulePipeToShell {
+					details = append(details, v.Detail)
+				}
+			}
+			switch {
+			case tc.flagged == "" && len(details) > 0:
+				t.Fatalf("violations = %q, want none", details)
+			case tc.flagged != "" && (len(details) != 1 || !strings.Contains(details[0], " runs "+tc.flagged+", ")):
+				t.Fatalf("violations = %q, want one naming %s", details, tc.flagged)
+			}
+		})
+	}
+}
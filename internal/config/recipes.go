@@ -0,0 +1,69 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Recipe is a single tool shared as a file: its config entry plus a name and metadata
+type Recipe struct {
+	Name       string `yaml:"name" schema:"required"`
+	Author     string `yaml:"author,omitempty"`
+	ToolConfig `yaml:",inline"`
+}
+
+// ParseRecipe parses a recipe file, rejecting unknown fields so typos don't silently drop settings
+func ParseRecipe(data []byte) (*Recipe, error) {
+	var recipe Recipe
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&recipe); err != nil {
+		return nil, fmt.Errorf("failed to parse recipe: %v", err)
+	}
+	if recipe.Name == "" {
+		return nil, fmt.Errorf("recipe has no name")
+	}
+	if len(recipe.Methods) == 0 {
+		return nil, fmt.Errorf("recipe %s has no installation methods", recipe.Name)
+	}
+	return &recipe, nil
+}
+
+// MarshalRecipe renders a recipe file
+func MarshalRecipe(recipe *Recipe) ([]byte, error) {
+	text, err := renderBlock(recipe, 0, 2)
+	return []byte(text), err
+}
+
+// MarshalTool renders a tool's config entry on its own, for comparing entries
+func MarshalTool(tool *ToolConfig) ([]byte, error) {
+	text, err := renderBlock(tool, 0, 2)
+	return []byte(text), err
+}
+
+// Recipes configures the remote index of shareable tool recipes. It is off unless Index is set.
+type Recipes struct {
+	Index string `yaml:"index"` // URL of the recipe index, a YAML document with a tools mapping
+}
+
+// RecipeIndex is a remote collection of tool recipes, in the format of the tools section of
+// a config file
+type RecipeIndex struct {
+	Tools map[string]*ToolConfig `yaml:"tools"`
+}
+
+// ParseRecipeIndex parses and validates a recipe index
+func ParseRecipeIndex(data []byte) (*RecipeIndex, error) {
+	var index RecipeIndex
+	if err := yaml.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("failed to parse recipe index: %v", err)
+	}
+	for name, tool := range index.Tools {
+		if tool == nil || len(tool.Methods) == 0 {
+			return nil, fmt.Errorf("recipe %s has no installation methods", name)
+		}
+	}
+	return &index, nil
+}
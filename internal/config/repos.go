@@ -0,0 +1,72 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// AptRepo is an apt repository a method adds before it runs, with the key its packages are
+// signed with. The key is stored in its own keyring that only this repository trusts.
+type AptRepo struct {
+	URL         string   `yaml:"url" schema:"required"`     // Base URL of the repository, e.g. https://apt.releases.hashicorp.com
+	Suites      []string `yaml:"suites,omitempty"`          // Defaults to the codename of the distribution, e.g. jammy; suites ending in / are flat repositories
+	Components  []string `yaml:"components,omitempty"`      // Defaults to main
+	KeyURL      string   `yaml:"key_url" schema:"required"` // URL of the signing key, armored or binary
+	KeyringPath string   `yaml:"keyring_path,omitempty"`    // Defaults to /etc/apt/keyrings/<repository host>.gpg
+}
+
+// Name returns the name of the repository's sources file and default keyring: the host and
+// path of its URL, e.g. apt.releases.hashicorp.com or download.docker.com-linux-ubuntu, with
+// the characters apt ignores files for replaced
+func (r AptRepo) Name() string {
+	u, err := url.Parse(r.URL)
+	if err != nil || u.Hostname() == "" {
+		return "dev-tools-installer"
+	}
+	name := strings.Join(append([]string{u.Hostname()}, strings.FieldsFunc(u.Path, func(c rune) bool { return c == '/' })...), "-")
+	return sourceNameChar.ReplaceAllString(name, "_")
+}
+
+// sourceNameChar matches the characters apt does not allow in the names of sources files
+var sourceNameChar = regexp.MustCompile(`[^A-Za-z0-9_.-]`)
+
+// Keyring returns the keyring the repository's key is stored in
+func (r AptRepo) Keyring() string {
+	if r.KeyringPath != "" {
+		return r.KeyringPath
+	}
+	return "/etc/apt/keyrings/" + r.Name() + ".gpg"
+}
+
+// tapName matches Homebrew tap names, user/repo
+var tapName = regexp.MustCompile(`^[A-Za-z0-9_.-]+/[A-Za-z0-9_.-]+$`)
+
+// validateRepositories checks the taps and apt repository of a method
+func validateRepositories(method InstallMethod) error {
+	for _, tap := range method.Taps {
+		if !tapName.MatchString(tap) {
+			return fmt.Errorf("taps: %q is not a tap name such as projectdiscovery/tap", tap)
+		}
+	}
+	repo := method.AptRepo
+	if repo == nil {
+		return nil
+	}
+	for field, value := range map[string]string{"url": repo.URL, "key_url": repo.KeyURL} {
+		if u, err := url.Parse(value); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return fmt.Errorf("apt_repo: %s %q is not an http or https URL", field, value)
+		}
+	}
+	if repo.KeyringPath != "" && !path.IsAbs(repo.KeyringPath) {
+		return fmt.Errorf("apt_repo: keyring_path %q must be absolute", repo.KeyringPath)
+	}
+	for _, suite := range append(append([]string{}, repo.Suites...), repo.Components...) {
+		if suite == "" || strings.ContainsAny(suite, " \t") {
+			return fmt.Errorf("apt_repo: suites and components must not be empty or contain spaces")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package config
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// SchemaID is the $id of the generated JSON Schema
+const SchemaID = "https://github.com/Abhaythakor/dev-tools-installer/installer.schema.json"
+
+// schemaEnums lists the allowed values of enumerated fields, keyed by Type.Field
+var schemaEnums = map[string][]string{
+	"InstallMethod.Type":        {MethodCargo, MethodPipx, MethodNpm, MethodMise, MethodAsdf, MethodDownload, MethodGithubRelease, MethodScript},
+	"InstallMethod.EnvMode":     {EnvInherit, EnvClean, EnvCustom},
+	"InstallerConfig.Integrity": {IntegrityAll, IntegrityManaged},
+	"InstallerConfig.EnvMode":   {EnvInherit, EnvClean, EnvCustom},
+}
+
+// jsonSchema is the subset of JSON Schema the generator emits
+type jsonSchema struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	ID                   string                 `json:"$id,omitempty"`
+	Title                string                 `json:"title,omitempty"`
+	Ref                  string                 `json:"$ref,omitempty"`
+	AnyOf                []*jsonSchema          `json:"anyOf,omitempty"`
+	Type                 string                 `json:"type,omitempty"`
+	Enum                 []string               `json:"enum,omitempty"`
+	Items                *jsonSchema            `json:"items,omitempty"`
+	Properties           map[string]*jsonSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	AdditionalProperties interface{}            `json:"additionalProperties,omitempty"`
+	Defs                 map[string]*jsonSchema `json:"$defs,omitempty"`
+}
+
+// Schema returns a JSON Schema for installer.yaml generated from the config structs.
+// Fields tagged schema:"required" are required; the output is deterministic.
+func Schema() ([]byte, error) {
+	defs := map[string]*jsonSchema{}
+	root := structSchema(reflect.TypeOf(InstallerConfig{}), defs)
+	root.Schema = "https://json-schema.org/draft/2020-12/schema"
+	root.ID = SchemaID
+	root.Title = "dev-tools-installer configuration"
+	root.Defs = defs
+
+	data, err := json.MarshalIndent(root, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// structSchema describes a struct type, registering nested struct types in defs
+func structSchema(t reflect.Type, defs map[string]*jsonSchema) *jsonSchema {
+	schema := &jsonSchema{Type: "object", Properties: map[string]*jsonSchema{}, AdditionalProperties: false}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if name == "-" || !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+
+		prop := typeSchema(field.Type, defs)
+		if values, ok := schemaEnums[t.Name()+"."+field.Name]; ok {
+			prop.Enum = values
+		}
+		schema.Properties[name] = prop
+		if field.Tag.Get("schema") == "required" {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+	return schema
+}
+
+// typeSchema describes a field type
+func typeSchema(t reflect.Type, defs map[string]*jsonSchema) *jsonSchema {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return typeSchema(t.Elem(), defs)
+	case reflect.String:
+		return &jsonSchema{Type: "string"}
+	case reflect.Bool:
+		return &jsonSchema{Type: "boolean"}
+	case reflect.Int, reflect.Int64, reflect.Int32:
+		return &jsonSchema{Type: "integer"}
+	case reflect.Float64, reflect.Float32:
+		return &jsonSchema{Type: "number"}
+	case reflect.Slice:
+		return &jsonSchema{Type: "array", Items: typeSchema(t.Elem(), defs)}
+	case reflect.Map:
+		return &jsonSchema{Type: "object", AdditionalProperties: typeSchema(t.Elem(), defs)}
+	case reflect.Struct:
+		// Commands are written as a command line or as a run/capture mapping
+		if t == reflect.TypeOf(Command{}) {
+			return &jsonSchema{AnyOf: []*jsonSchema{{Type: "string"}, structRef(t, defs)}}
+		}
+		return structRef(t, defs)
+	}
+	return &jsonSchema{}
+}
+
+// structRef registers a struct type in defs and returns a reference to it
+func structRef(t reflect.Type, defs map[string]*jsonSchema) *jsonSchema {
+	if _, ok := defs[t.Name()]; !ok {
+		defs[t.Name()] = nil // Reserve the name so recursive types terminate
+		defs[t.Name()] = structSchema(t, defs)
+	}
+	return &jsonSchema{Ref: "#/$defs/" + t.Name()}
+}
@@ -0,0 +1,31 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+)
+
+// ToolVars are the variables the installer sets for each tool, which vars and --var cannot
+// override
+var ToolVars = []string{"TOOL_NAME", "bindir", "tmpdir", "version"}
+
+// CheckVarName checks that name can be set as a variable with vars or --var
+func CheckVarName(name string) error {
+	switch {
+	case !captureName.MatchString(name):
+		return fmt.Errorf("variable name %q must be letters, digits and underscores", name)
+	case slices.Contains(ToolVars, name):
+		return fmt.Errorf("variable %s is set by the installer for each tool and cannot be overridden", name)
+	}
+	return nil
+}
+
+// validateVars checks the names of the config's vars
+func (c *InstallerConfig) validateVars() error {
+	for name := range c.Vars {
+		if err := CheckVarName(name); err != nil {
+			return fmt.Errorf("vars: %v", err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package config
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// versionsFileNames maps the asdf plugin names of .tool-versions files to tool names, for
+// plugins not named after the tool; versions_names adds to and overrides it
+var versionsFileNames = map[string]string{
+	"golang": "go",
+	"nodejs": "node",
+}
+
+// applyVersionsFile reads the versions_file of the config, an asdf or mise .tool-versions
+// file, and pins the tools it lists to its versions in place of their version and
+// version_from. Relative paths are looked up in the working directory and then each of its
+// parents, and finding none pins nothing, so that one config serves repos with and without
+// the file.
+func (c *InstallerConfig) applyVersionsFile() error {
+	if c.VersionsFile == "" {
+		return nil
+	}
+	path, err := findVersionsFile(c.VersionsFile)
+	if err != nil || path == "" {
+		return err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("versions_file: %v", err)
+	}
+	defer file.Close()
+	c.VersionsPath = path
+
+	scanner := bufio.NewScanner(file)
+	for n := 1; scanner.Scan(); n++ {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) < 2 {
+			return fmt.Errorf("versions_file %s:%d: %s has no version", path, n, fields[0])
+		}
+		name := c.versionsFileTool(fields[0])
+		tool := c.Tools[name]
+		if tool == nil {
+			configLog.Debug("versions_file entry without tool", "path", path, "line", n, "tool", name)
+			continue
+		}
+		// Later versions are fallbacks asdf tries in order, which one pin cannot express
+		version := fields[1]
+		if version == "system" || strings.Contains(version, ":") {
+			c.corrections = append(c.corrections, fmt.Sprintf("versions_file %s:%d pins %s to %s, which is not a version; the pin of the config is kept", path, n, name, version))
+			continue
+		}
+		tool.Version, tool.VersionFrom = version, ""
+		tool.VersionsFile = fmt.Sprintf("%s:%d", path, n)
+		configLog.Debug("versions_file pin", "path", path, "line", n, "tool", name, "version", version)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("versions_file %s: %v", path, err)
+	}
+	return nil
+}
+
+// versionsFileTool returns the tool a .tool-versions entry pins
+func (c *InstallerConfig) versionsFileTool(name string) string {
+	name = strings.ToLower(name)
+	if tool, ok := c.VersionsNames[name]; ok {
+		return tool
+	}
+	if tool, ok := versionsFileNames[name]; ok {
+		return tool
+	}
+	return name
+}
+
+// findVersionsFile returns the versions file a versions_file setting refers to, or "" when
+// a relative one is in neither the working directory nor any of its parents
+func findVersionsFile(path string) (string, error) {
+	if filepath.IsAbs(path) {
+		return path, nil
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("versions_file: %v", err)
+	}
+	for {
+		candidate := filepath.Join(dir, path)
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate, nil
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return "", fmt.Errorf("versions_file: %v", err)
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", nil
+		}
+		dir = parent
+	}
+}
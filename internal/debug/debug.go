@@ -0,0 +1,99 @@
+// Package debug provides the installer's debug logger. Output is off until Enable is
+// called and is tagged with the component that logged it, e.g. [exec] or [version].
+package debug
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+)
+
+var (
+	mu         sync.RWMutex
+	out        slog.Handler    // nil while debug output is off
+	components map[string]bool // Enabled components, nil for all
+)
+
+// Enable writes debug output to w, limited to the given components when any are given
+func Enable(w io.Writer, only ...string) {
+	mu.Lock()
+	defer mu.Unlock()
+	out = slog.NewTextHandler(w, &slog.HandlerOptions{Level: slog.LevelDebug})
+	components = nil
+	if len(only) > 0 {
+		components = map[string]bool{}
+		for _, component := range only {
+			components[component] = true
+		}
+	}
+}
+
+// EnableFromEnv enables debug output to w when INSTALLER_DEBUG is set: 1, true or all
+// enable every component, and a comma-separated list such as exec,version only those.
+// It reports whether debug output was enabled.
+func EnableFromEnv(w io.Writer) bool {
+	value := strings.TrimSpace(os.Getenv("INSTALLER_DEBUG"))
+	switch strings.ToLower(value) {
+	case "", "0", "false":
+		return false
+	case "1", "true", "all":
+		Enable(w)
+	default:
+		Enable(w, strings.Split(value, ",")...)
+	}
+	return true
+}
+
+// Components parses a --debug value: empty or "all" for every component, otherwise a
+// comma-separated list
+func Components(value string) []string {
+	if value == "" || value == "all" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
+
+// Logger returns the debug logger of a component
+func Logger(component string) *slog.Logger {
+	return slog.New(&handler{component: component})
+}
+
+// handler tags records with their component and forwards them while the component is enabled
+type handler struct {
+	component string
+	attrs     []slog.Attr
+}
+
+func (h *handler) Enabled(_ context.Context, level slog.Level) bool {
+	mu.RLock()
+	defer mu.RUnlock()
+	return out != nil && (components == nil || components[h.component]) && out.Enabled(context.Background(), level)
+}
+
+func (h *handler) Handle(ctx context.Context, r slog.Record) error {
+	mu.RLock()
+	target := out
+	mu.RUnlock()
+	if target == nil {
+		return nil
+	}
+	record := slog.NewRecord(r.Time, r.Level, "["+h.component+"] "+r.Message, r.PC)
+	record.AddAttrs(h.attrs...)
+	r.Attrs(func(attr slog.Attr) bool {
+		record.AddAttrs(attr)
+		return true
+	})
+	return target.Handle(ctx, record)
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &handler{component: h.component, attrs: append(append([]slog.Attr{}, h.attrs...), attrs...)}
+}
+
+// WithGroup is not used by the installer; groups are flattened
+func (h *handler) WithGroup(string) slog.Handler {
+	return h
+}
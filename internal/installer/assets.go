@@ -0,0 +1,202 @@
+package installer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+)
+
+// Scores of the parts of a release asset name that match this platform. Preferences are
+// worth more than a format or name match so that the preferred variant always wins.
+const (
+	assetOSScore         = 10
+	assetArchScore       = 10
+	assetPreferenceScore = 3
+	assetFormatScore     = 1
+	assetNameScore       = 1
+)
+
+// assetOSNames are the names release assets use for each GOOS
+var assetOSNames = map[string][]string{
+	"linux":   {"linux"},
+	"darwin":  {"darwin", "macos", "mac", "osx"},
+	"windows": {"windows", "win", "win64"},
+	"freebsd": {"freebsd"},
+	"openbsd": {"openbsd"},
+	"netbsd":  {"netbsd"},
+}
+
+// assetArchNames are the names release assets use for each GOARCH, after x86_64 and x86-64
+// have been normalized to amd64
+var assetArchNames = map[string][]string{
+	"amd64": {"amd64", "x64", "64bit"},
+	"arm64": {"arm64", "aarch64", "armv8"},
+	"386":   {"386", "i386", "i686", "x86", "32bit"},
+	"arm":   {"arm", "armv7", "armv7l", "armv6", "armhf"},
+}
+
+// assetUniversalNames mark macOS assets that run on every architecture
+var assetUniversalNames = []string{"universal", "all"}
+
+// assetSkippedSuffixes end the names of release assets that are not installable artifacts:
+// checksums, signatures, metadata, system packages and archives installArtifact cannot unpack
+var assetSkippedSuffixes = []string{
+	".sha256", ".sha256sum", ".sha512", ".sha512sum", ".md5", ".asc", ".sig", ".pem", ".cert", ".sbom",
+	".json", ".txt", ".yaml", ".yml", ".deb", ".rpm", ".apk", ".msi", ".pkg", ".dmg",
+	".tar.xz", ".txz", ".tar.bz2", ".tbz", ".tar.zst", ".7z",
+}
+
+// scoredAsset is a release asset with how well its name matches this platform
+type scoredAsset struct {
+	asset   releaseAsset
+	score   int
+	reasons []string // Matched parts of the name, e.g. linux, x86_64 or musl preferred
+	reject  string   // Why the asset cannot be used here, empty when it can
+}
+
+func (s scoredAsset) String() string {
+	if s.reject != "" {
+		return fmt.Sprintf("%s (%s)", s.asset.Name, s.reject)
+	}
+	return fmt.Sprintf("%s (score %d: %s)", s.asset.Name, s.score, strings.Join(s.reasons, ", "))
+}
+
+// assetPreferences returns the variant words assets are preferred for, best first:
+// asset_preferences, or musl binaries on musl systems such as Alpine and glibc ones elsewhere
+func (i *Installer) assetPreferences() []string {
+	if len(i.config.AssetPreferences) > 0 {
+		return i.config.AssetPreferences
+	}
+	if i.muslSystem() {
+		return []string{"musl", "static", "gnu"}
+	}
+	return []string{"gnu", "static", "musl"}
+}
+
+// muslSystem reports whether the system installed into uses musl rather than glibc
+func (i *Installer) muslSystem() bool {
+	if runtime.GOOS != "linux" {
+		return false
+	}
+	if _, err := os.Stat(i.inTarget("/etc/alpine-release")); err == nil {
+		return true
+	}
+	matches, _ := filepath.Glob(i.inTarget("/lib/ld-musl-*"))
+	return len(matches) > 0
+}
+
+// scoreAsset scores the name of a release asset of a tool against this platform
+func (i *Installer) scoreAsset(name string, asset releaseAsset) scoredAsset {
+	s := scoredAsset{asset: asset}
+	lower := strings.ToLower(asset.Name)
+	for _, suffix := range assetSkippedSuffixes {
+		if strings.HasSuffix(lower, suffix) {
+			s.reject = "not an installable artifact"
+			return s
+		}
+	}
+
+	// Names are compared word by word, so the arm of arm64 or the x86 of x86_64 do not count
+	words := strings.NewReplacer("_", "-", ".", "-", " ", "-").Replace(lower)
+	words = "-" + strings.ReplaceAll(words, "x86-64", "amd64") + "-"
+	has := func(word string) bool { return strings.Contains(words, "-"+word+"-") }
+	first := func(names []string) string {
+		for _, n := range names {
+			if has(n) {
+				return n
+			}
+		}
+		return ""
+	}
+
+	for goos, names := range assetOSNames {
+		if goos != runtime.GOOS && first(names) != "" {
+			s.reject = "built for " + goos
+			return s
+		}
+	}
+	osName := first(assetOSNames[runtime.GOOS])
+	if osName == "" {
+		s.reject = "names no operating system"
+		return s
+	}
+	s.score += assetOSScore
+	s.reasons = append(s.reasons, osName)
+
+	for goarch, names := range assetArchNames {
+		if goarch != runtime.GOARCH && first(names) != "" {
+			s.reject = "built for " + goarch
+			return s
+		}
+	}
+	switch archName := first(assetArchNames[runtime.GOARCH]); {
+	case archName != "":
+		if archName == "amd64" && strings.Contains(strings.NewReplacer("_", "-").Replace(lower), "x86-64") {
+			archName = "x86_64"
+		}
+		s.score += assetArchScore
+		s.reasons = append(s.reasons, archName)
+	case runtime.GOOS == "darwin" && first(assetUniversalNames) != "":
+		s.score += assetArchScore
+		s.reasons = append(s.reasons, "universal")
+	default:
+		s.reject = "names no architecture"
+		return s
+	}
+
+	preferences := i.assetPreferences()
+	for n, variant := range preferences {
+		if has(strings.ToLower(variant)) {
+			s.score += assetPreferenceScore * (len(preferences) - n)
+			s.reasons = append(s.reasons, variant+" preferred")
+			break
+		}
+	}
+	formats := []string{".tar.gz", ".tgz"}
+	if runtime.GOOS == "windows" {
+		formats = []string{".zip", ".exe"}
+	}
+	for _, format := range formats {
+		if strings.HasSuffix(lower, format) {
+			s.score += assetFormatScore
+			s.reasons = append(s.reasons, strings.TrimPrefix(format, "."))
+		}
+	}
+	if has(strings.ToLower(name)) {
+		s.score += assetNameScore
+		s.reasons = append(s.reasons, "names "+name)
+	}
+	return s
+}
+
+// matchAsset picks the release asset of a github_release method without an asset pattern by
+// scoring every asset against this platform, returning all scored assets best first. It
+// fails when no asset matches or the best ones score the same.
+func (i *Installer) matchAsset(name string, assets []releaseAsset) (scoredAsset, []scoredAsset, error) {
+	var scored []scoredAsset
+	for _, asset := range assets {
+		scored = append(scored, i.scoreAsset(name, asset))
+	}
+	slices.SortStableFunc(scored, func(a, b scoredAsset) int {
+		if (a.reject == "") != (b.reject == "") {
+			if a.reject == "" {
+				return -1
+			}
+			return 1
+		}
+		return b.score - a.score
+	})
+
+	platform := runtime.GOOS + "/" + runtime.GOARCH
+	if len(scored) == 0 || scored[0].reject != "" {
+		return scoredAsset{}, scored, fmt.Errorf("no release asset matches %s; set asset to choose one", platform)
+	}
+	if len(scored) > 1 && scored[1].reject == "" && scored[1].score == scored[0].score {
+		return scoredAsset{}, scored, fmt.Errorf("release assets %s and %s match %s equally well; set asset or asset_preferences to choose one",
+			scored[0].asset.Name, scored[1].asset.Name, platform)
+	}
+	return scored[0], scored, nil
+}
@@ -0,0 +1,109 @@
+package installer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/colors"
+)
+
+// defaultBackupLimit is the number of replaced binaries kept per tool when backup_limit is
+// not set
+const defaultBackupLimit = 3
+
+// Backup records a managed binary an install replaced, kept for rollback
+type Backup struct {
+	Version     string    `json:"version,omitempty"`
+	Path        string    `json:"path"` // Copy of the binary in the backups directory
+	SHA256      string    `json:"sha256,omitempty"`
+	InstalledAt time.Time `json:"installed_at"` // When the backed up binary was installed
+}
+
+// backupDir returns the directory the replaced binaries of a tool are kept in
+func (i *Installer) backupDir(name string) string {
+	return filepath.Join(i.stateDir(), "backups", name)
+}
+
+// backupBinary copies the managed binary of a tool at dest into its backups directory before
+// an install replaces it, returning the backup or nil when dest holds no managed binary
+func (i *Installer) backupBinary(name, dest string) (*Backup, error) {
+	i.mu.Lock()
+	ts := i.loadedState().Tools[name]
+	i.mu.Unlock()
+	if ts == nil || !ts.Managed || ts.Path != dest || !isExecutable(dest) {
+		return nil, nil
+	}
+
+	version := strings.ReplaceAll(ts.Version, string(filepath.Separator), "_")
+	if version == "" {
+		version = "unknown"
+	}
+	file := fmt.Sprintf("%s-%s", version, time.Now().Format("20060102T150405.000"))
+	backup := &Backup{Version: ts.Version, Path: filepath.Join(i.backupDir(name), file), SHA256: ts.SHA256, InstalledAt: ts.InstalledAt}
+	if err := copyExecutable(dest, backup.Path); err != nil {
+		return nil, fmt.Errorf("failed to back up %s: %v", dest, err)
+	}
+	return backup, nil
+}
+
+// recordBackup adds a backup to the state of a tool once its binary was replaced, deleting
+// the oldest backups beyond backup_limit
+func (i *Installer) recordBackup(name string, backup *Backup) {
+	if backup == nil {
+		return
+	}
+	limit := i.config.BackupLimit
+	if limit <= 0 {
+		limit = defaultBackupLimit
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	ts := i.loadedState().Tool(name)
+	ts.Backups = append(ts.Backups, *backup)
+	for len(ts.Backups) > limit {
+		os.Remove(ts.Backups[0].Path)
+		ts.Backups = ts.Backups[1:]
+	}
+}
+
+// removeBackups deletes every backup of a tool
+func (i *Installer) removeBackups(name string) {
+	os.RemoveAll(i.backupDir(name))
+	if ts := i.loadedState().Tools[name]; ts != nil {
+		ts.Backups = nil
+	}
+}
+
+// Rollback restores the binary the last install of a managed tool replaced, returning the
+// backup it restored
+func (i *Installer) Rollback(name string) (Backup, error) {
+	unlock, err := i.lockRun()
+	if err != nil {
+		return Backup{}, err
+	}
+	defer unlock()
+
+	ts := i.loadedState().Tools[name]
+	if ts == nil || len(ts.Backups) == 0 {
+		return Backup{}, fmt.Errorf("%s has no backup to roll back to", name)
+	}
+	backup := ts.Backups[len(ts.Backups)-1]
+	if backup.SHA256 != "" {
+		if err := verifyChecksum(backup.Path, backup.SHA256); err != nil {
+			return Backup{}, fmt.Errorf("backup %s is damaged: %v", backup.Path, err)
+		}
+	}
+	if err := copyExecutable(backup.Path, ts.Path); err != nil {
+		return Backup{}, fmt.Errorf("failed to restore %s: %v", ts.Path, err)
+	}
+	os.Remove(backup.Path)
+
+	ts.Version, ts.SHA256, ts.InstalledAt = backup.Version, backup.SHA256, backup.InstalledAt
+	ts.Backups = ts.Backups[:len(ts.Backups)-1]
+	fmt.Fprintf(i.display(), "%s│%s ✓ Restored %s %s%s\n", colors.Blue, colors.Green, ts.Path, orDash(backup.Version), colors.Reset)
+	return backup, i.saveState()
+}
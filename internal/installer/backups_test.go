@@ -0,0 +1,114 @@
+package installer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeBinary writes a managed binary to path and returns its digest
+func writeBinary(t *testing.T, path, content string) string {
+	t.Helper()
+	src := filepath.Join(t.TempDir(), "build")
+	if err := os.WriteFile(src, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := copyExecutable(src, path); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+func TestCopyExecutableReplacesTheDestinationAtomically(t *testing.T) {
+	dir := t.TempDir()
+	dest := filepath.Join(dir, "evtool")
+	writeBinary(t, dest, "v1")
+	writeBinary(t, dest, "v2")
+	if data, err := os.ReadFile(dest); err != nil || string(data) != "v2" {
+		t.Errorf("dest = %q, %v; want the second copy", data, err)
+	}
+	if !isExecutable(dest) {
+		t.Error("dest is not executable")
+	}
+
+	if err := copyExecutable(filepath.Join(dir, "missing"), dest); err == nil {
+		t.Error("copyExecutable succeeded without a source")
+	}
+	if data, _ := os.ReadFile(dest); string(data) != "v2" {
+		t.Errorf("a failed copy left dest = %q, want it untouched", data)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("directory holds %d files, want no temp files next to dest", len(entries))
+	}
+}
+
+func TestBackupsAndRollback(t *testing.T) {
+	i := New(loadTestConfig(t, "backup_limit: 2\ntools: {}\n"), WithOutput(io.Discard))
+	dest := filepath.Join(i.config.BinDir, "evtool")
+	installed := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	ts := i.loadedState().Tool("evtool")
+	ts.Path, ts.Managed, ts.Version, ts.InstalledAt = dest, true, "1.0.0", installed
+	ts.SHA256 = writeBinary(t, dest, "v1")
+
+	// Three upgrades keep the binaries of the last two
+	for _, version := range []string{"1.1.0", "1.2.0", "1.3.0"} {
+		backup, err := i.backupBinary("evtool", dest)
+		if err != nil || backup == nil {
+			t.Fatalf("backupBinary = %v, %v; want a backup of %s", backup, err, ts.Version)
+		}
+		digest := writeBinary(t, dest, "v"+version)
+		i.recordBackup("evtool", backup)
+		ts.Version, ts.SHA256, ts.InstalledAt = version, digest, time.Now()
+	}
+	if len(ts.Backups) != 2 || ts.Backups[0].Version != "1.1.0" || ts.Backups[1].Version != "1.2.0" {
+		t.Fatalf("backups = %+v, want 1.1.0 and 1.2.0", ts.Backups)
+	}
+	if entries, _ := os.ReadDir(i.backupDir("evtool")); len(entries) != 2 {
+		t.Errorf("backups directory holds %d files, want the 2 recorded", len(entries))
+	}
+
+	backup, err := i.Rollback("evtool")
+	if err != nil || backup.Version != "1.2.0" {
+		t.Fatalf("Rollback = %+v, %v; want 1.2.0 restored", backup, err)
+	}
+	if data, _ := os.ReadFile(dest); string(data) != "v1.2.0" {
+		t.Errorf("dest = %q after the rollback, want the 1.2.0 binary", data)
+	}
+	if ts.Version != "1.2.0" || len(ts.Backups) != 1 {
+		t.Errorf("state = %+v, want 1.2.0 with one backup left", ts)
+	}
+	if _, err := os.Stat(backup.Path); err == nil {
+		t.Error("the restored backup is left in the backups directory")
+	}
+
+	// A damaged backup is not restored
+	if err := os.WriteFile(ts.Backups[0].Path, []byte("corrupt"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := i.Rollback("evtool"); err == nil || !strings.Contains(err.Error(), "is damaged") {
+		t.Errorf("Rollback of a damaged backup = %v, want it refused", err)
+	}
+	if data, _ := os.ReadFile(dest); string(data) != "v1.2.0" {
+		t.Errorf("dest = %q after a refused rollback, want it untouched", data)
+	}
+}
+
+func TestBackupBinarySkipsBinariesItDidNotPlace(t *testing.T) {
+	i := New(loadTestConfig(t, "tools: {}\n"))
+	dest := filepath.Join(i.config.BinDir, "evtool")
+	writeBinary(t, dest, "v1")
+	ts := i.loadedState().Tool("evtool")
+	ts.Path = dest
+	if backup, err := i.backupBinary("evtool", dest); backup != nil || err != nil {
+		t.Errorf("backupBinary of an unmanaged binary = %+v, %v; want none", backup, err)
+	}
+	if _, err := i.Rollback("evtool"); err == nil || err.Error() != "evtool has no backup to roll back to" {
+		t.Errorf("Rollback without backups = %v", err)
+	}
+}
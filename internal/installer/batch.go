@@ -0,0 +1,217 @@
+package installer
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/colors"
+	"github.com/Abhaythakor/dev-tools-installer/internal/config"
+	"github.com/Abhaythakor/dev-tools-installer/internal/shellwords"
+)
+
+// packagesVar is where the batch_command of a method takes the packages of a batch
+const packagesVar = "${packages}"
+
+// packageBatch is one package manager command installing the packages of several tools that
+// would each run their own
+type packageBatch struct {
+	manager  string                          // Package manager, or the method name for batch_command methods
+	command  string                          // The command with packagesVar in place of the packages
+	packages []string                        // Package arguments, with the version pins of apt
+	tools    []string                        // Tools the batch installs, by install name
+	methods  map[string]config.InstallMethod // The method of each tool the batch replaces
+}
+
+// batchedInstall is a tool a package batch installed, for installTool to record
+type batchedInstall struct {
+	method  config.InstallMethod
+	manager string
+}
+
+// batchCommand returns the manager and packages of a method that can join a package batch,
+// with its command with packagesVar in place of the packages. Plain apt, dnf, yum, pacman and
+// brew installs can, as can methods with a batch_command; batch: false keeps a method out.
+func (i *Installer) batchCommand(name string, toolConfig *config.ToolConfig, method config.InstallMethod) (manager, command string, packages []string, ok bool) {
+	switch {
+	case method.Batch != nil && !*method.Batch,
+		method.Type != "", len(method.Commands) != 1, method.Commands[0].Capture != "",
+		strings.Contains(method.Commands[0].Run, "${secret:"),
+		len(method.Cleanup) > 0, len(method.Requires) > 0, method.InTarget, len(toolConfig.SystemPackages) > 0,
+		method.RunAs != "", toolConfig.RunAs != "", toolConfig.InstallTimeout != "",
+		method.EnvMode != "", len(method.Env) > 0, len(method.SuccessCodes) > 0, len(method.WarnCodes) > 0:
+		return "", "", nil, false
+	}
+	vars := i.commandVars(name, toolConfig.Version, i.toolBinDir(name))
+	if method.BatchCommand != "" {
+		command := strings.ReplaceAll(method.BatchCommand, packagesVar, "\x00")
+		command, err := expandChecked(name, "batch_command", command, vars)
+		if err != nil {
+			return "", "", nil, false
+		}
+		pkg, err := expandChecked(name, "package", method.Package, vars)
+		if err != nil {
+			return "", "", nil, false
+		}
+		return method.Name, strings.ReplaceAll(command, "\x00", packagesVar), []string{pkg}, true
+	}
+
+	// The packages must end the command, so that the command of the batch can end with all of them
+	parts, err := splitCommand(method.Commands[0].Run, vars)
+	if err != nil {
+		return "", "", nil, false
+	}
+	manager, names := packageInstall(parts)
+	if len(names) == 0 {
+		return "", "", nil, false
+	}
+	args := parts[len(parts)-len(names):]
+	for n, arg := range args {
+		if pkg, _, _ := strings.Cut(arg, "="); pkg != names[n] {
+			return "", "", nil, false
+		}
+	}
+	return manager, strings.Join(parts[:len(parts)-len(names)], " ") + " " + packagesVar, args, true
+}
+
+// planBatches groups the plan items installing through a command that other items also run
+// with different packages. Items join a batch only through the method they would try first,
+// and only when nothing else has to happen before it: no version_from failure, no missing
+// dependency and no side-by-side version.
+func (i *Installer) planBatches(plan Plan) []*packageBatch {
+	if i.Options.NoBatch {
+		return nil
+	}
+	grouped := map[string]*packageBatch{}
+	var batches []*packageBatch
+	for _, item := range plan {
+		name := item.status.Name
+		toolConfig := i.config.Tools[name]
+		if item.Action != actionInstall || item.status.sideBySide() || item.status.SameAs != "" || len(item.Methods) == 0 || toolConfig == nil {
+			continue
+		}
+		if i.resolveVersion(name) != nil || len(i.missingDependencies(name)) > 0 {
+			continue
+		}
+		method, _ := i.methodConfig(name, item.Methods[0])
+		manager, command, packages, ok := i.batchCommand(name, toolConfig, method)
+		if !ok {
+			continue
+		}
+		batch := grouped[command]
+		if batch == nil {
+			batch = &packageBatch{manager: manager, command: command, methods: map[string]config.InstallMethod{}}
+			grouped[command] = batch
+			batches = append(batches, batch)
+		}
+		if !slices.Contains(batch.tools, name) {
+			batch.tools = append(batch.tools, name)
+			batch.packages = append(batch.packages, packages...)
+			batch.methods[name] = method
+		}
+	}
+	// A batch of one tool is the tool's own command
+	return slices.DeleteFunc(batches, func(batch *packageBatch) bool { return len(batch.tools) < 2 })
+}
+
+// batchesLikely reports whether the first methods of several entries could run as one
+// package batch, going by the config alone since the entries are not checked yet
+func (i *Installer) batchesLikely(entries []string) bool {
+	if i.Options.NoBatch {
+		return false
+	}
+	seen := map[string]bool{}
+	for _, entry := range entries {
+		name, version := config.ParseToolEntry(entry)
+		toolConfig := i.config.Tools[name]
+		if version != "" || toolConfig == nil {
+			continue
+		}
+		methods := i.orderedMethods(toolConfig)
+		if len(methods) == 0 {
+			continue
+		}
+		if _, command, _, ok := i.batchCommand(name, toolConfig, methods[0]); ok {
+			if seen[command] {
+				return true
+			}
+			seen[command] = true
+		}
+	}
+	return false
+}
+
+// noteBatches adds the package batches of a plan to the reasons of its items
+func (i *Installer) noteBatches(plan Plan) {
+	for _, batch := range i.planBatches(plan) {
+		for n := range plan {
+			if name := plan[n].status.Name; slices.Contains(batch.tools, name) {
+				others := slices.DeleteFunc(slices.Clone(batch.tools), func(tool string) bool { return tool == name })
+				plan[n].reason("method %s joins %s in one %s command", plan[n].Methods[0], strings.Join(others, ", "), batch.manager)
+			}
+		}
+	}
+}
+
+// runBatches runs the package batches of a plan before its items install one by one. Each
+// tool a batch installed, as detected by its check afterwards, is only recorded by its own
+// install; the others, including all tools of a failed batch, run their methods as usual.
+func (i *Installer) runBatches(plan Plan) {
+	for _, batch := range i.planBatches(plan) {
+		if i.context().Err() != nil || i.budgetExhausted() {
+			return
+		}
+		// The batch command was expanded already, apart from the packages, which it splits into
+		argv, err := shellwords.SplitExpand(batch.command, func(s string) string {
+			return strings.Replace(s, packagesVar, strings.Join(batch.packages, " "), 1)
+		})
+		if err != nil || len(argv) == 0 {
+			i.printf("%s│%s ⚠ Skipping the %s batch: command %q: %v%s\n", colors.Blue, colors.Yellow, batch.manager, batch.command, err, colors.Reset)
+			continue
+		}
+		label := fmt.Sprintf("%d packages", len(batch.tools))
+		i.printf("%s│%s 📦 Installing %s in one %s command...%s\n", colors.Blue, colors.Yellow, strings.Join(batch.tools, ", "), batch.manager, colors.Reset)
+		if i.verbose() {
+			i.printf("%s│   %s%s%s\n", colors.Blue, colors.Gray, strings.Join(argv, " "), colors.Reset)
+		}
+		first := batch.tools[0]
+		env, err := i.commandEnv(batch.methods[first], i.commandVars(first, i.config.Tools[first].Version, i.toolBinDir(first)))
+		if err == nil {
+			err = i.runCommand(label, batch.manager, "", argv, env)
+		}
+		// The batch is not a tool; its failure is reported through the tools installed on their own
+		i.takeOutput(label)
+		i.takeExitCode(label)
+		i.takeSignature(label)
+
+		var missing []string
+		for _, name := range batch.tools {
+			if !i.probeTool(name).installed {
+				missing = append(missing, name)
+				continue
+			}
+			i.mu.Lock()
+			if i.batched == nil {
+				i.batched = map[string]batchedInstall{}
+			}
+			i.batched[name] = batchedInstall{method: batch.methods[name], manager: batch.manager}
+			i.mu.Unlock()
+		}
+		switch {
+		case len(missing) == 0:
+		case err != nil:
+			i.printf("%s│%s ❌ The %s batch failed: %v; installing %s on their own%s\n", colors.Blue, colors.Red, batch.manager, i.redact(err.Error()), strings.Join(missing, ", "), colors.Reset)
+		default:
+			i.printf("%s│%s ⚠ The %s batch did not install %s; installing them on their own%s\n", colors.Blue, colors.Yellow, batch.manager, strings.Join(missing, ", "), colors.Reset)
+		}
+	}
+}
+
+// takeBatched returns and forgets how a package batch installed a tool
+func (i *Installer) takeBatched(name string) (batchedInstall, bool) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	batched, ok := i.batched[name]
+	delete(i.batched, name)
+	return batched, ok
+}
@@ -0,0 +1,136 @@
+package installer
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// packageRunner is a fakeRunner whose package manager installs put the packages on PATH. It
+// fails the commands in fail, and its batches of several packages leave out those in skip.
+type packageRunner struct {
+	*fakeRunner
+	skip map[string]bool
+}
+
+func (r packageRunner) Run(ctx context.Context, argv, env []string, out io.Writer) error {
+	if err := r.fakeRunner.Run(ctx, argv, env, out); err != nil {
+		return err
+	}
+	n := slices.Index(argv, "-y")
+	if n < 0 {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	packages := argv[n+1:]
+	for _, pkg := range packages {
+		if len(packages) == 1 || !r.skip[pkg] {
+			r.installed[pkg] = true
+		}
+	}
+	return nil
+}
+
+// batchConfig installs three tools with apt and one with a command of its own
+const batchConfig = `
+tool_list: [jq, fd, rg, evtool]
+tools:
+  jq:
+    methods: [{name: apt, commands: ["apt-get install -y jq"]}]
+  fd:
+    methods: [{name: apt, commands: ["apt-get install -y fd"]}]
+  rg:
+    methods: [{name: apt, commands: ["apt-get install -y rg"]}]
+  evtool:
+    methods: [{name: fake, commands: ["install evtool"]}]
+`
+
+func TestPackageBatches(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		noBatch  bool
+		fail     string
+		skip     string
+		commands []string
+		printed  string
+	}{
+		{
+			name:     "batched",
+			commands: []string{"apt-get install -y jq fd rg", "install evtool"},
+			printed:  "📦 Installing jq, fd, rg in one apt-get command...",
+		},
+		{
+			name:     "no batch",
+			noBatch:  true,
+			commands: []string{"apt-get install -y jq", "apt-get install -y fd", "apt-get install -y rg", "install evtool"},
+		},
+		{
+			name:     "failed batch",
+			fail:     "apt-get install -y jq fd rg",
+			commands: []string{"apt-get install -y jq fd rg", "apt-get install -y jq", "apt-get install -y fd", "apt-get install -y rg", "install evtool"},
+			printed:  "❌ The apt-get batch failed: exit status 1; installing jq, fd, rg on their own",
+		},
+		{
+			name:     "package left out",
+			skip:     "fd",
+			commands: []string{"apt-get install -y jq fd rg", "apt-get install -y fd", "install evtool"},
+			printed:  "⚠ The apt-get batch did not install fd; installing them on their own",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			runner := packageRunner{newFakeRunner(t), map[string]bool{tc.skip: true}}
+			runner.fail[tc.fail] = true
+			i := newTestInstaller(t, batchConfig, runner)
+			i.Options.NoBatch, i.Options.Concurrency = tc.noBatch, 1
+			var out bytes.Buffer
+			if err := i.Apply(WithOutput(&out)); err != nil {
+				t.Fatal(err)
+			}
+			if err := i.Run(); err != nil {
+				t.Fatalf("Run: %v", err)
+			}
+			if got := runner.commands(); !slices.Equal(got, tc.commands) {
+				t.Errorf("commands = %q, want %q", got, tc.commands)
+			}
+			if tc.printed != "" && !strings.Contains(out.String(), tc.printed) {
+				t.Errorf("printed:\n%s\nwant %q", out.String(), tc.printed)
+			}
+			for _, result := range i.report {
+				if result.Status != statusInstalled {
+					t.Errorf("%s: %s, want installed", result.Name, result.Status)
+				}
+			}
+		})
+	}
+}
+
+func TestBatchCommand(t *testing.T) {
+	i := New(loadTestConfig(t, "tools: {}\n"))
+	for _, tc := range []struct {
+		name     string
+		yaml     string
+		command  string
+		packages []string
+	}{
+		{name: "apt", yaml: `{name: apt, commands: ["sudo apt-get install -y jq=1.7*"]}`, command: "sudo apt-get install -y " + packagesVar, packages: []string{"jq=1.7*"}},
+		{name: "batch_command", yaml: `{name: pipx, batch: true, commands: ["pipx install httpie"], batch_command: "pipx install ${packages}", package: httpie}`, command: "pipx install " + packagesVar, packages: []string{"httpie"}},
+		{name: "packages not last", yaml: `{name: apt, commands: ["apt-get install jq -y"]}`},
+		{name: "batch: false", yaml: `{name: apt, batch: false, commands: ["apt-get install -y jq"]}`},
+		{name: "two commands", yaml: `{name: apt, commands: ["apt-get update", "apt-get install -y jq"]}`},
+		{name: "env", yaml: `{name: apt, env: {DEBIAN_FRONTEND: noninteractive}, commands: ["apt-get install -y jq"]}`},
+		{name: "not a package manager", yaml: `{name: fake, commands: ["install jq"]}`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := loadTestConfig(t, "tools:\n  jq:\n    methods: ["+tc.yaml+"]\n")
+			toolConfig := cfg.Tools["jq"]
+			_, command, packages, ok := i.batchCommand("jq", toolConfig, toolConfig.Methods[0])
+			if ok != (tc.command != "") || command != tc.command || !slices.Equal(packages, tc.packages) {
+				t.Errorf("batchCommand = %q, %q, %v; want %q, %q", command, packages, ok, tc.command, tc.packages)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package installer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/colors"
+)
+
+// ErrBudgetExhausted is returned by runs that deferred tools because Options.Budget ran out
+var ErrBudgetExhausted = errors.New("time budget exhausted")
+
+// budgetWarnRatio is the share of the budget after which the pending tools are named
+const budgetWarnRatio = 0.8
+
+// startBudget starts tracking the run's time budget over entries. With BudgetHard, the
+// returned context is cancelled when the budget runs out, stopping in-flight installs.
+func (i *Installer) startBudget(ctx context.Context, entries []string) (context.Context, func()) {
+	if i.Options.Budget <= 0 {
+		return ctx, func() {}
+	}
+	i.budgetStart = time.Now()
+	i.pending = map[string]bool{}
+	for _, entry := range entries {
+		i.pending[entry] = true
+	}
+	warn := time.AfterFunc(time.Duration(float64(i.Options.Budget)*budgetWarnRatio), i.warnBudget)
+
+	cancel := func() {}
+	if i.Options.BudgetHard {
+		ctx, cancel = context.WithDeadline(ctx, i.budgetStart.Add(i.Options.Budget))
+	}
+	return ctx, func() {
+		warn.Stop()
+		cancel()
+	}
+}
+
+// warnBudget names the tools still pending once most of the budget is used
+func (i *Installer) warnBudget() {
+	i.mu.Lock()
+	pending := sortedKeys(i.pending)
+	i.mu.Unlock()
+	if len(pending) == 0 {
+		return
+	}
+	// A spinner may be drawing on the current line; it redraws below the warning
+	i.printf("\r%s%s⚠ %d%% of the %s budget used; still pending: %s%s\n", clearLine, colors.Yellow, int(budgetWarnRatio*100),
+		i.Options.Budget, strings.Join(pending, ", "), colors.Reset)
+}
+
+// finishPending marks an entry as processed for the budget warning
+func (i *Installer) finishPending(entry string) {
+	i.mu.Lock()
+	delete(i.pending, entry)
+	i.mu.Unlock()
+}
+
+// budgetExhausted reports whether the run has used up its time budget
+func (i *Installer) budgetExhausted() bool {
+	return i.Options.Budget > 0 && time.Since(i.budgetStart) >= i.Options.Budget
+}
+
+// deferEntry records that an entry was not installed because the budget ran out
+func (i *Installer) deferEntry(entry string, result ToolReport) ToolReport {
+	i.printf("%s│ %s⏸ %-9s%s │ deferred, %s budget exhausted\n", colors.Blue, colors.Yellow, entry, colors.Reset, i.Options.Budget)
+	result.Status, result.Error = statusDeferred, "deferred: time budget exhausted"
+	i.emit(Event{Type: EventToolFinished, Tool: entry, Status: result.Status, Error: result.Error})
+	return result
+}
+
+// budgetCancelled reports whether a failed install was cancelled by BudgetHard
+func (i *Installer) budgetCancelled() bool {
+	return i.Options.BudgetHard && errors.Is(i.context().Err(), context.DeadlineExceeded)
+}
+
+// budgetError summarizes the entries the budget deferred, or returns nil when there are none
+func (i *Installer) budgetError(results []ToolReport) error {
+	var deferred []string
+	for _, result := range results {
+		if result.Status == statusDeferred {
+			deferred = append(deferred, result.Name)
+		}
+	}
+	if len(deferred) == 0 {
+		return nil
+	}
+	fmt.Fprintf(i.display(), "%s⏸ Deferred after the %s budget ran out (took %s): %s%s\n", colors.Yellow, i.Options.Budget,
+		time.Since(i.budgetStart).Round(time.Second), strings.Join(deferred, ", "), colors.Reset)
+	return fmt.Errorf("%w: %d tools deferred", ErrBudgetExhausted, len(deferred))
+}
@@ -0,0 +1,65 @@
+package installer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/colors"
+	"github.com/Abhaythakor/dev-tools-installer/internal/config"
+)
+
+// captureSink receives the output of a tool's running capture command
+type captureSink struct {
+	secret bool
+	output *outputBuffer // Output of the last attempt, set once the command exited
+}
+
+// runningCapture returns the sink of a tool's running capture command, nil when the running
+// command captures nothing
+func (i *Installer) runningCapture(name string) *captureSink {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.captures[name]
+}
+
+// runCapture runs a capture command and returns its trimmed output. Secret values are
+// redacted from then on like resolved secrets.
+func (i *Installer) runCapture(name, methodName, step string, parts, env []string, command config.Command) (string, error) {
+	sink := &captureSink{secret: command.Secret}
+	i.mu.Lock()
+	if i.captures == nil {
+		i.captures = map[string]*captureSink{}
+	}
+	i.captures[name] = sink
+	i.mu.Unlock()
+	defer func() {
+		i.mu.Lock()
+		delete(i.captures, name)
+		i.mu.Unlock()
+	}()
+
+	if err := i.runCommand(name, methodName, step, parts, env); err != nil {
+		return "", err
+	}
+	value := ""
+	if sink.output != nil {
+		value = strings.TrimSpace(sink.output.String())
+	}
+	if value == "" {
+		return "", fmt.Errorf("%s printed nothing to capture as %s", parts[0], command.Capture)
+	}
+
+	if command.Secret {
+		i.secrets.mu.Lock()
+		if i.secrets.values == nil {
+			i.secrets.values = map[string]string{}
+		}
+		i.secrets.values["capture:"+name+"."+command.Capture] = value
+		i.secrets.mu.Unlock()
+	}
+	i.log(execLog).Debug("captured", "tool", name, "method", methodName, "name", command.Capture, "value", i.redact(value))
+	if i.verbose() {
+		i.printf("%s│   %scaptured %s = %s%s\n", colors.Blue, colors.Gray, command.Capture, value, colors.Reset)
+	}
+	return value, nil
+}
@@ -0,0 +1,216 @@
+package installer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/colors"
+	"github.com/Abhaythakor/dev-tools-installer/internal/config"
+)
+
+// Health of a checked tool_list entry
+const (
+	HealthOK       = "ok"
+	HealthMissing  = "missing"
+	HealthDrift    = "drift"    // The detected version differs from the pin
+	HealthModified = "modified" // The binary changed since the installer placed it
+)
+
+// ToolStatus is what Check found for a single tool_list entry
+type ToolStatus struct {
+	Entry    string   `json:"entry"`
+	Name     string   `json:"name"`
+	Present  bool     `json:"present"`
+	Version  string   `json:"version,omitempty"` // Detected version, or the version of a name@version entry
+	Pinned   string   `json:"pinned,omitempty"`
+	Path     string   `json:"path,omitempty"`
+	Missing  []string `json:"missing,omitempty"` // Provided commands that did not resolve
+	Health   string   `json:"health"`
+	Modified bool     `json:"modified"`           // Also set for drifted tools, whose health is drift
+	SameAs   string   `json:"same_as,omitempty"`  // Tool the entry installs with, checked in its place when missing
+	Error    string   `json:"error,omitempty"`    // Config error of the tool, such as a failing version_from
+	Unusable string   `json:"unusable,omitempty"` // A file the missing command resolves to that can't be run
+	Channel  string   `json:"channel,omitempty"`  // Channel the installer installed the tool from
+	Detect   string   `json:"detect,omitempty"`   // What the detect block checks, for tools that are not a command
+
+	unusablePath string // Path of that file
+
+	recorded string // Digest recorded when the binary was placed
+	current  string // Digest of the binary now, when it was modified
+}
+
+// Check inspects tool_list entries without installing, printing or recording anything.
+// It stops early when ctx is cancelled, returning the entries checked so far.
+func (i *Installer) Check(ctx context.Context, entries []string) []ToolStatus {
+	var statuses []ToolStatus
+	for _, entry := range entries {
+		if ctx.Err() != nil {
+			break
+		}
+		statuses = append(statuses, i.entryStatus(entry))
+	}
+	return statuses
+}
+
+// entryStatus checks a single tool_list entry
+func (i *Installer) entryStatus(entry string) ToolStatus {
+	name, version := config.ParseToolEntry(entry)
+	status := ToolStatus{Entry: entry, Name: name, Health: HealthMissing}
+
+	if version != "" {
+		status.Pinned = version
+		ts := i.loadedState().Tools[name]
+		if ts == nil || ts.Versions[version] == nil {
+			return status
+		}
+		status.Path = ts.Versions[version].Path
+		if !i.probeVersion(name, version) {
+			return status
+		}
+		status.Present, status.Version, status.Health = true, version, HealthOK
+		status.checkIntegrity(i, ts.Versions[version].SHA256)
+		return status
+	}
+
+	for _, tool := range []string{name, i.installName(name)} {
+		if err := i.resolveVersion(tool); err != nil && status.Error == "" {
+			status.Error = err.Error()
+		}
+	}
+	check := i.probeTool(name)
+	if target := i.installName(name); target != name {
+		status.SameAs = target
+		if !check.installed {
+			check = i.probeTool(target)
+		}
+	}
+	status.Present, status.Version, status.Pinned = check.installed, check.version, check.pinned
+	status.Path, status.Missing, status.Detect = check.path, check.missing, check.detect
+	if !check.installed {
+		if check.detect == "" {
+			status.unusablePath, status.Unusable = i.findUnusable(check.missing[0])
+		}
+		return status
+	}
+	status.Health = HealthOK
+	if check.drift {
+		status.Health = HealthDrift
+	}
+	if ts := i.loadedState().Tools[i.installName(name)]; ts != nil {
+		status.Channel = ts.Channel
+		status.checkIntegrity(i, ts.SHA256)
+	}
+	return status
+}
+
+// checkIntegrity re-hashes the binary and marks the status modified when it no longer
+// matches the recorded digest
+func (s *ToolStatus) checkIntegrity(i *Installer, recorded string) {
+	if recorded == "" || !i.integrityApplies(s.Path) {
+		return
+	}
+	current, err := hashFile(s.Path)
+	if err != nil || current == recorded {
+		return
+	}
+	s.Modified, s.recorded, s.current = true, recorded, current
+	if s.Health == HealthOK {
+		s.Health = HealthModified
+	}
+}
+
+// sideBySide reports whether the status is of a name@version entry
+func (s ToolStatus) sideBySide() bool {
+	return s.Entry != s.Name
+}
+
+// printStatus prints the check table row of a status
+func (i *Installer) printStatus(s ToolStatus) {
+	switch {
+	case !s.Present && s.sideBySide() && s.Path != "":
+		fmt.Fprintf(i.display(), "%s│ %s✗ %-9s%s │ Missing %s\n", colors.Blue, colors.Red, s.Entry, colors.Reset, s.Path)
+	case !s.Present && s.Path != "":
+		fmt.Fprintf(i.display(), "%s│ %s✗ %-9s%s │ Missing %s\n", colors.Blue, colors.Red, s.Entry, colors.Reset, strings.Join(s.Missing, ", "))
+	case !s.Present && s.Detect != "":
+		fmt.Fprintf(i.display(), "%s│ %s✗ %-9s%s │ Not found (%s)\n", colors.Blue, colors.Red, s.Entry, colors.Reset, s.Detect)
+	case !s.Present && s.Unusable != "":
+		fmt.Fprintf(i.display(), "%s│ %s✗ %-9s%s │ Not executable\n", colors.Blue, colors.Red, s.Entry, colors.Reset)
+	case !s.Present:
+		fmt.Fprintf(i.display(), "%s│ %s✗ %-9s%s │ Not installed\n", colors.Blue, colors.Red, s.Entry, colors.Reset)
+	case s.sideBySide():
+		i.printModified(s)
+		ts := i.loadedState().Tools[s.Name]
+		active := "inactive"
+		if ts.Active == s.Version {
+			active = "active"
+		}
+		fmt.Fprintf(i.display(), "%s│ %s✓ %-9s%s │ %s (%s; installed: %s)\n", colors.Blue, colors.Green, s.Entry, colors.Reset,
+			s.Version, active, strings.Join(installedVersions(ts), ", "))
+	case s.Version == "" && s.Pinned != "":
+		fmt.Fprintf(i.display(), "%s│ %s✓ %-9s%s │ Installed (version unknown, pinned %s)\n", colors.Blue, colors.Green, s.Entry, colors.Reset, s.Pinned)
+	case s.Version == "":
+		fmt.Fprintf(i.display(), "%s│ %s✓ %-9s%s │ Installed (version unknown)\n", colors.Blue, colors.Green, s.Entry, colors.Reset)
+	case s.Health == HealthDrift:
+		fmt.Fprintf(i.display(), "%s│ %s! %-9s%s │ %sinstalled %s, pinned %s%s%s\n", colors.Blue, colors.Yellow, s.Entry, colors.Reset, colors.Yellow, s.Version, s.Pinned, i.pinOrigin(s), colors.Reset)
+	default:
+		fmt.Fprintf(i.display(), "%s│ %s✓ %-9s%s │ %s%s\n", colors.Blue, colors.Green, s.Entry, colors.Reset, s.Version, colors.Reset)
+	}
+	if s.Present && !s.sideBySide() {
+		i.printModified(s)
+	}
+	if s.Unusable != "" {
+		fmt.Fprintf(i.display(), "%s│%s   %s%s\n", colors.Blue, colors.Yellow, s.Unusable, colors.Reset)
+		fmt.Fprintf(i.display(), "%s│%s   fix its permissions (chmod 755 %s) or remove it; installing adds a second copy%s\n", colors.Blue, colors.Gray, s.unusablePath, colors.Reset)
+	}
+	if s.SameAs != "" {
+		fmt.Fprintf(i.display(), "%s│%s   same as %s%s\n", colors.Blue, colors.Gray, s.SameAs, colors.Reset)
+	}
+	if s.Error != "" {
+		fmt.Fprintf(i.display(), "%s│%s   %s%s\n", colors.Blue, colors.Red, s.Error, colors.Reset)
+	}
+}
+
+// printModified warns that the binary of a status changed since it was installed
+func (i *Installer) printModified(s ToolStatus) {
+	if !s.Modified {
+		return
+	}
+	mtime := "unknown"
+	if info, err := os.Stat(s.Path); err == nil {
+		mtime = info.ModTime().Format("2006-01-02 15:04:05")
+	}
+	fmt.Fprintf(i.display(), "%s│%s ⚠ %s changed since it was installed (%s, modified %s)%s\n", colors.Blue, colors.Yellow, s.Entry, s.Path, mtime, colors.Reset)
+	fmt.Fprintf(i.display(), "%s│%s   recorded %s%s\n", colors.Blue, colors.Gray, s.recorded, colors.Reset)
+	fmt.Fprintf(i.display(), "%s│%s   current  %s%s\n", colors.Blue, colors.Gray, s.current, colors.Reset)
+}
+
+// report returns the run report of an entry as it was checked
+func (s ToolStatus) report() ToolReport {
+	result := ToolReport{Name: s.Entry, Version: s.Version, Pinned: s.Pinned, Channel: s.Channel, IntegrityChanged: s.Modified}
+	if !s.sideBySide() {
+		result.PreviousVersion, result.Drift = s.Version, s.Health == HealthDrift
+	}
+	switch {
+	case s.Error != "":
+		result.Status, result.Error = statusFailed, s.Error
+	case !s.Present:
+		result.Status = statusMissing
+	case s.Health == HealthDrift:
+		result.Status = statusDrift
+	default:
+		result.Status = statusOK
+	}
+	return result
+}
+
+// pinOrigin names the versions_file entry a pin comes from, as " (.tool-versions:3)", or
+// returns "" for other pins
+func (i *Installer) pinOrigin(s ToolStatus) string {
+	if tool := i.config.Tools[s.Name]; tool != nil && tool.VersionsFile != "" && !s.sideBySide() {
+		return " (" + filepath.Base(tool.VersionsFile) + ")"
+	}
+	return ""
+}
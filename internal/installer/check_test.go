@@ -0,0 +1,67 @@
+package installer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// checkConfig has a tool_list entry for each row of the check table
+const checkConfig = `
+tool_list: [present, pinned, drifted, missing, alias, font]
+tools:
+  present:
+    methods: [{name: fake, commands: ["install present"]}]
+  pinned:
+    version: 1.0.0
+    methods: [{name: fake, commands: ["install pinned"]}]
+  drifted:
+    version: 2.0.0
+    methods: [{name: fake, commands: ["install drifted"]}]
+  missing:
+    methods: [{name: fake, commands: ["install missing"]}]
+  alias:
+    same_as: present
+  font:
+    detect: {kind: file, path: /nonexistent/fonts/evtool.ttf}
+    methods: [{name: fake, commands: ["install font"]}]
+`
+
+func TestCheckGolden(t *testing.T) {
+	runner := newFakeRunner(t, "present", "pinned", "drifted")
+	i := newTestInstaller(t, checkConfig, runner)
+	// Paths differ between runs, so the golden files hold @BIN@ for the fake runner's directory
+	normalize := func(s string) []byte { return []byte(strings.ReplaceAll(s, runner.bin, "@BIN@")) }
+
+	statuses := i.Check(context.Background(), i.config.ToolList)
+	data, err := json.MarshalIndent(statuses, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, filepath.Join("testdata", "check", "statuses.json"), normalize(string(data)+"\n"))
+
+	var table bytes.Buffer
+	if err := i.Apply(WithOutput(&table)); err != nil {
+		t.Fatal(err)
+	}
+	for _, status := range statuses {
+		i.printStatus(status)
+	}
+	checkGolden(t, filepath.Join("testdata", "check", "table.golden"), normalize(table.String()))
+
+	// Verify prints the same rows inside the check box, with its summary
+	var verify bytes.Buffer
+	if err := i.Apply(WithOutput(&verify)); err != nil {
+		t.Fatal(err)
+	}
+	if err := i.Verify(); err == nil {
+		t.Fatal("Verify succeeded with missing and drifted tools")
+	}
+	checkGolden(t, filepath.Join("testdata", "check", "verify.golden"), normalize(verify.String()))
+	if !strings.Contains(verify.String(), table.String()) {
+		t.Errorf("verify output %q does not hold the check table %q", verify.String(), table.String())
+	}
+}
@@ -0,0 +1,54 @@
+//go:build !windows
+
+package installer
+
+import (
+	"io/fs"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"syscall"
+)
+
+// chownUser hands path to u
+func chownUser(path string, u *user.User) error {
+	uid, err := strconv.Atoi(u.Uid)
+	if err != nil {
+		return err
+	}
+	gid, err := strconv.Atoi(u.Gid)
+	if err != nil {
+		return err
+	}
+	return os.Lchown(path, uid, gid)
+}
+
+// chownTree hands the root-owned files under dir, and the root-owned directories between home
+// and dir, to u. Files owned by anyone else are left alone.
+func chownTree(dir, home string, u *user.User) error {
+	rootOwned := func(info fs.FileInfo) bool {
+		stat, ok := info.Sys().(*syscall.Stat_t)
+		return ok && stat.Uid == 0
+	}
+	for parent := filepath.Dir(dir); len(parent) > len(home); parent = filepath.Dir(parent) {
+		if info, err := os.Lstat(parent); err == nil && rootOwned(info) {
+			if err := chownUser(parent, u); err != nil {
+				return err
+			}
+		}
+	}
+	err := filepath.Walk(dir, func(path string, info fs.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !rootOwned(info) {
+			return nil
+		}
+		return chownUser(path, u)
+	})
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
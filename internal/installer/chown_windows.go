@@ -0,0 +1,15 @@
+//go:build windows
+
+package installer
+
+import "os/user"
+
+// chownUser does nothing on Windows, where the installer never runs as another user
+func chownUser(path string, u *user.User) error {
+	return nil
+}
+
+// chownTree does nothing on Windows, where the installer never runs under sudo
+func chownTree(dir, home string, u *user.User) error {
+	return nil
+}
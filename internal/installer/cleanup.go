@@ -0,0 +1,77 @@
+package installer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/colors"
+	"github.com/Abhaythakor/dev-tools-installer/internal/config"
+)
+
+// cleanupTimeout bounds each cleanup command, which runs even once the run was interrupted
+const cleanupTimeout = 2 * time.Minute
+
+// runCleanup runs the cleanup commands of a method after it succeeded, failed or was
+// cancelled. Failing cleanup commands are warned about and never change the method's result.
+func (i *Installer) runCleanup(name string, toolConfig *config.ToolConfig, method config.InstallMethod, bindir string) {
+	if len(method.Cleanup) == 0 {
+		return
+	}
+	inTarget := method.InTarget && i.Options.Root != ""
+	if inTarget {
+		bindir = i.inTarget(bindir)
+	}
+	vars := i.commandVars(name, toolConfig.Version, bindir)
+	env, err := i.commandEnv(method, vars)
+	if err != nil {
+		i.printf("%s│%s ⚠ Skipping cleanup of %s method: %v%s\n", colors.Blue, colors.Yellow, method.Name, err, colors.Reset)
+		return
+	}
+
+	for _, command := range method.Cleanup {
+		if _, err := expandChecked(name, fmt.Sprintf("cleanup command %q", command), command, vars); err != nil {
+			i.printf("%s│%s ⚠ Skipping cleanup of %s method: %v%s\n", colors.Blue, colors.Yellow, method.Name, err, colors.Reset)
+			continue
+		}
+		parts, err := splitCommand(command, vars)
+		if err != nil {
+			i.printf("%s│%s ⚠ Skipping cleanup command %q: %v%s\n", colors.Blue, colors.Yellow, command, err, colors.Reset)
+			continue
+		}
+		if len(parts) == 0 {
+			continue
+		}
+		if inTarget {
+			parts = append([]string{"chroot", i.Options.Root}, parts...)
+		}
+		parts = i.asUser(name, parts)
+		if i.verbose() {
+			i.printf("%s│   %scleanup: %s%s\n", colors.Blue, colors.Gray, i.redact(strings.Join(parts, " ")), colors.Reset)
+		}
+
+		// The run and the tool's install_timeout may be cancelled already, so cleanup gets
+		// its own deadline instead
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(i.context()), cleanupTimeout)
+		captured := i.newOutputBuffer()
+		output := &lineWriter{line: func(line string) { captured.line(sanitizeLine(line)) }}
+		err = i.commands().Run(ctx, parts, env, output)
+		cancel()
+		output.flush()
+		i.log(execLog).Debug("cleanup", "tool", name, "method", method.Name, "argv", i.redactArgs(parts), "error", err, "output", i.redact(captured.String()))
+		if err != nil {
+			i.printf("%s│%s ⚠ Cleanup of %s method failed: %s: %v%s\n", colors.Blue, colors.Yellow, method.Name, i.redact(strings.Join(parts, " ")), err, colors.Reset)
+		}
+	}
+}
+
+// describeCleanup renders the cleanup commands of a method, for display only
+func (i *Installer) describeCleanup(name string, toolConfig *config.ToolConfig, method config.InstallMethod, bindir string) []string {
+	vars := i.commandVars(name, toolConfig.Version, bindir)
+	var lines []string
+	for _, command := range method.Cleanup {
+		lines = append(lines, fmt.Sprintf("cleanup: %s", strings.TrimSpace(expandShown(command, vars))))
+	}
+	return lines
+}
@@ -0,0 +1,87 @@
+package installer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/colors"
+)
+
+// gitTimeout bounds each git command run to find the revision of the config
+const gitTimeout = 5 * time.Second
+
+// ConfigSource identifies the config a run used: the file, its digest and, when the file is in
+// a git work tree, the commit checked out there
+type ConfigSource struct {
+	ConfigPath   string `json:"config_path,omitempty"`
+	ConfigSHA256 string `json:"config_sha256,omitempty"`
+	ConfigCommit string `json:"config_commit,omitempty"` // HEAD of the git work tree holding the config
+	ConfigDirty  bool   `json:"config_dirty,omitempty"`  // The work tree had uncommitted changes, or the config is not committed
+}
+
+// describeSource describes a config source for display, e.g. sha256 3f2a9c01, commit 9e1b44c0 (dirty)
+func describeSource(s ConfigSource) string {
+	description := "sha256 " + shortHash(s.ConfigSHA256)
+	if s.ConfigCommit != "" {
+		description += ", commit " + shortHash(s.ConfigCommit)
+		if s.ConfigDirty {
+			description += " (dirty)"
+		}
+	}
+	return description
+}
+
+// configSource identifies the loaded config, asking git for the revision of the work tree
+// holding it. Without git, or outside a work tree, only the path and digest are known.
+func (i *Installer) configSource() ConfigSource {
+	source := ConfigSource{ConfigPath: i.config.Path, ConfigSHA256: i.config.SHA256}
+	if i.config.Path == "" {
+		return source
+	}
+	dir, file := filepath.Split(i.config.Path)
+	head, err := git(dir, "rev-parse", "--verify", "HEAD")
+	if err != nil {
+		i.log(execLog).Debug("config revision", "path", i.config.Path, "error", err)
+		return source
+	}
+	source.ConfigCommit = head
+	// Untracked files elsewhere in the work tree do not change what the config says, but an
+	// uncommitted config is not what the commit holds
+	changed, err := git(dir, "status", "--porcelain", "--untracked-files=no")
+	if err == nil && changed == "" {
+		changed, err = git(dir, "status", "--porcelain", "--", file)
+	}
+	source.ConfigDirty = err != nil || changed != ""
+	i.log(execLog).Debug("config revision", "path", i.config.Path, "commit", head, "dirty", source.ConfigDirty, "error", err)
+	return source
+}
+
+// git runs a git command in dir and returns its trimmed output. Optional locks are off so
+// that git status does not write the index of a work tree the installer only reads.
+func git(dir string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), gitTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...)
+	cmd.Env = append(os.Environ(), "GIT_OPTIONAL_LOCKS=0")
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %v", strings.Join(args, " "), err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// warnConfigChanged warns when the config differs from the one the last successful install of
+// every tool_list entry used, since this machine's tools were installed for that config
+func (i *Installer) warnConfigChanged() {
+	last := i.loadedState().LastInstall
+	if last == nil || last.ConfigSHA256 == "" || last.ConfigSHA256 == i.config.SHA256 {
+		return
+	}
+	i.printf("%s│%s ⚠ The config differs from the one the last successful install used (%s, %s); run install to apply it%s\n",
+		colors.Blue, colors.Yellow, describeSource(last.ConfigSource), last.Time.Local().Format("2006-01-02 15:04"), colors.Reset)
+}
@@ -0,0 +1,159 @@
+package installer
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/config"
+)
+
+// sourceConfig installs jq with the fakeRunner
+const sourceConfig = `
+tool_list: [jq]
+tools:
+  jq:
+    methods: [{name: fake, commands: ["install jq"]}]
+`
+
+// gitRepo makes the directory of path a git work tree with path committed, returning HEAD
+func gitRepo(t *testing.T, path string) string {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	t.Setenv("GIT_CONFIG_GLOBAL", os.DevNull)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	dir := filepath.Dir(path)
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"add", filepath.Base(path)},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "config"},
+	} {
+		if output, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, output)
+		}
+	}
+	head, err := git(dir, "rev-parse", "HEAD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return head
+}
+
+func TestConfigSource(t *testing.T) {
+	cfg := loadTestConfig(t, sourceConfig)
+	i := New(cfg)
+	if got, want := i.configSource(), (ConfigSource{ConfigPath: cfg.Path, ConfigSHA256: cfg.SHA256}); got != want {
+		t.Errorf("configSource outside a work tree = %+v, want %+v", got, want)
+	}
+
+	head := gitRepo(t, cfg.Path)
+	// The state directory and bindir are untracked files of the work tree
+	if got, want := i.configSource(), (ConfigSource{ConfigPath: cfg.Path, ConfigSHA256: cfg.SHA256, ConfigCommit: head}); got != want {
+		t.Errorf("configSource of a committed config = %+v, want %+v", got, want)
+	}
+
+	other := filepath.Join(filepath.Dir(cfg.Path), "other.yaml")
+	if err := os.WriteFile(other, []byte(sourceConfig), 0644); err != nil {
+		t.Fatal(err)
+	}
+	i.config.Path = other
+	if got := i.configSource(); got.ConfigCommit != head || !got.ConfigDirty {
+		t.Errorf("configSource of an uncommitted config = %+v, want commit %s, dirty", got, head)
+	}
+
+	i.config.Path = cfg.Path
+	if err := os.WriteFile(cfg.Path, []byte(sourceConfig+"# edited\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := i.configSource(); got.ConfigCommit != head || !got.ConfigDirty {
+		t.Errorf("configSource of an edited config = %+v, want commit %s, dirty", got, head)
+	}
+}
+
+func TestRunRecordsTheConfigSource(t *testing.T) {
+	i := newTestInstaller(t, sourceConfig, newFakeRunner(t))
+	want := ConfigSource{ConfigPath: i.config.Path, ConfigSHA256: i.config.SHA256, ConfigCommit: gitRepo(t, i.config.Path)}
+	var started []ConfigSource
+	events := EventsFunc(func(e Event) {
+		if e.Type == EventRunStarted {
+			started = append(started, e.ConfigSource)
+		}
+	})
+	if err := i.Apply(WithEvents(events)); err != nil {
+		t.Fatal(err)
+	}
+	i.Options.ReportPath = filepath.Join(t.TempDir(), "report.json")
+	if err := i.Run(); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	if len(started) != 1 || started[0] != want {
+		t.Errorf("run.started sources = %+v, want %+v", started, want)
+	}
+	data, err := os.ReadFile(i.Options.ReportPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report Report
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.ConfigSource != want {
+		t.Errorf("report source = %+v, want %+v", report.ConfigSource, want)
+	}
+	records, err := i.History()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 1 || records[0].ConfigSource != want {
+		t.Errorf("history = %+v, want one record with source %+v", records, want)
+	}
+	if last := i.loadedState().LastInstall; last == nil || last.ConfigSource != want {
+		t.Errorf("last install = %+v, want source %+v", last, want)
+	}
+}
+
+func TestVerifyWarnsWhenTheConfigChanged(t *testing.T) {
+	runner := newFakeRunner(t)
+	i := newTestInstaller(t, sourceConfig, runner)
+	if err := i.Run(); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	installed := i.config.SHA256
+
+	verify := func() string {
+		t.Helper()
+		cfg, err := config.LoadConfig(i.config.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var out bytes.Buffer
+		v := New(cfg, WithOutput(&out), WithRunner(runner))
+		v.Options.SkipPreflight = true
+		if err := v.Verify(); err != nil {
+			t.Fatalf("Verify: %v", err)
+		}
+		return out.String()
+	}
+	if out := verify(); strings.Contains(out, "The config differs") {
+		t.Errorf("verify of the installed config printed:\n%s\nwant no config warning", out)
+	}
+
+	data, err := os.ReadFile(i.config.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(i.config.Path, append(data, "# edited\n"...), 0644); err != nil {
+		t.Fatal(err)
+	}
+	want := "The config differs from the one the last successful install used (sha256 " + installed[:8] + ", "
+	if out := verify(); !strings.Contains(out, want) {
+		t.Errorf("verify of an edited config printed:\n%s\nwant %q", out, want)
+	}
+}
@@ -0,0 +1,121 @@
+package installer
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/config"
+)
+
+// varRef matches the ${name} and $name references expandVars expands
+var varRef = regexp.MustCompile(`\$\{([^}]*)\}|\$([A-Za-z_][A-Za-z0-9_]*)`)
+
+// ExpansionContext returns what the references in method commands, URLs and detect blocks
+// expand to: the installer variables, including those of tool when it is not empty, followed
+// by the other names the config references, or the config's env and the tool reference, with
+// their value in the environment. Values of secrets and of variables named like secrets are redacted.
+func (i *Installer) ExpansionContext(tool string) ([]ContextVar, error) {
+	var context []ContextVar
+	var tools []string
+	if tool == "" {
+		context = i.expansionContext("", "", "")
+		tools = slices.Sorted(maps.Keys(i.config.Tools))
+	} else {
+		name, version := config.ParseToolEntry(tool)
+		toolConfig := i.config.Tools[name]
+		if toolConfig == nil {
+			return nil, fmt.Errorf("tool %s is not configured", name)
+		}
+		i.resolveVersion(name)
+		bindir := i.toolBinDir(name)
+		if version != "" {
+			bindir = i.versionDir(name, version)
+		} else {
+			version = toolConfig.Version
+		}
+		context = i.expansionContext(name, version, bindir)
+		tools = []string{name}
+	}
+
+	for _, name := range i.referencedEnv(tools) {
+		// Without a tool, references to the variables of tools are not about the environment
+		if slices.ContainsFunc(context, func(v ContextVar) bool { return v.Name == name }) || tool == "" && slices.Contains(config.ToolVars, name) {
+			continue
+		}
+		value, ok := os.LookupEnv(name)
+		source := VarEnvironment
+		if !ok {
+			source = VarUnset
+		}
+		context = append(context, ContextVar{Name: name, Value: value, Source: source})
+	}
+	for n, v := range context {
+		if v.Value != "" && secretEnvPattern.MatchString(v.Name) {
+			context[n].Value = "***"
+		} else {
+			context[n].Value = i.redact(v.Value)
+		}
+	}
+	return context, nil
+}
+
+// referencedEnv returns the names the config's env and the methods and detect blocks of
+// tools reference, in sorted order
+func (i *Installer) referencedEnv(tools []string) []string {
+	texts := slices.Collect(maps.Values(i.config.Env))
+	for _, name := range tools {
+		toolConfig := i.config.Tools[name]
+		if toolConfig == nil {
+			continue
+		}
+		if detect := toolConfig.Detect; detect != nil {
+			texts = append(texts, detect.Path, detect.Command)
+		}
+		for _, method := range toolConfig.Methods {
+			texts = append(texts, method.Runs()...)
+			texts = append(texts, method.Cleanup...)
+			texts = append(texts, method.URL, method.Tag, method.Asset, method.Signature)
+			texts = append(texts, slices.Collect(maps.Values(method.Headers))...)
+			texts = append(texts, slices.Collect(maps.Values(method.Env))...)
+		}
+	}
+	names := map[string]bool{}
+	for _, text := range texts {
+		for _, match := range varRef.FindAllStringSubmatch(text, -1) {
+			name := match[1] + match[2]
+			if name != "" && !strings.Contains(name, ":") {
+				names[name] = true
+			}
+		}
+	}
+	return sortedKeys(names)
+}
+
+// PrintContext prints the expansion context of ExpansionContext as name=value lines, each
+// followed by where the value comes from
+func (i *Installer) PrintContext(tool string) error {
+	context, err := i.ExpansionContext(tool)
+	if err != nil {
+		return err
+	}
+	for _, v := range context {
+		fmt.Fprintf(i.display(), "%s=%s\t# %s\n", v.Name, v.Value, v.Source)
+	}
+	return nil
+}
+
+// ContextJSON prints the expansion context of ExpansionContext as JSON
+func (i *Installer) ContextJSON(tool string) error {
+	context, err := i.ExpansionContext(tool)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(i.display())
+	enc.SetIndent("", "  ")
+	return enc.Encode(context)
+}
@@ -0,0 +1,91 @@
+package installer
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/config"
+)
+
+// defaultMethodName names the method of tool_list entries without a tools entry
+const defaultMethodName = "default"
+
+// nativeManager is a system package manager the default method installs through
+type nativeManager struct {
+	command string
+	install string // Install command the package name is appended to
+}
+
+// nativeManagers are looked for in this order on Linux; macOS only uses Homebrew
+var nativeManagers = []nativeManager{
+	{"apt-get", "apt-get install -y"},
+	{"dnf", "dnf install -y"},
+	{"yum", "yum install -y"},
+	{"pacman", "pacman -S --noconfirm"},
+	{"zypper", "zypper --non-interactive install"},
+	{"apk", "apk add"},
+	{"brew", "brew install"},
+}
+
+// findNativeManager returns the package manager of the system installed into, or false when
+// it has none the default method supports
+func (i *Installer) findNativeManager() (nativeManager, bool) {
+	for _, manager := range nativeManagers {
+		if runtime.GOOS == "darwin" && manager.command != "brew" {
+			continue
+		}
+		if _, err := i.commands().LookPath(manager.command); err == nil {
+			return manager, true
+		}
+	}
+	return nativeManager{}, false
+}
+
+// applyDefaultTools gives the tool_list entries without a tools entry, which no other tool
+// provides, a default method installing the package of the same name. New applies it once;
+// strict_config turns the fallback off.
+func (i *Installer) applyDefaultTools() {
+	if i.config.StrictConfig {
+		return
+	}
+	for _, entry := range i.config.ToolList {
+		name, version := config.ParseToolEntry(entry)
+		if version != "" || i.config.Provider(name) != "" {
+			continue
+		}
+		manager, ok := i.findNativeManager()
+		if !ok {
+			return
+		}
+		command := manager.install + " " + name
+		// Package managers other than Homebrew need root
+		if manager.command != "brew" && os.Geteuid() > 0 {
+			command = "sudo " + command
+		}
+		if i.config.Tools == nil {
+			i.config.Tools = map[string]*config.ToolConfig{}
+		}
+		i.config.Tools[name] = &config.ToolConfig{
+			Default: true,
+			Methods: []config.InstallMethod{{Name: defaultMethodName, Commands: config.RunCommands(command), InTarget: i.Options.Root != ""}},
+		}
+	}
+}
+
+// usesAptGet reports whether a default method installs through apt-get
+func usesAptGet(method config.InstallMethod) bool {
+	manager, _ := packageInstall(strings.Fields(method.Commands[0].Run))
+	return manager == "apt-get"
+}
+
+// defaultNote describes how a tool without a tools entry installs, or returns "" for
+// configured tools
+func (i *Installer) defaultNote(name string) string {
+	toolConfig := i.config.Tools[name]
+	if toolConfig == nil || !toolConfig.Default {
+		return ""
+	}
+	return fmt.Sprintf("no tools entry, so the default method installs the %s package: %s", name, toolConfig.Methods[0].Commands[0].Run)
+}
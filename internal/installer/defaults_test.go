@@ -0,0 +1,94 @@
+package installer
+
+import (
+	"context"
+	"io"
+	"os"
+	"slices"
+	"testing"
+)
+
+// aptRunner is a fakeRunner with apt-get installed, whose apt-get installs put the packages
+// on PATH
+type aptRunner struct {
+	*fakeRunner
+}
+
+func (r aptRunner) Run(ctx context.Context, argv, env []string, out io.Writer) error {
+	r.fakeRunner.Run(ctx, argv, env, out)
+	if n := slices.Index(argv, "install"); n > 0 && argv[n-1] == "apt-get" {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		for _, pkg := range argv[n+1:] {
+			r.installed[pkg] = true
+		}
+	}
+	return nil
+}
+
+func TestDefaultMethodsUpdateThePackageListsOnce(t *testing.T) {
+	sudo := ""
+	if os.Geteuid() > 0 {
+		sudo = "sudo "
+	}
+	for _, tc := range []struct {
+		name     string
+		noBatch  bool
+		commands []string
+	}{
+		{"batched", false, []string{"apt-get update", "apt-get install -y jq fd"}},
+		{"one by one", true, []string{"apt-get update", "apt-get install -y jq", "apt-get install -y fd"}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			runner := aptRunner{newFakeRunner(t, "apt-get")}
+			i := newTestInstaller(t, "tool_list: [jq, fd]\n", runner)
+			i.Options.NoBatch = tc.noBatch
+			if err := i.Run(); err != nil {
+				t.Fatalf("Run: %v", err)
+			}
+			var want []string
+			for _, command := range tc.commands {
+				want = append(want, sudo+command)
+			}
+			if got := runner.commands(); !slices.Equal(got, want) {
+				t.Errorf("commands = %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestDefaultMethodsWithoutPendingInstallsLeaveThePackageLists(t *testing.T) {
+	runner := aptRunner{newFakeRunner(t, "apt-get", "jq")}
+	i := newTestInstaller(t, "tool_list: [jq]\n", runner)
+	if err := i.Run(); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if got := runner.commands(); len(got) > 0 {
+		t.Errorf("commands = %q, want none for an installed tool", got)
+	}
+}
+
+func TestNewAppliesDefaultMethodsOnce(t *testing.T) {
+	i := newTestInstaller(t, "tool_list: [jq]\n", aptRunner{newFakeRunner(t, "apt-get")})
+	tool := i.config.Tools["jq"]
+	if tool == nil || !tool.Default || tool.Methods[0].Name != defaultMethodName {
+		t.Fatalf("tools[jq] = %+v, want the default method applied by New", tool)
+	}
+	i.BuildPlan()
+	if _, err := i.ToolInfo("jq"); err != nil {
+		t.Fatal(err)
+	}
+	if err := i.Why("jq"); err != nil {
+		t.Fatal(err)
+	}
+	if i.config.Tools["jq"] != tool {
+		t.Error("planning and explaining the tool replaced its default method")
+	}
+}
+
+func TestNoDefaultMethodsWithoutAPackageManager(t *testing.T) {
+	i := newTestInstaller(t, "tool_list: [jq]\n", newFakeRunner(t))
+	if i.config.Tools["jq"] != nil {
+		t.Errorf("tools[jq] = %+v, want no default method without a package manager", i.config.Tools["jq"])
+	}
+}
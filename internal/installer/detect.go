@@ -0,0 +1,62 @@
+package installer
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/config"
+)
+
+// probeDetected inspects a tool with a detect block of kind file or command_output, which is
+// installed when the file exists or the output of the command matches
+func (i *Installer) probeDetected(name string, toolConfig *config.ToolConfig, check toolCheck) toolCheck {
+	detect := toolConfig.Detect
+	vars := i.commandVars(name, toolConfig.Version, i.toolBinDir(name))
+
+	switch detect.Kind {
+	case config.DetectFile:
+		path, err := expandChecked(name, "detect path", detect.Path, vars)
+		if err != nil {
+			check.detect = err.Error()
+			return check
+		}
+		path = expandHome(path)
+		check.detect = "file " + path
+		if i.Options.Root != "" {
+			path = i.inRoot(path)
+		}
+		_, err = os.Stat(path)
+		i.log(versionLog).Debug("detect file", "tool", name, "path", path, "error", err)
+		if err != nil {
+			return check
+		}
+		check.installed, check.path = true, path
+	case config.DetectCommandOutput:
+		parts, err := splitCommand(detect.Command, vars)
+		if err != nil || len(parts) == 0 {
+			i.log(versionLog).Debug("detect command_output", "tool", name, "command", detect.Command, "error", err)
+			return check
+		}
+		check.detect = fmt.Sprintf("output of %s matching %s", strings.Join(parts, " "), detect.Match)
+		output, err := i.commands().Output(parts)
+		i.log(versionLog).Debug("detect command_output", "tool", name, "argv", parts, "error", err, "output", string(output))
+		if err != nil {
+			return check
+		}
+		// Validate compiled the regex already
+		match := regexp.MustCompile(detect.Match).FindStringSubmatch(string(output))
+		if match == nil {
+			return check
+		}
+		check.installed = true
+		if len(match) > 1 {
+			check.version = match[1]
+		} else {
+			check.version = i.extractVersion(string(output), i.config.Patterns.Version)
+		}
+	}
+	check.drift = check.version != "" && check.pinned != "" && !i.versionsMatch(check.version, check.pinned)
+	return check
+}
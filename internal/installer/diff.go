@@ -0,0 +1,128 @@
+package installer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/colors"
+	"github.com/Abhaythakor/dev-tools-installer/internal/config"
+)
+
+// Diff change kinds
+const (
+	changeAdded     = "added"
+	changeRemoved   = "removed"
+	changeChanged   = "changed"
+	changeUnchanged = "unchanged"
+)
+
+// ConfigDiff describes how another config differs from the loaded one and what a run with it would do
+type ConfigDiff struct {
+	Tools []ToolDiff `json:"tools"`
+}
+
+// ToolDiff is the difference for one tool_list entry
+type ToolDiff struct {
+	Name           string `json:"name"`
+	Change         string `json:"change"`
+	OldVersion     string `json:"old_version,omitempty"`
+	NewVersion     string `json:"new_version,omitempty"`
+	MethodsChanged bool   `json:"methods_changed,omitempty"`
+	Current        string `json:"current,omitempty"` // Detected installed version
+	Action         string `json:"action"`
+}
+
+// Diff compares other against the loaded config and the installed state
+func (i *Installer) Diff(other *config.InstallerConfig) *ConfigDiff {
+	// Plan against the new config while sharing this machine's state
+	next := &Installer{config: other, state: i.loadedState(), Options: i.Options, out: i.out, diag: i.diag}
+	next.applyDefaultTools()
+
+	oldEntries := map[string]bool{}
+	for _, entry := range i.config.ToolList {
+		oldEntries[entry] = true
+	}
+
+	diff := &ConfigDiff{}
+	newEntries := map[string]bool{}
+	for _, item := range next.BuildPlan() {
+		newEntries[item.Entry] = true
+		name, _ := config.ParseToolEntry(item.Entry)
+		td := ToolDiff{Name: item.Entry, Change: changeUnchanged, Current: item.Current, Action: item.Action}
+
+		oldTool, newTool := i.config.Tools[name], other.Tools[name]
+		if oldTool != nil {
+			td.OldVersion = oldTool.Version
+		}
+		if newTool != nil {
+			td.NewVersion = newTool.Version
+		}
+
+		methodsChanged := oldTool != nil && newTool != nil && !reflect.DeepEqual(oldTool.Methods, newTool.Methods)
+		switch {
+		case !oldEntries[item.Entry]:
+			td.Change = changeAdded
+		case td.OldVersion != td.NewVersion || methodsChanged:
+			td.Change, td.MethodsChanged = changeChanged, methodsChanged
+		}
+		diff.Tools = append(diff.Tools, td)
+	}
+
+	for _, entry := range i.config.ToolList {
+		if newEntries[entry] {
+			continue
+		}
+		item := i.planEntry(entry)
+		td := ToolDiff{Name: entry, Change: changeRemoved, Current: item.Current, Action: actionSkip}
+		if oldTool := i.config.Tools[entry]; oldTool != nil {
+			td.OldVersion = oldTool.Version
+		}
+		// Anything still on disk is left behind once the entry is gone
+		if item.Action == actionSkip {
+			td.Action = actionOrphaned
+		}
+		diff.Tools = append(diff.Tools, td)
+	}
+
+	return diff
+}
+
+// Print writes the diff as colored human-readable lines
+func (d *ConfigDiff) Print(w io.Writer) {
+	shown := 0
+	for _, td := range d.Tools {
+		if td.Change == changeUnchanged && td.Action == actionSkip {
+			continue
+		}
+		shown++
+
+		var details string
+		if td.OldVersion != td.NewVersion {
+			details += fmt.Sprintf(" version %s → %s", orDash(td.OldVersion), orDash(td.NewVersion))
+		}
+		if td.MethodsChanged {
+			details += " methods changed"
+		}
+
+		switch td.Change {
+		case changeAdded:
+			fmt.Fprintf(w, "%s+ %-16s%s%s  (%s)\n", colors.Green, td.Name, colors.Reset, details, td.Action)
+		case changeRemoved:
+			fmt.Fprintf(w, "%s- %-16s%s%s  (%s)\n", colors.Red, td.Name, colors.Reset, details, td.Action)
+		default:
+			fmt.Fprintf(w, "%s~ %-16s%s%s  (%s)\n", colors.Yellow, td.Name, colors.Reset, details, td.Action)
+		}
+	}
+	if shown == 0 {
+		fmt.Fprintln(w, "No changes")
+	}
+}
+
+// PrintJSON writes the diff as indented JSON
+func (d *ConfigDiff) PrintJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(d)
+}
@@ -0,0 +1,195 @@
+package installer
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/config"
+)
+
+// knownTool is an entry of the database discover recognizes tools with
+type knownTool struct {
+	Brew        string // Homebrew formula, defaults to the tool name
+	VersionFlag string
+}
+
+// knownTools are the dev tools discover looks for on PATH. Binaries in the Go bin directory
+// are looked at as well; their build info names the package to go install.
+var knownTools = map[string]knownTool{
+	"amass":         {VersionFlag: "-version"},
+	"bat":           {},
+	"dlv":           {Brew: "delve", VersionFlag: "version"},
+	"dnsx":          {VersionFlag: "-version"},
+	"fd":            {},
+	"ffuf":          {VersionFlag: "-V"},
+	"fzf":           {},
+	"gau":           {VersionFlag: "--version"},
+	"gh":            {},
+	"gobuster":      {VersionFlag: "version"},
+	"golangci-lint": {},
+	"gopls":         {VersionFlag: "version"},
+	"gron":          {},
+	"helm":          {VersionFlag: "version"},
+	"httpx":         {VersionFlag: "-version"},
+	"hugo":          {},
+	"jq":            {},
+	"k9s":           {VersionFlag: "version"},
+	"katana":        {VersionFlag: "-version"},
+	"kubectl":       {},
+	"lazygit":       {VersionFlag: "--version"},
+	"naabu":         {VersionFlag: "-version"},
+	"nmap":          {},
+	"nuclei":        {VersionFlag: "-version"},
+	"rg":            {Brew: "ripgrep"},
+	"shellcheck":    {},
+	"sqlmap":        {},
+	"staticcheck":   {},
+	"subfinder":     {VersionFlag: "-version"},
+	"terraform":     {VersionFlag: "version"},
+	"tmux":          {VersionFlag: "-V"},
+	"waybackurls":   {},
+	"yq":            {},
+}
+
+// Discovered install methods
+const (
+	discoveredGo   = "go"
+	discoveredBrew = "brew"
+	discoveredApt  = "apt"
+)
+
+// DiscoveredTool is a tool discover found on this machine
+type DiscoveredTool struct {
+	Name    string
+	Path    string
+	Version string
+	Method  string // go, brew or apt; empty when the tool's origin is unknown
+	Package string // Package path for go install, the formula or the Debian package
+}
+
+// cellarFormula extracts the formula from a path inside the Homebrew Cellar
+var cellarFormula = regexp.MustCompile(`/Cellar/([^/]+)/`)
+
+// Discover looks for known tools on PATH and Go binaries in the Go bin directory, and works
+// out their versions and how they were most likely installed
+func (i *Installer) Discover() []DiscoveredTool {
+	var found []DiscoveredTool
+	seen := map[string]bool{}
+	for _, name := range sortedKeys(knownTools) {
+		if path, err := i.commands().LookPath(name); err == nil {
+			found = append(found, DiscoveredTool{Name: name, Path: path})
+			seen[name] = true
+		}
+	}
+	entries, _ := os.ReadDir(goBinDir())
+	for _, entry := range entries {
+		if info, err := entry.Info(); err == nil && info.Mode().IsRegular() && info.Mode().Perm()&0111 != 0 && !seen[entry.Name()] {
+			found = append(found, DiscoveredTool{Name: entry.Name(), Path: filepath.Join(goBinDir(), entry.Name())})
+		}
+	}
+
+	formulae := i.brewFormulae()
+	for n := range found {
+		tool := &found[n]
+		tool.Version = i.detectVersion(tool.Path, knownTools[tool.Name].VersionFlag)
+		tool.Method, tool.Package = i.installOrigin(tool.Name, tool.Path, formulae)
+	}
+	return found
+}
+
+// installOrigin works out how the binary at path was installed, preferring package managers,
+// which also install Go-built binaries, over the binary's Go build info
+func (i *Installer) installOrigin(name, path string, formulae map[string]bool) (method, pkg string) {
+	known := knownTools[name]
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		resolved = path
+	}
+
+	if m := cellarFormula.FindStringSubmatch(resolved); m != nil {
+		return discoveredBrew, m[1]
+	}
+	if formula := orDefault(known.Brew, name); formulae[formula] && strings.Contains(path, "brew") {
+		return discoveredBrew, formula
+	}
+	for _, candidate := range []string{resolved, path} {
+		if output, err := i.commands().Output([]string{"dpkg", "-S", candidate}); err == nil {
+			if pkg, _, ok := strings.Cut(strings.TrimSpace(string(output)), ":"); ok && !strings.Contains(pkg, " ") {
+				return discoveredApt, pkg
+			}
+		}
+	}
+	if module := i.goPackagePath(path); module != "" {
+		return discoveredGo, module
+	}
+	return "", ""
+}
+
+// goPackagePath returns the main package path recorded in a Go binary's build info
+func (i *Installer) goPackagePath(path string) string {
+	output, err := i.commands().Output([]string{"go", "version", "-m", path})
+	if err != nil {
+		return ""
+	}
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		if fields := strings.Fields(scanner.Text()); len(fields) >= 2 && fields[0] == "path" {
+			return fields[1]
+		}
+	}
+	return ""
+}
+
+// brewFormulae returns the installed Homebrew formulae, or nothing without Homebrew
+func (i *Installer) brewFormulae() map[string]bool {
+	formulae := map[string]bool{}
+	output, err := i.commands().Output([]string{"brew", "list", "--formula", "-1"})
+	if err != nil {
+		return formulae
+	}
+	for _, line := range strings.Fields(string(output)) {
+		formulae[line] = true
+	}
+	return formulae
+}
+
+// goBinDir returns the directory go install places binaries in
+func goBinDir() string {
+	if dir := os.Getenv("GOBIN"); dir != "" {
+		return dir
+	}
+	if dir := os.Getenv("GOPATH"); dir != "" {
+		return filepath.Join(filepath.SplitList(dir)[0], "bin")
+	}
+	return expandHome("~/go/bin")
+}
+
+// ToolConfig returns the config entry that reinstalls the tool the way it was found, or nil
+// when its origin is unknown
+func (t DiscoveredTool) ToolConfig() *config.ToolConfig {
+	tool := &config.ToolConfig{VersionFlag: knownTools[t.Name].VersionFlag}
+	switch t.Method {
+	case discoveredGo:
+		tool.Dependencies = []string{"go"}
+		tool.Methods = []config.InstallMethod{{Name: "go", Commands: config.RunCommands("go install -v " + t.Package + "@latest")}}
+	case discoveredBrew:
+		tool.Methods = []config.InstallMethod{{Name: "brew", Commands: config.RunCommands("brew install " + t.Package)}}
+	case discoveredApt:
+		tool.Methods = []config.InstallMethod{{Name: "apt", Commands: config.RunCommands("sudo apt-get update", "sudo apt-get install -y "+t.Package)}}
+	default:
+		return nil
+	}
+	return tool
+}
+
+// orDefault returns value, or fallback when it is empty
+func orDefault(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
@@ -0,0 +1,178 @@
+package installer
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/colors"
+	"github.com/Abhaythakor/dev-tools-installer/internal/config"
+)
+
+// diskCheck is the space a run needs on one filesystem
+type diskCheck struct {
+	Mount string   // Mount point of the filesystem
+	Dirs  []string // Directories on it the run writes to
+	Need  int64
+	Free  int64
+	Err   error // Set when the free space could not be determined
+}
+
+// short reports whether the filesystem lacks the space the run needs
+func (c diskCheck) short() bool {
+	return c.Err == nil && c.Need > c.Free
+}
+
+// String describes the shortfall, naming the filesystem and the directories it backs
+func (c diskCheck) String() string {
+	return fmt.Sprintf("not enough disk space on the filesystem at %s (%s): need %s, %s free, short by %s",
+		c.Mount, strings.Join(c.Dirs, ", "), formatBytes(c.Need), formatBytes(c.Free), formatBytes(c.Need-c.Free))
+}
+
+// checkDiskSpace refuses to start an install run when a filesystem it writes to is too full,
+// or only warns about it with Options.Force
+func (i *Installer) checkDiskSpace(plan []PlanItem) error {
+	for _, check := range i.diskChecks(plan) {
+		if !check.short() {
+			continue
+		}
+		if !i.Options.Force {
+			return fmt.Errorf("%s (use --force to install anyway)", check)
+		}
+		fmt.Fprintf(i.display(), "%s⚠ %s%s\n", colors.Yellow, check, colors.Reset)
+	}
+	return nil
+}
+
+// hasSizeEstimates reports whether any selected tool has a disk_estimate or a download method
+func (i *Installer) hasSizeEstimates() bool {
+	for _, entry := range i.selectedEntries() {
+		name, _ := config.ParseToolEntry(entry)
+		toolConfig := i.config.Tools[name]
+		if toolConfig == nil {
+			continue
+		}
+		if toolConfig.DiskEstimate != "" {
+			return true
+		}
+		for _, method := range toolConfig.Methods {
+			if method.Type == config.MethodDownload {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// diskChecks estimates the space the plan needs on each filesystem it writes to. A tool's
+// disk_estimate, or else the size of its download, is charged to the Go module cache when
+// its first method runs go install and to the install directory otherwise; downloads also
+// need room in the download cache.
+func (i *Installer) diskChecks(plan []PlanItem) []diskCheck {
+	needs := map[string]int64{}
+	for _, item := range plan {
+		if item.Action == actionSkip {
+			continue
+		}
+		name, version := config.ParseToolEntry(item.Entry)
+		toolConfig := i.config.Tools[name]
+		if toolConfig == nil || len(toolConfig.Methods) == 0 {
+			continue
+		}
+
+		dir := i.toolBinDir(name)
+		if version != "" {
+			dir = i.versionDir(name, version)
+		}
+		first := i.orderedMethods(toolConfig)[0]
+		if first.Type == "" && strings.Contains(strings.Join(first.Runs(), "\n"), "go install") {
+			dir = goModCache()
+		}
+
+		download := i.downloadSize(name, toolConfig, version)
+		estimate, _ := config.ParseSize(toolConfig.DiskEstimate)
+		if estimate == 0 {
+			estimate = download
+		}
+		needs[dir] += estimate
+		needs[i.downloadCacheDir()] += download
+	}
+
+	byMount := map[string]*diskCheck{}
+	for dir, need := range needs {
+		if need == 0 {
+			continue
+		}
+		existing := existingParent(dir)
+		mount := mountPoint(existing)
+		check := byMount[mount]
+		if check == nil {
+			check = &diskCheck{Mount: mount}
+			check.Free, check.Err = diskFree(existing)
+			byMount[mount] = check
+		}
+		check.Dirs = append(check.Dirs, dir)
+		check.Need += need
+	}
+
+	var checks []diskCheck
+	for _, mount := range sortedKeys(byMount) {
+		sort.Strings(byMount[mount].Dirs)
+		checks = append(checks, *byMount[mount])
+	}
+	return checks
+}
+
+// downloadSize returns the size of the artifact the tool's first download method fetches,
+// or 0 when the server does not report it
+func (i *Installer) downloadSize(name string, toolConfig *config.ToolConfig, version string) int64 {
+	if version == "" {
+		version = toolConfig.Version
+	}
+	for _, method := range i.orderedMethods(toolConfig) {
+		if method.Type != config.MethodDownload {
+			continue
+		}
+		vars := i.commandVars(name, version, i.toolBinDir(name))
+		headers, err := i.resolveHeaders(method, vars)
+		if err != nil {
+			return 0
+		}
+		url, err := expandChecked(name, "url", method.URL, vars)
+		if err != nil {
+			return 0
+		}
+		return headSize(i.mirrorURLs(url)[0], headers)
+	}
+	return 0
+}
+
+// goModCache returns the Go module cache directory
+func goModCache() string {
+	if dir := os.Getenv("GOMODCACHE"); dir != "" {
+		return dir
+	}
+	if output, err := exec.Command("go", "env", "GOMODCACHE").Output(); err == nil {
+		if dir := strings.TrimSpace(string(output)); dir != "" {
+			return dir
+		}
+	}
+	return expandHome("~/go/pkg/mod")
+}
+
+// existingParent returns dir or its closest ancestor that exists
+func existingParent(dir string) string {
+	for {
+		if _, err := os.Stat(dir); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return dir
+		}
+		dir = parent
+	}
+}
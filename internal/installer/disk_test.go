@@ -0,0 +1,93 @@
+package installer
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// fullDiskConfig needs more space than any test machine has
+const fullDiskConfig = `
+tool_list: [evtool]
+tools:
+  evtool:
+    disk_estimate: 1000TB
+    methods: [{name: fake, commands: ["install evtool"]}]
+`
+
+func TestDiskChecksChargeEstimatesToTheDirectoriesWrittenTo(t *testing.T) {
+	modCache := t.TempDir()
+	t.Setenv("GOMODCACHE", modCache)
+	i := newTestInstaller(t, `
+tool_list: [binary, module, present]
+tools:
+  binary:
+    disk_estimate: 10MB
+    methods: [{name: fake, commands: ["install binary"]}]
+  module:
+    disk_estimate: 5MB
+    methods: [{name: go, commands: ["go install example.com/module@latest"]}]
+  present:
+    disk_estimate: 1GB
+    methods: [{name: fake, commands: ["install present"]}]
+`, newFakeRunner(t, "present"))
+
+	checks := i.diskChecks(i.BuildPlan())
+	if len(checks) != 1 {
+		t.Fatalf("diskChecks = %+v, want one filesystem for the temp directories", checks)
+	}
+	want := []string{i.toolBinDir("binary"), modCache}
+	slices.Sort(want)
+	if check := checks[0]; check.Need != 15e6 || !slices.Equal(check.Dirs, want) || check.Err != nil {
+		t.Errorf("diskChecks = %+v, want 15MB needed in %q", check, want)
+	}
+	if checks[0].short() {
+		t.Errorf("%v, want 15MB to fit", checks[0])
+	}
+}
+
+func TestRunRefusesToStartWithoutDiskSpace(t *testing.T) {
+	runner := newFakeRunner(t)
+	i := newTestInstaller(t, fullDiskConfig, runner)
+	err := i.Run()
+	if err == nil || !strings.Contains(err.Error(), "not enough disk space") || !strings.HasSuffix(err.Error(), "(use --force to install anyway)") {
+		t.Fatalf("Run = %v, want the shortfall", err)
+	}
+	if len(runner.commands()) > 0 {
+		t.Errorf("Run ran %q without the space", runner.commands())
+	}
+
+	var out bytes.Buffer
+	i = newTestInstaller(t, fullDiskConfig, runner)
+	i.Options.Force = true
+	if err := i.Apply(WithOutput(&out)); err != nil {
+		t.Fatal(err)
+	}
+	if err := i.Run(); err != nil {
+		t.Fatalf("Run with --force: %v", err)
+	}
+	if !strings.Contains(out.String(), "⚠ not enough disk space") || !slices.Contains(runner.commands(), "install evtool") {
+		t.Errorf("Run with --force ran %q and printed:\n%s\nwant a warning and the install", runner.commands(), out.String())
+	}
+}
+
+func TestDoctorReportsDiskSpaceAndRequirements(t *testing.T) {
+	var out bytes.Buffer
+	config := strings.Replace(fullDiskConfig, "[evtool]", "[evtool, needy]", 1) + `
+  needy:
+    methods: [{name: fake, requires: [evtool-helper], commands: ["install needy"]}]
+`
+	i := newTestInstaller(t, config, newFakeRunner(t))
+	if err := i.Apply(WithOutput(&out)); err != nil {
+		t.Fatal(err)
+	}
+	if err := i.Doctor(); err == nil || err.Error() != "doctor found 2 problems" {
+		t.Errorf("Doctor = %v, want 2 problems", err)
+	}
+	for _, want := range []string{"✗ disk", "not enough disk space", "✗ requires", "needy (fake) requires evtool-helper (not found)"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("doctor printed:\n%s\nwant %q", out.String(), want)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+//go:build !windows
+
+package installer
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// diskFree returns the bytes available to the current user on the filesystem holding path
+func diskFree(path string) (int64, error) {
+	var st unix.Statfs_t
+	if err := unix.Statfs(path, &st); err != nil {
+		return 0, err
+	}
+	return int64(st.Bavail) * int64(st.Bsize), nil
+}
+
+// mountPoint returns the directory the filesystem holding path is mounted on
+func mountPoint(path string) string {
+	device := func(p string) (uint64, bool) {
+		info, err := os.Stat(p)
+		if err != nil {
+			return 0, false
+		}
+		st, ok := info.Sys().(*syscall.Stat_t)
+		return uint64(st.Dev), ok
+	}
+
+	dev, ok := device(path)
+	if !ok {
+		return path
+	}
+	for {
+		parent := filepath.Dir(path)
+		if parent == path {
+			return path
+		}
+		if parentDev, ok := device(parent); !ok || parentDev != dev {
+			return path
+		}
+		path = parent
+	}
+}
@@ -0,0 +1,27 @@
+//go:build windows
+
+package installer
+
+import (
+	"path/filepath"
+
+	"golang.org/x/sys/windows"
+)
+
+// diskFree returns the bytes available to the current user on the volume holding path
+func diskFree(path string) (int64, error) {
+	p, err := windows.UTF16PtrFromString(path)
+	if err != nil {
+		return 0, err
+	}
+	var free uint64
+	if err := windows.GetDiskFreeSpaceEx(p, &free, nil, nil); err != nil {
+		return 0, err
+	}
+	return int64(free), nil
+}
+
+// mountPoint returns the volume holding path
+func mountPoint(path string) string {
+	return filepath.VolumeName(path) + `\`
+}
@@ -0,0 +1,92 @@
+package installer
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/colors"
+	"github.com/Abhaythakor/dev-tools-installer/internal/config"
+)
+
+// Doctor checks that this machine has the disk space, network access and commands the
+// pending installs need
+func (i *Installer) Doctor() error {
+	fmt.Fprintf(i.display(), "\n%s╭─── Doctor ───╮%s\n", colors.Blue+colors.Bold, colors.Reset)
+
+	problems := 0
+	plan := i.BuildPlan()
+	checks := i.diskChecks(plan)
+	for _, check := range checks {
+		switch {
+		case check.Err != nil:
+			problems++
+			fmt.Fprintf(i.display(), "%s│ %s✗ %-9s%s │ %s: %v\n", colors.Blue, colors.Red, "disk", colors.Reset, check.Mount, check.Err)
+		case check.short():
+			problems++
+			fmt.Fprintf(i.display(), "%s│ %s✗ %-9s%s │ %s%s%s\n", colors.Blue, colors.Red, "disk", colors.Reset, colors.Red, check, colors.Reset)
+		default:
+			fmt.Fprintf(i.display(), "%s│ %s✓ %-9s%s │ %s: %s free, %s needed (%s)\n", colors.Blue, colors.Green, "disk", colors.Reset,
+				check.Mount, formatBytes(check.Free), formatBytes(check.Need), strings.Join(check.Dirs, ", "))
+		}
+	}
+	if len(checks) == 0 {
+		fmt.Fprintf(i.display(), "%s│ %s✓ %-9s%s │ no size estimates for pending installs\n", colors.Blue, colors.Green, "disk", colors.Reset)
+	}
+
+	hosts := i.planHosts(plan)
+	offline := probeHosts(hosts)
+	for _, host := range hosts {
+		if err := offline[host]; err != nil {
+			problems++
+			fmt.Fprintf(i.display(), "%s│ %s✗ %-9s%s │ %s unreachable: %v\n", colors.Blue, colors.Red, "network", colors.Reset, host, err)
+			continue
+		}
+		fmt.Fprintf(i.display(), "%s│ %s✓ %-9s%s │ %s reachable\n", colors.Blue, colors.Green, "network", colors.Reset, host)
+	}
+
+	problems += i.checkRequirements(plan)
+	i.checkSystemPackages(plan)
+
+	fmt.Fprintf(i.display(), "%s╰─── %s%d problems %s───╯%s\n\n", colors.Blue, colors.Green, problems, colors.Blue, colors.Reset)
+	if problems > 0 {
+		return fmt.Errorf("doctor found %d problems", problems)
+	}
+	return nil
+}
+
+// checkRequirements prints the unmet requires of the pending tools' methods and returns
+// the number of tools left without a usable method
+func (i *Installer) checkRequirements(plan []PlanItem) int {
+	problems := 0
+	for _, item := range plan {
+		if item.Action == actionSkip {
+			continue
+		}
+		name, _ := config.ParseToolEntry(item.Entry)
+		toolConfig := i.config.Tools[name]
+		if toolConfig == nil {
+			continue
+		}
+		usable := 0
+		var unmet []string
+		for _, method := range i.orderedMethods(toolConfig) {
+			missing, _ := i.methodRequirements(name, method)
+			if len(missing) == 0 {
+				usable++
+				continue
+			}
+			unmet = append(unmet, fmt.Sprintf("%s (%s) requires %s", item.Entry, method.Name, strings.Join(missing, ", ")))
+		}
+		for _, line := range unmet {
+			if usable == 0 {
+				fmt.Fprintf(i.display(), "%s│ %s✗ %-9s%s │ %s%s%s\n", colors.Blue, colors.Red, "requires", colors.Reset, colors.Red, line, colors.Reset)
+			} else {
+				fmt.Fprintf(i.display(), "%s│ %s! %-9s%s │ %s\n", colors.Blue, colors.Yellow, "requires", colors.Reset, line)
+			}
+		}
+		if usable == 0 && len(unmet) > 0 {
+			problems++
+		}
+	}
+	return problems
+}
@@ -0,0 +1,261 @@
+package installer
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// downloadFile fetches url with the given headers into a temporary file in dir and returns
+// its path, reading no faster than limiter allows. When progress is set it is called as the
+// body is read with the bytes so far and the total, or -1 when unknown.
+func downloadFile(log *slog.Logger, dir, url string, headers map[string]string, limiter *rateLimiter, progress func(done, total int64)) (string, error) {
+	resp, err := httpGet(log, url, headers)
+	if err != nil {
+		return "", &unavailableError{fmt.Errorf("failed to download %s: %v", url, err)}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", statusError("failed to download "+url, resp)
+	}
+
+	f, err := os.CreateTemp(dir, "download-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %v", err)
+	}
+	defer f.Close()
+
+	body := &countingReader{r: limiter.reader(resp.Body), total: resp.ContentLength, progress: progress}
+	if _, err := io.Copy(f, body); err != nil {
+		os.Remove(f.Name())
+		return "", &unavailableError{fmt.Errorf("failed to download %s: %v", url, err)}
+	}
+
+	return f.Name(), nil
+}
+
+// httpClient gives up on servers that accept a connection but never respond, so that
+// mirrors that hang fall back like mirrors that fail
+var httpClient = &http.Client{Transport: func() http.RoundTripper {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = 30 * time.Second
+	return transport
+}()}
+
+// httpGet sends a GET request with the given headers
+func httpGet(log *slog.Logger, url string, headers map[string]string) (*http.Response, error) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	return doRequest(log, req)
+}
+
+// doRequest sends req with httpClient, logging it to log without header values
+func doRequest(log *slog.Logger, req *http.Request) (*http.Response, error) {
+	started := time.Now()
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		log.Debug("request failed", "method", req.Method, "url", req.URL.String(), "error", err)
+		return nil, err
+	}
+	log.Debug("response", "method", req.Method, "url", req.URL.String(), "range", req.Header.Get("Range"),
+		"status", resp.Status, "length", resp.ContentLength, "duration", time.Since(started).Round(time.Millisecond))
+	return resp, nil
+}
+
+// statusError describes an unexpected response, marking 5xx responses as unavailable
+func statusError(context string, resp *http.Response) error {
+	err := fmt.Errorf("%s: %s", context, resp.Status)
+	if resp.StatusCode >= 500 {
+		return &unavailableError{err}
+	}
+	return err
+}
+
+// countingReader reports the number of bytes read through it
+type countingReader struct {
+	r        io.Reader
+	n        int64
+	total    int64
+	progress func(done, total int64)
+}
+
+// Read reads from the underlying reader and reports progress
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	if c.progress != nil && n > 0 {
+		c.progress(c.n, c.total)
+	}
+	return n, err
+}
+
+// extractTarGz extracts a .tar.gz archive into dest, dropping the first strip path components
+func extractTarGz(archive, dest string, strip int) error {
+	if err := os.MkdirAll(dest, 0755); err != nil {
+		return err
+	}
+	f, err := os.Open(archive)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("failed to read archive: %v", err)
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read archive: %v", err)
+		}
+
+		parts := strings.Split(filepath.Clean(header.Name), string(filepath.Separator))
+		if len(parts) <= strip {
+			continue
+		}
+		target := filepath.Join(dest, filepath.Join(parts[strip:]...))
+		if !strings.HasPrefix(target, filepath.Clean(dest)+string(filepath.Separator)) {
+			return fmt.Errorf("archive entry %s escapes destination", header.Name)
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := makeEntryDir(dest, target, header.Name); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := makeEntryDir(dest, filepath.Dir(target), header.Name); err != nil {
+				return err
+			}
+			removeSymlink(target)
+			out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(header.Mode)&0777)
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(out, tr); err != nil {
+				out.Close()
+				return err
+			}
+			out.Close()
+		case tar.TypeSymlink:
+			// Links may point elsewhere in the archive, as the bin directory of Node.js
+			// releases does, but not out of it
+			if filepath.IsAbs(header.Linkname) || !withinDir(dest, filepath.Join(filepath.Dir(target), header.Linkname)) {
+				return fmt.Errorf("archive entry %s links outside the destination (%s)", header.Name, header.Linkname)
+			}
+			if err := makeEntryDir(dest, filepath.Dir(target), header.Name); err != nil {
+				return err
+			}
+			os.Remove(target)
+			if err := os.Symlink(header.Linkname, target); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// extractZip extracts a .zip archive into dest
+func extractZip(archive, dest string) error {
+	if err := os.MkdirAll(dest, 0755); err != nil {
+		return err
+	}
+	r, err := zip.OpenReader(archive)
+	if err != nil {
+		return fmt.Errorf("failed to read archive: %v", err)
+	}
+	defer r.Close()
+
+	for _, file := range r.File {
+		target := filepath.Join(dest, file.Name)
+		if !strings.HasPrefix(target, filepath.Clean(dest)+string(filepath.Separator)) {
+			return fmt.Errorf("archive entry %s escapes destination", file.Name)
+		}
+		if file.FileInfo().IsDir() {
+			if err := makeEntryDir(dest, target, file.Name); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := makeEntryDir(dest, filepath.Dir(target), file.Name); err != nil {
+			return err
+		}
+		removeSymlink(target)
+
+		in, err := file.Open()
+		if err != nil {
+			return err
+		}
+		out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, file.Mode()&0777|0600)
+		if err != nil {
+			in.Close()
+			return err
+		}
+		_, err = io.Copy(out, in)
+		in.Close()
+		out.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// withinDir reports whether path is dir or inside it, comparing the paths as written
+func withinDir(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// makeEntryDir creates the directory dir of archive entry name inside dest. The deepest part
+// of dir that exists already must resolve inside dest, so that entries cannot be written
+// through a symlink an earlier entry created.
+func makeEntryDir(dest, dir, name string) error {
+	realDest, err := filepath.EvalSymlinks(dest)
+	if err != nil {
+		return err
+	}
+	existing := dir
+	for {
+		if _, err := os.Lstat(existing); err == nil || existing == filepath.Dir(existing) {
+			break
+		}
+		existing = filepath.Dir(existing)
+	}
+	real, err := filepath.EvalSymlinks(existing)
+	if err != nil {
+		return fmt.Errorf("archive entry %s: %v", name, err)
+	}
+	if !withinDir(realDest, real) {
+		return fmt.Errorf("archive entry %s escapes destination through a symlink", name)
+	}
+	return os.MkdirAll(dir, 0755)
+}
+
+// removeSymlink removes path when it is a symlink, so that a file entry replaces a link an
+// earlier entry created instead of writing through it
+func removeSymlink(path string) {
+	if info, err := os.Lstat(path); err == nil && info.Mode()&os.ModeSymlink != 0 {
+		os.Remove(path)
+	}
+}
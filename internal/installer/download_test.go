@@ -0,0 +1,130 @@
+package installer
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// tarEntry is an entry of a test archive: a file with content, a directory, or a symlink
+type tarEntry struct {
+	name, content, link string
+	dir                 bool
+}
+
+// writeTarGz writes a .tar.gz archive of entries into dir and returns its path
+func writeTarGz(t *testing.T, dir string, entries []tarEntry) string {
+	t.Helper()
+	path := filepath.Join(dir, "test.tar.gz")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	gz := gzip.NewWriter(f)
+	tw := tar.NewWriter(gz)
+	for _, e := range entries {
+		header := &tar.Header{Name: e.name, Mode: 0755, Typeflag: tar.TypeReg, Size: int64(len(e.content))}
+		switch {
+		case e.dir:
+			header.Typeflag, header.Size = tar.TypeDir, 0
+		case e.link != "":
+			header.Typeflag, header.Linkname, header.Size = tar.TypeSymlink, e.link, 0
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := tw.Write([]byte(e.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestExtractTarGzRejectsEscapingSymlinks(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		entries []tarEntry
+	}{
+		{"absolute link", []tarEntry{{name: "evtool", link: "/etc/hostname"}}},
+		{"relative link", []tarEntry{{name: "bin/evtool", link: "../../outside"}}},
+		{"file under directory link", []tarEntry{{name: "lib", link: ".."}, {name: "lib/evtool", content: "x"}}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := t.TempDir()
+			dest := filepath.Join(dir, "dest")
+			if err := extractTarGz(writeTarGz(t, dir, tc.entries), dest, 0); err == nil {
+				t.Fatal("extractTarGz succeeded, want an error")
+			}
+			if _, err := os.Lstat(filepath.Join(dir, "evtool")); err == nil {
+				t.Error("an entry was written outside the destination")
+			}
+		})
+	}
+}
+
+func TestExtractTarGzRejectsWritesThroughExistingSymlinks(t *testing.T) {
+	dir := t.TempDir()
+	dest, outside := filepath.Join(dir, "dest"), filepath.Join(dir, "outside")
+	for _, d := range []string{dest, outside} {
+		if err := os.Mkdir(d, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink(outside, filepath.Join(dest, "lib")); err != nil {
+		t.Fatal(err)
+	}
+	archive := writeTarGz(t, dir, []tarEntry{{name: "lib/sub/evtool", content: "x"}})
+	if err := extractTarGz(archive, dest, 0); err == nil || !strings.Contains(err.Error(), "through a symlink") {
+		t.Fatalf("extractTarGz error = %v, want an escape through a symlink", err)
+	}
+	if _, err := os.Lstat(filepath.Join(outside, "sub")); err == nil {
+		t.Error("a directory was created outside the destination")
+	}
+}
+
+func TestExtractTarGzKeepsInternalSymlinks(t *testing.T) {
+	dir := t.TempDir()
+	dest := filepath.Join(dir, "dest")
+	archive := writeTarGz(t, dir, []tarEntry{
+		{name: "node/lib/npm-cli.js", content: "cli"},
+		{name: "node/bin/", dir: true},
+		{name: "node/bin/npm", link: "../lib/npm-cli.js"},
+	})
+	if err := extractTarGz(archive, dest, 1); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dest, "bin", "npm"))
+	if err != nil || string(data) != "cli" {
+		t.Fatalf("bin/npm = %q, %v; want the content of lib/npm-cli.js", data, err)
+	}
+}
+
+func TestFindFileSkipsSymlinks(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Symlink("/etc/hostname", filepath.Join(dir, "evtool")); err != nil {
+		t.Fatal(err)
+	}
+	if path, err := findFile(dir, "evtool"); err == nil {
+		t.Fatalf("findFile returned the symlink %s", path)
+	}
+	sub := filepath.Join(dir, "sub")
+	if err := os.Mkdir(sub, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(sub, "evtool"), []byte("x"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if path, err := findFile(dir, "evtool"); err != nil || path != filepath.Join(sub, "evtool") {
+		t.Fatalf("findFile = %s, %v; want the regular file", path, err)
+	}
+}
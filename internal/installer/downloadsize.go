@@ -0,0 +1,246 @@
+package installer
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/colors"
+	"github.com/Abhaythakor/dev-tools-installer/internal/config"
+)
+
+// estimateConcurrency is how many download sizes are looked up at once
+const estimateConcurrency = 8
+
+// EstimateDownloads fills in the download size of each item of the plan that installs
+// something: the size of the artifact its first method fetches, from the size the state file
+// recorded for its URL, a HEAD request or the GitHub release asset. Other methods, such as go
+// install or a package manager, download an amount that cannot be predicted and leave the
+// size unknown.
+func (i *Installer) EstimateDownloads(plan Plan) {
+	i.loadedState()
+	slots := make(chan struct{}, estimateConcurrency)
+	var wg sync.WaitGroup
+	for n := range plan {
+		item := &plan[n]
+		if item.Action == actionSkip {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+			size, known := i.estimateDownload(item.Entry)
+			item.DownloadSize, item.DownloadUnknown = size, !known
+		}()
+	}
+	wg.Wait()
+}
+
+// estimateDownload returns the size of the artifact the first method of a tool_list entry
+// downloads, and whether it is known
+func (i *Installer) estimateDownload(entry string) (int64, bool) {
+	name, version := config.ParseToolEntry(entry)
+	toolConfig := i.config.Tools[i.installName(name)]
+	if toolConfig == nil || len(toolConfig.Methods) == 0 {
+		return 0, false
+	}
+	if version == "" {
+		version = toolConfig.Version
+	}
+	method := i.orderedMethods(toolConfig)[0]
+	if method.Type != config.MethodDownload && method.Type != config.MethodGithubRelease {
+		return 0, false
+	}
+	vars := i.commandVars(name, version, i.toolBinDir(name))
+	headers, err := i.resolveHeaders(method, vars)
+	if err != nil {
+		return 0, false
+	}
+	url, err := expandChecked(name, "url", method.URL, vars)
+	if err != nil {
+		return 0, false
+	}
+	if method.Type == config.MethodGithubRelease {
+		release, err := i.lookupRelease(name, method, vars, headers)
+		if err != nil {
+			return 0, false
+		}
+		chosen, _, err := i.pickAsset(name, method, release, vars)
+		if err != nil {
+			return 0, false
+		}
+		if chosen.asset.Size > 0 {
+			return chosen.asset.Size, true
+		}
+		url = chosen.asset.URL
+	}
+	urls := i.mirrorURLs(url)
+	for _, url := range urls {
+		if size := i.state.DownloadSizes[url]; size > 0 {
+			return size, true
+		}
+	}
+	size := headSize(urls[0], headers)
+	return size, size > 0
+}
+
+// headSize returns the size a server reports for url, or 0 when it does not report one
+func headSize(url string, headers map[string]string) int64 {
+	req, err := http.NewRequest(http.MethodHead, url, nil)
+	if err != nil {
+		return 0
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.ContentLength < 0 {
+		return 0
+	}
+	return resp.ContentLength
+}
+
+// downloadTotal describes what the items of an estimated plan download together, e.g.
+// "about 412.0 MiB, plus 3 tools of unknown size", or "" when the size of none is known
+func downloadTotal(plan []PlanItem) string {
+	var total int64
+	unknown, pending := 0, 0
+	for _, item := range plan {
+		if item.Action == actionSkip {
+			continue
+		}
+		pending++
+		total += item.DownloadSize
+		if item.DownloadUnknown {
+			unknown++
+		}
+	}
+	switch {
+	case unknown == pending:
+		return ""
+	case unknown > 0:
+		return fmt.Sprintf("about %s, plus %s of unknown size", formatBytes(total), toolCount(unknown))
+	}
+	return "about " + formatBytes(total)
+}
+
+// maxDownloadSize returns the total a run may download, Options.MaxDownloadSize or else
+// downloads.max_size, or 0 when unlimited
+func (i *Installer) maxDownloadSize() int64 {
+	if i.Options.MaxDownloadSize > 0 {
+		return i.Options.MaxDownloadSize
+	}
+	limit, _ := config.ParseSize(i.config.Downloads.MaxSize)
+	return limit
+}
+
+// checkDownloadSize refuses to start an install run whose estimated downloads exceed the
+// limit, or only warns about it with Options.Force
+func (i *Installer) checkDownloadSize(plan []PlanItem, limit int64) error {
+	var total int64
+	for _, item := range plan {
+		total += item.DownloadSize
+	}
+	if total <= limit {
+		return nil
+	}
+	msg := fmt.Sprintf("the run would download about %s, more than the max download size of %s", formatBytes(total), formatBytes(limit))
+	if !i.Options.Force {
+		return fmt.Errorf("%s (use --force to install anyway)", msg)
+	}
+	fmt.Fprintf(i.display(), "%s⚠ %s%s\n", colors.Yellow, msg, colors.Reset)
+	return nil
+}
+
+// startDownload fails a download once the run downloaded the max download size, so that
+// tools whose size was unknown cannot run past it, unless Options.Force is set
+func (i *Installer) startDownload() error {
+	limit := i.maxDownloadSize()
+	if limit == 0 || i.Options.Force {
+		return nil
+	}
+	i.mu.Lock()
+	total := i.downloadedTotal
+	i.mu.Unlock()
+	if total >= limit {
+		return fmt.Errorf("max download size of %s reached: %s downloaded this run (use --force to download anyway)", formatBytes(limit), formatBytes(total))
+	}
+	return nil
+}
+
+// countDownload adds the bytes a download of a tool fetched to the run's totals, and records
+// the size of a completed download of url for later estimates
+func (i *Installer) countDownload(name, url string, bytes, size int64) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.downloaded == nil {
+		i.downloaded = map[string]int64{}
+	}
+	i.downloaded[name] += bytes
+	i.downloadedTotal += bytes
+	if size > 0 && !i.replaying() {
+		state := i.loadedState()
+		if state.DownloadSizes == nil {
+			state.DownloadSizes = map[string]int64{}
+		}
+		state.DownloadSizes[url] = size
+	}
+}
+
+// takeDownloaded returns and forgets the bytes a tool downloaded
+func (i *Installer) takeDownloaded(name string) int64 {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	bytes := i.downloaded[name]
+	delete(i.downloaded, name)
+	return bytes
+}
+
+// unknownDownload reports whether the method that installed a tool downloads an amount the
+// installer does not see, such as go install or a package manager
+func (i *Installer) unknownDownload(name, methodName string) bool {
+	toolConfig := i.config.Tools[i.installName(name)]
+	if toolConfig == nil {
+		return true
+	}
+	for _, method := range toolConfig.Methods {
+		if method.Name == methodName {
+			return method.Type != config.MethodDownload && method.Type != config.MethodGithubRelease
+		}
+	}
+	return true
+}
+
+// toolCount renders a number of tools, e.g. "1 tool" or "3 tools"
+func toolCount(n int) string {
+	if n == 1 {
+		return "1 tool"
+	}
+	return fmt.Sprintf("%d tools", n)
+}
+
+// printDownloadTotal prints what an estimated plan downloads together when the size of any
+// of its downloads is known, flagging totals over the max download size
+func (i *Installer) printDownloadTotal(plan []PlanItem) {
+	total := downloadTotal(plan)
+	if total == "" {
+		return
+	}
+	var bytes int64
+	for _, item := range plan {
+		bytes += item.DownloadSize
+	}
+	if limit := i.maxDownloadSize(); limit > 0 && bytes > limit {
+		fmt.Fprintf(i.display(), "%s│ %sdownload: %s, more than the max download size of %s%s\n", colors.Blue, colors.Red, total, formatBytes(limit), colors.Reset)
+		return
+	}
+	fmt.Fprintf(i.display(), "%s│ %sdownload: %s%s\n", colors.Blue, colors.Gray, total, colors.Reset)
+}
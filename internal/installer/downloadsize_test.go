@@ -0,0 +1,118 @@
+package installer
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// sizeServer reports a size for /evtool.tar.gz only
+func sizeServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/evtool.tar.gz" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Length", "2048")
+	}))
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+// sizeConfig has a download the server reports the size of, one it does not know, a go
+// install of unknown size and a tool that is present
+func sizeConfig(url string) string {
+	return fmt.Sprintf(`
+tool_list: [evtool, recorded, gotool, present]
+tools:
+  evtool:
+    methods: [{name: download, type: download, url: "%[1]s/evtool.tar.gz"}]
+  recorded:
+    methods: [{name: download, type: download, url: "%[1]s/recorded.tar.gz"}]
+  gotool:
+    methods: [{name: go, commands: ["go install example.com/gotool@latest"]}]
+  present:
+    methods: [{name: download, type: download, url: "%[1]s/present.tar.gz"}]
+`, url)
+}
+
+func TestEstimateDownloads(t *testing.T) {
+	ts := sizeServer(t)
+	i := newTestInstaller(t, sizeConfig(ts.URL), newFakeRunner(t, "present"))
+	// The size of a completed download is recorded, so it is known without the server
+	i.loadedState().DownloadSizes = map[string]int64{ts.URL + "/recorded.tar.gz": 5000}
+
+	plan := i.BuildPlan()
+	i.EstimateDownloads(plan)
+	want := map[string]string{"evtool": "2048", "recorded": "5000", "gotool": "unknown", "present": "0"}
+	for _, item := range plan {
+		got := fmt.Sprint(item.DownloadSize)
+		if item.DownloadUnknown {
+			got = "unknown"
+		}
+		if got != want[item.Entry] {
+			t.Errorf("%s: download size %s, want %s", item.Entry, got, want[item.Entry])
+		}
+	}
+	if got, want := downloadTotal(plan), "about "+formatBytes(7048)+", plus 1 tool of unknown size"; got != want {
+		t.Errorf("downloadTotal = %q, want %q", got, want)
+	}
+}
+
+func TestDownloadTotal(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		plan []PlanItem
+		want string
+	}{
+		{"unknown", []PlanItem{{Action: actionInstall, DownloadUnknown: true}}, ""},
+		{"known", []PlanItem{{Action: actionInstall, DownloadSize: 1024}, {Action: actionSkip}}, "about " + formatBytes(1024)},
+		{"both", []PlanItem{{Action: actionInstall, DownloadSize: 1024}, {Action: actionUpgrade, DownloadUnknown: true}, {Action: actionInstall, DownloadUnknown: true}}, "about " + formatBytes(1024) + ", plus 2 tools of unknown size"},
+	} {
+		if got := downloadTotal(tc.plan); got != tc.want {
+			t.Errorf("%s: downloadTotal = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestRunRefusesToExceedTheMaxDownloadSize(t *testing.T) {
+	ts := sizeServer(t)
+	runner := newFakeRunner(t, "present")
+	i := newTestInstaller(t, "downloads: {max_size: 1KB}\n"+sizeConfig(ts.URL), runner)
+	err := i.Run()
+	want := "the run would download about " + formatBytes(2048) + ", more than the max download size of " + formatBytes(1000) + " (use --force to install anyway)"
+	if err == nil || err.Error() != want {
+		t.Fatalf("Run = %v, want %q", err, want)
+	}
+	if len(runner.commands()) > 0 {
+		t.Errorf("Run ran %q past the limit", runner.commands())
+	}
+}
+
+func TestStartDownloadStopsAtTheMaxDownloadSize(t *testing.T) {
+	i := New(loadTestConfig(t, "tools: {}\n"))
+	i.Options.MaxDownloadSize = 100
+	if err := i.startDownload(); err != nil {
+		t.Fatalf("startDownload before any download = %v", err)
+	}
+	url := "https://dl.example.com/evtool.tar.gz"
+	i.countDownload("evtool", url, 150, 150)
+	err := i.startDownload()
+	if err == nil || !strings.HasPrefix(err.Error(), "max download size of "+formatBytes(100)+" reached: "+formatBytes(150)+" downloaded this run") {
+		t.Errorf("startDownload past the limit = %v", err)
+	}
+	i.Options.Force = true
+	if err := i.startDownload(); err != nil {
+		t.Errorf("startDownload with --force = %v, want nil", err)
+	}
+
+	if got := i.takeDownloaded("evtool"); got != 150 {
+		t.Errorf("takeDownloaded = %d, want 150", got)
+	}
+	if got := i.loadedState().DownloadSizes[url]; got != 150 {
+		t.Errorf("recorded size = %d, want the 150 bytes of the complete download", got)
+	}
+}
@@ -0,0 +1,84 @@
+package installer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// EchoRunner is a CommandRunner for tests of programs embedding the installer, and of the
+// installer's own rendering: nothing runs, and each command prints its command line and
+// succeeds unless Results scripts it. Only the commands in Paths are found.
+type EchoRunner struct {
+	Paths   map[string]string     // Lookups by command name; other names are not found
+	Results map[string]EchoResult // Results by command line, the argv joined with spaces
+
+	mu       sync.Mutex
+	commands []string
+}
+
+// EchoResult is the scripted result of a command of an EchoRunner
+type EchoResult struct {
+	Output   string        // Printed in place of the command line
+	ExitCode int           // Non-zero codes fail the command
+	Delay    time.Duration // How long the command takes, for spinners and stall output
+}
+
+// Commands returns the command lines run and probed so far, in order
+func (r *EchoRunner) Commands() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string{}, r.commands...)
+}
+
+func (r *EchoRunner) LookPath(name string) (string, error) {
+	if path, ok := r.Paths[name]; ok {
+		return path, nil
+	}
+	return "", &exec.Error{Name: name, Err: exec.ErrNotFound}
+}
+
+func (r *EchoRunner) Output(argv []string) ([]byte, error) {
+	output, err := r.result(argv)
+	return []byte(output), err
+}
+
+func (r *EchoRunner) Run(ctx context.Context, argv, env []string, out io.Writer) error {
+	line := strings.Join(argv, " ")
+	result := r.Results[line]
+	if result.Delay > 0 {
+		select {
+		case <-time.After(result.Delay):
+		case <-ctx.Done():
+			r.record(line)
+			return ErrInterrupted
+		}
+	}
+	output, err := r.result(argv)
+	fmt.Fprint(out, output)
+	return err
+}
+
+// result records a command and returns its output and error
+func (r *EchoRunner) result(argv []string) (string, error) {
+	line := strings.Join(argv, " ")
+	r.record(line)
+	result, ok := r.Results[line]
+	if !ok {
+		return line + "\n", nil
+	}
+	if result.ExitCode != 0 {
+		return result.Output, &replayError{msg: fmt.Sprintf("exit status %d", result.ExitCode), code: result.ExitCode}
+	}
+	return result.Output, nil
+}
+
+func (r *EchoRunner) record(line string) {
+	r.mu.Lock()
+	r.commands = append(r.commands, line)
+	r.mu.Unlock()
+}
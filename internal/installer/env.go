@@ -0,0 +1,128 @@
+package installer
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/colors"
+	"github.com/Abhaythakor/dev-tools-installer/internal/config"
+)
+
+// secretEnvPattern matches names of variables whose values are redacted when displayed
+var secretEnvPattern = regexp.MustCompile(`(?i)(token|secret|passw(or)?d|credential|auth|api_?key|private_?key)`)
+
+// envMode returns the environment mode of a method
+func (i *Installer) envMode(method config.InstallMethod) string {
+	switch {
+	case method.EnvMode != "":
+		return method.EnvMode
+	case i.config.EnvMode != "":
+		return i.config.EnvMode
+	}
+	return config.EnvInherit
+}
+
+// commandEnv returns the environment a method's commands run with, resolving secrets
+func (i *Installer) commandEnv(method config.InstallMethod, vars map[string]string) ([]string, error) {
+	env, err := i.envValues(method, vars)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]string, 0, len(env))
+	for _, name := range sortedKeys(env) {
+		value, err := i.expandSecrets(env[name])
+		if err != nil {
+			return nil, fmt.Errorf("env %s: %v", name, err)
+		}
+		result = append(result, name+"="+value)
+	}
+	return result, nil
+}
+
+// envValues returns the variables of a method's environment with secret references left
+// unresolved. Configured env values may use the installer variables; one with a broken
+// reference is kept as written, and the first such reference is returned as the error.
+func (i *Installer) envValues(method config.InstallMethod, vars map[string]string) (map[string]string, error) {
+	env := map[string]string{}
+	switch i.envMode(method) {
+	case config.EnvClean:
+		for _, name := range append([]string{"PATH", "HOME"}, append(i.config.EnvAllow, method.EnvAllow...)...) {
+			if value, ok := os.LookupEnv(name); ok {
+				env[name] = value
+			}
+		}
+	case config.EnvCustom:
+		env["PATH"] = os.Getenv("PATH")
+	default:
+		for _, kv := range os.Environ() {
+			if name, value, ok := strings.Cut(kv, "="); ok {
+				env[name] = value
+			}
+		}
+	}
+
+	// Toolchains installed earlier in the run are found before the rest of PATH
+	if path, ok := env["PATH"]; ok {
+		env["PATH"] = i.overlaidPath(path)
+	}
+
+	var err error
+	for _, overrides := range []map[string]string{i.config.Env, method.Env} {
+		for _, name := range sortedKeys(overrides) {
+			expanded, expandErr := expandChecked(vars["TOOL_NAME"], "env "+name, overrides[name], vars)
+			if expandErr != nil {
+				expanded = overrides[name]
+				if err == nil {
+					err = expandErr
+				}
+			}
+			env[name] = expanded
+		}
+	}
+
+	return env, err
+}
+
+// describeEnv renders the effective environment of a method for display. Secret references
+// are shown unresolved and values of variables named like secrets are redacted. Inherited
+// environments only show the configured variables.
+func (i *Installer) describeEnv(method config.InstallMethod, vars map[string]string) []string {
+	// A broken reference is shown as written; the dry run reports it with the method
+	env, _ := i.envValues(method, vars)
+	inherit := i.envMode(method) == config.EnvInherit
+
+	var lines []string
+	for _, name := range sortedKeys(env) {
+		_, inMethod := method.Env[name]
+		_, inConfig := i.config.Env[name]
+		if inherit && !inMethod && !inConfig {
+			continue
+		}
+		lines = append(lines, i.redact(redactEnv(name+"="+env[name])))
+	}
+	return lines
+}
+
+// printEnv prints the effective environment of a method in verbose mode
+func (i *Installer) printEnv(method config.InstallMethod, vars map[string]string) {
+	if !i.verbose() {
+		return
+	}
+	if mode := i.envMode(method); mode != config.EnvInherit {
+		i.printf("%s│   %senv_mode: %s%s\n", colors.Blue, colors.Gray, mode, colors.Reset)
+	}
+	for _, kv := range i.describeEnv(method, vars) {
+		i.printf("%s│   %senv %s%s\n", colors.Blue, colors.Gray, kv, colors.Reset)
+	}
+}
+
+// redactEnv hides the value of a NAME=value pair when the name looks like a secret
+func redactEnv(kv string) string {
+	name, value, _ := strings.Cut(kv, "=")
+	if value != "" && secretEnvPattern.MatchString(name) {
+		return name + "=***"
+	}
+	return kv
+}
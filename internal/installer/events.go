@@ -0,0 +1,74 @@
+package installer
+
+import "time"
+
+// Event types
+const (
+	EventRunStarted    = "run.started"
+	EventRunFinished   = "run.finished"
+	EventToolStarted   = "tool.started"
+	EventMethodStarted = "method.started"
+	EventToolFinished  = "tool.finished" // Also sent for entries the run had nothing to do for
+	EventDownload      = "download.progress"
+	EventOutput        = "tool.output" // A line a method command printed
+)
+
+// EventsVersion is the version of the event types and their fields. Types and fields may be
+// added within a version; renaming or removing one bumps it.
+const EventsVersion = 1
+
+// Events receives progress events from a run, so programs embedding the installer can
+// render their own progress. Event may be called from several goroutines at once.
+type Events interface {
+	Event(Event)
+}
+
+// EventsFunc adapts a function to the Events interface
+type EventsFunc func(Event)
+
+// Event calls f(e)
+func (f EventsFunc) Event(e Event) {
+	f(e)
+}
+
+// Event is something that happened during a run
+type Event struct {
+	Type     string            `json:"type"`
+	Time     time.Time         `json:"time"`
+	Tool     string            `json:"tool,omitempty"`
+	Method   string            `json:"method,omitempty"`
+	Status   string            `json:"status,omitempty"` // Final status, for tool.finished
+	Error    string            `json:"error,omitempty"`
+	Total    int               `json:"total,omitempty"`   // Entries the run covers, for run.started
+	Summary  string            `json:"summary,omitempty"` // Summary line, for run.finished
+	Line     string            `json:"line,omitempty"`    // Output line without escapes, for tool.output
+	Download *DownloadProgress `json:"download,omitempty"`
+
+	ConfigSource // Config the run uses, for run.started
+}
+
+// DownloadProgress is the state of a running download
+type DownloadProgress struct {
+	File  string        `json:"file"`
+	Bytes int64         `json:"bytes"`
+	Total int64         `json:"total"` // -1 when the server sent no Content-Length
+	Rate  float64       `json:"rate"`  // Bytes per second
+	ETA   time.Duration `json:"eta"`   // Zero when the total is unknown
+	Done  bool          `json:"done"`
+}
+
+// emit sends an event to the configured Events receiver and the line progress of the run
+func (i *Installer) emit(e Event) {
+	if i.Options.Events == nil && i.progress == nil {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	if i.Options.Events != nil {
+		i.Options.Events.Event(e)
+	}
+	if i.progress != nil {
+		i.progress.Event(e)
+	}
+}
@@ -0,0 +1,109 @@
+package installer
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// eventStreamBuffer is how many events an event stream holds for a slow consumer before it
+// drops them
+const eventStreamBuffer = 1024
+
+// eventStreamDrain is how long Close waits for the consumer to take the events still held
+const eventStreamDrain = 2 * time.Second
+
+// EventStream writes the events of runs to w as newline-delimited JSON, for wrappers that
+// render their own progress. Writes happen in the background so that a slow or gone consumer
+// never holds up installs: events arriving while eventStreamBuffer others wait are dropped,
+// as is everything after a failed write. Each record carries EventsVersion as "v" and, once
+// events were dropped, the number dropped so far as "dropped".
+type EventStream struct {
+	w       io.Writer
+	events  chan Event
+	done    chan struct{}
+	dropped atomic.Int64
+	mu      sync.Mutex // Guards closed against events sent while Close runs
+	closed  bool
+}
+
+// streamRecord is one line of an event stream
+type streamRecord struct {
+	Version int   `json:"v"`
+	Dropped int64 `json:"dropped,omitempty"`
+	Event
+}
+
+// NewEventStream starts writing the events it receives to w
+func NewEventStream(w io.Writer) *EventStream {
+	s := &EventStream{w: w, events: make(chan Event, eventStreamBuffer), done: make(chan struct{})}
+	go s.write()
+	return s
+}
+
+// Event queues e for writing, or drops it when the consumer is behind
+func (s *EventStream) Event(e Event) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		s.dropped.Add(1)
+		return
+	}
+	select {
+	case s.events <- e:
+	default:
+		s.dropped.Add(1)
+	}
+}
+
+// write writes the queued events until Close, giving up on the consumer at the first error
+func (s *EventStream) write() {
+	defer close(s.done)
+	out := bufio.NewWriter(s.w)
+	failed := false
+	pending := int64(0) // Events in out that did not reach the consumer yet
+	for e := range s.events {
+		if failed {
+			s.dropped.Add(1)
+			continue
+		}
+		pending++
+		line, err := json.Marshal(streamRecord{Version: EventsVersion, Dropped: s.dropped.Load(), Event: e})
+		if err == nil {
+			_, err = out.Write(append(line, '\n'))
+		}
+		// Bursts of output lines go out in one write, anything else right away
+		if err == nil && len(s.events) == 0 {
+			err = out.Flush()
+		}
+		switch {
+		case err != nil:
+			// The events still buffered are lost with the consumer
+			failed = true
+			s.dropped.Add(pending)
+		case out.Buffered() == 0:
+			pending = 0
+		}
+	}
+}
+
+// Close stops the stream once the queued events are written or eventStreamDrain passed, and
+// returns the number of events dropped
+func (s *EventStream) Close() int64 {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.events)
+	}
+	s.mu.Unlock()
+	select {
+	case <-s.done:
+	case <-time.After(eventStreamDrain):
+		// A stuck consumer gets none of the events still queued
+		s.dropped.Add(int64(len(s.events)))
+	}
+	return s.dropped.Load()
+}
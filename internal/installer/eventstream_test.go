@@ -0,0 +1,106 @@
+package installer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+)
+
+// streamLine is a line of an event stream as a consumer decodes it
+type streamLine struct {
+	V       int    `json:"v"`
+	Dropped int64  `json:"dropped"`
+	Type    string `json:"type"`
+	Tool    string `json:"tool"`
+	Line    string `json:"line"`
+}
+
+// decodeStream decodes the lines of an event stream. It reports bad lines with Errorf, so
+// that it can run in its own goroutine.
+func decodeStream(t *testing.T, r io.Reader) []streamLine {
+	t.Helper()
+	var lines []streamLine
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		var line streamLine
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Errorf("line %q: %v", scanner.Text(), err)
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func TestEventStreamWritesJSONLines(t *testing.T) {
+	var out bytes.Buffer
+	s := NewEventStream(&out)
+	s.Event(Event{Type: EventRunStarted, Total: 1})
+	s.Event(Event{Type: EventToolStarted, Tool: "evtool"})
+	s.Event(Event{Type: EventOutput, Tool: "evtool", Line: "compiling..."})
+	if dropped := s.Close(); dropped != 0 {
+		t.Errorf("Close = %d, want no events dropped", dropped)
+	}
+	s.Event(Event{Type: EventRunFinished})
+
+	lines := decodeStream(t, &out)
+	want := []streamLine{
+		{V: EventsVersion, Type: EventRunStarted},
+		{V: EventsVersion, Type: EventToolStarted, Tool: "evtool"},
+		{V: EventsVersion, Type: EventOutput, Tool: "evtool", Line: "compiling..."},
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("stream = %+v, want %+v", lines, want)
+	}
+	for n := range want {
+		if lines[n] != want[n] {
+			t.Errorf("line %d = %+v, want %+v", n+1, lines[n], want[n])
+		}
+	}
+}
+
+func TestEventStreamDropsEventsForASlowConsumer(t *testing.T) {
+	r, w := io.Pipe()
+	s := NewEventStream(w)
+	// Nothing reads the pipe yet, so the stream holds eventStreamBuffer events and drops the rest
+	const sent = eventStreamBuffer + 500
+	for range sent {
+		s.Event(Event{Type: EventOutput, Tool: "evtool", Line: "compiling..."})
+	}
+	read := make(chan []streamLine)
+	go func() { read <- decodeStream(t, r) }()
+	dropped := s.Close()
+	w.Close()
+	lines := <-read
+
+	if dropped == 0 || int64(len(lines))+dropped != sent {
+		t.Fatalf("%d lines written and %d dropped, want %d sent", len(lines), dropped, sent)
+	}
+	// Each line counts the events dropped before it was written
+	for n := 1; n < len(lines); n++ {
+		if lines[n].Dropped < lines[n-1].Dropped || lines[n].Dropped > dropped {
+			t.Fatalf("line %d counts %d dropped after %d, want a count growing up to %d", n+1, lines[n].Dropped, lines[n-1].Dropped, dropped)
+		}
+	}
+	if last := lines[len(lines)-1]; last.Dropped != dropped {
+		t.Errorf("the last line counts %d dropped, want %d", last.Dropped, dropped)
+	}
+}
+
+// failingWriter fails every write, as a consumer that went away does
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("broken pipe") }
+
+func TestEventStreamDropsEverythingAfterAFailedWrite(t *testing.T) {
+	s := NewEventStream(failingWriter{})
+	for range 10 {
+		s.Event(Event{Type: EventToolStarted, Tool: "evtool"})
+	}
+	if dropped := s.Close(); dropped != 10 {
+		t.Errorf("Close = %d, want all 10 events dropped", dropped)
+	}
+}
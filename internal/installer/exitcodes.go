@@ -0,0 +1,68 @@
+package installer
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/colors"
+	"github.com/Abhaythakor/dev-tools-installer/internal/config"
+)
+
+// warnOutputLines is how many lines of output are shown when a command exits with one of its
+// method's warn_exit_codes
+const warnOutputLines = 5
+
+// methodConfig returns the method of a tool named methodName
+func (i *Installer) methodConfig(name, methodName string) (config.InstallMethod, bool) {
+	if toolConfig := i.config.Tools[name]; toolConfig != nil {
+		for _, method := range toolConfig.Methods {
+			if method.Name == methodName {
+				return method, true
+			}
+		}
+	}
+	return config.InstallMethod{}, false
+}
+
+// checkExitCode applies the success_exit_codes and warn_exit_codes of a method to the exit
+// code of one of its commands, returning nil for accepted codes and the command's error, or
+// one naming the code, otherwise. Accepted warning codes print the end of the output.
+func (i *Installer) checkExitCode(name, methodName, command string, code int, err error, output *outputBuffer) error {
+	method, _ := i.methodConfig(name, methodName)
+	switch {
+	case slices.Contains(method.SuccessExitCodes(), code):
+		return nil
+	case slices.Contains(method.WarnCodes, code):
+		i.printf("%s│%s ⚠ %s exited %d, accepted by warn_exit_codes%s\n", colors.Blue, colors.Yellow, command, code, colors.Reset)
+		lines := strings.Split(strings.TrimRight(output.String(), "\n"), "\n")
+		for _, line := range lines[max(0, len(lines)-warnOutputLines):] {
+			if line != "" {
+				i.printf("%s│%s   %s%s\n", colors.Blue, colors.Gray, line, colors.Reset)
+			}
+		}
+		return nil
+	case err == nil:
+		return fmt.Errorf("exit code %d is not in success_exit_codes", code)
+	}
+	return err
+}
+
+// setExitCode stores the exit code of a tool's last command for the report
+func (i *Installer) setExitCode(name string, code int) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.exitCodes == nil {
+		i.exitCodes = map[string]int{}
+	}
+	i.exitCodes[name] = code
+}
+
+// takeExitCode returns and forgets the exit code stored for a tool
+func (i *Installer) takeExitCode(name string) int {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	code := i.exitCodes[name]
+	delete(i.exitCodes, name)
+	return code
+}
@@ -0,0 +1,240 @@
+package installer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/colors"
+	"github.com/Abhaythakor/dev-tools-installer/internal/config"
+)
+
+// GitHub API defaults, see the github section of the config
+const (
+	defaultGitHubCacheTTL    = time.Hour
+	defaultGitHubConcurrency = 4
+)
+
+// githubClient is shared by every GitHub API lookup of a run. It caches responses on disk
+// and revalidates them with their ETag, limits the requests in flight, and stops querying
+// once the rate limit is exhausted.
+type githubClient struct {
+	dir   string
+	ttl   time.Duration
+	slots chan struct{}
+
+	mu           sync.Mutex
+	limitedUntil time.Time // When an exhausted rate limit resets
+}
+
+// githubCacheEntry is a cached API response
+type githubCacheEntry struct {
+	URL     string          `json:"url"`
+	ETag    string          `json:"etag,omitempty"`
+	Fetched time.Time       `json:"fetched"`
+	Body    json.RawMessage `json:"body"`
+}
+
+// rateLimitError reports an exhausted GitHub API rate limit
+type rateLimitError struct {
+	reset time.Time
+}
+
+func (e *rateLimitError) Error() string {
+	msg := "GitHub API rate limit exceeded"
+	if !e.reset.IsZero() {
+		msg += fmt.Sprintf(", resets at %s (in %s)", e.reset.Format("15:04"), strings.TrimSuffix(time.Until(e.reset).Round(time.Minute).String(), "0s"))
+	}
+	if os.Getenv("GITHUB_TOKEN") == "" {
+		msg += "; set GITHUB_TOKEN to raise the limit"
+	}
+	return msg
+}
+
+// newGitHubClient creates the GitHub client described by cfg
+func newGitHubClient(cfg config.GitHub) *githubClient {
+	dir := expandHome(os.ExpandEnv(cfg.CacheDir))
+	// Without a home there is nowhere to cache responses, rather than a directory relative to the working directory
+	if cache := cacheDir(); cfg.CacheDir == "" && cache != "" {
+		dir = filepath.Join(cache, "dev-tools-installer", "github")
+	}
+	ttl := defaultGitHubCacheTTL
+	if cfg.CacheTTL != "" {
+		ttl, _ = time.ParseDuration(cfg.CacheTTL)
+	}
+	slots := cfg.Concurrency
+	if slots == 0 {
+		slots = defaultGitHubConcurrency
+	}
+	return &githubClient{dir: dir, ttl: ttl, slots: make(chan struct{}, slots)}
+}
+
+// githubJSON decodes the GitHub API response at api into v. Fresh cached responses are used
+// without a request; stale ones are revalidated, and served as they are when the rate limit
+// is exhausted. NoCache ignores the cache but still updates it.
+func (i *Installer) githubJSON(api string, headers map[string]string, v any) error {
+	g := i.github
+	var cached *githubCacheEntry
+	if !i.Options.NoCache {
+		cached = g.load(api)
+	}
+	if cached != nil && time.Since(cached.Fetched) < g.ttl {
+		return json.Unmarshal(cached.Body, v)
+	}
+	if err := g.limited(); err != nil {
+		return g.stale(i, cached, err, v)
+	}
+
+	g.slots <- struct{}{}
+	defer func() { <-g.slots }()
+
+	req, err := http.NewRequest(http.MethodGet, api, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if token := os.Getenv("GITHUB_TOKEN"); token != "" && isGitHubAPI(api) {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	if cached != nil && cached.ETag != "" {
+		req.Header.Set("If-None-Match", cached.ETag)
+	}
+
+	resp, err := doRequest(i.log(httpLog), req)
+	if err != nil {
+		return &unavailableError{fmt.Errorf("failed to query %s: %v", api, err)}
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified && cached != nil:
+		cached.Fetched = time.Now()
+		g.store(cached)
+		return json.Unmarshal(cached.Body, v)
+	case isRateLimited(resp):
+		return g.stale(i, cached, g.exhausted(resp), v)
+	case resp.StatusCode != http.StatusOK:
+		return statusError("failed to query "+api, resp)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return &unavailableError{fmt.Errorf("failed to query %s: %v", api, err)}
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("failed to parse %s: %v", api, err)
+	}
+	g.store(&githubCacheEntry{URL: api, ETag: resp.Header.Get("ETag"), Fetched: time.Now(), Body: body})
+	return nil
+}
+
+// stale serves a cached response past its TTL when the API can't be queried
+func (g *githubClient) stale(i *Installer, cached *githubCacheEntry, err error, v any) error {
+	if cached == nil {
+		return err
+	}
+	i.printf("%s│%s ⚠ %v; using the response cached %s ago%s\n", colors.Blue, colors.Yellow, err,
+		time.Since(cached.Fetched).Round(time.Minute), colors.Reset)
+	return json.Unmarshal(cached.Body, v)
+}
+
+// limited returns the rate limit error while an exhausted limit has not reset
+func (g *githubClient) limited() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if time.Now().Before(g.limitedUntil) {
+		return &rateLimitError{reset: g.limitedUntil}
+	}
+	return nil
+}
+
+// exhausted records the reset time of a rate limited response, so that later lookups fail
+// without a request
+func (g *githubClient) exhausted(resp *http.Response) error {
+	reset := time.Now().Add(time.Minute)
+	if seconds, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		reset = time.Unix(seconds, 0)
+	} else if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		reset = time.Now().Add(time.Duration(seconds) * time.Second)
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.limitedUntil = reset
+	return &rateLimitError{reset: reset}
+}
+
+// isRateLimited reports whether resp rejects a request for exceeding a rate limit
+func isRateLimited(resp *http.Response) bool {
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests:
+		return true
+	case http.StatusForbidden:
+		return resp.Header.Get("X-RateLimit-Remaining") == "0" || resp.Header.Get("Retry-After") != ""
+	}
+	return false
+}
+
+// isGitHubAPI reports whether api is on api.github.com, the only host GITHUB_TOKEN is sent to
+func isGitHubAPI(api string) bool {
+	u, err := url.Parse(api)
+	return err == nil && u.Host == "api.github.com"
+}
+
+// cacheFile returns the file the response of api is cached in
+func (g *githubClient) cacheFile(api string) string {
+	sum := sha256.Sum256([]byte(api))
+	return filepath.Join(g.dir, hex.EncodeToString(sum[:12])+".json")
+}
+
+// load returns the cached response of api, or nil
+func (g *githubClient) load(api string) *githubCacheEntry {
+	if g.dir == "" {
+		return nil
+	}
+	data, err := os.ReadFile(g.cacheFile(api))
+	if err != nil {
+		return nil
+	}
+	var entry githubCacheEntry
+	if json.Unmarshal(data, &entry) != nil || entry.URL != api {
+		return nil
+	}
+	return &entry
+}
+
+// store caches a response, atomically since parallel installs may look up the same release
+func (g *githubClient) store(entry *githubCacheEntry) {
+	data, err := json.Marshal(entry)
+	if err != nil || g.dir == "" {
+		return
+	}
+	if err := os.MkdirAll(g.dir, 0755); err != nil {
+		return
+	}
+	tmp, err := os.CreateTemp(g.dir, "response-*")
+	if err != nil {
+		return
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return
+	}
+	tmp.Close()
+	if os.Rename(tmp.Name(), g.cacheFile(entry.URL)) != nil {
+		os.Remove(tmp.Name())
+	}
+}
@@ -0,0 +1,143 @@
+package installer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// releaseAPIServer serves a release with an ETag, answering If-None-Match with 304, or
+// rejects every request as rate limited once limited is set
+type releaseAPIServer struct {
+	mu       sync.Mutex
+	limited  bool
+	requests []http.Header
+}
+
+func (s *releaseAPIServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.requests = append(s.requests, r.Header.Clone())
+	limited := s.limited
+	s.mu.Unlock()
+	switch {
+	case limited:
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10))
+		http.Error(w, `{"message": "API rate limit exceeded"}`, http.StatusForbidden)
+	case r.Header.Get("If-None-Match") == `"r1"`:
+		w.WriteHeader(http.StatusNotModified)
+	default:
+		w.Header().Set("ETag", `"r1"`)
+		fmt.Fprint(w, `{"tag_name": "v1.2.0"}`)
+	}
+}
+
+// lookup returns the tag of the release at api
+func lookup(i *Installer, api string) (string, error) {
+	var release struct {
+		TagName string `json:"tag_name"`
+	}
+	err := i.githubJSON(api, nil, &release)
+	return release.TagName, err
+}
+
+func TestGitHubJSONCachesAndRevalidatesResponses(t *testing.T) {
+	server := &releaseAPIServer{}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+	api := ts.URL + "/repos/example/evtool/releases/latest"
+	t.Setenv("GITHUB_TOKEN", "secret")
+	i := New(loadTestConfig(t, fmt.Sprintf("github: {cache_dir: %s}\ntools: {}\n", t.TempDir())))
+
+	for n := range 2 {
+		if tag, err := lookup(i, api); err != nil || tag != "v1.2.0" {
+			t.Fatalf("lookup %d = %q, %v; want v1.2.0", n+1, tag, err)
+		}
+	}
+	if len(server.requests) != 1 {
+		t.Fatalf("%d requests, want the second lookup served from the cache", len(server.requests))
+	}
+	if auth := server.requests[0].Get("Authorization"); auth != "" {
+		t.Errorf("sent Authorization %q to a host other than api.github.com", auth)
+	}
+
+	// Past its TTL the response is revalidated with its ETag
+	i.github.ttl = 0
+	if tag, err := lookup(i, api); err != nil || tag != "v1.2.0" {
+		t.Fatalf("revalidated lookup = %q, %v; want the cached v1.2.0", tag, err)
+	}
+	if len(server.requests) != 2 || server.requests[1].Get("If-None-Match") != `"r1"` {
+		t.Errorf("requests = %v, want a second one with If-None-Match", server.requests)
+	}
+}
+
+func TestGitHubJSONServesStaleResponsesWhenRateLimited(t *testing.T) {
+	server := &releaseAPIServer{}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+	api := ts.URL + "/repos/example/evtool/releases/latest"
+	t.Setenv("GITHUB_TOKEN", "")
+	var out bytes.Buffer
+	i := New(loadTestConfig(t, fmt.Sprintf("github: {cache_dir: %s, cache_ttl: 1ns}\ntools: {}\n", t.TempDir())), WithOutput(&out))
+	if _, err := lookup(i, api); err != nil {
+		t.Fatal(err)
+	}
+
+	server.mu.Lock()
+	server.limited = true
+	server.mu.Unlock()
+	if tag, err := lookup(i, api); err != nil || tag != "v1.2.0" {
+		t.Fatalf("rate limited lookup = %q, %v; want the stale v1.2.0", tag, err)
+	}
+	if want := "⚠ GitHub API rate limit exceeded, resets at"; !strings.Contains(out.String(), want) {
+		t.Errorf("printed %q, want %q", out.String(), want)
+	}
+
+	// Lookups without a cached response fail without a request until the limit resets
+	var limited *rateLimitError
+	_, err := lookup(i, ts.URL+"/repos/example/other/releases/latest")
+	if !errors.As(err, &limited) || !strings.HasSuffix(err.Error(), "; set GITHUB_TOKEN to raise the limit") {
+		t.Errorf("lookup of an uncached release = %v, want the rate limit error", err)
+	}
+	if len(server.requests) != 2 {
+		t.Errorf("%d requests, want none once the limit was exhausted", len(server.requests))
+	}
+}
+
+func TestIsRateLimited(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		status int
+		header http.Header
+		want   bool
+	}{
+		{"too many requests", http.StatusTooManyRequests, http.Header{}, true},
+		{"exhausted limit", http.StatusForbidden, http.Header{"X-Ratelimit-Remaining": {"0"}}, true},
+		{"secondary limit", http.StatusForbidden, http.Header{"Retry-After": {"60"}}, true},
+		{"forbidden", http.StatusForbidden, http.Header{"X-Ratelimit-Remaining": {"42"}}, false},
+		{"not found", http.StatusNotFound, http.Header{}, false},
+	} {
+		if got := isRateLimited(&http.Response{StatusCode: tc.status, Header: tc.header}); got != tc.want {
+			t.Errorf("%s: isRateLimited = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestIsGitHubAPI(t *testing.T) {
+	for api, want := range map[string]bool{
+		"https://api.github.com/repos/example/evtool/releases/latest": true,
+		"https://api.github.com.evil.example/repos":                   false,
+		"https://ghmirror.internal/api/repos/example/evtool":          false,
+	} {
+		if got := isGitHubAPI(api); got != want {
+			t.Errorf("isGitHubAPI(%s) = %v, want %v", api, got, want)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package installer
+
+import (
+	"bytes"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// spinnerFrame matches a frame of the sequential spinner, which draws as often as the
+// command it waits for leaves it time to
+var spinnerFrame = regexp.MustCompile(`\r[^\r\n]*[` + strings.Join(spinnerChars, "") + `][^\r\n]*\033\[K`)
+
+// goldenConfig has a tool that is present, one that installs and one whose install fails
+const goldenConfig = `
+tool_list: [present, fresh, broken]
+tools:
+  fresh:
+    methods:
+      - name: fake
+        commands: ["install fresh"]
+  broken:
+    methods:
+      - name: fake
+        commands: ["install broken"]
+`
+
+// TestRunOutputGolden compares the output of representative runs with the golden files in
+// testdata/golden; UPDATE_GOLDEN=1 rewrites them after an intended change
+func TestRunOutputGolden(t *testing.T) {
+	present := map[string]string{"present": "/usr/bin/present", "fresh": "/usr/bin/fresh"}
+	for _, tc := range []struct {
+		name    string
+		yaml    string
+		paths   map[string]string
+		results map[string]EchoResult
+		width   int // Columns of the terminal; 0 for output that is not a terminal
+		quiet   bool
+	}{
+		{name: "all-installed", yaml: goldenConfig, paths: map[string]string{"present": "/usr/bin/present", "fresh": "/usr/bin/fresh", "broken": "/usr/bin/broken"}, width: 80},
+		{name: "mixed-failures", yaml: goldenConfig, paths: map[string]string{"present": "/usr/bin/present"}, width: 80,
+			results: map[string]EchoResult{"install broken": {Output: "E: unable to locate package broken\n", ExitCode: 100}}},
+		{name: "long-names", width: 40, paths: present, yaml: `
+tool_list: [present, a-tool-with-a-name-far-wider-than-the-terminal]
+tools:
+  a-tool-with-a-name-far-wider-than-the-terminal:
+    methods:
+      - name: a-method-with-a-long-name
+        commands: ["install a-tool-with-a-name-far-wider-than-the-terminal"]
+`},
+		{name: "non-tty", yaml: goldenConfig, paths: map[string]string{"present": "/usr/bin/present"},
+			results: map[string]EchoResult{"install broken": {Output: "E: unable to locate package broken\n", ExitCode: 100}}},
+		{name: "quiet", yaml: goldenConfig, paths: map[string]string{"present": "/usr/bin/present"}, width: 80, quiet: true,
+			results: map[string]EchoResult{"install broken": {Output: "E: unable to locate package broken\n", ExitCode: 100}}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			results := map[string]EchoResult{}
+			for name, path := range tc.paths {
+				results[path+" --version"] = EchoResult{Output: name + " 2.1.0\n"}
+				results[name+" --version"] = EchoResult{Output: name + " 2.1.0\n"}
+			}
+			for line, result := range tc.results {
+				results[line] = result
+			}
+			runner := &EchoRunner{Paths: tc.paths, Results: results}
+			var out bytes.Buffer
+			output := WithOutput(&out)
+			if tc.width > 0 {
+				output = WithTerminal(&out, tc.width, 24)
+			}
+			i := New(loadTestConfig(t, tc.yaml), WithRunner(runner), output)
+			i.Options.SkipPreflight = true
+			if tc.quiet {
+				i.Options.Verbosity = VerbosityQuiet
+			}
+			i.Run()
+			checkGolden(t, filepath.Join("testdata", "golden", tc.name+".golden"), spinnerFrame.ReplaceAll(out.Bytes(), nil))
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package installer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/config"
+)
+
+// fakeRunner stands in for the commands of a run. A tool is on PATH once a command
+// "install <tool>" succeeded, or when it is in installed from the start, and reports version
+// 1.0.0. Commands listed in fail exit with an error, and every command run is recorded.
+type fakeRunner struct {
+	mu        sync.Mutex
+	bin       string
+	installed map[string]bool
+	fail      map[string]bool
+	ran       []string
+}
+
+// newFakeRunner creates a fakeRunner whose tools live in a temp directory
+func newFakeRunner(t *testing.T, installed ...string) *fakeRunner {
+	r := &fakeRunner{bin: t.TempDir(), installed: map[string]bool{}, fail: map[string]bool{}}
+	for _, name := range installed {
+		r.installed[name] = true
+	}
+	return r
+}
+
+func (r *fakeRunner) LookPath(name string) (string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.installed[filepath.Base(name)] {
+		return filepath.Join(r.bin, filepath.Base(name)), nil
+	}
+	return "", &exec.Error{Name: name, Err: exec.ErrNotFound}
+}
+
+func (r *fakeRunner) Output(argv []string) ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	// Version probes run tools by name or by the path LookPath returned
+	name := filepath.Base(argv[0])
+	if (argv[0] == name || filepath.Dir(argv[0]) == r.bin) && r.installed[name] {
+		return []byte(name + " version 1.0.0\n"), nil
+	}
+	return nil, errors.New("exit status 127")
+}
+
+func (r *fakeRunner) Run(ctx context.Context, argv, env []string, out io.Writer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	command := strings.Join(argv, " ")
+	r.ran = append(r.ran, command)
+	if r.fail[command] {
+		fmt.Fprintf(out, "%s: failed\n", command)
+		return errors.New("exit status 1")
+	}
+	if len(argv) == 2 && argv[0] == "install" {
+		r.installed[argv[1]] = true
+	}
+	return nil
+}
+
+// commands returns the commands run so far
+func (r *fakeRunner) commands() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string{}, r.ran...)
+}
+
+// loadTestConfig writes a config into a temp directory, with state_dir and bindir inside it
+// unless the config sets them, and loads it
+func loadTestConfig(t *testing.T, yaml string) *config.InstallerConfig {
+	t.Helper()
+	dir := t.TempDir()
+	if !strings.Contains(yaml, "state_dir:") {
+		yaml = "state_dir: " + filepath.Join(dir, "state") + "\n" + yaml
+	}
+	if !strings.Contains(yaml, "bindir:") {
+		yaml = "bindir: " + filepath.Join(dir, "bin") + "\n" + yaml
+	}
+	path := filepath.Join(dir, "installer.yaml")
+	if err := os.WriteFile(path, []byte(yaml), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	return cfg
+}
+
+// newTestInstaller creates an Installer for a config running its commands with runner,
+// without the preflight checks that need the network, and discarding its output
+func newTestInstaller(t *testing.T, yaml string, runner CommandRunner) *Installer {
+	t.Helper()
+	i := New(loadTestConfig(t, yaml), WithOutput(io.Discard), WithRunner(runner))
+	i.Options.SkipPreflight = true
+	return i
+}
+
+// checkGolden compares got with the golden file at path, or rewrites the file when
+// UPDATE_GOLDEN is set
+func checkGolden(t *testing.T, path string, got []byte) {
+	t.Helper()
+	if os.Getenv("UPDATE_GOLDEN") != "" {
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run the test with UPDATE_GOLDEN=1 to create it)", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("output differs from %s:\n got %q\nwant %q", path, got, want)
+	}
+}
@@ -0,0 +1,86 @@
+package installer
+
+import (
+	"regexp"
+	"slices"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/colors"
+	"github.com/Abhaythakor/dev-tools-installer/internal/config"
+)
+
+// builtinHint is a known fix for a common failure, used when no configured hint matches
+type builtinHint struct {
+	match *regexp.Regexp
+	hint  string
+}
+
+// builtinHints cover failures whose fix is the same for every tool
+var builtinHints = []builtinHint{
+	{regexp.MustCompile(`no required module provides package|module declares its path as`),
+		"the Go module path probably changed; check the tool's repository for its current install path"},
+	{regexp.MustCompile(`requires go >= ?\d|go\.mod requires go >= ?\d`),
+		"the module needs a newer Go toolchain than the one installed"},
+	{regexp.MustCompile(`NO_PUBKEY|EXPKEYSIG|KEYEXPIRED|is not signed|The following signatures couldn't be verified`),
+		"the apt repository key is missing or expired; the recipe adding the repository is probably stale"},
+	{regexp.MustCompile(`externally-managed-environment`),
+		"pip refuses to install into the system Python (PEP 668); use a pipx method or a virtualenv"},
+	{regexp.MustCompile(`npm (ERR!|error) code EACCES`),
+		"npm's global prefix is not writable; set a user prefix with npm config set prefix ~/.local"},
+	{regexp.MustCompile("linker `?cc`? not found"),
+		"cargo needs a C compiler; install build-essential or your system's equivalent"},
+	{regexp.MustCompile(`(?i)API rate limit exceeded`),
+		"the GitHub API rate limit ran out; set GITHUB_TOKEN to raise it"},
+}
+
+// failureHints returns the hints matching the output and error of a failed method: those of
+// the method, the tool and the config in that order, or the built-in ones when none matched
+func (i *Installer) failureHints(toolConfig *config.ToolConfig, method config.InstallMethod, output string) []string {
+	var hints []string
+	for _, hint := range slices.Concat(method.Hints, toolConfig.Hints, i.config.Hints) {
+		if hint.Matches(output) && !slices.Contains(hints, hint.Hint) {
+			hints = append(hints, hint.Hint)
+		}
+	}
+	if len(hints) > 0 {
+		return hints
+	}
+	for _, hint := range builtinHints {
+		if hint.match.MatchString(output) {
+			hints = append(hints, hint.hint)
+		}
+	}
+	return hints
+}
+
+// printHints prints the hints of a failed method beneath its error
+func (i *Installer) printHints(hints []string) {
+	for _, hint := range hints {
+		i.printf("%s│%s   💡 %s%s\n", colors.Blue, colors.Yellow, hint, colors.Reset)
+	}
+}
+
+// addHints stores the hints of a tool's failed methods for the report
+func (i *Installer) addHints(name string, hints []string) {
+	if len(hints) == 0 {
+		return
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.hints == nil {
+		i.hints = map[string][]string{}
+	}
+	for _, hint := range hints {
+		if !slices.Contains(i.hints[name], hint) {
+			i.hints[name] = append(i.hints[name], hint)
+		}
+	}
+}
+
+// takeHints returns and forgets the hints stored for a tool
+func (i *Installer) takeHints(name string) []string {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	hints := i.hints[name]
+	delete(i.hints, name)
+	return hints
+}
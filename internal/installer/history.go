@@ -0,0 +1,254 @@
+package installer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/colors"
+)
+
+// historyFileName is the name of the run history file inside the state directory
+const historyFileName = "history.jsonl"
+
+// defaultHistoryLimit is the number of runs kept when history_limit is not set
+const defaultHistoryLimit = 200
+
+// HistoryRecord is one run in the history file
+type HistoryRecord struct {
+	Time    time.Time `json:"time"`
+	Command string    `json:"command"`
+	ConfigSource
+	Retry bool          `json:"retry,omitempty"` // The run retried the tools that failed before
+	Tools []HistoryTool `json:"tools"`
+}
+
+// HistoryTool is the action a run took for one tool
+type HistoryTool struct {
+	Name   string `json:"name"`
+	Action string `json:"action"` // installed, upgraded, reinstalled, failed, deferred, missing or skipped
+	Before string `json:"before,omitempty"`
+	After  string `json:"after,omitempty"`
+	Method string `json:"method,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// historyPath returns the path of the history file
+func (i *Installer) historyPath() string {
+	return filepath.Join(i.stateDir(), historyFileName)
+}
+
+// appendHistory records the current run in the history file
+func (i *Installer) appendHistory(command string) error {
+	record := HistoryRecord{Time: time.Now(), Command: command, ConfigSource: i.source, Retry: i.Options.RetryFailed}
+	for _, result := range i.report {
+		action := "skipped"
+		switch result.Status {
+		case statusInstalled, statusUpgraded, statusReinstalled, statusFailed, statusDeferred, statusMissing:
+			action = result.Status
+		}
+		record.Tools = append(record.Tools, HistoryTool{
+			Name:   result.Name,
+			Action: action,
+			Before: result.PreviousVersion,
+			After:  result.Version,
+			Method: result.Method,
+			Error:  result.Error,
+		})
+	}
+
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	limit := i.config.HistoryLimit
+	if limit <= 0 {
+		limit = defaultHistoryLimit
+	}
+	return appendBounded(i.historyPath(), line, limit)
+}
+
+// appendBounded appends line to a line-oriented file, rewriting it atomically when it exceeds limit lines.
+// Appends are a single write so an interrupted run can at worst leave a truncated final line, which readers skip;
+// the next append starts on a line of its own after it.
+func appendBounded(path string, line []byte, limit int) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	lines := bytes.SplitAfter(data, []byte("\n"))
+	if len(lines) > 0 && len(lines[len(lines)-1]) == 0 {
+		lines = lines[:len(lines)-1]
+	}
+
+	if len(lines) < limit {
+		if len(data) > 0 && data[len(data)-1] != '\n' {
+			line = append([]byte("\n"), line...)
+		}
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			return err
+		}
+		if _, err := f.Write(line); err != nil {
+			f.Close()
+			return err
+		}
+		if err := f.Sync(); err != nil {
+			f.Close()
+			return err
+		}
+		return f.Close()
+	}
+
+	var rotated bytes.Buffer
+	for _, kept := range lines[len(lines)-limit+1:] {
+		rotated.Write(kept)
+		if !bytes.HasSuffix(kept, []byte("\n")) {
+			rotated.WriteByte('\n')
+		}
+	}
+	rotated.Write(line)
+
+	// The new file must be on disk before it replaces the old one, and the rename after it
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(rotated.Bytes())
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp, path)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	syncDir(filepath.Dir(path))
+	return nil
+}
+
+// History returns the recorded runs, oldest first
+func (i *Installer) History() ([]HistoryRecord, error) {
+	f, err := os.Open(i.historyPath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []HistoryRecord
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var record HistoryRecord
+		// Skip lines left truncated by an interrupted write
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			continue
+		}
+		records = append(records, record)
+	}
+	return records, scanner.Err()
+}
+
+// PrintHistory prints the most recent runs, or the timeline of a single tool when tool is set
+func (i *Installer) PrintHistory(tool string, limit int) error {
+	records, err := i.History()
+	if err != nil {
+		return fmt.Errorf("failed to read history: %v", err)
+	}
+	if len(records) == 0 {
+		fmt.Fprintln(i.display(), "No runs recorded yet")
+		return nil
+	}
+
+	if tool == "" {
+		if limit > 0 && len(records) > limit {
+			records = records[len(records)-limit:]
+		}
+		for _, record := range records {
+			counts := map[string]int{}
+			for _, t := range record.Tools {
+				counts[t.Action]++
+			}
+			var parts []string
+			for _, action := range []string{"installed", "upgraded", "reinstalled", "failed", "deferred", "missing", "skipped"} {
+				if counts[action] > 0 {
+					parts = append(parts, fmt.Sprintf("%d %s", counts[action], action))
+				}
+			}
+			actions := strings.Join(parts, ", ")
+			if record.Retry {
+				actions = "retry: " + actions
+			}
+			fmt.Fprintf(i.display(), "%s%s%s  %-7s  config %s  %s\n", colors.Blue, record.Time.Local().Format("2006-01-02 15:04:05"), colors.Reset,
+				record.Command, shortHash(record.ConfigSHA256), actions)
+		}
+		return nil
+	}
+
+	var lines []string
+	for _, record := range records {
+		for _, t := range record.Tools {
+			if t.Name != tool && !strings.HasPrefix(t.Name, tool+"@") {
+				continue
+			}
+			// Unchanged tools clutter a timeline, so only actions are shown
+			if t.Action == "skipped" {
+				continue
+			}
+			change := orDash(t.Before) + " → " + orDash(t.After)
+			line := fmt.Sprintf("%s%s%s  %-9s  %-24s  %s  config %s", colors.Blue, record.Time.Local().Format("2006-01-02 15:04:05"), colors.Reset,
+				t.Action, change, orDash(t.Method), shortHash(record.ConfigSHA256))
+			if t.Error != "" {
+				line += fmt.Sprintf("  %s%s%s", colors.Red, t.Error, colors.Reset)
+			}
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) == 0 {
+		fmt.Fprintf(i.display(), "No recorded actions for %s\n", tool)
+		return nil
+	}
+	if limit > 0 && len(lines) > limit {
+		lines = lines[len(lines)-limit:]
+	}
+	for _, line := range lines {
+		fmt.Fprintln(i.display(), line)
+	}
+	return nil
+}
+
+// shortHash abbreviates a hex digest for display
+func shortHash(hash string) string {
+	if len(hash) > 8 {
+		return hash[:8]
+	}
+	return orDash(hash)
+}
+
+// orDash returns s, or "-" when s is empty
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
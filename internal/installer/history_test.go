@@ -0,0 +1,78 @@
+package installer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/config"
+)
+
+func TestAppendBoundedAfterTruncatedLine(t *testing.T) {
+	for _, tc := range []struct {
+		name  string
+		limit int
+		want  string
+	}{
+		{"append", 10, "{\"a\":1}\n{\"b\":\n{\"c\":3}\n"},
+		{"rotate", 2, "{\"b\":\n{\"c\":3}\n"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), historyFileName)
+			// An interrupted run left its line without a line ending
+			if err := os.WriteFile(path, []byte("{\"a\":1}\n{\"b\":"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := appendBounded(path, []byte("{\"c\":3}\n"), tc.limit); err != nil {
+				t.Fatal(err)
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tc.want {
+				t.Errorf("file = %q, want %q", data, tc.want)
+			}
+			if _, err := os.Stat(path + ".tmp"); err == nil {
+				t.Error("the temporary file of the rotation was left behind")
+			}
+		})
+	}
+}
+
+func TestAppendHistoryActions(t *testing.T) {
+	i := New(&config.InstallerConfig{StateDir: t.TempDir()})
+	i.report = []ToolReport{
+		{Name: "a", Status: statusInstalled},
+		{Name: "b", Status: statusMissing},
+		{Name: "c", Status: statusOK},
+		{Name: "d", Status: statusSkipped},
+	}
+	if err := i.appendHistory("check"); err != nil {
+		t.Fatal(err)
+	}
+	// A truncated run is skipped, and the next one is read after it
+	f, err := os.OpenFile(i.historyPath(), os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString(`{"time":"2026-`)
+	f.Close()
+	if err := i.appendHistory("check"); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := i.History()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("History returned %d records, want 2", len(records))
+	}
+	want := map[string]string{"a": "installed", "b": "missing", "c": "skipped", "d": "skipped"}
+	for _, tool := range records[1].Tools {
+		if tool.Action != want[tool.Name] {
+			t.Errorf("%s: action %s, want %s", tool.Name, tool.Action, want[tool.Name])
+		}
+	}
+}
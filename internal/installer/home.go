@@ -0,0 +1,61 @@
+package installer
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"runtime"
+)
+
+// homeDir returns the user's home directory: HOME, or the passwd entry of the current user
+// when HOME is unset, as under systemd units and in minimal containers. Every default path
+// under ~ is resolved through it; it returns "" when neither is known.
+func homeDir() string {
+	if home, err := os.UserHomeDir(); err == nil {
+		return home
+	}
+	if current, err := user.Current(); err == nil {
+		return current.HomeDir
+	}
+	return ""
+}
+
+// cacheDir returns the user's cache directory like os.UserCacheDir, falling back to ~/.cache
+// under homeDir when the environment does not say, or "" when there is no home either
+func cacheDir() string {
+	if cache, err := os.UserCacheDir(); err == nil {
+		return cache
+	}
+	if home := homeDir(); home != "" {
+		return filepath.Join(home, ".cache")
+	}
+	return ""
+}
+
+// FillEnvironment sets HOME and USER from the passwd database when they are unset, so that
+// default paths and the commands the installer runs see the real values, and returns a
+// warning for each variable it set or could not resolve
+func FillEnvironment() []string {
+	if runtime.GOOS == "windows" || os.Getenv("HOME") != "" && os.Getenv("USER") != "" {
+		return nil
+	}
+	home, username := "", ""
+	if current, err := user.Current(); err == nil {
+		home, username = current.HomeDir, current.Username
+	}
+	var warnings []string
+	for _, v := range []struct{ name, value string }{{"HOME", home}, {"USER", username}} {
+		switch {
+		case os.Getenv(v.name) != "":
+		case v.value == "" && v.name == "HOME":
+			warnings = append(warnings, "HOME is not set and the current user has no passwd entry; set HOME, or bindir, state_dir and github.cache_dir, for the paths under ~")
+		case v.value == "":
+			warnings = append(warnings, v.name+" is not set and the current user has no passwd entry")
+		default:
+			os.Setenv(v.name, v.value)
+			warnings = append(warnings, fmt.Sprintf("%s is not set; using %s from the passwd database", v.name, v.value))
+		}
+	}
+	return warnings
+}
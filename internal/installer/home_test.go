@@ -0,0 +1,71 @@
+package installer
+
+import (
+	"os"
+	"os/user"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"testing"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/config"
+)
+
+// unsetenv unsets environment variables for the rest of a test
+func unsetenv(t *testing.T, names ...string) {
+	for _, name := range names {
+		t.Setenv(name, "")
+		os.Unsetenv(name)
+	}
+}
+
+func TestDefaultPathsWithoutHOME(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows paths do not come from HOME")
+	}
+	current, err := user.Current()
+	if err != nil || current.HomeDir == "" {
+		t.Skip("the current user has no passwd entry")
+	}
+	unsetenv(t, "HOME", "XDG_CACHE_HOME")
+
+	if home := homeDir(); home != current.HomeDir {
+		t.Errorf("homeDir = %q, want the passwd home %q", home, current.HomeDir)
+	}
+	cache := filepath.Join(current.HomeDir, ".cache")
+	if got := cacheDir(); got != cache {
+		t.Errorf("cacheDir = %q, want %q", got, cache)
+	}
+	if client := newGitHubClient(config.GitHub{}); client.dir != filepath.Join(cache, "dev-tools-installer", "github") {
+		t.Errorf("GitHub cache in %q, want it under %q", client.dir, cache)
+	}
+	i := newTestInstaller(t, "tool_list: []\n", newFakeRunner(t))
+	if vars := i.expansionContext("", "", ""); !slices.Contains(vars, ContextVar{Name: "home", Value: current.HomeDir, Source: VarDetected}) {
+		t.Errorf("expansion context = %+v, want ${home} from the passwd entry", vars)
+	}
+}
+
+func TestFillEnvironment(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Windows does not use HOME and USER")
+	}
+	current, err := user.Current()
+	if err != nil || current.HomeDir == "" || current.Username == "" {
+		t.Skip("the current user has no passwd entry")
+	}
+	unsetenv(t, "HOME", "USER")
+
+	want := []string{
+		"HOME is not set; using " + current.HomeDir + " from the passwd database",
+		"USER is not set; using " + current.Username + " from the passwd database",
+	}
+	if warnings := FillEnvironment(); !slices.Equal(warnings, want) {
+		t.Errorf("FillEnvironment = %q, want %q", warnings, want)
+	}
+	if os.Getenv("HOME") != current.HomeDir || os.Getenv("USER") != current.Username {
+		t.Errorf("HOME = %q and USER = %q, want the passwd values", os.Getenv("HOME"), os.Getenv("USER"))
+	}
+	if warnings := FillEnvironment(); warnings != nil {
+		t.Errorf("FillEnvironment = %q with both set, want nothing", warnings)
+	}
+}
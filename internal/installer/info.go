@@ -0,0 +1,303 @@
+package installer
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/colors"
+	"github.com/Abhaythakor/dev-tools-installer/internal/config"
+)
+
+// infoHistoryLimit is the number of recorded actions info shows
+const infoHistoryLimit = 5
+
+// ToolInfo is everything known about a tool, as info --json prints it
+type ToolInfo struct {
+	Name         string            `json:"name"`
+	Config       map[string]any    `json:"config"`  // Effective tool config, with methods in the order they are tried
+	Vars         map[string]string `json:"vars"`    // Installer variables the tool's methods see on this platform
+	Methods      []MethodInfo      `json:"methods"` // In the order they are tried
+	Dependencies []string          `json:"dependencies,omitempty"`
+	Dependents   []string          `json:"dependents,omitempty"` // Tools that depend on this one or require a command it provides
+	Installed    bool              `json:"installed"`
+	Version      string            `json:"version,omitempty"` // Detected version
+	Path         string            `json:"path,omitempty"`
+	Detect       string            `json:"detect,omitempty"` // What the detect block checks, for tools that are not a command
+	Pinned       string            `json:"pinned,omitempty"`
+	PinError     string            `json:"pin_error,omitempty"` // Why version_from failed
+	Drift        bool              `json:"drift"`
+	State        *ToolState        `json:"state,omitempty"` // What the state file recorded
+}
+
+// MethodInfo is a method of a tool as a run would try it
+type MethodInfo struct {
+	Name    string   `json:"name"`
+	Type    string   `json:"type,omitempty"`
+	RunAs   string   `json:"run_as,omitempty"`
+	Steps   []string `json:"steps"`             // Commands or downloads with variables resolved
+	Skipped string   `json:"skipped,omitempty"` // Why a run would skip the method
+}
+
+// ToolInfo collects the effective config of a tool with its variables resolved for this
+// platform, its methods, dependencies and dependents, its installed version and its state
+func (i *Installer) ToolInfo(tool string) (*ToolInfo, error) {
+	name, _ := config.ParseToolEntry(tool)
+	toolConfig := i.config.Tools[name]
+	if toolConfig == nil {
+		return nil, fmt.Errorf("tool %s is not configured", name)
+	}
+	info := &ToolInfo{Name: name, Dependencies: toolConfig.Dependencies}
+	if err := i.resolveVersion(name); err != nil {
+		info.PinError = err.Error()
+	}
+	bindir := i.toolBinDir(name)
+	info.Vars = i.commandVars(name, toolConfig.Version, bindir)
+
+	effective := i.effectiveConfig(name, toolConfig)
+	fields, err := config.Fields(effective)
+	if err != nil {
+		return nil, err
+	}
+	info.Config = fields
+	for _, method := range i.orderedMethods(toolConfig) {
+		m := MethodInfo{Name: method.Name, Type: method.Type, RunAs: i.methodUser(toolConfig, method)}
+		for _, step := range append(i.describeSteps(name, toolConfig, method, bindir), i.describeCleanup(name, toolConfig, method, bindir)...) {
+			m.Steps = append(m.Steps, i.redact(step))
+		}
+		if reason := i.rootSkipReason(method); reason != "" {
+			m.Skipped = reason
+		} else if missing, _ := i.methodRequirements(name, method); len(missing) > 0 {
+			m.Skipped = "requires " + strings.Join(missing, ", ")
+		}
+		info.Methods = append(info.Methods, m)
+	}
+	info.Dependents = i.dependents(name)
+
+	check := i.probeTool(name)
+	info.Installed, info.Version, info.Path, info.Pinned, info.Drift = check.installed, check.version, check.path, check.pinned, check.drift
+	info.Detect = check.detect
+	info.State = i.loadedState().Tools[name]
+	return info, nil
+}
+
+// effectiveConfig returns a copy of a tool's config as a run applies it: install_dir resolved,
+// methods in the order they are tried, and their variables and defaults filled in
+func (i *Installer) effectiveConfig(name string, toolConfig *config.ToolConfig) config.ToolConfig {
+	effective := *toolConfig
+	effective.InstallDir = i.toolBinDir(name)
+	effective.Methods = nil
+	for _, method := range i.orderedMethods(toolConfig) {
+		vars := keepCaptures(method, i.commandVars(name, toolConfig.Version, effective.InstallDir))
+		method.Commands, method.Cleanup = slices.Clone(method.Commands), slices.Clone(method.Cleanup)
+		for n, command := range method.Commands {
+			method.Commands[n].Run = expandShown(command.Run, vars)
+		}
+		for n, command := range method.Cleanup {
+			method.Cleanup[n] = expandShown(command, vars)
+		}
+		if method.Env != nil {
+			env := map[string]string{}
+			for k, v := range method.Env {
+				env[k] = i.redact(expandShown(v, vars))
+			}
+			method.Env = env
+		}
+		switch method.Type {
+		case config.MethodCargo, config.MethodPipx, config.MethodNpm:
+			method.Version = methodVersion(toolConfig, method)
+			method.Package = expandVersion(method.Package, method.Version)
+		case config.MethodMise, config.MethodAsdf:
+			method.Version = methodVersion(toolConfig, method)
+			method.Plugin = managerPlugin(name, method)
+		case config.MethodDownload, config.MethodGithubRelease:
+			method.URL, method.Asset = expandShown(method.URL, vars), expandShown(method.Asset, vars)
+			if method.Type == config.MethodGithubRelease && method.Tag == "" && toolConfig.Version != "" {
+				method.Tag = "v${version}"
+			}
+			method.Tag = expandShown(method.Tag, vars)
+			if method.Binary == "" {
+				method.Binary = name
+			}
+		}
+		if method.RunAs == "" {
+			method.RunAs = i.methodUser(toolConfig, method)
+		}
+		effective.Methods = append(effective.Methods, method)
+	}
+	return effective
+}
+
+// dependents returns the tools that depend on a tool, or whose methods require a command it
+// provides
+func (i *Installer) dependents(name string) []string {
+	provides := i.config.Tools[name].Commands(name)
+	needs := func(list []string) bool {
+		return slices.Contains(list, name) || slices.ContainsFunc(list, func(need string) bool { return slices.Contains(provides, need) })
+	}
+	var dependents []string
+	for _, other := range sortedKeys(i.config.Tools) {
+		toolConfig := i.config.Tools[other]
+		if other == name || toolConfig == nil {
+			continue
+		}
+		requires := needs(toolConfig.Dependencies)
+		for _, method := range toolConfig.Methods {
+			requires = requires || needs(method.Requires)
+		}
+		if requires {
+			dependents = append(dependents, other)
+		}
+	}
+	return dependents
+}
+
+// InfoJSON prints the info of a tool as indented JSON
+func (i *Installer) InfoJSON(tool string) error {
+	info, err := i.ToolInfo(tool)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(i.display())
+	enc.SetIndent("", "  ")
+	return enc.Encode(info)
+}
+
+// Info prints everything known about a tool: its metadata and effective methods, the tools
+// it depends on and that depend on it, the version installed on this machine, what the state
+// file recorded and its recent history
+func (i *Installer) Info(tool string) error {
+	info, err := i.ToolInfo(tool)
+	if err != nil {
+		return err
+	}
+	name, toolConfig := info.Name, i.config.Tools[info.Name]
+
+	fmt.Fprintf(i.display(), "\n%s╭─── %s ───╮%s\n", colors.Blue+colors.Bold, name, colors.Reset)
+	if toolConfig.Description != "" {
+		whyRow(i.display(), colors.Blue, "about", toolConfig.Description)
+	}
+	if toolConfig.Homepage != "" {
+		whyRow(i.display(), colors.Blue, "homepage", toolConfig.Homepage)
+	}
+	if toolConfig.Docs != "" {
+		whyRow(i.display(), colors.Blue, "docs", toolConfig.Docs)
+	}
+	if note := i.defaultNote(name); note != "" {
+		whyRow(i.display(), colors.Yellow, "config", note)
+	}
+	if info.Detect != "" {
+		whyRow(i.display(), colors.Blue, "detect", info.Detect)
+	} else {
+		whyRow(i.display(), colors.Blue, "provides", strings.Join(toolConfig.Commands(name), ", "))
+	}
+	if len(info.Dependencies) > 0 {
+		whyRow(i.display(), colors.Blue, "depends", strings.Join(info.Dependencies, ", "))
+	}
+	if len(info.Dependents) > 0 {
+		whyRow(i.display(), colors.Blue, "needed by", strings.Join(info.Dependents, ", "))
+	}
+	switch {
+	case info.PinError != "":
+		whyRow(i.display(), colors.Red, "pinned", fmt.Sprintf("%s (%s)", toolConfig.VersionFrom, info.PinError))
+	case toolConfig.VersionsFile != "":
+		whyRow(i.display(), colors.Blue, "pinned", fmt.Sprintf("%s (from %s)", toolConfig.Version, toolConfig.VersionsFile))
+	case toolConfig.VersionFrom != "":
+		whyRow(i.display(), colors.Blue, "pinned", fmt.Sprintf("%s (from %s)", toolConfig.Version, toolConfig.VersionFrom))
+	case toolConfig.Version != "":
+		whyRow(i.display(), colors.Blue, "pinned", toolConfig.Version)
+	}
+	if toolConfig.Disabled {
+		whyRow(i.display(), colors.Yellow, "disabled", "runs skip this tool")
+	}
+	whyRow(i.display(), colors.Blue, "platform", fmt.Sprintf("%s/%s, bindir %s", info.Vars["os"], info.Vars["arch"], info.Vars["bindir"]))
+	for n, method := range i.orderedMethods(toolConfig) {
+		label := ""
+		if n == 0 {
+			label = "methods"
+		}
+		m := info.Methods[n]
+		// Command methods list their commands as steps
+		summary := fmt.Sprintf("%d. %s", n+1, methodSummary(method))
+		if method.Type == "" {
+			summary = fmt.Sprintf("%d. %s", n+1, method.Name)
+		}
+		if m.RunAs != "" {
+			summary += " as " + m.RunAs
+		}
+		if m.Skipped != "" {
+			whyRow(i.display(), colors.Blue, label, fmt.Sprintf("%s %s(skipped: %s)%s", summary, colors.Yellow, m.Skipped, colors.Reset))
+			continue
+		}
+		whyRow(i.display(), colors.Blue, label, summary)
+		for _, step := range m.Steps {
+			whyRow(i.display(), colors.Blue, "", fmt.Sprintf("   %s%s%s", colors.Gray, step, colors.Reset))
+		}
+	}
+
+	switch {
+	case !info.Installed:
+		whyRow(i.display(), colors.Red, "installed", "no")
+	case info.Drift:
+		whyRow(i.display(), colors.Yellow, "installed", fmt.Sprintf("%s at %s, pinned %s", info.Version, info.Path, info.Pinned))
+	default:
+		whyRow(i.display(), colors.Green, "installed", fmt.Sprintf("%s at %s", orDash(info.Version), info.Path))
+	}
+
+	if ts := info.State; ts != nil {
+		if ts.Method != "" {
+			whyRow(i.display(), colors.Blue, "state", fmt.Sprintf("%s %s via %s on %s", orDash(ts.Version), orDefault(ts.Action, "installed"), ts.Method, ts.InstalledAt.Format("2006-01-02 15:04")))
+		}
+		if ts.Package != "" {
+			whyRow(i.display(), colors.Blue, "package", fmt.Sprintf("%s %s", ts.Package, ts.PackageVersion))
+		}
+		if ts.Managed {
+			whyRow(i.display(), colors.Blue, "managed", fmt.Sprintf("%s (sha256 %s)", ts.Path, shortHash(ts.SHA256)))
+		}
+		for _, version := range sortedKeys(ts.Versions) {
+			vs := ts.Versions[version]
+			line := fmt.Sprintf("%s installed via %s on %s", version, vs.Method, vs.InstalledAt.Format("2006-01-02 15:04"))
+			if version == ts.Active {
+				line += " (active)"
+			}
+			whyRow(i.display(), colors.Blue, "version", line)
+		}
+	}
+	fmt.Fprintf(i.display(), "%s╰───────╯%s\n\n", colors.Blue, colors.Reset)
+
+	return i.PrintHistory(name, infoHistoryLimit)
+}
+
+// methodSummary describes a method in one line
+func methodSummary(method config.InstallMethod) string {
+	switch {
+	case method.Type == config.MethodGithubRelease:
+		return fmt.Sprintf("%s (github_release %s)", method.Name, method.Repo)
+	case method.Type == config.MethodDownload:
+		return fmt.Sprintf("%s (download %s)", method.Name, method.URL)
+	case method.Type == config.MethodScript && method.File != "":
+		return fmt.Sprintf("%s (script %s)", method.Name, method.File)
+	case method.Type == config.MethodScript && method.URL != "":
+		return fmt.Sprintf("%s (script %s)", method.Name, method.URL)
+	case method.Type == config.MethodScript:
+		return fmt.Sprintf("%s (inline script)", method.Name)
+	case method.Type != "":
+		return fmt.Sprintf("%s (%s %s)", method.Name, method.Type, method.Package)
+	case len(method.Commands) > 0:
+		return fmt.Sprintf("%s: %s", method.Name, strings.Join(method.Runs(), " && "))
+	}
+	return method.Name
+}
+
+// failureHint returns where to read up on a tool that failed to install, or nothing
+func (i *Installer) failureHint(name string) string {
+	toolConfig := i.config.Tools[name]
+	if toolConfig == nil {
+		return ""
+	}
+	if toolConfig.Homepage != "" {
+		return toolConfig.Homepage
+	}
+	return toolConfig.Docs
+}
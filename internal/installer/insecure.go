@@ -0,0 +1,87 @@
+package installer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/config"
+)
+
+// ErrInsecureConfig is returned when a run would use root with commands from files other
+// users can change
+var ErrInsecureConfig = errors.New("insecure config")
+
+// checkInsecureConfig refuses an install whose plan uses root while other users can change
+// the config or its scripts, unless Options.InsecureConfig is set
+func (i *Installer) checkInsecureConfig() error {
+	if i.Options.InsecureConfig {
+		return nil
+	}
+	problems := i.config.InsecureFiles()
+	if len(problems) == 0 {
+		return nil
+	}
+	privileged := i.privilegedReason(i.BuildPlan())
+	if privileged == "" {
+		return nil
+	}
+	return fmt.Errorf("%w: %s, and %s; fix the permissions, or pass --insecure-config to run it anyway", ErrInsecureConfig, strings.Join(problems, "; "), privileged)
+}
+
+// privilegedReason returns how a plan would run commands as root, or "" when it would not
+func (i *Installer) privilegedReason(plan Plan) string {
+	if os.Geteuid() == 0 {
+		return "the installer runs as root"
+	}
+	for _, item := range plan {
+		if item.Action == actionSkip {
+			continue
+		}
+		if len(item.SystemPackages) > 0 {
+			return fmt.Sprintf("%s installs system packages through sudo", item.Entry)
+		}
+		name := i.installName(item.status.Name)
+		toolConfig := i.config.Tools[name]
+		if toolConfig == nil {
+			continue
+		}
+		for _, method := range i.orderedMethods(toolConfig) {
+			if user := i.methodUser(toolConfig, method); user != "" {
+				return fmt.Sprintf("method %s of %s runs as %s through sudo", method.Name, name, user)
+			}
+			if method.AptRepo != nil {
+				return fmt.Sprintf("method %s of %s adds an apt repository through sudo", method.Name, name)
+			}
+			if usesSudo(i.config, method) {
+				return fmt.Sprintf("method %s of %s uses sudo", method.Name, name)
+			}
+		}
+	}
+	return ""
+}
+
+// usesSudo reports whether a method's commands or script run sudo
+func usesSudo(cfg *config.InstallerConfig, method config.InstallMethod) bool {
+	var text []string
+	switch method.Type {
+	case "":
+		text = method.Runs()
+	case config.MethodScript:
+		content := method.Content
+		if method.File != "" {
+			data, _ := os.ReadFile(cfg.ScriptPath(method))
+			content = string(data)
+		}
+		text = []string{content}
+	}
+	text = append(text, method.Cleanup...)
+	for _, t := range text {
+		if slices.Contains(strings.Fields(t), "sudo") {
+			return true
+		}
+	}
+	return false
+}
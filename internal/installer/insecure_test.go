@@ -0,0 +1,51 @@
+package installer
+
+import (
+	"errors"
+	"os"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestCheckInsecureConfig(t *testing.T) {
+	const yaml = `
+tool_list: [evtool]
+tools:
+  evtool:
+    methods:
+      - name: fake
+        commands: ["install evtool"]
+`
+	for _, tc := range []struct {
+		name     string
+		mode     os.FileMode
+		insecure bool // --insecure-config
+		want     string
+	}{
+		{name: "private config", mode: 0644},
+		{name: "world-writable config", mode: 0666, want: "is writable by every user, and the installer runs as root"},
+		{name: "world-writable config with --insecure-config", mode: 0666, insecure: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if runtime.GOOS == "windows" || os.Geteuid() != 0 {
+				t.Skip("the check applies to runs as root on Unix")
+			}
+			i := newTestInstaller(t, yaml, newFakeRunner(t))
+			if err := os.Chmod(i.config.Path, tc.mode); err != nil {
+				t.Fatal(err)
+			}
+			i.Options.InsecureConfig = tc.insecure
+			err := i.checkInsecureConfig()
+			if tc.want == "" {
+				if err != nil {
+					t.Errorf("checkInsecureConfig = %v, want nil", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInsecureConfig) || !strings.Contains(err.Error(), i.config.Path+" "+tc.want) {
+				t.Errorf("checkInsecureConfig = %v, want an ErrInsecureConfig saying %q", err, tc.want)
+			}
+		})
+	}
+}
@@ -1,44 +1,55 @@
 package installer
 
 import (
-	"bufio"
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
+	"maps"
 	"os"
-	"os/exec"
+	"os/signal"
+	"os/user"
 	"path/filepath"
-	"regexp"
+	"slices"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
+	"github.com/Abhaythakor/dev-tools-installer/internal/colors"
 	"github.com/Abhaythakor/dev-tools-installer/internal/config"
+	"github.com/Abhaythakor/dev-tools-installer/internal/version"
 )
 
-// Color codes for terminal output
-const (
-	colorReset  = "\033[0m"
-	colorRed    = "\033[31m"
-	colorGreen  = "\033[32m"
-	colorYellow = "\033[33m"
-	colorBlue   = "\033[34m"
-	colorGray   = "\033[37m"
-	clearLine   = "\033[K"
-)
+// clearLine erases the rest of the terminal line
+const clearLine = "\033[K"
+
+// killGrace is how long a process tree gets to exit after SIGTERM before it is killed
+const killGrace = 5 * time.Second
 
 var spinnerChars = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
 
 // Progress represents a progress indicator
 type Progress struct {
+	out     *terminalState
 	message string
 	stop    chan bool
 	stopped bool
+	release func() // Shows the cursor again
 	mu      sync.Mutex
 }
 
-// NewProgress creates a new progress indicator
+// NewProgress creates a new progress indicator on stdout
 func NewProgress(message string) *Progress {
+	return newProgress(stdoutTerminal, message)
+}
+
+// newProgress creates a progress indicator drawn to out
+func newProgress(out *terminalState, message string) *Progress {
 	return &Progress{
+		out:     out,
 		message: message,
 		stop:    make(chan bool),
 		stopped: false,
@@ -47,7 +58,12 @@ func NewProgress(message string) *Progress {
 
 // Start starts the progress indicator
 func (p *Progress) Start() {
+	p.release = p.out.acquire(func() {
+		p.Stop()
+		clearProgressLine(p.out)
+	})
 	go func() {
+		defer recoverDrawing("spinner")
 		i := 0
 		for {
 			p.mu.Lock()
@@ -55,20 +71,21 @@ func (p *Progress) Start() {
 				p.mu.Unlock()
 				return
 			}
+			message := p.message
 			p.mu.Unlock()
 
 			select {
 			case <-p.stop:
 				// Clear the line before returning
-				fmt.Printf("\r%s", strings.Repeat(" ", 80))
-				fmt.Printf("\r")
+				clearProgressLine(p.out)
 				return
 			default:
-				fmt.Printf("\r%s│ %s%s %s",
-					colorBlue,
-					colorYellow,
+				fmt.Fprintf(p.out, "\r%s│ %s%s %s%s",
+					colors.Blue,
+					colors.Yellow,
 					spinnerChars[i%len(spinnerChars)],
-					p.message)
+					message,
+					clearLine)
 				i++
 				time.Sleep(80 * time.Millisecond)
 			}
@@ -76,78 +93,898 @@ func (p *Progress) Start() {
 	}()
 }
 
+// UpdateMessage replaces the message shown next to the spinner
+func (p *Progress) UpdateMessage(message string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.message = message
+}
+
 // Stop stops the progress indicator
 func (p *Progress) Stop() {
 	p.mu.Lock()
+	if p.stopped {
+		p.mu.Unlock()
+		return
+	}
 	p.stopped = true
 	p.mu.Unlock()
 	close(p.stop)
+	if p.release != nil {
+		p.release()
+	}
 }
 
 // Installer manages tool installation
 type Installer struct {
-	config *config.InstallerConfig
+	config          *config.InstallerConfig
+	state           *State
+	report          []ToolReport
+	renderer        *Renderer      // Set while tools install in parallel
+	quiet           io.Writer      // Receives the failures and summary of quiet runs
+	progress        *lineProgress  // Renders the run as one status line, see ProgressLine
+	out             *terminalState // Receives what the installer prints, stdout unless WithOutput or WithTerminal
+	diag            *terminalState // Receives diagnostics when out only gets results, stderr unless WithDiagnostics
+	term            *terminalState // Where the current run draws its usual output: out, diag or nowhere
+	logger          *slog.Logger   // Receives debug records instead of the debug logger, see WithLogger
+	ctx             context.Context
+	secrets         secretStore
+	offline         map[string]error           // Hosts the connectivity preflight could not reach
+	tempDir         string                     // Per-run temp directory, set while installing
+	slots           chan struct{}              // Download slots, see downloads.concurrency
+	limiter         *rateLimiter               // Shared bandwidth limit, nil when unlimited
+	github          *githubClient              // Shared by GitHub API lookups
+	tracer          *tracer                    // Trace of the current run, nil when tracing is off
+	runner          CommandRunner              // Recording or replaying runner of the current run
+	requiring       map[string]bool            // Tools being installed for a method's requires list
+	outputs         map[string]string          // Sanitized output of each tool's last failed command
+	captures        map[string]*captureSink    // Output sink of each tool's running capture command
+	batched         map[string]batchedInstall  // Tools a package batch installed, not recorded yet
+	pulled          map[string][]string        // System packages installed for each tool, as manager:package
+	repos           map[string]error           // Taps and apt repositories set up this run, with the error when that failed
+	taps            map[string]bool            // Homebrew taps present, once listed
+	aptStale        string                     // Why apt-get update has to run before apt installs, empty once it ran
+	repoMu          sync.Mutex                 // Serializes setting up taps and apt repositories
+	pathOverlay     []string                   // Directories of commands installed this run, searched before PATH
+	pathMu          sync.Mutex                 // Guards pathOverlay, which is read while mu is held
+	downloaded      map[string]int64           // Bytes each tool downloaded this run
+	downloadedTotal int64                      // Bytes all tools downloaded this run
+	attempts        map[string]ToolReport      // Outcome of each tool installed this run, shared with its aliases
+	versions        map[string]error           // Tools whose version_from ran this run, with its error
+	exitCodes       map[string]int             // Exit code of each tool's last command
+	timeouts        map[string]*toolTimeout    // install_timeout of each tool whose methods are running
+	signatures      map[string]SignatureReport // Verified signature of each tool's downloaded artifact
+	hints           map[string][]string        // Hints matching the failed methods of each tool
+	users           map[string]runAs           // User the current method of each tool runs as, when not the installer's
+	sudo            *user.User                 // User who ran the installer through sudo, nil otherwise
+	pending         map[string]bool            // Entries not processed yet, for the budget warning
+	budgetStart     time.Time                  // When the time budget started
+	source          ConfigSource               // Config the current run uses, with its git revision
+	mu              sync.Mutex                 // Guards state while tools install in parallel
+	Options         Options
+}
+
+// Options controls optional installer behavior
+type Options struct {
+	Fix             bool              // Reinstall pinned tools whose installed version drifted from the pin
+	ReportPath      string            // Write a JSON report of the run to this file
+	Integrity       bool              // Fail verification when a binary changed since the installer placed it
+	DryRun          bool              // Print the plan instead of installing
+	Only            []string          // Limit the run to these tool_list entries or tool names
+	Selected        []string          // Limit the run to exactly these tool_list entries, as a group selection resolved to
+	Concurrency     int               // Number of tools installed at once
+	Verbosity       Verbosity         // How much the run prints; verbose runs print each command of a method as it runs
+	Events          Events            // Receives progress events, for programs embedding the installer
+	Force           bool              // Install even when preflight checks fail, and install present tools again
+	SkipPreflight   bool              // Skip the connectivity check before installing
+	KeepTemp        bool              // Keep the per-run temp directory for debugging
+	Prefer          []string          // Method names or types tried first, ahead of preferred_methods
+	Channel         string            // Channel whose methods are tried, overriding the default_channel of tools
+	IncludeDisabled bool              // Process tools marked disabled like any other
+	Record          string            // Record the commands of the run into this file
+	Replay          string            // Serve commands from this recording instead of running them
+	NoCache         bool              // Query the GitHub API without using cached responses
+	Budget          time.Duration     // Stop starting installs once the run has taken this long
+	BudgetHard      bool              // Also cancel in-flight installs when the budget runs out
+	PathSnippet     string            // Write the PATH export line for installed tools to this file
+	Root            string            // Install into the system mounted at this directory instead of this one
+	WaitLock        bool              // Wait for another run holding the state directory lock instead of failing
+	ShowScripts     bool              // Print the content of script methods in dry runs
+	Porcelain       bool              // Print one tab-separated line per tool instead of the check table
+	PrintFailed     bool              // Print the entries that failed on stdout, one per line, and everything else on stderr
+	NoBatch         bool              // Run the package manager command of each tool instead of one for several tools
+	Progress        string            // How an install shows its progress: ProgressBox, the default, or ProgressLine
+	InsecureConfig  bool              // Install with root although other users can change the config or its scripts
+	RetryFailed     bool              // Limit the run to entries that failed at their last install and entries not installed before
+	MaxDownloadSize int64             // Total bytes the run may download, overriding downloads.max_size
+	Vars            map[string]string // Installer variables overriding detected ones and the config's vars, as set with --var
+	Reinstall       []string          // With Force, the entries or tools installed again; every entry when empty
+	UninstallFirst  bool              // Run the uninstall_commands of tools before installing them again
+	Runner          CommandRunner     // Runs commands; defaults to running them on this machine
 }
 
-// New creates a new Installer instance
-func New(config *config.InstallerConfig) *Installer {
-	return &Installer{
-		config: config,
+// New creates an Installer for cfg, applying opts in order. Without options a run prints to
+// stdout, runs commands on this machine, installs one tool at a time and logs to the debug
+// logger enabled with --debug or INSTALLER_DEBUG. New panics when an option is invalid, such
+// as a concurrency below one; Apply returns the error instead.
+func New(cfg *config.InstallerConfig, opts ...Option) *Installer {
+	i := &Installer{}
+	i.configure(cfg)
+	if err := i.Apply(opts...); err != nil {
+		panic(err)
 	}
+	// The default methods look for the package manager through the runner and root options
+	i.applyDefaultTools()
+	return i
 }
 
+// configure sets the config and everything derived from it, dropping the loaded state and
+// resolved secrets
+func (i *Installer) configure(cfg *config.InstallerConfig) {
+	slots := cfg.Downloads.Concurrency
+	if slots == 0 {
+		slots = defaultDownloadSlots
+	}
+	rate, _ := config.ParseRate(cfg.Downloads.Bandwidth)
+	i.config = cfg
+	i.state = nil
+	i.secrets.values = nil
+	i.slots = make(chan struct{}, slots)
+	i.limiter = newRateLimiter(rate)
+	i.github = newGitHubClient(cfg.GitHub)
+	i.sudo = sudoInvoker()
+}
+
+// Errors identifying how a run failed, wrapped with details; see also ErrBudgetExhausted
+var (
+	ErrInstallFailed = errors.New("installation failed") // A tool could not be installed
+	ErrVerifyFailed  = errors.New("verification failed") // Verify found missing or modified tools
+	ErrDrift         = errors.New("version drift")       // Verify found only tools whose version differs from the pin
+	ErrInterrupted   = errors.New("interrupted")
+)
+
 // Run checks and installs tools as needed
 func (i *Installer) Run() error {
-	fmt.Printf("\n%s╭─── System Tools Check ───╮%s\n", colorBlue+"\033[1m", colorReset)
+	return i.run(true)
+}
 
-	installed := 0
-	for _, name := range i.config.ToolList {
-		if i.checkTool(name) {
-			installed++
-		} else {
-			if err := i.installTool(name); err != nil {
-				fmt.Printf("%s│%s Failed to install %s: %v%s\n", colorBlue, colorRed, name, err, colorReset)
-				continue
+// Verify checks tools without installing anything and fails when any are missing or drifted
+func (i *Installer) Verify() error {
+	return i.run(false)
+}
+
+// Install executes the install and upgrade actions of a plan from BuildPlan and reports the
+// outcome of every item, reporting skipped items as they were checked. Unlike Run it prints
+// no check table and records no history or report file, but it saves the state.
+func (i *Installer) Install(ctx context.Context, plan Plan) (results []ToolReport, err error) {
+	if len(plan) == 0 {
+		return nil, nil
+	}
+	unlock, err := i.lockRun()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	if err := i.startInstall(plan); err != nil {
+		return nil, err
+	}
+	defer func() {
+		err = withFinishError(err, i.finishInstall())
+	}()
+
+	var stopBudget func()
+	i.ctx, stopBudget = i.startBudget(ctx, plan.entries())
+	defer stopBudget()
+	results = i.execute(plan)
+
+	if !i.replaying() {
+		if err := i.saveState(); err != nil {
+			return results, err
+		}
+	}
+	if ctx.Err() != nil {
+		return results, ErrInterrupted
+	}
+	return results, i.budgetError(results)
+}
+
+// startInstall prepares the runner, preflight checks and temp directory of an install,
+// building the plan the preflight checks look at when it is nil
+func (i *Installer) startInstall(plan Plan) error {
+	i.attempts = nil
+	i.downloaded, i.downloadedTotal = nil, 0
+	if err := i.startRunner(); err != nil {
+		return err
+	}
+	// Preflight checks would make recordings depend on the machine they replay on
+	if i.runner == nil {
+		if err := i.preflight(plan); err != nil {
+			i.finishRunner()
+			return err
+		}
+	}
+	if err := i.createTempDir(); err != nil {
+		i.finishRunner()
+		return err
+	}
+	return nil
+}
+
+// finishInstall cleans up after startInstall
+func (i *Installer) finishInstall() error {
+	i.removeTempDir()
+	return i.finishRunner()
+}
+
+// withFinishError adds the error of finishing a run to the run's error. The failures of a
+// replay that ran unrecorded commands come from the stale recording, so that error is kept
+// with theirs.
+func withFinishError(err, finishErr error) error {
+	switch {
+	case finishErr == nil:
+		return err
+	case err == nil:
+		return finishErr
+	}
+	return fmt.Errorf("%w; %v", err, finishErr)
+}
+
+// run checks every tool_list entry, installing missing ones when install is set
+func (i *Installer) run(install bool) (err error) {
+	defer func(term *terminalState) { i.term = term }(i.term)
+	i.term = i.stdout()
+	// The results are the only thing left on the output; the check table and progress go to
+	// the diagnostics, drawn as for a terminal when they go to one
+	var failedOut io.Writer
+	if i.Options.PrintFailed {
+		failedOut, i.term = i.term, i.stderr()
+	}
+	if install && i.Options.RetryFailed && len(i.selectedEntries()) == 0 {
+		fmt.Fprintf(i.display(), "%s✓ Nothing to retry: no tool failed at its last install%s\n", colors.Green, colors.Reset)
+		return nil
+	}
+	if install && i.Options.DryRun {
+		i.printPlan(i.BuildPlan())
+		return nil
+	}
+	if install {
+		if err := i.checkInsecureConfig(); err != nil {
+			return err
+		}
+	}
+
+	unlock, err := i.lockRun()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	defer i.restoreOwnership()
+	i.versions = nil
+
+	// Porcelain output replaces everything else the run prints, as do the failures and
+	// summary of quiet runs and the status line of line progress
+	var porcelain io.Writer
+	line := install && i.Options.Progress == ProgressLine && !i.Options.Porcelain && i.Options.Verbosity >= VerbosityNormal
+	if i.Options.Porcelain || i.Options.Verbosity < VerbosityNormal || line {
+		out := i.term
+		i.term = discardTerminal
+		switch {
+		case i.Options.Porcelain:
+			porcelain = out
+		case i.Options.Verbosity == VerbosityQuiet:
+			i.quiet = out
+			defer func() { i.quiet = nil }()
+		case line:
+			i.progress = newLineProgress(out)
+			i.progress.Start()
+			defer func() {
+				i.progress.Close()
+				i.progress = nil
+			}()
+		}
+	}
+
+	finish := i.finishRunner
+	if install {
+		err = i.startInstall(nil)
+		finish = i.finishInstall
+	} else {
+		err = i.startRunner()
+	}
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = withFinishError(err, finish())
+	}()
+
+	// Interrupts stop running commands and skip the remaining installs
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	i.ctx = ctx
+
+	command := "verify"
+	if install {
+		command = "install"
+	}
+	i.startTrace(command)
+	i.source = i.configSource()
+	defer func() { i.finishTrace(err) }()
+
+	fmt.Fprintf(i.display(), "\n%s╭─── System Tools Check ───╮%s\n", colors.Blue+colors.Bold, colors.Reset)
+	if i.Options.Root != "" {
+		fmt.Fprintf(i.display(), "%s│ %starget root %s%s\n", colors.Blue, colors.Gray, i.Options.Root, colors.Reset)
+	}
+
+	for _, entry := range i.disabledEntries() {
+		fmt.Fprintf(i.display(), "%s│ %s- %-9s │ disabled%s\n", colors.Blue, colors.Gray, entry, colors.Reset)
+	}
+
+	entries := i.selectedEntries()
+	if install {
+		i.warnSudo(entries)
+	} else {
+		i.warnConfigChanged()
+	}
+	i.emit(Event{Type: EventRunStarted, Total: len(entries), ConfigSource: i.source})
+	binaries := map[string]string{}
+	var results []ToolReport
+	if install {
+		var stopBudget func()
+		i.ctx, stopBudget = i.startBudget(ctx, entries)
+		defer stopBudget()
+	}
+	if install && (i.Options.Concurrency > 1 || i.batchesLikely(entries) || i.repositoriesLikely(entries)) {
+		// Every entry is checked before the first install starts, so that installs can run in
+		// parallel or in package batches, and apt repositories are added together
+		results = i.execute(i.checkPlan(entries, binaries))
+	} else {
+		for _, entry := range entries {
+			plan := i.checkPlan([]string{entry}, binaries)
+			if install {
+				results = append(results, i.execute(plan)...)
+			} else {
+				result := plan[0].status.report()
+				switch {
+				case result.Status == statusMissing:
+					i.quietf("%s✗ %s: not installed%s\n", colors.Red, entry, colors.Reset)
+				case result.Drift:
+					i.quietf("%s✗ %s: %s installed, pinned %s%s\n", colors.Red, entry, result.Version, result.Pinned, colors.Reset)
+				}
+				results = append(results, result)
 			}
+		}
+	}
+
+	installed, drifted, tampered, deferred, skipped, reinstalled, timedOut := 0, 0, 0, 0, 0, 0, 0
+	channels := map[string]int{}
+	var downloaded int64
+	unknownDownloads := 0
+	for _, result := range results {
+		downloaded += result.DownloadedBytes
+		if result.DownloadUnknown {
+			unknownDownloads++
+		}
+		if result.Status == statusReinstalled {
+			reinstalled++
+		}
+		if result.TimedOut {
+			timedOut++
+		}
+		switch result.Status {
+		case statusDeferred:
+			deferred++
+		case statusSkipped:
+			skipped++
+		case statusMissing, statusFailed:
+		default:
 			installed++
 		}
+		if result.Drift {
+			drifted++
+		}
+		if result.IntegrityChanged {
+			tampered++
+		}
+		// Tools on stable are the norm, so only the others are counted in the summary
+		if result.Channel != "" && result.Channel != config.ChannelStable {
+			channels[result.Channel]++
+		}
+		i.report = append(i.report, result)
+	}
+	if porcelain != nil {
+		i.writePorcelain(porcelain, results)
+	}
+	if failedOut != nil {
+		i.writeFailed(failedOut, results, install)
+	}
+	i.tracer.setRoot("installer.tools", len(entries))
+	i.tracer.setRoot("installer.tools.installed", installed)
+
+	summary := fmt.Sprintf("%d/%d tools installed", installed, len(entries))
+	if reinstalled > 0 {
+		summary += fmt.Sprintf(", %d reinstalled", reinstalled)
+	}
+	if drifted > 0 {
+		summary += fmt.Sprintf(", %s%d drifted", colors.Yellow, drifted)
+	}
+	for _, channel := range slices.Sorted(maps.Keys(channels)) {
+		summary += fmt.Sprintf(", %d on %s", channels[channel], channel)
+	}
+	if deferred > 0 {
+		summary += fmt.Sprintf(", %s%d deferred", colors.Yellow, deferred)
+	}
+	if skipped > 0 {
+		summary += fmt.Sprintf(", %s%d skipped (dependency failed)", colors.Yellow, skipped)
+	}
+	if timedOut > 0 {
+		summary += fmt.Sprintf(", %s%d timed out", colors.Red, timedOut)
+	}
+	if downloaded > 0 {
+		summary += fmt.Sprintf(", %sdownloaded %s total", colors.Green, formatBytes(downloaded))
+		if unknownDownloads > 0 {
+			summary += fmt.Sprintf(" (%s unknown)", toolCount(unknownDownloads))
+		}
+	}
+	fmt.Fprintf(i.display(), "%s╰─── %s%s %s───╯%s\n\n",
+		colors.Blue,
+		colors.Green,
+		summary,
+		colors.Blue,
+		colors.Reset)
+	i.quietf("%s%s%s\n", colors.Green, summary, colors.Reset)
+	i.emit(Event{Type: EventRunFinished, Summary: sanitizeLine(summary)})
+	// PATH advice is about this machine's shells, not those of a target root
+	if install && !i.replaying() && i.Options.Root == "" {
+		if err := i.printPathAdvice(results); err != nil {
+			return err
+		}
+	}
+
+	// Replays must not change the state of this machine
+	if !i.replaying() {
+		i.recordCheck(command, results)
+		if install {
+			i.recordFailures(results)
+		}
+		if err := i.saveState(); err != nil {
+			return err
+		}
+	}
+	if err := i.writeReport(); err != nil {
+		return err
+	}
+	if !i.replaying() {
+		if err := i.appendHistory(command); err != nil {
+			fmt.Fprintf(i.display(), "%s⚠ Failed to record run history: %v%s\n", colors.Yellow, err, colors.Reset)
+		}
+	}
+
+	if ctx.Err() != nil {
+		return ErrInterrupted
+	}
+	if err := i.budgetError(results); err != nil {
+		return err
+	}
+	if install {
+		// Tools skipped because a dependency failed are not failures of their own
+		switch failed := len(entries) - installed - deferred - skipped; {
+		case failed > 0 && skipped > 0:
+			return fmt.Errorf("%w: %d of %d tools failed, %d skipped because a dependency failed", ErrInstallFailed, failed, len(entries), skipped)
+		case failed > 0:
+			return fmt.Errorf("%w: %d of %d tools failed", ErrInstallFailed, failed, len(entries))
+		case skipped > 0:
+			return fmt.Errorf("%w: %d of %d tools skipped because a dependency failed", ErrInstallFailed, skipped, len(entries))
+		}
+		return nil
+	}
+	if missing := len(entries) - installed; missing > 0 {
+		return fmt.Errorf("%w: %d missing, %d drifted", ErrVerifyFailed, missing, drifted)
 	}
+	if i.Options.Integrity && tampered > 0 {
+		return fmt.Errorf("%w: %d binaries changed since they were installed", ErrVerifyFailed, tampered)
+	}
+	if drifted > 0 {
+		return fmt.Errorf("%w: %d tools differ from their pins", ErrDrift, drifted)
+	}
+	return nil
+}
 
-	fmt.Printf("%s╰─── %s%d/%d tools installed %s───╯%s\n\n",
-		colorBlue,
-		colorGreen,
-		installed,
-		len(i.config.ToolList),
-		colorBlue,
-		colorReset)
+// context returns the context of the current run
+func (i *Installer) context() context.Context {
+	if i.ctx == nil {
+		return context.Background()
+	}
+	return i.ctx
+}
+
+// selectedEntries returns the tool_list entries the run covers
+func (i *Installer) selectedEntries() []string {
+	entries, _ := i.partitionEntries()
+	return entries
+}
+
+// disabledEntries returns the tool_list entries the run skips because their tool is disabled
+func (i *Installer) disabledEntries() []string {
+	_, disabled := i.partitionEntries()
+	return disabled
+}
+
+// partitionEntries splits the tool_list entries matching Options.Only, Options.Selected and
+// Options.RetryFailed into the ones a run covers and the disabled ones, which Options.IncludeDisabled covers as well
+func (i *Installer) partitionEntries() (entries, disabled []string) {
+	only := map[string]bool{}
+	for _, name := range i.Options.Only {
+		only[name] = true
+	}
+	for _, entry := range i.config.ToolList {
+		name, _ := config.ParseToolEntry(entry)
+		switch {
+		case len(only) > 0 && !only[entry] && !only[name]:
+		case len(i.Options.Selected) > 0 && !slices.Contains(i.Options.Selected, entry):
+		case i.Options.RetryFailed && !i.retryEntry(entry):
+		case i.isDisabled(name) && !i.Options.IncludeDisabled:
+			disabled = append(disabled, entry)
+		default:
+			entries = append(entries, entry)
+		}
+	}
+	return entries, disabled
+}
+
+// CheckEntries fails unless each name is a tool_list entry or the tool of one
+func (i *Installer) CheckEntries(names []string) error {
+	for _, name := range names {
+		found := false
+		for _, entry := range i.config.ToolList {
+			tool, _ := config.ParseToolEntry(entry)
+			found = found || entry == name || tool == name
+		}
+		if !found {
+			return fmt.Errorf("%s is not in tool_list", name)
+		}
+	}
+	return nil
+}
 
+// AddEntries adds entries such as tool@version that are not in tool_list to it for this run,
+// for one-off installs of configured tools. Each must name a tool under tools or in tool_list.
+func (i *Installer) AddEntries(entries []string) error {
+	for _, entry := range entries {
+		name, _ := config.ParseToolEntry(entry)
+		listed := slices.ContainsFunc(i.config.ToolList, func(e string) bool {
+			tool, _ := config.ParseToolEntry(e)
+			return tool == name
+		})
+		if i.config.Tools[name] == nil && !listed {
+			return fmt.Errorf("%s is not configured under tools or in tool_list", name)
+		}
+		if !slices.Contains(i.config.ToolList, entry) && (entry != name || !listed) {
+			i.config.ToolList = append(i.config.ToolList, entry)
+		}
+	}
 	return nil
 }
 
-// checkTool checks if a tool is installed and returns true if installed
-func (i *Installer) checkTool(name string) bool {
-	_, err := exec.LookPath(name)
+// Select limits the run to the tool_list entries a selection of tool names, @group references
+// and !exclusions resolves to, adding the configured tools it names that are not in tool_list
+// as AddEntries does
+func (i *Installer) Select(patterns []string) (config.Selection, error) {
+	sel, err := i.config.ResolveSelection(patterns)
 	if err != nil {
-		fmt.Printf("%s│ %s✗ %-9s%s │ Not installed\n", colorBlue, colorRed, name, colorReset)
-		return false
+		return config.Selection{}, err
+	}
+	if len(sel.Entries) == 0 {
+		return sel, fmt.Errorf("%s selects no tools", strings.Join(patterns, " "))
+	}
+	if err := i.AddEntries(sel.Entries); err != nil {
+		return sel, err
+	}
+	i.Options.Selected = sel.Entries
+	return sel, nil
+}
+
+// isDisabled reports whether a tool is marked disabled in the config
+func (i *Installer) isDisabled(name string) bool {
+	toolConfig := i.config.Tools[name]
+	return toolConfig != nil && toolConfig.Disabled
+}
+
+// checkPlan checks tool_list entries, printing a row for each, and plans their actions.
+// binaries maps the resolved binaries of the tools checked so far to their names.
+func (i *Installer) checkPlan(entries []string, binaries map[string]string) Plan {
+	var plan Plan
+	for _, entry := range entries {
+		status := i.entryStatus(entry)
+		i.printStatus(status)
+		i.noteSameBinary(status, binaries)
+		plan = append(plan, i.planStatus(status))
+	}
+	return plan
+}
+
+// execute carries out the install and upgrade actions of a plan, one after another or
+// with up to Options.Concurrency workers
+func (i *Installer) execute(plan Plan) []ToolReport {
+	results := make([]ToolReport, len(plan))
+	for n, item := range plan {
+		results[n] = item.report()
+		// Entries needing nothing are finished right away, so that progress counts every entry
+		if item.Action == actionSkip {
+			i.emit(Event{Type: EventToolFinished, Tool: item.Entry, Status: results[n].Status})
+		}
+	}
+	i.setupRepositories(plan)
+	i.runBatches(plan)
+	if i.Options.Concurrency > 1 {
+		i.runParallel(plan, results)
+		return results
+	}
+	for n, item := range plan {
+		if item.Action != actionSkip {
+			results[n] = i.executeItem(item, results[n])
+		}
+		i.finishPending(item.Entry)
+	}
+	return results
+}
+
+// executeItem installs or upgrades the entry of a plan item, unless the budget ran out
+func (i *Installer) executeItem(item PlanItem, result ToolReport) ToolReport {
+	if i.budgetExhausted() {
+		return i.deferEntry(item.Entry, result)
+	}
+	if prev, ok := i.attempt(item); ok {
+		return i.sameAsResult(item, result, prev)
+	}
+	result = i.installEntry(item.Entry, result, item.Action)
+	i.recordAttempt(item, result)
+	return result
+}
+
+// installEntry carries out the action the plan chose for a tool_list entry: installs it when
+// missing, upgrades it when drifted or installs it again with --force
+func (i *Installer) installEntry(entry string, result ToolReport, action string) ToolReport {
+	name, _ := config.ParseToolEntry(entry)
+	i.emit(Event{Type: EventToolStarted, Tool: entry})
+	span := i.tracer.start(name, "tool "+entry, map[string]interface{}{"installer.tool": entry})
+	result = i.installSafely(entry, result, action)
+	if result.Status == statusFailed && i.budgetCancelled() {
+		result.Status, result.Error = statusDeferred, "cancelled: time budget exhausted"
+	}
+	result.Error = i.redact(result.Error)
+	result.ExitCode = i.takeExitCode(i.installName(name))
+	result.SystemPackages = i.takeSystemPackages(i.installName(name))
+	if result.DownloadedBytes = i.takeDownloaded(i.installName(name)); result.DownloadedBytes == 0 {
+		switch result.Status {
+		case statusInstalled, statusUpgraded, statusReinstalled:
+			result.DownloadUnknown = i.unknownDownload(name, result.Method)
+		}
+	}
+	if signature := i.takeSignature(i.installName(name)); result.Status != statusFailed {
+		result.Signature = signature
+	}
+	if hints := i.takeHints(i.installName(name)); result.Status == statusFailed {
+		result.Hints = hints
+	}
+	if result.Status == statusFailed {
+		i.quietf("%s✗ %s: %s%s\n", colors.Red, entry, result.Error, colors.Reset)
+		for _, hint := range result.Hints {
+			i.quietf("%s  💡 %s%s\n", colors.Yellow, hint, colors.Reset)
+		}
+	}
+	if output := i.takeOutput(i.installName(name)); result.Status == statusFailed {
+		result.Output = output
+		if hint := i.failureHint(i.installName(name)); hint != "" {
+			i.printf("%s│%s   see: %s%s\n", colors.Blue, colors.Gray, hint, colors.Reset)
+		}
+	}
+	i.tracer.set(name, "installer.status", result.Status)
+	if result.Method != "" {
+		i.tracer.set(name, "installer.method", result.Method)
+	}
+	var spanErr error
+	if result.Error != "" {
+		spanErr = errors.New(result.Error)
+	}
+	i.tracer.finish(name, span, spanErr)
+	i.emit(Event{Type: EventToolFinished, Tool: entry, Method: result.Method, Status: result.Status, Error: result.Error})
+	return result
+}
+
+// installSafely runs installChecked, turning a panic while the tool installs into a failure
+// of the tool carrying the stack trace, so that the run goes on with the other tools
+func (i *Installer) installSafely(entry string, result ToolReport, action string) (report ToolReport) {
+	defer func() {
+		r := recover()
+		if r == nil {
+			return
+		}
+		p := recovered(r)
+		if i.renderer == nil && i.liveOutput() {
+			clearProgressLine(i.display())
+		}
+		i.log(execLog).Error("panic", "tool", entry, "panic", fmt.Sprint(p.value), "stack", p.stack)
+		i.printf("%s│%s ❌ %s failed: %v%s\n", colors.Blue, colors.Red, entry, p, colors.Reset)
+		report = result
+		report.Status, report.Error, report.Stack = statusFailed, p.Error(), p.stack
+	}()
+	return i.installChecked(entry, result, action)
+}
+
+// installChecked performs the install for installEntry
+func (i *Installer) installChecked(entry string, result ToolReport, action string) ToolReport {
+	name, version := config.ParseToolEntry(entry)
+
+	// A tool whose pin could not be resolved must not be installed unpinned
+	if err := i.resolveVersion(name); err != nil {
+		i.printf("%s│%s Failed to install %s: %v%s\n", colors.Blue, colors.Red, entry, err, colors.Reset)
+		result.Status, result.Error = statusFailed, err.Error()
+		return result
 	}
+	if missing := i.missingDependencies(name); len(missing) > 0 {
+		// Like parallel runs, a tool whose dependency failed earlier is skipped, not failed
+		if failed := i.failedDependency(name); failed != "" {
+			i.printf("%s│%s ⏭ Skipping %s: dependency %s failed%s\n", colors.Blue, colors.Yellow, entry, failed, colors.Reset)
+			result.Status, result.Error = statusSkipped, fmt.Sprintf("skipped: dependency %s failed", failed)
+			return result
+		}
+		err := fmt.Errorf("missing dependencies: %s", strings.Join(missing, ", "))
+		i.printf("%s│%s Failed to install %s: %v%s\n", colors.Blue, colors.Red, entry, err, colors.Reset)
+		result.Status, result.Error = statusFailed, err.Error()
+		return result
+	}
+
+	status := statusInstalled
+	switch action {
+	case actionUpgrade:
+		status = statusUpgraded
+	case actionReinstall:
+		status = statusReinstalled
+	}
+
+	if version != "" {
+		if err := i.installVersion(name, version); err != nil {
+			i.printf("%s│%s Failed to install %s: %v%s\n", colors.Blue, colors.Red, entry, err, colors.Reset)
+			result.Status, result.Error = statusFailed, err.Error()
+			return result
+		}
+		result.Status, result.Version = status, version
+		return result
+	}
+
+	if action == actionReinstall && i.Options.UninstallFirst {
+		if err := i.uninstallFirst(name); err != nil {
+			i.printf("%s│%s Failed to reinstall %s: %v%s\n", colors.Blue, colors.Red, name, err, colors.Reset)
+			result.Status, result.Error = statusFailed, err.Error()
+			return result
+		}
+	}
+	if err := i.installTool(name); err != nil {
+		i.printf("%s│%s Failed to install %s: %v%s\n", colors.Blue, colors.Red, name, err, colors.Reset)
+		var timeout *toolTimeoutError
+		result.Status, result.Error, result.TimedOut = statusFailed, err.Error(), errors.As(err, &timeout)
+		return result
+	}
+
+	result.Status, result.IntegrityChanged = status, false
+	i.mu.Lock()
+	if ts := i.loadedState().Tools[i.installName(name)]; ts != nil {
+		result.Version, result.Method, result.PackageVersion, result.Channel = ts.Version, ts.Method, ts.PackageVersion, ts.Channel
+		ts.Action = status
+	}
+	i.mu.Unlock()
+	result.Drift = result.Pinned != "" && result.Version != "" && !i.versionsMatch(result.Version, result.Pinned)
+	return result
+}
+
+// toolCheck is the result of checking an installed tool
+type toolCheck struct {
+	installed bool
+	path      string   // Resolved binary path
+	version   string   // Detected version, empty when unknown
+	pinned    string   // Version pinned in the config
+	drift     bool     // Detected version differs from the pin
+	missing   []string // Provided commands that did not resolve
+	detect    string   // What the detect block checked, for tools that are not a command
+}
 
-	version := i.getToolVersion(name)
-	if version == "" {
-		fmt.Printf("%s│ %s✓ %-9s%s │ Installed (version unknown)\n", colorBlue, colorGreen, name, colorReset)
-		return true
+// probeTool inspects a tool without printing anything
+func (i *Installer) probeTool(name string) toolCheck {
+	check := toolCheck{}
+	toolConfig := i.config.Tools[name]
+	if toolConfig != nil {
+		check.pinned = toolConfig.Version
+	}
+	if toolConfig.DetectKind() != config.DetectCommand {
+		return i.probeDetected(name, toolConfig, check)
 	}
 
-	fmt.Printf("%s│ %s✓ %-9s%s │ %s%s\n", colorBlue, colorGreen, name, colorReset, version, colorReset)
-	return true
+	// With provides, every command must resolve; the first one is the tool's binary
+	for _, command := range toolConfig.Commands(name) {
+		path, err := i.commands().LookPath(command)
+		if err != nil {
+			check.missing = append(check.missing, command)
+		} else if check.path == "" {
+			check.path = path
+		}
+	}
+	if len(check.missing) > 0 {
+		return check
+	}
+	check.installed = true
+	check.version = i.getToolVersion(name)
+	check.drift = check.version != "" && check.pinned != "" && !i.versionsMatch(check.version, check.pinned)
+	return check
 }
 
-// getToolVersion returns the version of a tool
+// getToolVersion returns the detected version of a tool
 func (i *Installer) getToolVersion(name string) string {
-	// If version is defined in YAML, use that
-	if version := i.config.Tools[name].Version; version != "" {
-		return version
+	versionFlag := ""
+	if toolConfig := i.config.Tools[name]; toolConfig != nil {
+		versionFlag = toolConfig.VersionFlag
+	}
+	return i.detectVersion(i.toolCommand(name), versionFlag)
+}
+
+// toolCommand returns the command a tool is checked and versioned by: the first command it
+// provides
+func (i *Installer) toolCommand(name string) string {
+	return i.config.Tools[name].Commands(name)[0]
+}
+
+// missingDependencies returns the dependencies of a tool that are not installed. A dependency
+// names a tool or a command some tool provides; a command on PATH satisfies it either way.
+func (i *Installer) missingDependencies(name string) []string {
+	var missing []string
+	for _, dep := range i.config.Tools[name].Dependencies {
+		if _, err := i.commands().LookPath(dep); err == nil {
+			continue
+		}
+		provider := i.config.Provider(dep)
+		if provider != "" && i.probeTool(provider).installed {
+			continue
+		}
+		if provider != "" && provider != dep {
+			dep += " (provided by " + provider + ")"
+		}
+		missing = append(missing, dep)
+	}
+	return missing
+}
+
+// failedDependency returns the first dependency of a tool whose install failed, or was itself
+// skipped or deferred, earlier in the run, or "" when none did
+func (i *Installer) failedDependency(name string) string {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	for _, dep := range i.config.Tools[name].Dependencies {
+		tool := dep
+		if provider := i.config.Provider(dep); provider != "" {
+			tool = provider
+		}
+		switch i.attempts[i.installName(tool)].Status {
+		case statusFailed, statusSkipped, statusDeferred:
+			return dep
+		}
 	}
+	return ""
+}
+
+// versionsMatch reports whether a detected version satisfies a pin, ignoring a leading v and
+// missing trailing components. Strings that are not versions are compared as text.
+func (i *Installer) versionsMatch(detected, pinned string) bool {
+	c, err := version.Compare(detected, pinned)
+	if err != nil {
+		i.log(versionLog).Debug("comparing as text", "error", err)
+		return strings.TrimPrefix(strings.TrimSpace(detected), "v") == strings.TrimPrefix(strings.TrimSpace(pinned), "v")
+	}
+	return c == 0
+}
+
+// detectVersion runs a binary with common version flags and extracts its version
+func (i *Installer) detectVersion(bin, versionFlag string) string {
+	bin = i.realBinary(bin)
 
 	// Common version flags to try
 	versionFlags := []string{
@@ -161,178 +998,502 @@ func (i *Installer) getToolVersion(name string) string {
 	}
 
 	// Get version flag from config if specified
-	if i.config.Tools[name].VersionFlag != "" {
-		versionFlags = []string{i.config.Tools[name].VersionFlag}
+	if versionFlag != "" {
+		versionFlags = []string{versionFlag}
 	}
 
 	var version string
 	for _, flag := range versionFlags {
-		cmd := exec.Command(name, flag)
-		output, err := cmd.CombinedOutput()
+		output, err := i.commands().Output([]string{bin, flag})
+		i.log(versionLog).Debug("probe", "argv", []string{bin, flag}, "error", err, "output", string(output))
 		if err != nil {
 			continue
 		}
 
 		// Try to extract version from output
-		version = extractVersion(string(output))
+		version = i.extractVersion(string(output), i.config.Patterns.Version)
 		if version != "" {
 			break
 		}
 	}
+	i.log(versionLog).Debug("detected", "binary", bin, "version", version)
 
 	return version
 }
 
 // installTool attempts to install a tool using the first available method
 func (i *Installer) installTool(name string) error {
+	name = i.installName(name)
 	toolConfig := i.config.Tools[name]
+	var method config.InstallMethod
+	var path string
+	if batched, ok := i.takeBatched(name); ok {
+		method = batched.method
+		i.emit(Event{Type: EventMethodStarted, Tool: name, Method: method.Name})
+		i.printf("%s│%s 📦 %s was installed by the %s batch%s\n", colors.Blue, colors.Gray, name, batched.manager, colors.Reset)
+	} else {
+		var err error
+		if method, path, err = i.install(name, toolConfig, i.toolBinDir(name)); err != nil {
+			return err
+		}
+	}
+	i.extendPath(name)
+
+	pkg := i.queryPackage(name, toolConfig, method)
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.recordInstall(name, toolConfig, method, path, pkg)
+	return nil
+}
+
+// install tries each installation method until one succeeds, returning the method used
+// and, for managed methods, the path of the placed binary
+func (i *Installer) install(name string, toolConfig *config.ToolConfig, bindir string) (config.InstallMethod, string, error) {
 	if toolConfig == nil || len(toolConfig.Methods) == 0 {
-		return fmt.Errorf("no installation methods available for %s", name)
+		return config.InstallMethod{}, "", fmt.Errorf("no installation methods available for %s", name)
+	}
+
+	if note := i.defaultNote(name); note != "" {
+		i.printf("%s│%s ⓘ %s has %s%s\n", colors.Blue, colors.Gray, name, note, colors.Reset)
+	}
+
+	// install_timeout bounds all methods together
+	stopTimeout := i.startToolTimeout(name, toolConfig)
+	defer stopTimeout()
+	i.installSystemPackages(name, toolConfig)
+
+	methods := i.orderedMethods(toolConfig)
+	if len(methods) == 0 {
+		return config.InstallMethod{}, "", fmt.Errorf("no installation method of %s is on the %s channel", name, i.toolChannel(toolConfig))
 	}
 
 	// Try each installation method until one succeeds
-	for _, method := range toolConfig.Methods {
-		fmt.Printf("%s│%s 📦 Installing %s using %s method...%s\n", colorBlue, colorYellow, name, method.Name, colorReset)
-
-		for _, command := range method.Commands {
-			// Replace environment variables and version
-			command = os.ExpandEnv(command)
-			if version := toolConfig.Version; version != "" {
-				command = strings.ReplaceAll(command, "${version}", version)
+	var lastErr error
+	for _, method := range methods {
+		if i.context().Err() != nil {
+			return config.InstallMethod{}, "", ErrInterrupted
+		}
+		if err := i.toolTimedOut(name); err != nil {
+			return config.InstallMethod{}, "", err
+		}
+		if reason := i.rootSkipReason(method); reason != "" {
+			lastErr = fmt.Errorf("%s: %s", method.Name, reason)
+			i.printf("%s│%s ⏭ Skipping %s method of %s: %s%s\n", colors.Blue, colors.Gray, method.Name, name, reason, colors.Reset)
+			continue
+		}
+		missing, providers := i.methodRequirements(name, method)
+		if len(missing) > 0 {
+			lastErr = fmt.Errorf("%s: requires %s", method.Name, strings.Join(missing, ", "))
+			i.printf("%s│%s ⏭ Skipping %s method of %s: requires %s%s\n", colors.Blue, colors.Gray, method.Name, name, strings.Join(missing, ", "), colors.Reset)
+			continue
+		}
+		var requireErr error
+		for _, provider := range providers {
+			if requireErr = i.installRequirement(name, method.Name, provider); requireErr != nil {
+				break
 			}
+		}
+		if requireErr != nil {
+			i.printf("%s│%s ❌ %v%s\n", colors.Blue, colors.Red, requireErr, colors.Reset)
+			lastErr = fmt.Errorf("%s: %v", method.Name, requireErr)
+			continue
+		}
 
-			// Split the command into parts
-			parts := strings.Fields(command)
-			if len(parts) == 0 {
-				continue
-			}
+		i.emit(Event{Type: EventMethodStarted, Tool: name, Method: method.Name})
+		if i.renderer != nil {
+			i.renderer.Step(name, method.Name, "")
+		} else {
+			fmt.Fprintf(i.display(), "%s│%s 📦 Installing %s using %s method...%s\n", colors.Blue, colors.Yellow, name, method.Name, colors.Reset)
+		}
 
-			// Create the command
-			execCmd := exec.Command(parts[0], parts[1:]...)
+		// Every method starts with an empty ${tmpdir}, and reports its own exit code and signature.
+		// Its hints only match its own output; the report keeps the last output there was.
+		i.takeExitCode(name)
+		i.takeSignature(name)
+		previous := i.takeOutput(name)
+		i.setToolPosition(name, method.Name, "")
+		if err := i.resetToolTempDir(name); err != nil {
+			return config.InstallMethod{}, "", fmt.Errorf("failed to prepare temp directory: %v", err)
+		}
+		i.clearRunAs(name)
+		// A broken reference fails the method before it adds repositories or runs anything
+		if err := i.checkMethodVars(name, toolConfig, method, bindir); err != nil {
+			i.printf("%s│%s ❌ Failed to install %s: %v%s\n", colors.Blue, colors.Red, name, err, colors.Reset)
+			lastErr = fmt.Errorf("%s: %v", method.Name, err)
+			continue
+		}
+		if err := i.prepareRepositories(name, method, i.commandVars(name, toolConfig.Version, bindir)); err != nil {
+			i.printf("%s│%s ❌ %s%s\n", colors.Blue, colors.Red, i.redact(err.Error()), colors.Reset)
+			lastErr = fmt.Errorf("%s: %v", method.Name, err)
+			continue
+		}
+		if err := i.setRunAs(name, toolConfig, method); err != nil {
+			return config.InstallMethod{}, "", fmt.Errorf("failed to run as another user: %v", err)
+		}
 
-			// Create pipes for stdout and stderr
-			stdout, err := execCmd.StdoutPipe()
-			if err != nil {
-				return fmt.Errorf("failed to create stdout pipe: %v", err)
+		methodType := method.Type
+		if methodType == "" {
+			methodType = "commands"
+		}
+		span := i.tracer.start(name, "method "+method.Name, map[string]interface{}{"installer.method": method.Name, "installer.method.type": methodType})
+		var path string
+		var err error
+		switch method.Type {
+		case "":
+			err = i.runCommands(name, toolConfig, method, bindir)
+		case config.MethodDownload, config.MethodGithubRelease:
+			path, err = i.runReleaseMethod(name, toolConfig, method, bindir)
+		case config.MethodScript:
+			err = i.runScript(name, toolConfig, method, bindir)
+		default:
+			err = i.runTypedMethod(name, toolConfig, method)
+		}
+		i.tracer.finish(name, span, err)
+		i.runCleanup(name, toolConfig, method, bindir)
+		if timeoutErr := i.toolTimedOut(name); err != nil && timeoutErr != nil {
+			return config.InstallMethod{}, "", timeoutErr
+		}
+		// Tools that are not a command must be detected once their method succeeded
+		if err == nil && toolConfig.DetectKind() != config.DetectCommand {
+			if check := i.probeDetected(name, toolConfig, toolCheck{}); !check.installed {
+				err = fmt.Errorf("the method succeeded, but detect did not find %s (%s)", name, check.detect)
 			}
-			stderr, err := execCmd.StderrPipe()
-			if err != nil {
-				return fmt.Errorf("failed to create stderr pipe: %v", err)
+		}
+		if err != nil {
+			err = i.explainOffline(name, toolConfig, method, bindir, err)
+			i.printf("%s│%s ❌ Failed to install %s: %v%s\n", colors.Blue, colors.Red, name, err, colors.Reset)
+			output := i.takeOutput(name)
+			hints := i.failureHints(toolConfig, method, output+"\n"+err.Error())
+			i.printHints(hints)
+			i.addHints(name, hints)
+			if output == "" {
+				output = previous
 			}
+			i.setOutput(name, output)
+			lastErr = fmt.Errorf("%s: %v", method.Name, err)
+			continue
+		}
+		return method, path, nil
+	}
 
-			// Start the command
-			if err := execCmd.Start(); err != nil {
-				fmt.Printf("%s│%s ❌ Failed to start command: %s%s\n", colorBlue, colorRed, command, colorReset)
-				continue
-			}
+	return config.InstallMethod{}, "", fmt.Errorf("all installation methods failed for %s (last: %v)", name, lastErr)
+}
 
-			// Create progress indicator with tool name and method
-			progress := NewProgress(fmt.Sprintf("Installing %s (%s): %s", name, method.Name, filepath.Base(parts[0])))
-			progress.Start()
-
-			// Create a WaitGroup for the scanner goroutine
-			var wg sync.WaitGroup
-			wg.Add(1)
-
-			// Read command output in the background
-			go func() {
-				defer wg.Done()
-				scanner := NewSafeScanner(io.MultiReader(stdout, stderr))
-				for scanner.Scan() {
-					line := scanner.Text()
-					// Only show output for go install commands
-					if strings.Contains(command, "go install") || strings.Contains(command, "go get") {
-						if show, formatted := formatGoInstallOutput(line); show {
-							progress.Stop()
-							fmt.Printf("%s│ %s%s%s\n", colorBlue, colorGray, formatted, colorReset)
-							progress = NewProgress(fmt.Sprintf("Installing %s (%s): %s", name, method.Name, filepath.Base(parts[0])))
-							progress.Start()
-						}
-					}
-				}
-			}()
+// runCommands executes the commands of a plain method in order
+func (i *Installer) runCommands(name string, toolConfig *config.ToolConfig, method config.InstallMethod, bindir string) error {
+	// Commands run inside the target see its paths
+	inTarget := method.InTarget && i.Options.Root != ""
+	if inTarget {
+		bindir = i.inTarget(bindir)
+	}
+	vars := i.commandVars(name, toolConfig.Version, bindir)
 
-			// Wait for command to complete
-			err = execCmd.Wait()
+	// Check every command's variables up front, so a broken reference fails before anything ran
+	checked := keepCaptures(method, maps.Clone(vars))
+	var commands []config.Command
+	for _, command := range method.Commands {
+		if _, err := expandChecked(name, fmt.Sprintf("command %q", command.Run), command.Run, checked); err != nil {
+			return err
+		}
+		if strings.TrimSpace(command.Run) != "" {
+			commands = append(commands, command)
+		}
+	}
 
-			// Wait for scanner to finish
-			wg.Wait()
+	env, err := i.commandEnv(method, vars)
+	if err != nil {
+		return err
+	}
+	i.printEnv(method, vars)
 
-			// Stop the progress indicator and clear the line
-			progress.Stop()
-			fmt.Printf("\r%s", strings.Repeat(" ", 80)) // Clear the line
-			fmt.Printf("\r")                            // Return to start of line
+	for n, command := range commands {
+		// Commands are expanded as they run, so they see the values captured before them
+		parts, err := splitCommand(command.Run, vars)
+		if err != nil {
+			return fmt.Errorf("command %q: %v", command.Run, err)
+		}
+		if len(parts) == 0 {
+			continue
+		}
+		if inTarget {
+			parts = append([]string{"chroot", i.Options.Root}, parts...)
+		}
+		step := fmt.Sprintf("step %d/%d", n+1, len(commands))
+		if i.verbose() {
+			i.printf("%s│   %s%s: %s%s\n", colors.Blue, colors.Gray, step, strings.Join(parts, " "), colors.Reset)
+		}
 
+		// A single command needs no step counter in the progress line
+		label := step
+		if len(commands) == 1 {
+			label = ""
+		}
+		started := time.Now()
+		if command.Capture != "" {
+			value, err := i.runCapture(name, method.Name, label, parts, env, command)
 			if err != nil {
-				fmt.Printf("%s│%s ❌ Failed to install %s: %v%s\n", colorBlue, colorRed, name, err, colorReset)
-				continue
+				if label == "" {
+					return err
+				}
+				return fmt.Errorf("%s (%s) failed: %v", step, i.redact(strings.Join(parts, " ")), err)
+			}
+			vars["capture:"+command.Capture] = value
+		} else if err := i.runCommand(name, method.Name, label, parts, env); err != nil {
+			if label == "" {
+				return err
 			}
+			return fmt.Errorf("%s (%s) failed: %v", step, i.redact(strings.Join(parts, " ")), err)
+		}
+		if i.verbose() {
+			i.printf("%s│   %s✓ %s done in %s%s\n", colors.Blue, colors.Gray, step, time.Since(started).Round(time.Millisecond), colors.Reset)
+		}
+	}
+	return nil
+}
+
+// execCommand executes a single command with env (nil for the installer's environment), showing
+// a progress indicator labelled with step while it runs, and reports whether it failed because
+// a package manager lock was held
+func (i *Installer) execCommand(name, methodName, step string, parts, env []string) (bool, error) {
+	command := strings.Join(parts, " ")
+
+	// Create progress indicator with tool name and method
+	detail := strings.TrimSpace(step + " " + filepath.Base(parts[0]))
+	stall := &stallMonitor{detail: detail, last: time.Now()}
 
-			return nil
+	// Handle command output line by line. Both streams share one writer, so lines are
+	// never handled concurrently, and Run returns only once all output was handled.
+	usesLock, locked := usesPackageLock(parts), false
+	goCommand, modules := strings.Contains(command, "go install") || strings.Contains(command, "go get"), 0
+	captured, sink := i.newOutputBuffer(), i.runningCapture(name)
+	output := &lineWriter{line: func(line string) {
+		// Logs and the report get the output without escapes and progress redraws
+		stall.activity()
+		clean := sanitizeLine(line)
+		captured.line(clean)
+		if sink == nil || !sink.secret {
+			i.log(execLog).Debug("output", "tool", name, "line", i.redact(clean))
+			i.emit(Event{Type: EventOutput, Tool: name, Method: methodName, Line: i.redact(clean)})
 		}
+		if usesLock && i.isLockError(line) {
+			locked = true
+		}
+		// go install reports each module it fetches; they are counted on the progress line,
+		// and only listed with --verbose
+		if goCommand {
+			if module, ok := goDownloading(line); ok {
+				modules++
+				if i.verbose() {
+					stall.replace(func() {
+						i.printf("%s│ %s%s%s\n", colors.Blue, colors.Gray, line, colors.Reset)
+					}, func() *stepProgress { return i.startProgress(name, methodName, detail) })
+				}
+				stall.update(fmt.Sprintf("%s: downloaded %d modules, %s", detail, modules, module))
+			}
+		}
+	}}
+
+	started := time.Now()
+	if debugging(i.log(execLog)) {
+		i.log(execLog).Debug("start", "tool", name, "method", methodName, "argv", i.redactArgs(parts), "env", i.debugEnv(env))
+	}
+	span := i.tracer.start(name, "exec "+filepath.Base(parts[0]), map[string]interface{}{"process.command_line": i.redact(command), "installer.step": step})
+	stall.progress = i.startProgress(name, methodName, detail)
+	// A panic while the command runs fails the tool, which must not leave its spinner drawing
+	defer func() {
+		if r := recover(); r != nil {
+			p := recovered(r)
+			stall.stop()
+			panic(p)
+		}
+	}()
+
+	i.setToolPosition(name, methodName, step)
+	ctx, cancel := context.WithCancel(i.toolContext(name))
+	defer cancel()
+	done, watched := make(chan struct{}), make(chan struct{})
+	go func() {
+		i.watchStall(stall, command, i.stallTimeout(name, methodName), cancel, done)
+		close(watched)
+	}()
+	err := i.commands().Run(ctx, parts, env, output)
+	close(done)
+	<-watched
+	output.flush()
+	if output.panicked != nil {
+		panic(output.panicked)
+	}
+	if stall.stalled > 0 && i.toolContext(name).Err() == nil {
+		err = fmt.Errorf("stalled: no output for %s, killed", stall.stalled)
 	}
+	i.log(execLog).Debug("exit", "tool", name, "code", exitCode(err), "duration", time.Since(started).Round(time.Millisecond), "error", err)
+	i.tracer.set(name, "process.exit_code", exitCode(err))
+	i.tracer.finish(name, span, err)
 
-	return fmt.Errorf("all installation methods failed for %s", name)
+	// Stop the progress indicator and clear the line
+	stall.stop()
+	if code := exitCode(err); code >= 0 {
+		i.setExitCode(name, code)
+		err = i.checkExitCode(name, methodName, filepath.Base(parts[0]), code, err, captured)
+	}
+	if sink != nil {
+		sink.output = captured
+	}
+	if err != nil && (sink == nil || !sink.secret) {
+		i.setOutput(name, i.redact(captured.String()))
+	}
+
+	var notStarted *startError
+	if errors.As(err, &notStarted) {
+		return false, fmt.Errorf("failed to start command: %s", command)
+	}
+	return locked && err != nil, err
 }
 
-// SafeScanner wraps bufio.Scanner with error handling
-type SafeScanner struct {
-	*bufio.Scanner
+// stepProgress shows the running step of a method on a spinner line, or on the tool's status
+// line while tools install in parallel. Without a terminal the step is not shown, since
+// redrawing it would fill pipes and logs with spinner frames.
+type stepProgress struct {
+	i        *Installer
+	name     string
+	method   string
+	progress *Progress // Nil while tools install in parallel or output is no terminal
 }
 
-// NewSafeScanner creates a new SafeScanner
-func NewSafeScanner(r io.Reader) *SafeScanner {
-	return &SafeScanner{bufio.NewScanner(r)}
+// startProgress shows a running step of a method until stop is called
+func (i *Installer) startProgress(name, methodName, detail string) *stepProgress {
+	s := &stepProgress{i: i, name: name, method: methodName}
+	if i.renderer != nil {
+		i.renderer.Step(name, methodName, detail)
+		return s
+	}
+	if !i.liveOutput() {
+		return s
+	}
+	s.progress = newProgress(i.display(), s.message(detail))
+	s.progress.Start()
+	return s
+}
+
+// message returns the spinner line for a step
+func (s *stepProgress) message(detail string) string {
+	return fmt.Sprintf("Installing %s (%s): %s", s.name, s.method, detail)
 }
 
-// formatGoInstallOutput formats the output of go install commands
-func formatGoInstallOutput(line string) (bool, string) {
-	if strings.Contains(line, "go: downloading") {
-		return true, line
+// update replaces the step shown in place
+func (s *stepProgress) update(detail string) {
+	switch {
+	case s.progress != nil:
+		s.progress.UpdateMessage(s.message(detail))
+	case s.i.renderer != nil:
+		s.i.renderer.Step(s.name, s.method, detail)
 	}
-	return false, ""
 }
 
-// extractVersion extracts version information from command output
-func extractVersion(output string) string {
-	// Common version patterns
-	patterns := []string{
-		`(?i)version\s+(v\d+\.\d+\.\d+)`, // matches version v1.2.3 (case insensitive)
-		`(?i)amass\s+-\s+v\d+\.\d+\.\d+`, // matches amass - v1.2.3
-		`v\d+\.\d+\.\d+`,                 // matches v1.2.3
-		`\d+\.\d+\.\d+`,                  // matches 1.2.3
-		`go\d+\.\d+\.\d+`,                // matches go1.2.3
-		`Version: (v\d+\.\d+\.\d+)`,      // matches Version: v1.2.3
+// stop clears the step
+func (s *stepProgress) stop() {
+	if s.progress != nil {
+		s.progress.Stop()
+		clearProgressLine(s.i.display())
 	}
+}
 
-	version := strings.TrimSpace(output)
-	for _, pattern := range patterns {
-		re := regexp.MustCompile(pattern)
-		if strings.Contains(pattern, "(") {
-			// Handle patterns with capture groups
-			if match := re.FindStringSubmatch(version); len(match) > 1 {
-				return match[1]
-			}
-		} else {
-			// Handle simple patterns
-			if match := re.FindString(version); match != "" {
-				// Clean up amass version format
-				if strings.Contains(match, "amass - ") {
-					return strings.TrimPrefix(strings.TrimPrefix(match, "amass - "), "v")
-				}
-				// Clean up go version format
-				if strings.HasPrefix(match, "go") {
-					return strings.TrimPrefix(match, "go")
-				}
-				return match
-			}
+// printf prints install output with secrets redacted, above the status lines while tools
+// install in parallel
+func (i *Installer) printf(format string, args ...interface{}) {
+	line := i.redact(fmt.Sprintf(format, args...))
+	if i.renderer != nil {
+		i.renderer.Printf("%s", line)
+		return
+	}
+	fmt.Fprint(i.display(), line)
+}
+
+// stdout returns where the installer prints: stdout, or the writer given WithOutput or WithTerminal
+func (i *Installer) stdout() *terminalState {
+	if i.out == nil {
+		return stdoutTerminal
+	}
+	return i.out
+}
+
+// stderr returns where the installer prints diagnostics: stderr, or the writer given WithDiagnostics
+func (i *Installer) stderr() *terminalState {
+	if i.diag == nil {
+		return stderrTerminal
+	}
+	return i.diag
+}
+
+// display returns where the installer draws its usual output: stdout outside of runs, and
+// during runs nowhere when porcelain or quiet output replaces it
+func (i *Installer) display() *terminalState {
+	if i.term == nil {
+		return i.stdout()
+	}
+	return i.term
+}
+
+// clearProgressLine blanks the spinner line on out and returns the cursor to its start
+func clearProgressLine(out io.Writer) {
+	fmt.Fprintf(out, "\r%s", strings.Repeat(" ", 80)) // Clear the line
+	fmt.Fprintf(out, "\r")                            // Return to start of line
+}
+
+// lineWriter calls line for each complete line written to it
+type lineWriter struct {
+	line     func(string)
+	partial  []byte
+	panicked *toolPanic // Panic of the line handler; later output is discarded
+}
+
+// Write splits p into lines, keeping an incomplete last line for the next write. A panic
+// handling a line is kept for the caller rather than crashing the goroutine copying the
+// output of a command.
+func (w *lineWriter) Write(p []byte) (int, error) {
+	if w.panicked != nil {
+		return len(p), nil
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			w.panicked, w.partial = recovered(r), nil
 		}
+	}()
+	w.partial = append(w.partial, p...)
+	for {
+		n := bytes.IndexByte(w.partial, '\n')
+		if n < 0 {
+			break
+		}
+		w.line(strings.TrimRight(string(w.partial[:n]), "\r"))
+		w.partial = w.partial[n+1:]
 	}
+	return len(p), nil
+}
 
-	// If no version pattern matched, return first line
-	if lines := strings.Split(version, "\n"); len(lines) > 0 {
-		return lines[0]
+// flush handles a final line that had no newline
+func (w *lineWriter) flush() {
+	defer func() {
+		if r := recover(); r != nil {
+			w.panicked = recovered(r)
+		}
+	}()
+	if len(w.partial) > 0 && w.panicked == nil {
+		w.line(string(w.partial))
+		w.partial = nil
 	}
+}
 
-	return ""
+// goDownloading returns the module and version of a "go: downloading" line of go install
+func goDownloading(line string) (string, bool) {
+	module, ok := strings.CutPrefix(strings.TrimSpace(line), "go: downloading ")
+	return module, ok
+}
+
+// extractVersion extracts version information from command output, trying the configured
+// patterns first
+func (i *Installer) extractVersion(output string, configured []string) string {
+	extracted := version.Extract(output, configured)
+	i.log(versionLog).Debug("extracted", "version", extracted)
+	return extracted
 }
@@ -0,0 +1,210 @@
+package installer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// diamondConfig installs d, which needs b and c, which both need a
+const diamondConfig = `
+tool_list: [a, b, c, d]
+tools:
+  a:
+    methods: [{name: fake, commands: ["install a"]}]
+  b:
+    dependencies: [a]
+    methods: [{name: fake, commands: ["install b"]}]
+  c:
+    dependencies: [a]
+    methods: [{name: fake, commands: ["install c"]}]
+  d:
+    dependencies: [b, c]
+    methods: [{name: fake, commands: ["install d"]}]
+`
+
+func TestInstallDiamondDependencies(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		fail      string
+		err       string
+		statuses  map[string]string
+		installed []string
+	}{
+		{
+			name:     "all succeed",
+			statuses: map[string]string{"a": statusInstalled, "b": statusInstalled, "c": statusInstalled, "d": statusInstalled},
+		},
+		{
+			name:     "one side fails",
+			fail:     "install b",
+			err:      "1 of 4 tools failed, 1 skipped because a dependency failed",
+			statuses: map[string]string{"a": statusInstalled, "b": statusFailed, "c": statusInstalled, "d": statusSkipped},
+		},
+		{
+			name:     "root fails",
+			fail:     "install a",
+			err:      "1 of 4 tools failed, 3 skipped because a dependency failed",
+			statuses: map[string]string{"a": statusFailed, "b": statusSkipped, "c": statusSkipped, "d": statusSkipped},
+		},
+	} {
+		for _, concurrency := range []int{1, 2} {
+			t.Run(fmt.Sprintf("%s/concurrency %d", tc.name, concurrency), func(t *testing.T) {
+				runner := newFakeRunner(t)
+				if tc.fail != "" {
+					runner.fail[tc.fail] = true
+				}
+				i := newTestInstaller(t, diamondConfig, runner)
+				i.Options.Concurrency = concurrency
+				err := i.Run()
+				switch {
+				case tc.err == "" && err != nil:
+					t.Fatalf("Run: %v", err)
+				case tc.err != "" && (err == nil || !strings.Contains(err.Error(), tc.err) || !errors.Is(err, ErrInstallFailed)):
+					t.Fatalf("Run error = %v, want ErrInstallFailed with %q", err, tc.err)
+				}
+
+				for _, result := range i.report {
+					if want := tc.statuses[result.Name]; result.Status != want {
+						t.Errorf("%s: status %s, want %s", result.Name, result.Status, want)
+					}
+				}
+				// a is installed once although two tools depend on it, and before them
+				ran := runner.commands()
+				if ran[0] != "install a" || strings.Count(strings.Join(ran, "\n"), "install a") != 1 {
+					t.Errorf("commands = %q, want install a once and first", ran)
+				}
+				if tc.fail == "" && ran[len(ran)-1] != "install d" {
+					t.Errorf("commands = %q, want install d last", ran)
+				}
+			})
+		}
+	}
+}
+
+// upgradingRunner is a fakeRunner whose installs leave version 2.0.0 behind
+type upgradingRunner struct {
+	*fakeRunner
+	upgraded map[string]bool
+}
+
+func (r upgradingRunner) Output(argv []string) ([]byte, error) {
+	r.mu.Lock()
+	upgraded := r.upgraded[filepath.Base(argv[0])]
+	r.mu.Unlock()
+	if upgraded {
+		return []byte(filepath.Base(argv[0]) + " version 2.0.0\n"), nil
+	}
+	return r.fakeRunner.Output(argv)
+}
+
+func (r upgradingRunner) Run(ctx context.Context, argv, env []string, out io.Writer) error {
+	err := r.fakeRunner.Run(ctx, argv, env, out)
+	if err == nil && len(argv) == 2 && argv[0] == "install" {
+		r.mu.Lock()
+		r.upgraded[argv[1]] = true
+		r.mu.Unlock()
+	}
+	return err
+}
+
+// driftConfig pins a tool the runner reports at 1.0.0 to 2.0.0
+const driftConfig = `
+tool_list: [evtool]
+tools:
+  evtool:
+    version: 2.0.0
+    methods: [{name: fake, commands: ["install evtool"]}]
+`
+
+func TestVerifyReportsDrift(t *testing.T) {
+	runner := newFakeRunner(t, "evtool")
+	i := newTestInstaller(t, driftConfig, runner)
+	i.Options.ReportPath = filepath.Join(t.TempDir(), "report.json")
+	var quiet bytes.Buffer
+	i.quiet = &quiet
+	if err := i.Verify(); !errors.Is(err, ErrDrift) {
+		t.Fatalf("Verify = %v, want %v", err, ErrDrift)
+	}
+	if want := "evtool: 1.0.0 installed, pinned 2.0.0"; !strings.Contains(quiet.String(), want) {
+		t.Errorf("verify printed %q, want %q", quiet.String(), want)
+	}
+
+	data, err := os.ReadFile(i.Options.ReportPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report Report
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Tools) != 1 || !report.Tools[0].Drift || report.Tools[0].Version != "1.0.0" || report.Tools[0].Pinned != "2.0.0" {
+		t.Errorf("report tools = %+v, want evtool drifted from 2.0.0 at 1.0.0", report.Tools)
+	}
+	if len(runner.commands()) > 0 {
+		t.Errorf("verify ran %q", runner.commands())
+	}
+}
+
+func TestFixUpgradesDriftedTools(t *testing.T) {
+	for _, fix := range []bool{false, true} {
+		t.Run(fmt.Sprintf("fix %v", fix), func(t *testing.T) {
+			runner := upgradingRunner{newFakeRunner(t, "evtool"), map[string]bool{}}
+			i := newTestInstaller(t, driftConfig, runner)
+			i.Options.Fix = fix
+			if err := i.Run(); err != nil {
+				t.Fatalf("Run: %v", err)
+			}
+			result := i.report[0]
+			switch {
+			case !fix && (len(runner.commands()) > 0 || !result.Drift):
+				t.Errorf("install without --fix ran %q and reported %+v, want evtool left drifted", runner.commands(), result)
+			case fix && (result.Status != statusUpgraded || result.Drift || result.Version != "2.0.0"):
+				t.Errorf("install --fix reported %+v, want evtool upgraded to its pin", result)
+			}
+		})
+	}
+}
+
+func TestSelectLimitsTheRun(t *testing.T) {
+	runner := newFakeRunner(t)
+	i := newTestInstaller(t, `
+tool_list: [a, b, c]
+groups:
+  ab: [a, b]
+tools:
+  a:
+    methods: [{name: fake, commands: ["install a"]}]
+  b:
+    methods: [{name: fake, commands: ["install b"]}]
+  c:
+    methods: [{name: fake, commands: ["install c"]}]
+`, runner)
+	i.Options.Concurrency = 1
+	if _, err := i.Select([]string{"@ab", "!a", "!b"}); err == nil || err.Error() != "@ab !a !b selects no tools" {
+		t.Errorf("Select of nothing = %v, want it refused", err)
+	}
+	sel, err := i.Select([]string{"!a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "!a"; sel.Excluded["a"] != want {
+		t.Errorf("excluded = %q, want a excluded by %s", sel.Excluded, want)
+	}
+	if err := i.Run(); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if got, want := runner.commands(), []string{"install b", "install c"}; strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("commands = %q, want %q", got, want)
+	}
+	if i.loadedState().LastCheck != nil {
+		t.Error("a run limited to a selection recorded the status of the whole tool_list")
+	}
+}
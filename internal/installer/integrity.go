@@ -0,0 +1,48 @@
+package installer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/config"
+)
+
+// hashFile returns the hex sha256 digest of a file
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// integrityApplies reports whether binaries at path are hashed under the configured scope
+func (i *Installer) integrityApplies(path string) bool {
+	if i.config.Integrity != config.IntegrityManaged {
+		return true
+	}
+	resolved, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		resolved = path
+	}
+	dirs := []string{i.binDir(), i.stateDir()}
+	for name := range i.config.Tools {
+		dirs = append(dirs, i.toolBinDir(name))
+	}
+	for _, dir := range dirs {
+		if strings.HasPrefix(resolved, filepath.Clean(dir)+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,47 @@
+package installer
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/colors"
+	"github.com/Abhaythakor/dev-tools-installer/internal/config"
+)
+
+// List prints the configured tools, the commands they provide and their descriptions. With a
+// filter, only tools whose name or provided commands contain it are listed.
+func (i *Installer) List(filter string) error {
+	selected := map[string]bool{}
+	for _, entry := range i.config.ToolList {
+		name, _ := config.ParseToolEntry(entry)
+		selected[name] = true
+	}
+
+	found := false
+	for _, name := range sortedKeys(i.config.Tools) {
+		commands := i.config.Tools[name].Commands(name)
+		if filter != "" && !strings.Contains(name, filter) && !slices.ContainsFunc(commands, func(c string) bool { return strings.Contains(c, filter) }) {
+			continue
+		}
+		found = true
+		note := ""
+		switch {
+		case i.config.Tools[name] != nil && i.config.Tools[name].Disabled:
+			note = colors.Gray + " (disabled)" + colors.Reset
+		case i.config.Tools[name] != nil && i.config.Tools[name].Default:
+			note = colors.Gray + " (default method)" + colors.Reset
+		case !selected[name]:
+			note = colors.Gray + " (not in tool_list)" + colors.Reset
+		}
+		description := ""
+		if i.config.Tools[name] != nil {
+			description = i.config.Tools[name].Description
+		}
+		fmt.Fprintf(i.display(), "%s%-16s%s %-24s %s%s\n", colors.Blue, name, colors.Reset, strings.Join(commands, ", "), description, note)
+	}
+	if !found && filter != "" {
+		return fmt.Errorf("no tool provides %q", filter)
+	}
+	return nil
+}
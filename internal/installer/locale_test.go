@@ -0,0 +1,113 @@
+package installer
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// localizedRunner prints a fixture of localized output: the banner when a tool's version is
+// probed, and the output of every command it runs, which fail
+type localizedRunner struct {
+	*fakeRunner
+	banner, output string
+}
+
+func (r localizedRunner) Output(argv []string) ([]byte, error) {
+	if _, err := r.fakeRunner.Output(argv); err != nil {
+		return nil, err
+	}
+	return []byte(r.banner), nil
+}
+
+func (r localizedRunner) Run(ctx context.Context, argv, env []string, out io.Writer) error {
+	r.fakeRunner.Run(ctx, argv, env, out)
+	io.WriteString(out, r.output)
+	return errors.New("exit status 100")
+}
+
+// localeFixture reads a file of testdata/locale
+func localeFixture(t *testing.T, name string) string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", "locale", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestLocalizedLockErrors(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		fixture  string
+		patterns string
+		locked   bool
+	}{
+		{"french lock", "apt-lock.fr.txt", "", true},
+		{"german lock", "apt-lock.de.txt", "", false},
+		{"german lock with pattern", "apt-lock.de.txt", `output_patterns: {lock: ["KONNTE NICHT ERLANGT WERDEN"]}`, true},
+		{"german missing package", "apt-missing.de.txt", `output_patterns: {lock: ["konnte nicht erlangt werden"]}`, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			runner := localizedRunner{fakeRunner: newFakeRunner(t), output: localeFixture(t, tc.fixture)}
+			i := newTestInstaller(t, tc.patterns+`
+lock_wait: 1ns
+tool_list: [evtool]
+tools:
+  evtool:
+    methods: [{name: apt, commands: ["sudo apt-get install -y evtool"]}]
+`, runner)
+			if err := i.Run(); err == nil {
+				t.Fatal("Run succeeded with a failing apt-get")
+			}
+			if locked := strings.Contains(i.report[0].Error, "package manager lock still held"); locked != tc.locked {
+				t.Errorf("error %q, want a held lock %v", i.report[0].Error, tc.locked)
+			}
+		})
+	}
+}
+
+func TestLocalizedVersionBanner(t *testing.T) {
+	for _, tc := range []struct {
+		patterns string
+		version  string
+	}{
+		{"", "2.11.0"},
+		{`output_patterns: {version: ['Versión (\d+\.\d+\.\d+)']}`, "3.4.5"},
+	} {
+		t.Run(tc.version, func(t *testing.T) {
+			runner := localizedRunner{fakeRunner: newFakeRunner(t, "evtool"), banner: localeFixture(t, "version.es.txt")}
+			i := newTestInstaller(t, tc.patterns+`
+tool_list: [evtool]
+tools:
+  evtool:
+    methods: [{name: fake, commands: ["install evtool"]}]
+`, runner)
+			if statuses := i.Check(context.Background(), i.config.ToolList); statuses[0].Version != tc.version {
+				t.Errorf("version = %q, want %s", statuses[0].Version, tc.version)
+			}
+		})
+	}
+}
+
+func TestProbesRunInTheCLocale(t *testing.T) {
+	sh, err := exec.LookPath("sh")
+	if err != nil {
+		t.Skip("no sh")
+	}
+	t.Setenv("LANG", "de_DE.UTF-8")
+	t.Setenv("LC_MESSAGES", "fr_FR.UTF-8")
+	t.Setenv("LANGUAGE", "de:fr")
+	output, err := localRunner{}.Output([]string{sh, "-c", `echo "$LANG|$LC_ALL|$LC_MESSAGES|$LANGUAGE"`})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := strings.TrimSpace(string(output)); got != "C|C||" {
+		t.Errorf("probe locale = %q, want LANG and LC_ALL set to C and nothing else", got)
+	}
+}
@@ -0,0 +1,138 @@
+package installer
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// defaultLockWait is how long commands are retried while a package manager lock is held
+const defaultLockWait = 5 * time.Minute
+
+// Backoff between attempts while waiting for a package manager lock
+const (
+	lockRetryInitial = 5 * time.Second
+	lockRetryMax     = 30 * time.Second
+)
+
+// lockingCommands are package managers that take a system-wide lock
+var lockingCommands = map[string]bool{"apt": true, "apt-get": true, "dpkg": true, "dnf": true, "yum": true}
+
+// lockPatterns match package manager output when another process holds the lock
+var lockPatterns = []string{
+	"could not get lock",
+	"unable to acquire the dpkg frontend lock",
+	"unable to lock the administration directory",
+	"failed to obtain the transaction lock",
+	"another app is currently holding the yum lock",
+	"impossible d'obtenir le verrou", // apt under a French locale
+}
+
+// runCommand executes a single command, retrying it with backoff for up to lock_wait
+// while another process holds the package manager lock it needs
+func (i *Installer) runCommand(name, methodName, step string, parts, env []string) error {
+	parts = i.asUser(name, parts)
+	started := time.Now()
+	delay := lockRetryInitial
+	for {
+		locked, err := i.execCommand(name, methodName, step, parts, env)
+		if !locked {
+			return err
+		}
+		waited := time.Since(started)
+		if waited >= i.lockWait() {
+			return fmt.Errorf("%v: package manager lock still held after %s", err, waited.Round(time.Second))
+		}
+		i.log(execLog).Debug("package manager lock held, retrying", "tool", name, "delay", delay, "waited", waited.Round(time.Second))
+		i.tracer.event(name, "retry", map[string]interface{}{"installer.retry.reason": "package manager lock", "installer.retry.delay": delay.String()})
+		if err := i.waitForLock(name, methodName, started, delay); err != nil {
+			return err
+		}
+		delay = min(delay*2, lockRetryMax)
+	}
+}
+
+// waitForLock sleeps for delay while showing how long the lock has been waited for
+func (i *Installer) waitForLock(name, methodName string, started time.Time, delay time.Duration) error {
+	waiting := func() string {
+		return fmt.Sprintf("waiting for package manager lock (%s)", time.Since(started).Round(time.Second))
+	}
+	progress := i.startProgress(name, methodName, waiting())
+	defer progress.stop()
+	for deadline := time.Now().Add(delay); time.Now().Before(deadline); {
+		select {
+		case <-i.toolContext(name).Done():
+			// The tool's install_timeout cancels the wait as well as an interrupt
+			if err := i.toolTimedOut(name); err != nil {
+				return err
+			}
+			return ErrInterrupted
+		case <-time.After(time.Second):
+		}
+		progress.update(waiting())
+	}
+	return nil
+}
+
+// lockWait returns how long to wait for a held package manager lock
+func (i *Installer) lockWait() time.Duration {
+	if wait, err := time.ParseDuration(i.config.LockWait); err == nil {
+		return wait
+	}
+	return defaultLockWait
+}
+
+// usesPackageLock reports whether a command runs a package manager that takes a system-wide
+// lock, looking past sudo, env and their options and assignments
+func usesPackageLock(parts []string) bool {
+	n := commandIndex(parts)
+	return n >= 0 && lockingCommands[filepath.Base(parts[n])]
+}
+
+// wrapperValueFlags are the options of sudo and env that take the next word as their value
+var wrapperValueFlags = map[string]map[string]bool{
+	"sudo": {
+		"-u": true, "--user": true, "-g": true, "--group": true, "-U": true, "--other-user": true,
+		"-C": true, "--close-from": true, "-D": true, "--chdir": true, "-p": true, "--prompt": true,
+		"-r": true, "--role": true, "-t": true, "--type": true, "-T": true, "--command-timeout": true,
+	},
+	"env": {"-u": true, "--unset": true, "-C": true, "--chdir": true, "-S": true, "--split-string": true},
+}
+
+// commandIndex returns the index of the command a command line runs, looking past sudo, env,
+// their options with the values of those that take one, and assignments. It returns -1 when
+// the line only runs the wrappers.
+func commandIndex(parts []string) int {
+	wrapper := ""
+	for n := 0; n < len(parts); n++ {
+		part := parts[n]
+		switch base := filepath.Base(part); {
+		case base == "sudo" || base == "env":
+			wrapper = base
+		case strings.HasPrefix(part, "-"):
+			// A value flag may end a group of short flags, as in sudo -Eu user
+			flags := wrapperValueFlags[wrapper]
+			if flags[part] || !strings.HasPrefix(part, "--") && flags["-"+part[len(part)-1:]] {
+				n++
+			}
+		case strings.Contains(part, "="):
+		default:
+			return n
+		}
+	}
+	return -1
+}
+
+// isLockError reports whether a line of output says the package manager lock is held,
+// also matching the lock patterns of output_patterns
+func (i *Installer) isLockError(line string) bool {
+	line = strings.ToLower(line)
+	for _, pattern := range slices.Concat(lockPatterns, i.config.Patterns.Lock) {
+		if strings.Contains(line, strings.ToLower(pattern)) {
+			return true
+		}
+	}
+	return false
+}
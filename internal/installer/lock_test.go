@@ -0,0 +1,72 @@
+package installer
+
+import (
+	"context"
+	"errors"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUsesPackageLock(t *testing.T) {
+	for command, want := range map[string]bool{
+		"apt-get install -y jq":                                      true,
+		"sudo apt-get install -y jq":                                 true,
+		"sudo -u builder apt-get install -y jq":                      true,
+		"sudo -u builder -H --preserve-env=PATH -- apt-get install":  true,
+		"sudo -Eu builder dnf install -y jq":                         true,
+		"sudo --user builder /usr/bin/apt install jq":                true,
+		"env -u LANG DEBIAN_FRONTEND=noninteractive apt-get install": true,
+		"sudo -u apt ls /var/lib/apt":                                false,
+		"sudo -H go install example.com/tool@latest":                 false,
+		"sudo -u builder":                                            false,
+	} {
+		if got := usesPackageLock(strings.Fields(command)); got != want {
+			t.Errorf("usesPackageLock(%q) = %v, want %v", command, got, want)
+		}
+	}
+}
+
+func TestPackageInstallLooksPastSudoOptions(t *testing.T) {
+	manager, packages := packageInstall(strings.Fields("sudo -u builder -H -- apt-get install -y jq ripgrep=14.1.0-1"))
+	if manager != "apt-get" || !slices.Equal(packages, []string{"jq", "ripgrep"}) {
+		t.Errorf("packageInstall = %q, %q; want apt-get installing jq and ripgrep", manager, packages)
+	}
+}
+
+// lockedRunner fails every command with apt's lock error
+type lockedRunner struct {
+	*fakeRunner
+}
+
+func (r lockedRunner) Run(ctx context.Context, argv, env []string, out io.Writer) error {
+	r.fakeRunner.Run(ctx, argv, env, out)
+	io.WriteString(out, "E: Could not get lock /var/lib/dpkg/lock-frontend. It is held by process 1234 (unattended-upgr)\n")
+	return errors.New("exit status 100")
+}
+
+func TestInstallTimeoutEndsTheWaitForALock(t *testing.T) {
+	i := newTestInstaller(t, `
+lock_wait: 1m
+tool_list: [evtool]
+tools:
+  evtool:
+    install_timeout: 100ms
+    methods: [{name: apt, commands: ["sudo apt-get install -y evtool"]}]
+`, lockedRunner{newFakeRunner(t)})
+	stop := i.startToolTimeout("evtool", i.config.Tools["evtool"])
+	defer stop()
+	i.setToolPosition("evtool", "apt", "")
+
+	start := time.Now()
+	err := i.runCommand("evtool", "apt", "", strings.Fields("sudo apt-get install -y evtool"), nil)
+	var timeout *toolTimeoutError
+	if !errors.As(err, &timeout) || err.Error() != "tool timeout after 100ms (was on method 'apt')" {
+		t.Fatalf("runCommand error = %v, want the tool timeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Errorf("runCommand took %v, want the wait ended by the 100ms install_timeout", elapsed)
+	}
+}
@@ -0,0 +1,59 @@
+package installer
+
+import (
+	"context"
+	"log/slog"
+	"os"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/debug"
+)
+
+// component is one of the installer's components that log debug records
+type component struct {
+	name  string
+	debug *slog.Logger // Debug logger of the component, used without WithLogger
+}
+
+// Components of the installer
+var (
+	execLog    = component{"exec", debug.Logger("exec")}
+	versionLog = component{"version", debug.Logger("version")}
+	planLog    = component{"plan", debug.Logger("plan")}
+	httpLog    = component{"http", debug.Logger("http")}
+)
+
+// log returns the logger of a component: the WithLogger logger with a component attribute,
+// or the component's debug logger
+func (i *Installer) log(c component) *slog.Logger {
+	if i.logger != nil {
+		return i.logger.With("component", c.name)
+	}
+	return c.debug
+}
+
+// debugging reports whether logger writes output, to skip building costly attributes
+func debugging(logger *slog.Logger) bool {
+	return logger.Enabled(context.Background(), slog.LevelDebug)
+}
+
+// redactArgs hides secret values in a command line
+func (i *Installer) redactArgs(parts []string) []string {
+	redacted := make([]string, len(parts))
+	for n, part := range parts {
+		redacted[n] = i.redact(part)
+	}
+	return redacted
+}
+
+// debugEnv returns the environment a process runs with, redacted for logging. A nil env
+// inherits the installer's environment.
+func (i *Installer) debugEnv(env []string) []string {
+	if env == nil {
+		env = os.Environ()
+	}
+	redacted := make([]string, len(env))
+	for n, kv := range env {
+		redacted[n] = i.redact(redactEnv(kv))
+	}
+	return redacted
+}
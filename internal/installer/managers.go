@@ -0,0 +1,182 @@
+package installer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/colors"
+	"github.com/Abhaythakor/dev-tools-installer/internal/config"
+	"github.com/Abhaythakor/dev-tools-installer/internal/version"
+)
+
+// versionManager reports whether a method type delegates to a version manager, mise or asdf
+func versionManager(methodType string) bool {
+	return methodType == config.MethodMise || methodType == config.MethodAsdf
+}
+
+// shimsDir returns the directory mise or asdf puts the shims of the tools it manages in, or ""
+// for other method types
+func shimsDir(methodType string) string {
+	switch methodType {
+	case config.MethodMise:
+		if dir := os.Getenv("MISE_DATA_DIR"); dir != "" {
+			return filepath.Join(dir, "shims")
+		}
+		if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+			return filepath.Join(dir, "mise", "shims")
+		}
+		return expandHome("~/.local/share/mise/shims")
+	case config.MethodAsdf:
+		if dir := os.Getenv("ASDF_DATA_DIR"); dir != "" {
+			return filepath.Join(dir, "shims")
+		}
+		return expandHome("~/.asdf/shims")
+	}
+	return ""
+}
+
+// managerPlugin returns the tool of a mise method or the plugin of an asdf method
+func managerPlugin(name string, method config.InstallMethod) string {
+	if method.Plugin != "" {
+		return method.Plugin
+	}
+	return name
+}
+
+// renderManagerCommands builds the commands of a mise or asdf method. mise installs the
+// version and makes it the global one in one command; asdf installs it and then sets it in
+// ~/.tool-versions, with set -u from asdf 0.16 on and global before. Without a version the
+// latest one is installed.
+func renderManagerCommands(methodType, bin, plugin, version string, legacy bool) [][]string {
+	if version == "" {
+		version = "latest"
+	}
+	if methodType == config.MethodMise {
+		return [][]string{{bin, "use", "-g", plugin + "@" + version}}
+	}
+	global := []string{bin, "set", "-u", plugin, version}
+	if legacy {
+		global = []string{bin, "global", plugin, version}
+	}
+	return [][]string{{bin, "install", plugin, version}, global}
+}
+
+// runVersionManager installs a tool through mise or asdf, adding the asdf plugin first when
+// it is missing and pinning the latest version asdf knows when no version is set
+func (i *Installer) runVersionManager(name string, method config.InstallMethod, bin, version string, env []string) error {
+	plugin := managerPlugin(name, method)
+	legacy := false
+	if method.Type == config.MethodAsdf {
+		legacy = i.asdfLegacy(bin)
+		if !i.asdfPluginAdded(bin, plugin) {
+			i.printf("%s│%s 🔌 Adding asdf plugin %s for %s%s\n", colors.Blue, colors.Yellow, plugin, name, colors.Reset)
+			if err := i.runCommand(name, method.Name, "plugin", []string{bin, "plugin", "add", plugin}, env); err != nil {
+				return fmt.Errorf("asdf plugin add %s failed: %v", plugin, err)
+			}
+		}
+		if version == "" {
+			output, err := i.commands().Output([]string{bin, "latest", plugin})
+			i.log(execLog).Debug("asdf latest", "plugin", plugin, "error", err, "output", string(output))
+			if latest := strings.TrimSpace(string(output)); err == nil && latest != "" && !strings.ContainsAny(latest, " \n") {
+				version = latest
+			}
+		}
+	}
+
+	commands := renderManagerCommands(method.Type, bin, plugin, version, legacy)
+	for n, argv := range commands {
+		step := ""
+		if len(commands) > 1 {
+			step = fmt.Sprintf("step %d/%d", n+1, len(commands))
+		}
+		if i.verbose() {
+			i.printf("%s│   %s%s%s\n", colors.Blue, colors.Gray, strings.Join(argv, " "), colors.Reset)
+		}
+		if err := i.runCommand(name, method.Name, step, argv, env); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// asdfLegacy reports whether asdf predates 0.16, which replaced asdf global with asdf set
+func (i *Installer) asdfLegacy(bin string) bool {
+	output, err := i.commands().Output([]string{bin, "--version"})
+	if err != nil {
+		return false
+	}
+	c, err := version.Compare(i.extractVersion(string(output), nil), "0.16.0")
+	return err == nil && c < 0
+}
+
+// asdfPluginAdded reports whether asdf has a plugin
+func (i *Installer) asdfPluginAdded(bin, plugin string) bool {
+	output, err := i.commands().Output([]string{bin, "plugin", "list"})
+	if err != nil {
+		return false
+	}
+	for _, line := range strings.Split(string(output), "\n") {
+		if strings.TrimSpace(line) == plugin {
+			return true
+		}
+	}
+	return false
+}
+
+// describeManager renders the commands of a mise or asdf method, for display only
+func (i *Installer) describeManager(name string, method config.InstallMethod, version string) []string {
+	chain := toolchains[method.Type]
+	bin := chain.command
+	legacy := false
+	if method.Type == config.MethodAsdf {
+		if found, err := i.findToolchain(chain); err == nil {
+			legacy = i.asdfLegacy(found)
+		}
+	}
+	var lines []string
+	plugin := managerPlugin(name, method)
+	if method.Type == config.MethodAsdf {
+		lines = append(lines, fmt.Sprintf("%s plugin add %s (unless added)", bin, plugin))
+	}
+	for _, argv := range renderManagerCommands(method.Type, bin, plugin, version, legacy) {
+		lines = append(lines, strings.Join(argv, " "))
+	}
+	return lines
+}
+
+// realBinary returns the binary a mise or asdf shim runs, found with mise which or asdf
+// which, so that versions are probed from the tool rather than from the shim, which can
+// answer --version with the version of the manager. Other commands are returned unchanged.
+func (i *Installer) realBinary(bin string) string {
+	if i.Options.Root != "" {
+		return bin
+	}
+	path := bin
+	if !strings.ContainsAny(bin, `/\`) {
+		found, err := i.commands().LookPath(bin)
+		if err != nil {
+			return bin
+		}
+		path = found
+	}
+	dir := filepath.Clean(filepath.Dir(path))
+	for _, methodType := range []string{config.MethodMise, config.MethodAsdf} {
+		if dir != filepath.Clean(shimsDir(methodType)) {
+			continue
+		}
+		manager, err := i.findToolchain(toolchains[methodType])
+		if err != nil {
+			return bin
+		}
+		output, err := i.commands().Output([]string{manager, "which", filepath.Base(path)})
+		real, _, _ := strings.Cut(strings.TrimSpace(string(output)), "\n")
+		i.log(versionLog).Debug("shim", "path", path, "manager", methodType, "binary", real, "error", err)
+		if err == nil && filepath.IsAbs(real) {
+			return real
+		}
+		return bin
+	}
+	return bin
+}
@@ -0,0 +1,221 @@
+package installer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/colors"
+)
+
+// meterInterval is how often a download's progress is redrawn and reported
+const meterInterval = 100 * time.Millisecond
+
+// downloadMeter renders the progress of a download and reports it as events
+type downloadMeter struct {
+	i       *Installer
+	name    string
+	method  string
+	url     string
+	file    string
+	live    bool // Redraw a progress bar in place instead of printing milestone lines
+	started time.Time
+	updated time.Time
+	offset  int64 // Bytes that were already downloaded when a download resumed
+	done    int64
+	total   int64
+	printed int64 // Last milestone printed in non-live mode
+}
+
+// download fetches url, or a mirror of it, for a tool while showing its progress, returning
+// the temporary file
+func (i *Installer) download(name, methodName, url string, headers map[string]string) (string, error) {
+	if i.replaying() {
+		return "", fmt.Errorf("replay: downloads are not recorded")
+	}
+	if err := i.startDownload(); err != nil {
+		return "", err
+	}
+	dir := ""
+	if i.tempDir != "" {
+		dir = i.toolTempDir(name)
+	}
+	var file string
+	err := i.withMirrors(name, url, func(url string) error {
+		release, err := i.acquireDownload(name, methodName)
+		if err != nil {
+			return err
+		}
+		defer release()
+
+		meter := i.newMeter(name, methodName, url)
+		file, err = downloadFile(i.log(httpLog), dir, url, headers, i.limiter, meter.update)
+		meter.finish(err == nil)
+		return err
+	})
+	return file, err
+}
+
+// downloadCached fetches url like download, but into the download cache so that a failed
+// download resumes on the next attempt. It also reports whether the download resumed.
+func (i *Installer) downloadCached(name, methodName, url string, headers map[string]string) (string, bool, error) {
+	if i.replaying() {
+		return "", false, fmt.Errorf("replay: downloads are not recorded")
+	}
+	if err := i.startDownload(); err != nil {
+		return "", false, err
+	}
+	if err := os.MkdirAll(i.downloadCacheDir(), 0755); err != nil {
+		return "", false, fmt.Errorf("failed to create download cache: %v", err)
+	}
+
+	var file string
+	var resumed bool
+	err := i.withMirrors(name, url, func(url string) error {
+		// Tools downloading the same URL in parallel must not share a partial file
+		sum := sha256.Sum256([]byte(name + "\n" + url))
+		file = filepath.Join(i.downloadCacheDir(), hex.EncodeToString(sum[:8])+"-"+path.Base(url))
+
+		release, err := i.acquireDownload(name, methodName)
+		if err != nil {
+			return err
+		}
+		defer release()
+
+		meter := i.newMeter(name, methodName, url)
+		offset, err := resumeFile(i.log(httpLog), file, url, headers, i.limiter, meter.resume, meter.update)
+		resumed = offset > 0
+		meter.finish(err == nil)
+		return err
+	})
+	return file, resumed, err
+}
+
+// newMeter creates the meter of a download of url
+func (i *Installer) newMeter(name, methodName, url string) *downloadMeter {
+	return &downloadMeter{
+		i:       i,
+		name:    name,
+		method:  methodName,
+		url:     url,
+		file:    path.Base(url),
+		live:    i.liveOutput(),
+		started: time.Now(),
+		total:   -1,
+	}
+}
+
+// liveOutput reports whether progress can be redrawn in place
+func (i *Installer) liveOutput() bool {
+	if i.renderer != nil {
+		return i.renderer.Live()
+	}
+	tty, _ := i.display().current()
+	return tty
+}
+
+// update records the bytes downloaded so far, redrawing at most every meterInterval
+func (m *downloadMeter) update(done, total int64) {
+	m.done, m.total = done, total
+	if now := time.Now(); now.Sub(m.updated) >= meterInterval {
+		m.updated = now
+		m.report(false)
+	}
+}
+
+// resume records that a download continues after offset bytes of total and says so
+func (m *downloadMeter) resume(offset, total int64) {
+	m.offset, m.done, m.total = offset, offset, total
+	if total > 0 {
+		m.printed = offset * 10 / total
+		m.i.printf("%s│   %s%s: resuming at %s/%s%s\n", colors.Blue, colors.Gray, m.file, formatBytes(offset), formatBytes(total), colors.Reset)
+		return
+	}
+	m.i.printf("%s│   %s%s: resuming at %s%s\n", colors.Blue, colors.Gray, m.file, formatBytes(offset), colors.Reset)
+}
+
+// finish reports the final state of the download and clears its progress bar
+func (m *downloadMeter) finish(ok bool) {
+	if ok {
+		m.report(true)
+	}
+	m.i.tracer.set(m.name, "installer.download.bytes", m.done-m.offset)
+	size := int64(0)
+	if ok {
+		size = max(m.total, m.done)
+	}
+	m.i.countDownload(m.name, m.url, m.done-m.offset, size)
+	if m.live && m.i.renderer == nil {
+		clearProgressLine(m.i.display())
+	}
+}
+
+// report draws the progress bar or prints the next milestone, and emits an event
+func (m *downloadMeter) report(final bool) {
+	progress := m.progress(final)
+	m.i.emit(Event{Type: EventDownload, Tool: m.name, Method: m.method, Download: progress})
+
+	switch {
+	case final && m.live:
+	case m.live && m.i.renderer != nil:
+		m.i.renderer.Step(m.name, m.method, m.describe(progress))
+	case m.live:
+		fmt.Fprintf(m.i.display(), "\r%s│ %sInstalling %s (%s): %s%s%s", colors.Blue, colors.Yellow, m.name, m.method, m.describe(progress), clearLine, colors.Reset)
+	case m.total > 0:
+		// Print a line every 10%
+		if decile := m.done * 10 / m.total; decile > m.printed {
+			m.printed = decile
+			m.i.printf("%s│   %s%s: %d%% of %s%s\n", colors.Blue, colors.Gray, m.file, decile*10, formatBytes(m.total), colors.Reset)
+		}
+	default:
+		// Without a total, print a line every 10 MiB
+		if step := m.done / (10 << 20); step > m.printed || final {
+			m.printed = step
+			m.i.printf("%s│   %s%s: %s downloaded%s\n", colors.Blue, colors.Gray, m.file, formatBytes(m.done), colors.Reset)
+		}
+	}
+}
+
+// progress returns the download's current numbers
+func (m *downloadMeter) progress(final bool) *DownloadProgress {
+	p := &DownloadProgress{File: m.file, Bytes: m.done, Total: m.total, Done: final}
+	if elapsed := time.Since(m.started).Seconds(); elapsed > 0 {
+		p.Rate = float64(m.done-m.offset) / elapsed
+	}
+	if m.total > 0 && p.Rate > 0 {
+		p.ETA = time.Duration(float64(m.total-m.done) / p.Rate * float64(time.Second))
+	}
+	return p
+}
+
+// describe renders a progress bar, or a byte counter when the total is unknown
+func (m *downloadMeter) describe(p *DownloadProgress) string {
+	if p.Total <= 0 {
+		return fmt.Sprintf("downloading %s %s (%s/s)", p.File, formatBytes(p.Bytes), formatBytes(int64(p.Rate)))
+	}
+	const width = 20
+	filled := int(p.Bytes * width / p.Total)
+	bar := strings.Repeat("█", filled) + strings.Repeat("░", width-filled)
+	return fmt.Sprintf("downloading %s %s %3d%% %s/%s (%s/s, ETA %s)",
+		p.File, bar, p.Bytes*100/p.Total, formatBytes(p.Bytes), formatBytes(p.Total),
+		formatBytes(int64(p.Rate)), p.ETA.Round(time.Second))
+}
+
+// formatBytes renders a byte count with a binary unit
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,356 @@
+package installer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/colors"
+	"github.com/Abhaythakor/dev-tools-installer/internal/config"
+)
+
+// nodeBootstrapVersion is the Node.js release installed when npm is bootstrapped
+const nodeBootstrapVersion = "22.12.0"
+
+// toolchain describes the command a typed method needs and where it lands when bootstrapped
+type toolchain struct {
+	command string
+	dirs    []string // Directories searched when the command is not on PATH
+}
+
+// toolchains maps typed methods to their toolchain
+var toolchains = map[string]toolchain{
+	config.MethodCargo: {command: "cargo", dirs: []string{"~/.cargo/bin"}},
+	config.MethodPipx:  {command: "pipx", dirs: []string{"~/.local/bin"}},
+	config.MethodNpm:   {command: "npm", dirs: []string{"~/.local/node/bin"}},
+	config.MethodMise:  {command: "mise", dirs: []string{"~/.local/bin"}},
+	config.MethodAsdf:  {command: "asdf", dirs: []string{"~/.asdf/bin"}},
+}
+
+// toolChannel returns the channel a tool installs from: --channel, its default_channel or
+// stable
+func (i *Installer) toolChannel(toolConfig *config.ToolConfig) string {
+	switch {
+	case i.Options.Channel != "":
+		return i.Options.Channel
+	case toolConfig != nil && toolConfig.DefaultChannel != "":
+		return toolConfig.DefaultChannel
+	}
+	return config.ChannelStable
+}
+
+// recordedChannel returns the channel recorded for an install of a tool, empty for tools
+// that don't use channels
+func (i *Installer) recordedChannel(toolConfig *config.ToolConfig) string {
+	labeled := slices.ContainsFunc(toolConfig.Methods, func(method config.InstallMethod) bool { return method.Channel != "" })
+	if i.Options.Channel == "" && toolConfig.DefaultChannel == "" && !labeled {
+		return ""
+	}
+	return i.toolChannel(toolConfig)
+}
+
+// orderedMethods returns the tool's methods on its channel in the order they are tried: by
+// their position in --prefer and then preferred_methods, then methods labeled with the
+// channel ahead of unlabeled ones, then by priority, keeping the YAML order for ties
+func (i *Installer) orderedMethods(toolConfig *config.ToolConfig) []config.InstallMethod {
+	preferred := append(append([]string{}, i.Options.Prefer...), i.config.Preferred...)
+	rank := func(method config.InstallMethod) int {
+		for n, pref := range preferred {
+			if strings.EqualFold(pref, method.Name) || strings.EqualFold(pref, method.Type) {
+				return n
+			}
+		}
+		return len(preferred)
+	}
+
+	channel := i.toolChannel(toolConfig)
+	var methods []config.InstallMethod
+	for _, method := range toolConfig.Methods {
+		if method.OnChannel(channel) {
+			methods = append(methods, method)
+		}
+	}
+	sort.SliceStable(methods, func(a, b int) bool {
+		if ra, rb := rank(methods[a]), rank(methods[b]); ra != rb {
+			return ra < rb
+		}
+		if la, lb := methods[a].Channel != "", methods[b].Channel != ""; la != lb {
+			return la
+		}
+		return methods[a].Priority > methods[b].Priority
+	})
+	return methods
+}
+
+// methodRequirements checks a method's requires list. It returns the requirements that
+// cannot be met, and the tools to install first for those other tools provide.
+func (i *Installer) methodRequirements(name string, method config.InstallMethod) (missing, providers []string) {
+	for _, command := range method.Requires {
+		if _, err := i.commands().LookPath(command); err == nil {
+			continue
+		}
+		provider := i.config.Provider(command)
+		if provider == "" || provider == name || len(i.config.Tools[provider].Methods) == 0 {
+			missing = append(missing, command+" (not found)")
+			continue
+		}
+		if !slices.Contains(providers, provider) {
+			providers = append(providers, provider)
+		}
+	}
+	return missing, providers
+}
+
+// installRequirement installs a tool a method of another tool requires
+func (i *Installer) installRequirement(name, method, provider string) error {
+	i.mu.Lock()
+	if i.requiring == nil {
+		i.requiring = map[string]bool{}
+	}
+	cycle := i.requiring[provider]
+	i.requiring[provider] = true
+	i.mu.Unlock()
+	if cycle {
+		return fmt.Errorf("%s is already being installed for another requirement", provider)
+	}
+	defer func() {
+		i.mu.Lock()
+		delete(i.requiring, provider)
+		i.mu.Unlock()
+	}()
+
+	i.printf("%s│%s 📦 Installing %s, required by the %s method of %s%s\n", colors.Blue, colors.Yellow, provider, method, name, colors.Reset)
+	if err := i.installTool(provider); err != nil {
+		return fmt.Errorf("failed to install required %s: %v", provider, err)
+	}
+	return nil
+}
+
+// toolchainMu serializes toolchain lookups and bootstraps
+var toolchainMu sync.Mutex
+
+// runTypedMethod installs a tool through a cargo, pipx, npm, mise or asdf method
+func (i *Installer) runTypedMethod(name string, toolConfig *config.ToolConfig, method config.InstallMethod) error {
+	chain, ok := toolchains[method.Type]
+	if !ok {
+		return fmt.Errorf("unknown method type %q", method.Type)
+	}
+
+	bin, err := i.ensureToolchain(name, chain, method)
+	if err != nil {
+		return err
+	}
+
+	version := methodVersion(toolConfig, method)
+	vars := i.commandVars(name, version, i.toolBinDir(name))
+	env, err := i.commandEnv(method, vars)
+	if err != nil {
+		return err
+	}
+	i.printEnv(method, vars)
+	if versionManager(method.Type) {
+		if err := i.runVersionManager(name, method, bin, strings.TrimPrefix(version, "v"), env); err != nil {
+			return err
+		}
+		// The tool is run through its shim
+		chain.dirs = append(slices.Clip(chain.dirs), shimsDir(method.Type))
+	} else {
+		parts := i.renderTypedCommand(method.Type, bin, expandVersion(method.Package, version), version)
+		if err := i.runCommand(name, method.Name, "", parts, env); err != nil {
+			return err
+		}
+	}
+
+	return i.verifyInstall(name, toolConfig, version, chain)
+}
+
+// ensureToolchain locates the toolchain of a typed method, bootstrapping it when allowed
+func (i *Installer) ensureToolchain(name string, chain toolchain, method config.InstallMethod) (string, error) {
+	// Parallel installs must not bootstrap the same toolchain twice
+	toolchainMu.Lock()
+	defer toolchainMu.Unlock()
+
+	bin, err := i.findToolchain(chain)
+	if err == nil {
+		return bin, nil
+	}
+	if !method.Bootstrap {
+		return "", fmt.Errorf("%s is not installed (set bootstrap: true to install it automatically)", chain.command)
+	}
+	i.printf("%s│%s 🔧 Bootstrapping %s for %s...%s\n", colors.Blue, colors.Yellow, chain.command, name, colors.Reset)
+	if err := i.bootstrapToolchain(name, method.Type); err != nil {
+		return "", fmt.Errorf("failed to bootstrap %s: %v", chain.command, err)
+	}
+	if bin, err = i.findToolchain(chain); err != nil {
+		return "", fmt.Errorf("%s still not found after bootstrap", chain.command)
+	}
+	return bin, nil
+}
+
+// methodVersion returns the version a typed method should install
+func methodVersion(toolConfig *config.ToolConfig, method config.InstallMethod) string {
+	if method.Version != "" {
+		return expandVersion(method.Version, toolConfig.Version)
+	}
+	return toolConfig.Version
+}
+
+// expandVersion substitutes ${version} in s
+func expandVersion(s, version string) string {
+	if version == "" {
+		return s
+	}
+	return strings.ReplaceAll(s, "${version}", version)
+}
+
+// renderTypedCommand builds the install command for a typed method
+func (i *Installer) renderTypedCommand(methodType, bin, pkg, version string) []string {
+	switch methodType {
+	case config.MethodCargo:
+		parts := []string{bin, "install", pkg, "--locked"}
+		if version != "" {
+			parts = append(parts, "--version", strings.TrimPrefix(version, "v"))
+		}
+		return parts
+	case config.MethodPipx:
+		if version != "" {
+			pkg += "==" + strings.TrimPrefix(version, "v")
+		}
+		return []string{bin, "install", "--force", pkg}
+	case config.MethodNpm:
+		if version != "" {
+			pkg += "@" + strings.TrimPrefix(version, "v")
+		}
+		parts := []string{bin, "install", "-g", pkg}
+		// Global installs into a root-owned prefix fail without sudo, so fall back to ~/.local
+		if runtime.GOOS != "windows" && !i.npmPrefixWritable(bin) {
+			parts = append(parts, "--prefix", expandHome("~/.local"))
+		}
+		return parts
+	}
+	return nil
+}
+
+// npmPrefixWritable reports whether npm's global prefix is writable by the current user
+func (i *Installer) npmPrefixWritable(bin string) bool {
+	output, err := i.commands().Output([]string{bin, "config", "get", "prefix"})
+	if err != nil {
+		return false
+	}
+	dir := filepath.Join(strings.TrimSpace(string(output)), "lib")
+	f, err := os.CreateTemp(dir, ".write-test-")
+	if err != nil {
+		return false
+	}
+	f.Close()
+	os.Remove(f.Name())
+	return true
+}
+
+// findToolchain locates a toolchain command on PATH or in its bootstrap directories
+func (i *Installer) findToolchain(chain toolchain) (string, error) {
+	if path, err := i.commands().LookPath(chain.command); err == nil {
+		return path, nil
+	}
+	for _, dir := range chain.dirs {
+		path := filepath.Join(expandHome(dir), chain.command)
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("%s not found", chain.command)
+}
+
+// bootstrapToolchain installs the toolchain needed by a typed method
+func (i *Installer) bootstrapToolchain(name, methodType string) error {
+	// Bootstraps download toolchains and share the download slots; Node.js takes its slot
+	// in download
+	if methodType != config.MethodNpm {
+		release, err := i.acquireDownload(name, "bootstrap")
+		if err != nil {
+			return err
+		}
+		defer release()
+	}
+
+	switch methodType {
+	case config.MethodCargo:
+		return i.runCommand(name, "rustup", "", []string{"sh", "-c",
+			"curl --proto '=https' --tlsv1.2 -sSf https://sh.rustup.rs | sh -s -- -y --no-modify-path"}, nil)
+	case config.MethodPipx:
+		python, err := i.commands().LookPath("python3")
+		if err != nil {
+			return fmt.Errorf("python3 is required to bootstrap pipx")
+		}
+		return i.runCommand(name, "pip", "", []string{python, "-m", "pip", "install", "--user", "pipx"}, nil)
+	case config.MethodNpm:
+		return i.bootstrapNode(name)
+	case config.MethodMise:
+		return i.runCommand(name, "mise", "", []string{"sh", "-c", "curl -fsSL https://mise.run | sh"}, nil)
+	}
+	return fmt.Errorf("no bootstrap available for %s", methodType)
+}
+
+// bootstrapNode downloads the official Node.js release into ~/.local/node
+func (i *Installer) bootstrapNode(name string) error {
+	arch := map[string]string{"amd64": "x64", "arm64": "arm64"}[runtime.GOARCH]
+	if arch == "" || (runtime.GOOS != "linux" && runtime.GOOS != "darwin") {
+		return fmt.Errorf("no Node.js release for %s/%s", runtime.GOOS, runtime.GOARCH)
+	}
+
+	release := fmt.Sprintf("node-v%s-%s-%s", nodeBootstrapVersion, runtime.GOOS, arch)
+	url := fmt.Sprintf("https://nodejs.org/dist/v%s/%s.tar.gz", nodeBootstrapVersion, release)
+
+	archive, err := i.download(name, "node", url, nil)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(archive)
+
+	dest := expandHome("~/.local/node")
+	if err := os.RemoveAll(dest); err != nil {
+		return err
+	}
+	return extractTarGz(archive, dest, 1)
+}
+
+// verifyInstall checks that the tool is resolvable and reports the expected version
+func (i *Installer) verifyInstall(name string, toolConfig *config.ToolConfig, version string, chain toolchain) error {
+	name = i.toolCommand(name)
+	path, err := i.commands().LookPath(name)
+	if err != nil {
+		path, err = i.findToolchain(toolchain{command: name, dirs: append(chain.dirs, "~/.local/bin")})
+		if err != nil {
+			return fmt.Errorf("%s not found after install", name)
+		}
+		i.printf("%s│%s ⚠ %s installed to %s, which is not on PATH%s\n", colors.Blue, colors.Yellow, name, filepath.Dir(path), colors.Reset)
+	}
+
+	if version == "" {
+		return nil
+	}
+	detected := i.detectVersion(path, toolConfig.VersionFlag)
+	if detected == "" {
+		i.printf("%s│%s ⚠ Could not verify %s version%s\n", colors.Blue, colors.Yellow, name, colors.Reset)
+		return nil
+	}
+	if !i.versionsMatch(detected, version) {
+		return fmt.Errorf("installed version %s, expected %s", detected, version)
+	}
+	return nil
+}
+
+// expandHome replaces a leading ~ with the user's home directory
+func expandHome(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home := homeDir(); home != "" {
+			return filepath.Join(home, path[1:])
+		}
+	}
+	return path
+}
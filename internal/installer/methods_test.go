@@ -0,0 +1,57 @@
+package installer
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// versionFlagRunner is a fakeRunner with cargo installed, whose tools print their version
+// 1.2.0 for --print-version and usage mentioning another version for other flags
+type versionFlagRunner struct {
+	*fakeRunner
+}
+
+func (r versionFlagRunner) Output(argv []string) ([]byte, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(argv) != 2 || filepath.Dir(argv[0]) != r.bin || !r.installed[filepath.Base(argv[0])] {
+		return nil, errors.New("exit status 127")
+	}
+	if argv[1] == "--print-version" {
+		return []byte(filepath.Base(argv[0]) + " 1.2.0\n"), nil
+	}
+	return []byte("usage: evtool [--print-version] (built with libfetch 3.4.5)\n"), nil
+}
+
+func (r versionFlagRunner) Run(ctx context.Context, argv, env []string, out io.Writer) error {
+	if len(argv) > 2 && argv[0] == filepath.Join(r.bin, "cargo") && argv[1] == "install" {
+		argv = []string{"install", argv[2]}
+	}
+	return r.fakeRunner.Run(ctx, argv, env, out)
+}
+
+func TestTypedMethodVerifiesTheVersionWithTheVersionFlag(t *testing.T) {
+	runner := versionFlagRunner{newFakeRunner(t, "cargo")}
+	i := newTestInstaller(t, `
+tool_list: [evtool]
+tools:
+  evtool:
+    version: 1.2.0
+    version_flag: --print-version
+    methods:
+      - {name: cargo, type: cargo, package: evtool}
+`, runner)
+	if err := i.Run(); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if want := "install evtool"; !slices.Contains(runner.commands(), want) {
+		t.Errorf("commands = %q, want %q", runner.commands(), want)
+	}
+	if result := i.report[0]; result.Status != statusInstalled || result.Version != "1.2.0" {
+		t.Errorf("report = %+v, want evtool installed at the version its version flag prints", result)
+	}
+}
@@ -0,0 +1,66 @@
+package installer
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/colors"
+	"github.com/Abhaythakor/dev-tools-installer/internal/config"
+)
+
+// unavailableError reports that a server could not be reached or failed with a 5xx status,
+// as opposed to rejecting the request
+type unavailableError struct {
+	err error
+}
+
+func (e *unavailableError) Error() string {
+	return e.err.Error()
+}
+
+// mirrorURLs returns the URLs to try for url: its rewrite by each matching mirror in
+// config order, followed by url itself
+func (i *Installer) mirrorURLs(url string) []string {
+	var urls []string
+	for _, mirror := range i.config.Mirrors {
+		if strings.HasPrefix(url, mirror.Prefix) {
+			urls = append(urls, mirror.URL+strings.TrimPrefix(url, mirror.Prefix))
+		}
+	}
+	return append(urls, url)
+}
+
+// withMirrors calls fetch with each URL to try for url until one succeeds, moving on to the
+// next only when a mirror is unavailable
+func (i *Installer) withMirrors(name, url string, fetch func(url string) error) error {
+	urls := i.mirrorURLs(url)
+	var err error
+	for n, candidate := range urls {
+		if err = fetch(candidate); err == nil {
+			return nil
+		}
+		var unavailable *unavailableError
+		if n == len(urls)-1 || !errors.As(err, &unavailable) {
+			return err
+		}
+		i.printf("%s│%s ⚠ %v; trying %s%s\n", colors.Blue, colors.Yellow, err, urls[n+1], colors.Reset)
+		i.tracer.event(name, "retry", map[string]interface{}{"installer.retry.reason": "mirror unavailable", "url.full": urls[n+1]})
+	}
+	return err
+}
+
+// downloadURLs returns the URLs a download, github_release or script method fetches, before
+// mirrors are applied. Release lookups are represented by the latest release on the stable
+// channel, and release assets by their download prefix. A url with a broken reference has
+// none, since the method fails before it fetches anything.
+func downloadURLs(method config.InstallMethod, vars map[string]string) []string {
+	switch method.Type {
+	case config.MethodDownload, config.MethodScript:
+		if url, err := expandChecked(vars["TOOL_NAME"], "url", method.URL, vars); err == nil && url != "" {
+			return []string{url}
+		}
+	case config.MethodGithubRelease:
+		return []string{releaseAPI(method, vars, config.ChannelStable), "https://github.com/" + method.Repo + "/releases/download/"}
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+package installer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// mirrorConfig rewrites GitHub URLs to two mirrors
+const mirrorConfig = `
+mirrors:
+  - {prefix: "https://github.com/", url: "https://ghmirror.internal/"}
+  - {prefix: "https://github.com/example/", url: "https://cache.internal/example/"}
+tools: {}
+`
+
+func TestMirrorURLs(t *testing.T) {
+	i := New(loadTestConfig(t, mirrorConfig))
+	for _, tc := range []struct {
+		url  string
+		want []string
+	}{
+		{"https://github.com/example/evtool/releases/download/v1.0.0/evtool.tar.gz", []string{
+			"https://ghmirror.internal/example/evtool/releases/download/v1.0.0/evtool.tar.gz",
+			"https://cache.internal/example/evtool/releases/download/v1.0.0/evtool.tar.gz",
+			"https://github.com/example/evtool/releases/download/v1.0.0/evtool.tar.gz",
+		}},
+		{"https://github.com/other/tool.tar.gz", []string{"https://ghmirror.internal/other/tool.tar.gz", "https://github.com/other/tool.tar.gz"}},
+		{"https://dl.example.com/evtool.tar.gz", []string{"https://dl.example.com/evtool.tar.gz"}},
+	} {
+		if got := i.mirrorURLs(tc.url); !slices.Equal(got, tc.want) {
+			t.Errorf("mirrorURLs(%s) = %q, want %q", tc.url, got, tc.want)
+		}
+	}
+}
+
+func TestWithMirrors(t *testing.T) {
+	const url = "https://github.com/example/evtool.tar.gz"
+	unavailable := func(url string) error {
+		return &unavailableError{fmt.Errorf("failed to download %s: 503 Service Unavailable", url)}
+	}
+	for _, tc := range []struct {
+		name    string
+		fail    map[string]func(string) error
+		tried   []string
+		err     string
+		warning string
+	}{
+		{
+			name:  "first mirror",
+			tried: []string{"https://ghmirror.internal/example/evtool.tar.gz"},
+		},
+		{
+			name:    "unavailable mirror",
+			fail:    map[string]func(string) error{"https://ghmirror.internal/example/evtool.tar.gz": unavailable},
+			tried:   []string{"https://ghmirror.internal/example/evtool.tar.gz", "https://cache.internal/example/evtool.tar.gz"},
+			warning: "⚠ failed to download https://ghmirror.internal/example/evtool.tar.gz: 503 Service Unavailable; trying https://cache.internal/example/evtool.tar.gz",
+		},
+		{
+			name: "rejected request",
+			fail: map[string]func(string) error{"https://ghmirror.internal/example/evtool.tar.gz": func(url string) error {
+				return fmt.Errorf("failed to download %s: 404 Not Found", url)
+			}},
+			tried: []string{"https://ghmirror.internal/example/evtool.tar.gz"},
+			err:   "failed to download https://ghmirror.internal/example/evtool.tar.gz: 404 Not Found",
+		},
+		{
+			name: "all unavailable",
+			fail: map[string]func(string) error{
+				"https://ghmirror.internal/example/evtool.tar.gz": unavailable,
+				"https://cache.internal/example/evtool.tar.gz":    unavailable,
+				url: unavailable,
+			},
+			tried: []string{"https://ghmirror.internal/example/evtool.tar.gz", "https://cache.internal/example/evtool.tar.gz", url},
+			err:   "failed to download " + url + ": 503 Service Unavailable",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+			i := New(loadTestConfig(t, mirrorConfig), WithOutput(&out))
+			var tried []string
+			err := i.withMirrors("evtool", url, func(candidate string) error {
+				tried = append(tried, candidate)
+				if fail := tc.fail[candidate]; fail != nil {
+					return fail(candidate)
+				}
+				return nil
+			})
+			if (err == nil && tc.err != "") || (err != nil && err.Error() != tc.err) {
+				t.Errorf("withMirrors = %v, want %q", err, tc.err)
+			}
+			if !slices.Equal(tried, tc.tried) {
+				t.Errorf("tried %q, want %q", tried, tc.tried)
+			}
+			if tc.warning != "" && !strings.Contains(out.String(), tc.warning) {
+				t.Errorf("printed %q, want %q", out.String(), tc.warning)
+			}
+		})
+	}
+}
+
+func TestDownloadFallsBackFromAFailingMirror(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/mirror/") {
+			http.Error(w, "down for maintenance", http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprint(w, "evtool archive")
+	}))
+	defer server.Close()
+
+	i := New(loadTestConfig(t, fmt.Sprintf("mirrors: [{prefix: %q, url: %q}]\ntools: {}\n", server.URL+"/origin/", server.URL+"/mirror/")), WithOutput(&bytes.Buffer{}))
+	dir := t.TempDir()
+	var path string
+	err := i.withMirrors("evtool", server.URL+"/origin/evtool.tar.gz", func(url string) error {
+		var err error
+		path, err = downloadFile(i.log(execLog), dir, url, nil, nil, nil)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("withMirrors = %v, want the origin's download", err)
+	}
+	if data, err := os.ReadFile(path); err != nil || string(data) != "evtool archive" {
+		t.Errorf("downloaded %q (%v), want the origin's file", data, err)
+	}
+
+	// A mirror rejecting the request is not skipped, since the origin would reject it too
+	var unavailable *unavailableError
+	if err := statusError("failed to download", &http.Response{StatusCode: http.StatusForbidden, Status: "403 Forbidden"}); errors.As(err, &unavailable) {
+		t.Errorf("statusError(403) = %v, want an error that does not move on to the next mirror", err)
+	}
+}
@@ -0,0 +1,125 @@
+package installer
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+)
+
+// Option configures an Installer; see New and Apply
+type Option func(*Installer) error
+
+// Apply applies options to the installer in order, stopping at the first invalid one
+func (i *Installer) Apply(opts ...Option) error {
+	for _, opt := range opts {
+		if err := opt(i); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WithOutput sends what the installer prints to w instead of stdout. Output to anything
+// but a terminal has no spinners or live status lines.
+func WithOutput(w io.Writer) Option {
+	return func(i *Installer) error {
+		if w == nil {
+			return errors.New("WithOutput: writer is nil")
+		}
+		i.out = newTerminal(w, nil)
+		return nil
+	}
+}
+
+// WithDiagnostics sends diagnostics to w instead of stderr: the check table and progress of
+// runs whose output only lists the failed tools, see Options.PrintFailed
+func WithDiagnostics(w io.Writer) Option {
+	return func(i *Installer) error {
+		if w == nil {
+			return errors.New("WithDiagnostics: writer is nil")
+		}
+		i.diag = newTerminal(w, nil)
+		return nil
+	}
+}
+
+// WithTerminal sends what the installer prints to w like WithOutput, but renders it as for
+// a terminal of width columns and height rows, with spinners, lines redrawn in place and
+// cursor sequences, so that tests can compare it with golden output
+func WithTerminal(w io.Writer, width, height int) Option {
+	return func(i *Installer) error {
+		if w == nil {
+			return errors.New("WithTerminal: writer is nil")
+		}
+		if width <= 0 || height <= 0 {
+			return fmt.Errorf("WithTerminal: size %dx%d is not positive", width, height)
+		}
+		i.out = newTerminal(w, &virtualTerminal{width: width, height: height})
+		return nil
+	}
+}
+
+// WithRunner runs commands through r instead of on this machine
+func WithRunner(r CommandRunner) Option {
+	return func(i *Installer) error {
+		if r == nil {
+			return errors.New("WithRunner: runner is nil")
+		}
+		i.Options.Runner = r
+		return nil
+	}
+}
+
+// WithRoot installs into the system mounted at dir instead of this one, see Options.Root
+func WithRoot(dir string) Option {
+	return func(i *Installer) error {
+		if dir == "" {
+			return errors.New("WithRoot: directory is empty")
+		}
+		i.Options.Root = dir
+		return nil
+	}
+}
+
+// WithLogger sends debug records to l instead of the debug logger. Each record carries a
+// component attribute: exec, version, plan or http.
+func WithLogger(l *slog.Logger) Option {
+	return func(i *Installer) error {
+		if l == nil {
+			return errors.New("WithLogger: logger is nil")
+		}
+		i.logger = l
+		return nil
+	}
+}
+
+// WithEvents sends the progress events of runs to h
+func WithEvents(h Events) Option {
+	return func(i *Installer) error {
+		if h == nil {
+			return errors.New("WithEvents: receiver is nil")
+		}
+		i.Options.Events = h
+		return nil
+	}
+}
+
+// WithConcurrency installs up to n tools at once instead of one by one
+func WithConcurrency(n int) Option {
+	return func(i *Installer) error {
+		if n < 1 {
+			return fmt.Errorf("concurrency must be at least 1, got %d", n)
+		}
+		i.Options.Concurrency = n
+		return nil
+	}
+}
+
+// WithDryRun makes Run print the plan and the commands it would run instead of installing
+func WithDryRun() Option {
+	return func(i *Installer) error {
+		i.Options.DryRun = true
+		return nil
+	}
+}
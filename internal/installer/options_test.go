@@ -0,0 +1,55 @@
+package installer
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/config"
+)
+
+func TestOptionsAreValidated(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		opt  Option
+		err  string
+	}{
+		{"nil output", WithOutput(nil), "WithOutput: writer is nil"},
+		{"nil diagnostics", WithDiagnostics(nil), "WithDiagnostics: writer is nil"},
+		{"nil terminal", WithTerminal(nil, 80, 24), "WithTerminal: writer is nil"},
+		{"empty terminal", WithTerminal(&strings.Builder{}, 0, 24), "size 0x24 is not positive"},
+		{"nil runner", WithRunner(nil), "WithRunner: runner is nil"},
+		{"nil logger", WithLogger(nil), "WithLogger: logger is nil"},
+		{"nil events", WithEvents(nil), "WithEvents: receiver is nil"},
+		{"no concurrency", WithConcurrency(0), "concurrency must be at least 1, got 0"},
+		{"negative concurrency", WithConcurrency(-2), "concurrency must be at least 1, got -2"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			i := New(&config.InstallerConfig{})
+			if err := i.Apply(tc.opt); err == nil || !strings.Contains(err.Error(), tc.err) {
+				t.Errorf("Apply error = %v, want %q", err, tc.err)
+			}
+			defer func() {
+				if recover() == nil {
+					t.Error("New accepted the invalid option")
+				}
+			}()
+			New(&config.InstallerConfig{}, tc.opt)
+		})
+	}
+}
+
+func TestOptionsApplyInOrder(t *testing.T) {
+	var first, second strings.Builder
+	i := New(&config.InstallerConfig{}, WithOutput(&first), WithConcurrency(2), WithDryRun(), WithOutput(&second))
+	if i.Options.Concurrency != 2 || !i.Options.DryRun {
+		t.Errorf("Options = %+v, want concurrency 2 and a dry run", i.Options)
+	}
+	i.printf("hello\n")
+	if first.String() != "" || second.String() != "hello\n" {
+		t.Errorf("outputs = %q, %q; want the last WithOutput to win", first.String(), second.String())
+	}
+	// An invalid option stops Apply before the options after it
+	if err := i.Apply(WithConcurrency(3), WithRunner(nil), WithConcurrency(4)); err == nil || i.Options.Concurrency != 3 {
+		t.Errorf("Apply = %v with concurrency %d, want an error after setting 3", err, i.Options.Concurrency)
+	}
+}
@@ -0,0 +1,90 @@
+package installer
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/config"
+)
+
+// defaultOutputLimit is the output kept per command when output_limit is not set
+const defaultOutputLimit = 64 << 10
+
+// ansiEscape matches CSI sequences such as colors and cursor movement, OSC sequences such as
+// window titles, and two-character escapes
+var ansiEscape = regexp.MustCompile(`\x1b(\[[0-?]*[ -/]*[@-~]|\][^\x07\x1b]*(\x07|\x1b\\)|[@-Z\\-_])`)
+
+// sanitizeLine strips ANSI escapes from a line of command output and collapses progress
+// redrawn with carriage returns to its final state
+func sanitizeLine(line string) string {
+	line = strings.TrimRight(ansiEscape.ReplaceAllString(line, ""), "\r")
+	if n := strings.LastIndexByte(line, '\r'); n >= 0 {
+		line = line[n+1:]
+	}
+	return line
+}
+
+// outputBuffer keeps the sanitized output of a command within a size limit, holding on to
+// its first and last lines and dropping the middle
+type outputBuffer struct {
+	limit     int
+	head      []byte
+	tail      []byte
+	truncated int
+}
+
+// newOutputBuffer returns a buffer holding up to output_limit bytes
+func (i *Installer) newOutputBuffer() *outputBuffer {
+	limit, _ := config.ParseSize(i.config.OutputLimit)
+	if limit <= 0 {
+		limit = defaultOutputLimit
+	}
+	return &outputBuffer{limit: int(limit)}
+}
+
+// line appends a line of output
+func (b *outputBuffer) line(line string) {
+	data := line + "\n"
+	if room := b.limit/2 - len(b.head); room > 0 {
+		n := min(room, len(data))
+		b.head = append(b.head, data[:n]...)
+		data = data[n:]
+	}
+	b.tail = append(b.tail, data...)
+	if over := len(b.tail) - (b.limit - b.limit/2); over > 0 {
+		b.truncated += over
+		b.tail = append(b.tail[:0:0], b.tail[over:]...)
+	}
+}
+
+// String returns the kept output with a marker where the middle was dropped
+func (b *outputBuffer) String() string {
+	output := string(b.head)
+	if b.truncated > 0 {
+		if !strings.HasSuffix(output, "\n") {
+			output += "\n"
+		}
+		output += fmt.Sprintf("[... %s truncated ...]\n", formatBytes(int64(b.truncated)))
+	}
+	return strings.ToValidUTF8(output+string(b.tail), "")
+}
+
+// setOutput stores the output of a tool's last failed command for the report
+func (i *Installer) setOutput(name, output string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.outputs == nil {
+		i.outputs = map[string]string{}
+	}
+	i.outputs[name] = output
+}
+
+// takeOutput returns and forgets the output stored for a tool
+func (i *Installer) takeOutput(name string) string {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	output := i.outputs[name]
+	delete(i.outputs, name)
+	return output
+}
@@ -0,0 +1,169 @@
+package installer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// keptOutput passes raw command output through the capture path of execCommand
+func keptOutput(raw string, limit int) string {
+	kept := &outputBuffer{limit: limit}
+	w := &lineWriter{line: func(line string) { kept.line(sanitizeLine(line)) }}
+	w.Write([]byte(raw))
+	w.flush()
+	return kept.String()
+}
+
+func TestOutputKeptForLogsAndReports(t *testing.T) {
+	for _, name := range []string{"cargo", "go", "pip"} {
+		t.Run(name, func(t *testing.T) {
+			raw, err := os.ReadFile(filepath.Join("testdata", "output", name+".raw"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			kept := keptOutput(string(raw), defaultOutputLimit)
+			checkGolden(t, filepath.Join("testdata", "output", name+".golden"), []byte(kept))
+			if strings.ContainsAny(kept, "\x1b\r") {
+				t.Errorf("kept output %q has escapes or carriage returns", kept)
+			}
+		})
+	}
+}
+
+func TestOutputKeptWithinTheLimit(t *testing.T) {
+	raw, err := os.ReadFile(filepath.Join("testdata", "output", "cargo.raw"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	full := keptOutput(string(raw), defaultOutputLimit)
+	kept := keptOutput(string(raw), 200)
+	head, tail, ok := strings.Cut(kept, "\n[... ")
+	if !ok || !strings.HasPrefix(full, head) {
+		t.Fatalf("kept output %q, want the head of %q and a truncation marker", kept, full)
+	}
+	marker, tail, _ := strings.Cut(tail, " ...]\n")
+	if !strings.HasSuffix(full, tail) || len(head)+len(tail) > 200 {
+		t.Errorf("kept output %q, want at most 200 bytes of the head and tail of %q", kept, full)
+	}
+	if !strings.HasSuffix(marker, " B truncated") {
+		t.Errorf("marker %q, want the size dropped", marker)
+	}
+}
+
+// outputConfig installs ok-tool and fails bad-tool
+const outputConfig = `
+tool_list: [ok-tool, bad-tool]
+tools:
+  ok-tool:
+    methods: [{name: fake, commands: ["install ok-tool"]}]
+  bad-tool:
+    methods: [{name: fake, commands: ["install bad-tool"]}]
+`
+
+// runWithOutputs installs outputConfig with set applied, returning what went to the output and
+// to the diagnostics
+func runWithOutputs(t *testing.T, config string, set func(*Installer)) (out, diag string) {
+	t.Helper()
+	runner := newFakeRunner(t)
+	runner.fail["install bad-tool"] = true
+	i := newTestInstaller(t, config, runner)
+	var stdout, stderr strings.Builder
+	if err := i.Apply(WithOutput(&stdout), WithDiagnostics(&stderr)); err != nil {
+		t.Fatal(err)
+	}
+	set(i)
+	if err := i.Run(); err == nil {
+		t.Fatal("Run succeeded, want bad-tool to fail")
+	}
+	return stdout.String(), stderr.String()
+}
+
+func TestRunOutputs(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		set      func(*Installer)
+		out      []string // Lines out must contain
+		notOut   []string
+		diag     []string
+		emptyOut bool
+	}{
+		{
+			name:   "normal",
+			set:    func(i *Installer) {},
+			out:    []string{"System Tools Check", "Failed to install bad-tool"},
+			notOut: []string{"\t"},
+		},
+		{
+			name:   "porcelain",
+			set:    func(i *Installer) { i.Options.Porcelain = true },
+			out:    []string{"ok-tool\tinstalled\t1.0.0\tfake\n", "bad-tool\tfailed\t-\t-\n"},
+			notOut: []string{"System Tools Check", "│"},
+		},
+		{
+			name:   "quiet",
+			set:    func(i *Installer) { i.Options.Verbosity = VerbosityQuiet },
+			out:    []string{"bad-tool"},
+			notOut: []string{"System Tools Check", "Installing ok-tool"},
+		},
+		{
+			name:     "silent",
+			set:      func(i *Installer) { i.Options.Verbosity = VerbositySilent },
+			emptyOut: true,
+		},
+		{
+			name:   "print failed",
+			set:    func(i *Installer) { i.Options.PrintFailed = true },
+			out:    []string{"bad-tool\n"},
+			notOut: []string{"ok-tool", "System Tools Check"},
+			diag:   []string{"System Tools Check", "Failed to install bad-tool"},
+		},
+	} {
+		for _, concurrency := range []int{1, 2} {
+			t.Run(fmt.Sprintf("%s/concurrency %d", tc.name, concurrency), func(t *testing.T) {
+				out, diag := runWithOutputs(t, outputConfig, func(i *Installer) {
+					i.Options.Concurrency = concurrency
+					tc.set(i)
+				})
+				if tc.emptyOut && out != "" {
+					t.Errorf("output = %q, want none", out)
+				}
+				for _, want := range tc.out {
+					if !strings.Contains(out, want) {
+						t.Errorf("output does not contain %q:\n%s", want, out)
+					}
+				}
+				for _, unwanted := range tc.notOut {
+					if strings.Contains(out, unwanted) {
+						t.Errorf("output contains %q:\n%s", unwanted, out)
+					}
+				}
+				if len(tc.diag) == 0 && diag != "" {
+					t.Errorf("diagnostics = %q, want none", diag)
+				}
+				for _, want := range tc.diag {
+					if !strings.Contains(diag, want) {
+						t.Errorf("diagnostics do not contain %q:\n%s", want, diag)
+					}
+				}
+			})
+		}
+	}
+}
+
+// Installers print to their own outputs, so that runs side by side do not mix their output
+func TestConcurrentRunsKeepTheirOutputs(t *testing.T) {
+	for _, name := range []string{"first", "second", "third"} {
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			tool := name + "-tool"
+			config := fmt.Sprintf("tool_list: [%s, bad-tool]\ntools:\n  %s:\n    methods: [{name: fake, commands: [\"install %s\"]}]\n  bad-tool:\n    methods: [{name: fake, commands: [\"install bad-tool\"]}]\n", tool, tool, tool)
+			out, _ := runWithOutputs(t, config, func(i *Installer) { i.Options.Porcelain = true })
+			if want := tool + "\tinstalled\t1.0.0\tfake\nbad-tool\tfailed\t-\t-\n"; out != want {
+				t.Errorf("output = %q, want %q", out, want)
+			}
+		})
+	}
+}
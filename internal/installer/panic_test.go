@@ -0,0 +1,107 @@
+package installer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// panickingRunner panics running "install broken" after printing a line, unless the panic
+// is left to the handler of that line
+type panickingRunner struct {
+	*fakeRunner
+	inHandler bool
+}
+
+func (r panickingRunner) Run(ctx context.Context, argv, env []string, out io.Writer) error {
+	if strings.Join(argv, " ") != "install broken" {
+		return r.fakeRunner.Run(ctx, argv, env, out)
+	}
+	fmt.Fprintln(out, "unpacking broken")
+	if !r.inHandler {
+		panic("runner bug")
+	}
+	fmt.Fprintln(out, "configuring broken")
+	return nil
+}
+
+func TestPanicFailsOnlyItsTool(t *testing.T) {
+	const yaml = `
+tool_list: [first, broken, after]
+tools:
+  first:
+    methods:
+      - name: fake
+        commands: ["install first"]
+  broken:
+    methods:
+      - name: fake
+        commands: ["install broken"]
+  after:
+    methods:
+      - name: fake
+        commands: ["install after"]
+`
+	for _, tc := range []struct {
+		name      string
+		inHandler bool
+		panic     string
+	}{
+		{name: "output callback", inHandler: true, panic: "internal error: panic: output handler bug"},
+		{name: "runner", panic: "internal error: panic: runner bug"},
+	} {
+		for _, concurrency := range []int{1, 2} {
+			t.Run(fmt.Sprintf("%s concurrency %d", tc.name, concurrency), func(t *testing.T) {
+				runner := newFakeRunner(t)
+				var out bytes.Buffer
+				i := newTestInstaller(t, yaml, panickingRunner{fakeRunner: runner, inHandler: tc.inHandler})
+				var mu sync.Mutex
+				var lines []string
+				events := EventsFunc(func(e Event) {
+					if e.Type != EventOutput {
+						return
+					}
+					mu.Lock()
+					lines = append(lines, e.Line)
+					mu.Unlock()
+					if e.Line == "unpacking broken" {
+						panic("output handler bug")
+					}
+				})
+				if err := i.Apply(WithTerminal(&out, 80, 24), WithEvents(events), WithConcurrency(concurrency)); err != nil {
+					t.Fatal(err)
+				}
+
+				err := i.Run()
+				if !errors.Is(err, ErrInstallFailed) || !strings.Contains(err.Error(), "1 of 3 tools failed") {
+					t.Fatalf("Run error = %v, want only broken failed", err)
+				}
+				for _, result := range i.report {
+					switch {
+					case result.Name != "broken" && result.Status != statusInstalled:
+						t.Errorf("%s: status %s, want it installed", result.Name, result.Status)
+					case result.Name == "broken" && (result.Status != statusFailed || result.Error != tc.panic):
+						t.Errorf("broken: status %s, error %q; want failed with %q", result.Status, result.Error, tc.panic)
+					case result.Name == "broken" && !strings.Contains(result.Stack, "panic_test.go"):
+						t.Errorf("broken: stack %q, want the stack of the panic", result.Stack)
+					}
+				}
+				if len(i.report) != 3 {
+					t.Errorf("report has %d tools, want 3", len(i.report))
+				}
+				if slices.Contains(lines, "configuring broken") {
+					t.Errorf("output lines = %q, want the output after the panic of its handler discarded", lines)
+				}
+				if hidden, shown := strings.Count(out.String(), hideCursorSeq), strings.Count(out.String(), showCursorSeq); hidden != shown {
+					t.Errorf("cursor hidden %d times and shown %d times, want it shown again", hidden, shown)
+				}
+			})
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package installer
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// toolPanic is a panic recovered while a tool installed, which fails the tool instead of
+// the run
+type toolPanic struct {
+	value interface{}
+	stack string // Stack of the goroutine that panicked
+}
+
+func (p *toolPanic) Error() string {
+	return fmt.Sprintf("internal error: panic: %v", p.value)
+}
+
+// recovered wraps the value of a recovered panic with the stack of the goroutine that
+// panicked, keeping the first stack when a toolPanic is recovered again further up. Call it
+// from the deferred function that recovered, before the stack unwinds.
+func recovered(r interface{}) *toolPanic {
+	if p, ok := r.(*toolPanic); ok {
+		return p
+	}
+	return &toolPanic{value: r, stack: string(debug.Stack())}
+}
+
+// recoverDrawing stops a goroutine drawing progress that panicked, leaving the run without
+// the animation rather than killing it. Defer it directly in the goroutine.
+func recoverDrawing(what string) {
+	if r := recover(); r != nil {
+		p := recovered(r)
+		execLog.debug.Error("drawing panicked", "what", what, "panic", fmt.Sprint(p.value), "stack", p.stack)
+	}
+}
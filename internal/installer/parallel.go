@@ -0,0 +1,186 @@
+package installer
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/colors"
+)
+
+// runParallel installs the entries a plan doesn't skip with up to Options.Concurrency
+// workers while showing one status line per in-flight tool. A tool starts once the tools
+// providing its dependencies are installed; when one of them fails it is skipped.
+func (i *Installer) runParallel(plan Plan, results []ToolReport) {
+	// Entries of the same tool share its binary and state, so they install one after another
+	var groups [][]int
+	group := map[string]int{}
+	for n, item := range plan {
+		if item.Action == actionSkip {
+			i.finishPending(item.Entry)
+			continue
+		}
+		// Aliases install with the tool they are the same as
+		name := i.installName(item.status.Name)
+		if g, ok := group[name]; ok {
+			groups[g] = append(groups[g], n)
+			continue
+		}
+		group[name] = len(groups)
+		groups = append(groups, []int{n})
+	}
+	if len(groups) == 0 {
+		return
+	}
+
+	i.renderer = newTerminalRenderer(i.display())
+	i.renderer.Start()
+	defer func() {
+		i.renderer.Close()
+		i.renderer = nil
+	}()
+
+	deps := i.groupDependencies(plan, groups)
+	dependents := make([][]int, len(groups))
+	waiting := make([]int, len(groups))
+	var ready []int
+	for g := range groups {
+		for _, dep := range deps[g] {
+			dependents[dep] = append(dependents[dep], g)
+		}
+		waiting[g] = len(deps[g])
+		if waiting[g] == 0 {
+			ready = append(ready, g)
+		}
+	}
+
+	// Ready groups start as workers free up; a finished group releases the groups waiting
+	// for it, or skips them when it failed
+	done := make(chan int)
+	skipped := make([]bool, len(groups))
+	for running := 0; running > 0 || len(ready) > 0; {
+		for running < i.Options.Concurrency && len(ready) > 0 {
+			g := ready[0]
+			ready = ready[1:]
+			running++
+			go func() {
+				i.runGroup(plan, groups[g], results)
+				done <- g
+			}()
+		}
+		g := <-done
+		running--
+		failed := failedEntry(results, groups[g])
+		for _, dependent := range dependents[g] {
+			if failed != "" {
+				i.skipGroup(plan, groups, dependents, dependent, failed, results, skipped)
+				continue
+			}
+			if waiting[dependent]--; waiting[dependent] == 0 && !skipped[dependent] {
+				ready = append(ready, dependent)
+			}
+		}
+	}
+}
+
+// runGroup installs the entries of one tool, one after another
+func (i *Installer) runGroup(plan Plan, indexes []int, results []ToolReport) {
+	for _, n := range indexes {
+		item := plan[n]
+		if i.budgetExhausted() {
+			results[n] = i.deferEntry(item.Entry, results[n])
+			i.finishPending(item.Entry)
+			continue
+		}
+		if prev, ok := i.attempt(item); ok {
+			results[n] = i.sameAsResult(item, results[n], prev)
+			i.finishPending(item.Entry)
+			continue
+		}
+		i.renderer.Begin(item.status.Name)
+		results[n] = i.installEntry(item.Entry, results[n], item.Action)
+		i.recordAttempt(item, results[n])
+		i.renderer.Finish(item.status.Name, finalLine(results[n]))
+		i.finishPending(item.Entry)
+	}
+}
+
+// groupDependencies returns, for each group of plan entries, the groups providing the
+// dependencies of its tools. Dependencies outside the groups are checked when installing.
+func (i *Installer) groupDependencies(plan Plan, groups [][]int) [][]int {
+	group := map[string]int{}
+	for g, indexes := range groups {
+		group[i.installName(plan[indexes[0]].status.Name)] = g
+	}
+	deps := make([][]int, len(groups))
+	for g, indexes := range groups {
+		for _, n := range indexes {
+			// Aliases install with their tool, so they wait for its dependencies as well
+			name := plan[n].status.Name
+			for _, tool := range []string{name, i.installName(name)} {
+				toolConfig := i.config.Tools[tool]
+				if toolConfig == nil {
+					continue
+				}
+				for _, dep := range toolConfig.Dependencies {
+					provider, ok := group[i.installName(i.config.Provider(dep))]
+					if ok && provider != g && !slices.Contains(deps[g], provider) {
+						deps[g] = append(deps[g], provider)
+					}
+				}
+			}
+		}
+	}
+	return deps
+}
+
+// skipGroup marks the entries of a group, and of every group depending on it, as skipped
+// because the dependency failed
+func (i *Installer) skipGroup(plan Plan, groups, dependents [][]int, g int, failed string, results []ToolReport, skipped []bool) {
+	if skipped[g] {
+		return
+	}
+	skipped[g] = true
+	for _, n := range groups[g] {
+		item := plan[n]
+		results[n].Status, results[n].Error = statusSkipped, fmt.Sprintf("skipped: dependency %s failed", failed)
+		i.renderer.Printf("%s\n", finalLine(results[n]))
+		i.emit(Event{Type: EventToolFinished, Tool: item.Entry, Status: results[n].Status, Error: results[n].Error})
+		i.finishPending(item.Entry)
+	}
+	for _, dependent := range dependents[g] {
+		i.skipGroup(plan, groups, dependents, dependent, failed, results, skipped)
+	}
+}
+
+// failedEntry returns the first entry of a group that was not installed, or ""
+func failedEntry(results []ToolReport, indexes []int) string {
+	for _, n := range indexes {
+		switch results[n].Status {
+		case statusFailed, statusDeferred, statusSkipped:
+			return results[n].Name
+		}
+	}
+	return ""
+}
+
+// finalLine renders the line a finished install collapses to
+func finalLine(result ToolReport) string {
+	if result.Status == statusDeferred {
+		return fmt.Sprintf("%s│ %s⏸ %-9s%s │ %sCancelled, budget exhausted%s", colors.Blue, colors.Yellow, result.Name, colors.Reset, colors.Yellow, colors.Reset)
+	}
+	if result.Status == statusFailed {
+		return fmt.Sprintf("%s│ %s✗ %-9s%s │ %sInstall failed%s", colors.Blue, colors.Red, result.Name, colors.Reset, colors.Red, colors.Reset)
+	}
+	if result.Status == statusSkipped {
+		return fmt.Sprintf("%s│ %s⏭ %-9s%s │ %sSkipped, %s%s", colors.Blue, colors.Yellow, result.Name, colors.Reset, colors.Yellow, strings.TrimPrefix(result.Error, "skipped: "), colors.Reset)
+	}
+	details := orDash(result.Version)
+	if result.Method != "" {
+		details += " (" + result.Method + ")"
+	}
+	if result.Status == statusReinstalled {
+		details += ", reinstalled"
+	}
+	return fmt.Sprintf("%s│ %s✓ %-9s%s │ %s", colors.Blue, colors.Green, result.Name, colors.Reset, details)
+}
@@ -0,0 +1,138 @@
+package installer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/colors"
+	"github.com/Abhaythakor/dev-tools-installer/internal/config"
+)
+
+// binCandidates returns the directories a tool's commands may have been placed in: its
+// install directory, those of the language toolchains and the shims of mise and asdf
+func (i *Installer) binCandidates(name string) []string {
+	dirs := []string{i.toolBinDir(name), goBinDir(), cargoBinDir(), expandHome("~/.local/bin")}
+	// Tools mise and asdf manage are run through their shims
+	if toolConfig := i.config.Tools[name]; toolConfig != nil {
+		for _, method := range toolConfig.Methods {
+			if dir := shimsDir(method.Type); dir != "" && !slices.Contains(dirs, dir) {
+				dirs = append(dirs, dir)
+			}
+		}
+	}
+	return dirs
+}
+
+// MissingPathDirs returns the directories holding commands of the given tools that don't
+// resolve because the directory is not on PATH, followed by the other directories the PATH
+// overlay added this run that exist
+func (i *Installer) MissingPathDirs(names []string) []string {
+	onPath := map[string]bool{}
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		onPath[filepath.Clean(dir)] = true
+	}
+	var dirs []string
+	for _, dir := range i.toolPathDirs(names) {
+		if !onPath[dir] {
+			dirs = append(dirs, dir)
+		}
+	}
+	for _, dir := range i.overlayDirs() {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() && !slices.Contains(dirs, dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+// toolPathDirs returns the install and toolchain directories holding commands of the given
+// tools, whether or not they are on PATH. Commands resolving elsewhere on PATH are skipped.
+func (i *Installer) toolPathDirs(names []string) []string {
+	var dirs []string
+	add := func(dir string) {
+		if !slices.Contains(dirs, dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+	for _, name := range names {
+		var candidates []string
+		for _, dir := range i.binCandidates(name) {
+			candidates = append(candidates, filepath.Clean(dir))
+		}
+		for _, command := range i.config.Tools[name].Commands(name) {
+			if path, err := i.commands().LookPath(command); err == nil {
+				if dir := filepath.Dir(path); slices.Contains(candidates, dir) {
+					add(dir)
+				}
+				continue
+			}
+			for _, dir := range candidates {
+				if isExecutable(filepath.Join(dir, command)) {
+					add(dir)
+					break
+				}
+			}
+		}
+	}
+	return dirs
+}
+
+// isExecutable reports whether path is an executable file
+func isExecutable(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().IsRegular() && info.Mode().Perm()&0111 != 0
+}
+
+// pathExports renders the shell lines adding dirs to PATH. fish gets fish_add_path, every
+// other shell a POSIX export.
+func pathExports(dirs []string, shell string) string {
+	shown := make([]string, len(dirs))
+	home := homeDir()
+	for n, dir := range dirs {
+		shown[n] = dir
+		if home != "" && strings.HasPrefix(dir, home+string(filepath.Separator)) {
+			shown[n] = "$HOME" + strings.TrimPrefix(dir, home)
+		}
+	}
+	if shell == "fish" {
+		return "fish_add_path " + strings.Join(shown, " ")
+	}
+	return fmt.Sprintf("export PATH=\"%s:$PATH\"", strings.Join(shown, ":"))
+}
+
+// printPathAdvice prints how to add the directories of newly installed tools missing from
+// PATH, and writes the POSIX export line to Options.PathSnippet
+func (i *Installer) printPathAdvice(results []ToolReport) error {
+	var names []string
+	for _, result := range results {
+		if result.Status == statusInstalled || result.Status == statusUpgraded {
+			name, _ := config.ParseToolEntry(result.Name)
+			names = append(names, name)
+		}
+	}
+	dirs := i.MissingPathDirs(names)
+	if len(dirs) == 0 {
+		return nil
+	}
+
+	fmt.Fprintf(i.display(), "%s⚠ Installed tools in directories not on PATH: %s%s\n", colors.Yellow, strings.Join(dirs, ", "), colors.Reset)
+	fmt.Fprintf(i.display(), "  %sbash/zsh:%s %s\n", colors.Gray, colors.Reset, pathExports(dirs, "sh"))
+	fmt.Fprintf(i.display(), "  %sfish:%s     %s\n\n", colors.Gray, colors.Reset, pathExports(dirs, "fish"))
+	if i.Options.PathSnippet == "" {
+		return nil
+	}
+
+	shell := "sh"
+	if filepath.Ext(i.Options.PathSnippet) == ".fish" {
+		shell = "fish"
+	}
+	snippet := "# Written by dev-tools-installer; source it from your shell's rc file\n" + pathExports(dirs, shell) + "\n"
+	if err := os.WriteFile(i.Options.PathSnippet, []byte(snippet), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %v", i.Options.PathSnippet, err)
+	}
+	fmt.Fprintf(i.display(), "%sWrote %s; add: source %s%s\n\n", colors.Gray, i.Options.PathSnippet, i.Options.PathSnippet, colors.Reset)
+	return nil
+}
@@ -0,0 +1,167 @@
+package installer
+
+import (
+	"context"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/colors"
+	"github.com/Abhaythakor/dev-tools-installer/internal/config"
+)
+
+// overlayRunner looks commands up in the directories of the run's PATH overlay before PATH,
+// and runs commands found there by their full path, since the installer's own PATH predates
+// the toolchains the run installed
+type overlayRunner struct {
+	runner CommandRunner
+	dirs   []string
+}
+
+func (r overlayRunner) LookPath(name string) (string, error) {
+	if path, ok := r.resolve(name); ok {
+		return path, nil
+	}
+	return r.runner.LookPath(name)
+}
+
+func (r overlayRunner) Output(argv []string) ([]byte, error) {
+	return r.runner.Output(r.argv(argv))
+}
+
+func (r overlayRunner) Run(ctx context.Context, argv, env []string, out io.Writer) error {
+	return r.runner.Run(ctx, r.argv(argv), env, out)
+}
+
+// resolve finds a command name in the overlay directories
+func (r overlayRunner) resolve(name string) (string, bool) {
+	if strings.ContainsAny(name, `/\`) {
+		return "", false
+	}
+	for _, dir := range r.dirs {
+		if path, err := exec.LookPath(filepath.Join(dir, name)); err == nil {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+// argv returns argv with its command replaced by its path when the overlay has it
+func (r overlayRunner) argv(argv []string) []string {
+	if path, ok := r.resolve(argv[0]); ok {
+		return append([]string{path}, argv[1:]...)
+	}
+	return argv
+}
+
+// cargoBinDir returns the directory cargo installs binaries in
+func cargoBinDir() string {
+	if home := os.Getenv("CARGO_HOME"); home != "" {
+		return filepath.Join(home, "bin")
+	}
+	return expandHome("~/.cargo/bin")
+}
+
+// nvmBinDir returns the bin directory of the Node.js version nvm installed last, or "" when
+// nvm installed none
+func nvmBinDir() string {
+	nvm := os.Getenv("NVM_DIR")
+	if nvm == "" {
+		nvm = expandHome("~/.nvm")
+	}
+	dirs, _ := filepath.Glob(filepath.Join(nvm, "versions", "node", "*", "bin"))
+	latest, modified := "", int64(0)
+	for _, dir := range dirs {
+		if info, err := os.Stat(dir); err == nil && info.ModTime().UnixNano() > modified {
+			latest, modified = dir, info.ModTime().UnixNano()
+		}
+	}
+	return latest
+}
+
+// toolchainDirs returns the directories a toolchain providing command puts commands in:
+// where the toolchain itself is installed and where the tools it installs land
+func toolchainDirs(command string) []string {
+	switch command {
+	case "go":
+		return []string{"/usr/local/go/bin", goBinDir()}
+	case "cargo", "rustup", "rustc":
+		return []string{cargoBinDir()}
+	case "node", "npm", "nvm":
+		if nvm := nvmBinDir(); nvm != "" {
+			return []string{expandHome("~/.local/node/bin"), nvm}
+		}
+		return []string{expandHome("~/.local/node/bin")}
+	case "pipx":
+		return []string{expandHome("~/.local/bin")}
+	case "mise":
+		return []string{expandHome("~/.local/bin"), shimsDir(config.MethodMise)}
+	case "asdf":
+		return []string{expandHome("~/.asdf/bin"), shimsDir(config.MethodAsdf)}
+	}
+	return nil
+}
+
+// extendPath adds the directories a tool installed this run put commands in to the PATH
+// overlay, so that the methods and checks after it find them: the directories its commands
+// were found in and, for toolchains, those of the tools they install. Directories on PATH
+// already are left out, as are targets of --root, whose commands are looked up inside it.
+func (i *Installer) extendPath(name string) {
+	toolConfig := i.config.Tools[name]
+	if i.Options.Root != "" || toolConfig == nil {
+		return
+	}
+	var onPath, added []string
+	for _, dir := range filepath.SplitList(os.Getenv("PATH")) {
+		onPath = append(onPath, filepath.Clean(dir))
+	}
+	add := func(dir string) {
+		dir = filepath.Clean(dir)
+		if slices.Contains(onPath, dir) || slices.Contains(added, dir) {
+			return
+		}
+		i.pathMu.Lock()
+		defer i.pathMu.Unlock()
+		if !slices.Contains(i.pathOverlay, dir) {
+			i.pathOverlay = append(i.pathOverlay, dir)
+			added = append(added, dir)
+		}
+	}
+	for _, command := range toolConfig.Commands(name) {
+		dirs := append(i.binCandidates(name), toolchainDirs(command)...)
+		for _, dir := range dirs {
+			if _, err := exec.LookPath(filepath.Join(dir, command)); err == nil {
+				add(dir)
+				break
+			}
+		}
+		for _, dir := range toolchainDirs(command) {
+			add(dir)
+		}
+	}
+	if len(added) > 0 {
+		i.log(execLog).Debug("path overlay", "tool", name, "added", added)
+		if i.verbose() {
+			i.printf("%s│   %sadded to PATH for the rest of the run: %s%s\n", colors.Blue, colors.Gray, strings.Join(added, ", "), colors.Reset)
+		}
+	}
+}
+
+// overlaidPath returns path with the directories of the PATH overlay in front
+func (i *Installer) overlaidPath(path string) string {
+	overlay := i.overlayDirs()
+	if len(overlay) == 0 {
+		return path
+	}
+	return strings.Join(append(overlay, filepath.SplitList(path)...), string(os.PathListSeparator))
+}
+
+// overlayDirs returns the directories of the PATH overlay
+func (i *Installer) overlayDirs() []string {
+	i.pathMu.Lock()
+	defer i.pathMu.Unlock()
+	return slices.Clone(i.pathOverlay)
+}
@@ -0,0 +1,70 @@
+package installer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/colors"
+)
+
+// binDir returns the directory managed binaries are installed into
+func (i *Installer) binDir() string {
+	if i.config.BinDir != "" {
+		return i.inRoot(expandHome(os.ExpandEnv(i.config.BinDir)))
+	}
+	return i.inRoot(expandHome("~/.local/bin"))
+}
+
+// toolBinDir returns the directory a tool's managed binary is installed into and ${bindir}
+// points at: its install_dir, or bindir
+func (i *Installer) toolBinDir(name string) string {
+	if toolConfig := i.config.Tools[name]; toolConfig != nil && toolConfig.InstallDir != "" {
+		return i.inRoot(expandHome(os.ExpandEnv(toolConfig.InstallDir)))
+	}
+	return i.binDir()
+}
+
+// stateDir returns the directory holding the state file, inside the target with Options.Root
+func (i *Installer) stateDir() string {
+	if i.config.StateDir != "" {
+		return i.inRoot(expandHome(os.ExpandEnv(i.config.StateDir)))
+	}
+	if dir := os.Getenv("XDG_STATE_HOME"); dir != "" {
+		return i.inRoot(filepath.Join(dir, "dev-tools-installer"))
+	}
+	return i.inRoot(expandHome("~/.local/state/dev-tools-installer"))
+}
+
+// downloadCacheDir returns the directory partial downloads are kept in so they can resume
+func (i *Installer) downloadCacheDir() string {
+	return filepath.Join(i.stateDir(), "downloads")
+}
+
+// versionDir returns the directory a side-by-side version of a tool is installed into
+func (i *Installer) versionDir(name, version string) string {
+	return filepath.Join(i.stateDir(), "versions", name, version)
+}
+
+// loadedState returns the state file, loading it on first use
+func (i *Installer) loadedState() *State {
+	if i.state != nil {
+		return i.state
+	}
+
+	state, err := LoadState(i.stateDir())
+	if err != nil {
+		fmt.Fprintf(i.display(), "%s│%s ⚠ %v, starting with empty state%s\n", colors.Blue, colors.Yellow, err, colors.Reset)
+		state = &State{Tools: map[string]*ToolState{}, path: filepath.Join(i.stateDir(), stateFileName)}
+	}
+	i.state = state
+	return state
+}
+
+// saveState writes the state file if it was loaded during this run
+func (i *Installer) saveState() error {
+	if i.state == nil {
+		return nil
+	}
+	return i.state.Save()
+}
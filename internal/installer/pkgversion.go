@@ -0,0 +1,176 @@
+package installer
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/config"
+	"github.com/Abhaythakor/dev-tools-installer/internal/version"
+)
+
+// packageQueries build the command asking a package manager for the installed version of a package
+var packageQueries = map[string]func(pkg string) []string{
+	"apt":     func(pkg string) []string { return []string{"dpkg-query", "-W", "-f=${Version}", pkg} },
+	"apt-get": func(pkg string) []string { return []string{"dpkg-query", "-W", "-f=${Version}", pkg} },
+	"dnf":     func(pkg string) []string { return []string{"rpm", "-q", "--qf", "%{VERSION}-%{RELEASE}", pkg} },
+	"yum":     func(pkg string) []string { return []string{"rpm", "-q", "--qf", "%{VERSION}-%{RELEASE}", pkg} },
+	"brew":    func(pkg string) []string { return []string{"brew", "info", "--json=v2", pkg} },
+	"pacman":  func(pkg string) []string { return []string{"pacman", "-Q", pkg} },
+}
+
+// packageValueFlags are package manager flags that take the next argument as their value
+var packageValueFlags = map[string]bool{"-o": true, "-t": true, "--target-release": true}
+
+// installedPackage is the package a package-manager method installed, with the version the
+// package manager assigned it
+type installedPackage struct {
+	manager string
+	name    string
+	version string
+}
+
+// packageInstall finds the package manager and packages of an install command, looking past
+// sudo, env and their options and assignments as usesPackageLock does. It returns an empty
+// manager for commands that do not install packages.
+func packageInstall(parts []string) (string, []string) {
+	n := commandIndex(parts)
+	if n < 0 {
+		return "", nil
+	}
+	base := filepath.Base(parts[n])
+	if _, ok := packageQueries[base]; !ok {
+		return "", nil
+	}
+	return base, installArgs(base, parts[n+1:])
+}
+
+// installArgs returns the packages named by the arguments of a package manager, or nil when
+// the arguments do not install packages
+func installArgs(manager string, args []string) []string {
+	installing := false
+	var packages []string
+	for n := 0; n < len(args); n++ {
+		arg := args[n]
+		switch {
+		case packageValueFlags[arg]:
+			n++
+		case manager == "pacman" && (arg == "--sync" || strings.HasPrefix(arg, "-S") && strings.Trim(arg[2:], "yu") == ""):
+			installing = true
+		case strings.HasPrefix(arg, "-"):
+		case manager != "pacman" && !installing && len(packages) == 0:
+			if arg != "install" && arg != "reinstall" {
+				return nil
+			}
+			installing = true
+		default:
+			// apt pins versions as name=version
+			name, _, _ := strings.Cut(arg, "=")
+			packages = append(packages, name)
+		}
+	}
+	if !installing {
+		return nil
+	}
+	return packages
+}
+
+// methodPackage returns the package a commands method installed through a package manager,
+// preferring the one named after the tool or its command when a command installs several
+func (i *Installer) methodPackage(name string, toolConfig *config.ToolConfig, method config.InstallMethod) (string, string) {
+	if method.Type != "" {
+		return "", ""
+	}
+	vars := keepCaptures(method, i.commandVars(name, toolConfig.Version, i.toolBinDir(name)))
+	for _, command := range method.Runs() {
+		parts, err := splitCommand(command, vars)
+		if err != nil {
+			continue
+		}
+		manager, packages := packageInstall(parts)
+		if len(packages) == 0 {
+			continue
+		}
+		for _, pkg := range packages {
+			if pkg == name || pkg == i.toolCommand(name) {
+				return manager, pkg
+			}
+		}
+		return manager, packages[0]
+	}
+	return "", ""
+}
+
+// queryPackage asks the package manager of a successful method which version of the package it
+// installed, returning a zero installedPackage when the method is not a package install or the
+// package manager cannot tell
+func (i *Installer) queryPackage(name string, toolConfig *config.ToolConfig, method config.InstallMethod) installedPackage {
+	manager, pkg := i.methodPackage(name, toolConfig, method)
+	if manager == "" {
+		return installedPackage{}
+	}
+	argv := packageQueries[manager](pkg)
+	if method.InTarget && i.Options.Root != "" {
+		argv = append([]string{"chroot", i.Options.Root}, argv...)
+	}
+	output, err := i.commands().Output(argv)
+	i.log(versionLog).Debug("package query", "argv", argv, "error", err, "output", string(output))
+	if err != nil {
+		return installedPackage{}
+	}
+	v := parsePackageVersion(manager, pkg, string(output))
+	if v == "" {
+		return installedPackage{}
+	}
+	return installedPackage{manager: manager, name: pkg, version: v}
+}
+
+// parsePackageVersion extracts the version from the output of a package query
+func parsePackageVersion(manager, pkg, output string) string {
+	switch manager {
+	case "brew":
+		var info struct {
+			Formulae []struct {
+				Installed []struct {
+					Version string `json:"version"`
+				} `json:"installed"`
+			} `json:"formulae"`
+			Casks []struct {
+				Installed string `json:"installed"`
+			} `json:"casks"`
+		}
+		if err := json.Unmarshal([]byte(output), &info); err != nil {
+			return ""
+		}
+		if len(info.Formulae) > 0 && len(info.Formulae[0].Installed) > 0 {
+			return info.Formulae[0].Installed[0].Version
+		}
+		if len(info.Casks) > 0 {
+			return info.Casks[0].Installed
+		}
+		return ""
+	case "pacman":
+		// pacman -Q prints "name version"
+		fields := strings.Fields(output)
+		if len(fields) == 2 && fields[0] == pkg {
+			return fields[1]
+		}
+		return ""
+	}
+	return strings.TrimSpace(output)
+}
+
+// packageVersionMatches reports whether the version a binary reports agrees with the version
+// of the package it came from. Package versions carry epochs and packaging revisions, as in
+// 1:2.43.0-1ubuntu1 or 1.7.1_1, so only the release components are compared.
+func (i *Installer) packageVersionMatches(detected, packaged string) bool {
+	if _, rest, ok := strings.Cut(packaged, ":"); ok {
+		packaged = rest
+	}
+	vd, errD := version.Parse(detected)
+	vp, errP := version.Parse(packaged)
+	if errD != nil || errP != nil {
+		return i.versionsMatch(detected, packaged)
+	}
+	return version.Version{Release: vd.Release}.Compare(version.Version{Release: vp.Release}) == 0
+}
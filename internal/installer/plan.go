@@ -0,0 +1,469 @@
+package installer
+
+import (
+	"fmt"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/colors"
+	"github.com/Abhaythakor/dev-tools-installer/internal/config"
+)
+
+// Plan actions
+const (
+	actionInstall   = "install"
+	actionUpgrade   = "upgrade"
+	actionReinstall = "reinstall" // Installed again although present, with --force
+	actionSkip      = "skip"
+	actionOrphaned  = "would-be-orphaned"
+)
+
+// Where the pin of a plan item comes from
+const (
+	pinConfig       = "config"        // The version of the tools entry
+	pinVersionsFile = "versions_file" // An entry of the versions_file
+	pinVersionFrom  = "version_from"  // The output of the version_from command
+	pinToolList     = "tool_list"     // The @version of the tool_list entry
+)
+
+// PlanItem is the action a run would take for one tool_list entry
+type PlanItem struct {
+	Entry           string   `json:"entry"`
+	Action          string   `json:"action"`
+	Current         string   `json:"current,omitempty"`          // Detected version
+	Target          string   `json:"target,omitempty"`           // Pinned version
+	Pin             string   `json:"pin,omitempty"`              // Where the pinned version comes from: config, versions_file, version_from or tool_list
+	Methods         []string `json:"methods,omitempty"`          // Methods in the order they would be tried
+	Channel         string   `json:"channel,omitempty"`          // Channel the methods are picked from, for tools that use channels
+	SystemPackages  []string `json:"system_packages,omitempty"`  // System packages installed before the methods, as manager:package
+	DownloadSize    int64    `json:"download_size,omitempty"`    // Bytes the first method downloads, once estimated with EstimateDownloads
+	DownloadUnknown bool     `json:"download_unknown,omitempty"` // The first method downloads an amount that cannot be predicted, such as go install
+	Path            string   `json:"-"`                          // Binary the decision is based on
+	Reasons         []string `json:"reasons,omitempty"`          // Why the planner chose the action
+
+	status        ToolStatus // The check the decision is based on
+	systemSkipped []string   // System packages skipped for lack of their package manager
+}
+
+// Plan is the action a run would take for each of the tool_list entries it covers
+type Plan []PlanItem
+
+// BuildPlan decides what a run would do for each tool_list entry. It probes installed
+// versions but runs no install commands; dry runs, diff, doctor and why all use it.
+func (i *Installer) BuildPlan() Plan {
+	var plan Plan
+	for _, entry := range i.selectedEntries() {
+		plan = append(plan, i.planEntry(entry))
+	}
+	i.noteBatches(plan)
+	i.noteSystemPackages(plan)
+	return plan
+}
+
+// entries returns the tool_list entries of the plan
+func (p Plan) entries() []string {
+	entries := make([]string, len(p))
+	for n, item := range p {
+		entries[n] = item.Entry
+	}
+	return entries
+}
+
+// report returns the run report of a plan item before it is executed; entries the plan
+// installs are reported missing until they are
+func (p PlanItem) report() ToolReport {
+	result := p.status.report()
+	if p.Action != actionSkip {
+		result.Status = statusMissing
+	}
+	return result
+}
+
+// planEntry checks a single tool_list entry and decides what a run would do for it
+func (i *Installer) planEntry(entry string) PlanItem {
+	return i.planStatus(i.entryStatus(entry))
+}
+
+// planStatus decides what a run would do for a checked tool_list entry, recording why
+func (i *Installer) planStatus(status ToolStatus) PlanItem {
+	entry, name := status.Entry, status.Name
+	item := PlanItem{Entry: entry, Action: actionSkip, Current: status.Version, Target: status.Pinned, Path: status.Path, status: status}
+	toolConfig := i.config.Tools[i.installName(name)]
+	if status.SameAs != "" {
+		item.reason("%s is the same as %s, which is checked and installed in its place", name, status.SameAs)
+	}
+
+	if status.sideBySide() {
+		item.Pin = pinToolList
+		if note := i.config.InlinePinNote(name, status.Pinned); note != "" {
+			item.reason("%s %s", entry, note)
+		}
+		if status.Present {
+			item.reason("%s is installed side by side at %s", entry, item.Path)
+		} else {
+			item.Action, item.Path = actionInstall, ""
+			item.reason("%s is not installed side by side", entry)
+		}
+	} else {
+		drift := status.Health == HealthDrift
+		switch {
+		case !status.Present && status.Path != "":
+			item.Action = actionInstall
+			item.reason("%s provides %s, which were not found on PATH", name, strings.Join(status.Missing, ", "))
+		case !status.Present && status.Detect != "":
+			item.Action = actionInstall
+			item.reason("%s was not found: detect checks the %s", name, status.Detect)
+		case !status.Present:
+			item.Action = actionInstall
+			item.reason("%s was not found on PATH", name)
+		case status.Detect != "" && status.Version == "":
+			item.reason("%s was found by the %s; its version could not be detected", name, status.Detect)
+		case status.Detect != "":
+			item.reason("%s was found by the %s and reports version %s", name, status.Detect, status.Version)
+		case status.Version == "":
+			item.reason("%s resolved to %s; its version could not be detected", name, status.Path)
+		default:
+			item.reason("%s resolved to %s and reports version %s", name, status.Path, status.Version)
+		}
+		from := i.config.Tools[name]
+		switch {
+		case status.Error != "":
+			item.reason("%s", status.Error)
+		case status.Pinned == "" || from == nil:
+		case from.VersionsFile != "":
+			item.Pin = pinVersionsFile
+			item.reason("the pin %s comes from %s", status.Pinned, from.VersionsFile)
+		case from.VersionFrom != "":
+			item.Pin = pinVersionFrom
+			item.reason("version_from %q resolved to %s", from.VersionFrom, status.Pinned)
+		default:
+			item.Pin = pinConfig
+			// Only worth telling apart when some pins come from the versions_file
+			if i.config.VersionsPath != "" {
+				item.reason("the pin %s comes from the config, not %s", status.Pinned, i.config.VersionsPath)
+			}
+		}
+		switch {
+		case status.Pinned == "":
+			item.reason("no version is pinned")
+		case drift && i.Options.Fix:
+			item.Action = actionUpgrade
+			item.reason("the detected version differs from the pin %s and --fix upgrades it", status.Pinned)
+		case drift:
+			item.reason("the detected version differs from the pin %s; run with --fix to upgrade", status.Pinned)
+		case status.Present && status.Version != "":
+			item.reason("the detected version matches the pin %s", status.Pinned)
+		}
+	}
+
+	if item.Action == actionSkip && status.Present && i.forced(entry, name) {
+		item.Action = actionReinstall
+		item.reason("--force installs %s again although it is present", entry)
+	}
+
+	if item.Action != actionSkip {
+		if toolConfig == nil {
+			item.reason("there is no tools entry for %s, so it cannot be installed", i.installName(name))
+			return item
+		}
+		if note := i.defaultNote(i.installName(name)); note != "" {
+			item.reason("%s", note)
+		}
+		if item.Channel = i.recordedChannel(toolConfig); item.Channel != "" {
+			for _, method := range toolConfig.Methods {
+				if !method.OnChannel(item.Channel) {
+					item.reason("method %s is skipped: it is on the %s channel, not %s", method.Name, method.Channel, item.Channel)
+				}
+			}
+		}
+		for _, method := range i.orderedMethods(toolConfig) {
+			if reason := i.rootSkipReason(method); reason != "" {
+				item.reason("method %s is skipped: %s", method.Name, reason)
+				continue
+			}
+			if status.sideBySide() && !sideBySide(method) {
+				item.reason("method %s is skipped: %s methods cannot install side by side", method.Name, method.Type)
+				continue
+			}
+			missing, providers := i.methodRequirements(name, method)
+			if len(missing) > 0 {
+				item.reason("method %s is skipped: requires %s", method.Name, strings.Join(missing, ", "))
+				continue
+			}
+			if len(providers) > 0 {
+				item.reason("method %s installs %s first", method.Name, strings.Join(providers, ", "))
+			}
+			item.Methods = append(item.Methods, method.Name)
+		}
+	}
+	i.log(planLog).Debug("decided", "entry", entry, "action", item.Action, "methods", item.Methods, "reasons", item.Reasons)
+	return item
+}
+
+// PrintPlan prints the plan as a table of the action for each entry, the method a run would
+// try first and the reasons behind the decision
+func (i *Installer) PrintPlan() {
+	fmt.Fprintf(i.display(), "\n%s╭─── Plan ───╮%s\n", colors.Blue+colors.Bold, colors.Reset)
+
+	plan := i.BuildPlan()
+	i.EstimateDownloads(plan)
+	counts := map[string]int{}
+	for _, item := range plan {
+		counts[item.Action]++
+		via := ""
+		if len(item.Methods) > 0 {
+			via = " via " + item.Methods[0]
+		}
+		if item.Channel != "" && item.Action != actionSkip {
+			via += " on " + item.Channel
+		}
+		target := item.Target + i.pinOrigin(item.status)
+		switch item.Action {
+		case actionSkip:
+			fmt.Fprintf(i.display(), "%s│ %s= %-9s%s │ skip %s\n", colors.Blue, colors.Green, item.Entry, colors.Reset, orDash(item.Current))
+		case actionUpgrade:
+			fmt.Fprintf(i.display(), "%s│ %s↑ %-9s%s │ upgrade %s → %s%s\n", colors.Blue, colors.Yellow, item.Entry, colors.Reset, item.Current, target, via)
+		case actionReinstall:
+			fmt.Fprintf(i.display(), "%s│ %s↻ %-9s%s │ reinstall %s%s\n", colors.Blue, colors.Yellow, item.Entry, colors.Reset, orDash(item.Current), via)
+		default:
+			fmt.Fprintf(i.display(), "%s│ %s+ %-9s%s │ %s%s\n", colors.Blue, colors.Yellow, item.Entry, colors.Reset, strings.TrimSpace("install "+target), via)
+		}
+		for _, reason := range item.Reasons {
+			fmt.Fprintf(i.display(), "%s│   %s%s%s\n", colors.Blue, colors.Gray, reason, colors.Reset)
+		}
+		if item.Action != actionSkip && !item.DownloadUnknown {
+			fmt.Fprintf(i.display(), "%s│   %sdownload: %s%s\n", colors.Blue, colors.Gray, formatBytes(item.DownloadSize), colors.Reset)
+		}
+	}
+	i.printDownloadTotal(plan)
+
+	reinstalls := ""
+	if counts[actionReinstall] > 0 {
+		reinstalls = fmt.Sprintf(", %d to reinstall", counts[actionReinstall])
+	}
+	fmt.Fprintf(i.display(), "%s╰─── %s%d to install, %d to upgrade%s, %d to skip %s───╯%s\n\n",
+		colors.Blue, colors.Green, counts[actionInstall], counts[actionUpgrade], reinstalls, counts[actionSkip], colors.Blue, colors.Reset)
+}
+
+// forced reports whether --force installs a tool_list entry again although it is present:
+// every entry without Options.Reinstall, or the entries and tools it names
+func (i *Installer) forced(entry, name string) bool {
+	if !i.Options.Force {
+		return false
+	}
+	return len(i.Options.Reinstall) == 0 || slices.Contains(i.Options.Reinstall, entry) || slices.Contains(i.Options.Reinstall, name)
+}
+
+// reason records a step of the planner's decision
+func (p *PlanItem) reason(format string, args ...interface{}) {
+	p.Reasons = append(p.Reasons, fmt.Sprintf(format, args...))
+}
+
+// printPlan prints the plan along with the commands each method would run
+func (i *Installer) printPlan(plan []PlanItem) {
+	fmt.Fprintf(i.display(), "\n%s╭─── Installation Plan ───╮%s\n", colors.Blue+colors.Bold, colors.Reset)
+	i.EstimateDownloads(plan)
+
+	installs, upgrades, reinstalls := 0, 0, 0
+	for _, item := range plan {
+		switch item.Action {
+		case actionSkip:
+			fmt.Fprintf(i.display(), "%s│ %s✓ %-9s%s │ skip (%s)\n", colors.Blue, colors.Green, item.Entry, colors.Reset, orDash(item.Current))
+		case actionUpgrade:
+			upgrades++
+			fmt.Fprintf(i.display(), "%s│ %s↑ %-9s%s │ upgrade %s → %s\n", colors.Blue, colors.Yellow, item.Entry, colors.Reset, item.Current, item.Target)
+		case actionReinstall:
+			reinstalls++
+			fmt.Fprintf(i.display(), "%s│ %s↻ %-9s%s │ reinstall %s\n", colors.Blue, colors.Yellow, item.Entry, colors.Reset, orDash(item.Current))
+		default:
+			installs++
+			fmt.Fprintf(i.display(), "%s│ %s+ %-9s%s │ %s\n", colors.Blue, colors.Yellow, item.Entry, colors.Reset, strings.TrimSpace("install "+item.Target))
+		}
+		i.printVersionFrom(item)
+		if item.Action == actionSkip {
+			continue
+		}
+
+		name, version := config.ParseToolEntry(item.Entry)
+		toolConfig := i.config.Tools[name]
+		if toolConfig == nil {
+			fmt.Fprintf(i.display(), "%s│   %sno installation methods available%s\n", colors.Blue, colors.Red, colors.Reset)
+			continue
+		}
+		bindir := i.toolBinDir(name)
+		if version != "" {
+			copied := *toolConfig
+			copied.Version = version
+			toolConfig, bindir = &copied, i.versionDir(name, version)
+		}
+		methods := i.orderedMethods(toolConfig)
+		var order []string
+		reordered := false
+		for n, method := range methods {
+			order = append(order, method.Name)
+			reordered = reordered || method.Name != toolConfig.Methods[n].Name
+		}
+		if item.Channel != "" {
+			fmt.Fprintf(i.display(), "%s│   %schannel: %s%s\n", colors.Blue, colors.Gray, item.Channel, colors.Reset)
+		}
+		if line := item.systemPackageLine(); line != "" {
+			fmt.Fprintf(i.display(), "%s│   %s%s%s\n", colors.Blue, colors.Gray, line, colors.Reset)
+		}
+		if !item.DownloadUnknown {
+			fmt.Fprintf(i.display(), "%s│   %sdownload: %s%s\n", colors.Blue, colors.Gray, formatBytes(item.DownloadSize), colors.Reset)
+		}
+		if len(methods) > 1 && (reordered || len(i.Options.Prefer)+len(i.config.Preferred) > 0) {
+			fmt.Fprintf(i.display(), "%s│   %sorder: %s%s\n", colors.Blue, colors.Gray, strings.Join(order, " → "), colors.Reset)
+		}
+		for _, method := range methods {
+			fmt.Fprintf(i.display(), "%s│   %s%s:%s\n", colors.Blue, colors.Yellow, method.Name, colors.Reset)
+			if err := i.checkMethodVars(name, toolConfig, method, bindir); err != nil {
+				fmt.Fprintf(i.display(), "%s│     %s❌ %v%s\n", colors.Blue, colors.Red, err, colors.Reset)
+			}
+			vars := i.commandVars(name, toolConfig.Version, bindir)
+			if mode := i.envMode(method); mode != config.EnvInherit {
+				fmt.Fprintf(i.display(), "%s│     %senv_mode: %s%s\n", colors.Blue, colors.Gray, mode, colors.Reset)
+			}
+			for _, kv := range i.describeEnv(method, vars) {
+				fmt.Fprintf(i.display(), "%s│     %senv %s%s\n", colors.Blue, colors.Gray, kv, colors.Reset)
+			}
+			for _, command := range i.describeMethod(name, toolConfig, method, bindir) {
+				fmt.Fprintf(i.display(), "%s│     %s%s%s\n", colors.Blue, colors.Gray, command, colors.Reset)
+			}
+			for _, mirror := range i.describeMirrors(method, vars) {
+				fmt.Fprintf(i.display(), "%s│     %s%s%s\n", colors.Blue, colors.Gray, mirror, colors.Reset)
+			}
+		}
+	}
+
+	i.printDownloadTotal(plan)
+	summary := fmt.Sprintf("%d to install, %d to upgrade", installs, upgrades)
+	if reinstalls > 0 {
+		summary += fmt.Sprintf(", %d to reinstall", reinstalls)
+	}
+	fmt.Fprintf(i.display(), "%s╰─── %s%s %s───╯%s\n\n", colors.Blue, colors.Green, summary, colors.Blue, colors.Reset)
+}
+
+// printVersionFrom prints the version_from command a plan item's pin came from and what it
+// resolved to
+func (i *Installer) printVersionFrom(item PlanItem) {
+	toolConfig := i.config.Tools[item.status.Name]
+	switch {
+	case item.status.Error != "":
+		fmt.Fprintf(i.display(), "%s│   %s%s%s\n", colors.Blue, colors.Red, item.status.Error, colors.Reset)
+	case toolConfig != nil && toolConfig.VersionFrom != "" && !item.status.sideBySide():
+		fmt.Fprintf(i.display(), "%s│   %sversion_from: %s → %s%s\n", colors.Blue, colors.Gray, toolConfig.VersionFrom, toolConfig.Version, colors.Reset)
+	}
+}
+
+// describeMirrors renders the mirrors a download or github_release method tries first
+func (i *Installer) describeMirrors(method config.InstallMethod, vars map[string]string) []string {
+	var lines []string
+	for _, url := range downloadURLs(method, vars) {
+		candidates := i.mirrorURLs(url)
+		for _, mirror := range candidates[:len(candidates)-1] {
+			lines = append(lines, fmt.Sprintf("mirror %s → %s", url, mirror))
+		}
+	}
+	return lines
+}
+
+// describeMethod renders the commands a method would run, for display only
+func (i *Installer) describeMethod(name string, toolConfig *config.ToolConfig, method config.InstallMethod, bindir string) []string {
+	lines := append(i.describeSteps(name, toolConfig, method, bindir), i.describeCleanup(name, toolConfig, method, bindir)...)
+	lines = append(i.describeRepositories(method, i.commandVars(name, toolConfig.Version, bindir)), lines...)
+	if user := i.methodUser(toolConfig, method); user != "" && method.Type != config.MethodDownload && method.Type != config.MethodGithubRelease {
+		lines = append([]string{"run as " + user + " through sudo -u " + user + " -H"}, lines...)
+	}
+	return lines
+}
+
+// describeSteps renders the commands or downloads of a method
+func (i *Installer) describeSteps(name string, toolConfig *config.ToolConfig, method config.InstallMethod, bindir string) []string {
+	vars := i.commandVars(name, toolConfig.Version, bindir)
+	switch method.Type {
+	case "":
+		// Captured values are only known at run time, so their references are shown as they are
+		vars = keepCaptures(method, vars)
+		var commands []string
+		for _, command := range method.Commands {
+			line := strings.TrimSpace(expandShown(command.Run, vars))
+			if command.Capture != "" {
+				line += " → ${capture:" + command.Capture + "}"
+			}
+			commands = append(commands, line)
+		}
+		return commands
+	case config.MethodDownload:
+		return append([]string{fmt.Sprintf("download %s → %s", expandShown(method.URL, vars), bindir)}, describeSignature(method)...)
+	case config.MethodGithubRelease:
+		if method.Asset == "" {
+			return append(i.describeAssetMatch(name, method, vars, bindir), describeSignature(method)...)
+		}
+		return append([]string{fmt.Sprintf("download %s release asset %s → %s", method.Repo, expandShown(method.Asset, vars), bindir)},
+			describeSignature(method)...)
+	case config.MethodScript:
+		return i.describeScript(method, vars)
+	case config.MethodMise, config.MethodAsdf:
+		return i.describeManager(name, method, strings.TrimPrefix(methodVersion(toolConfig, method), "v"))
+	default:
+		version := methodVersion(toolConfig, method)
+		parts := i.renderTypedCommand(method.Type, toolchains[method.Type].command, expandVersion(method.Package, version), version)
+		return []string{strings.Join(parts, " ")}
+	}
+}
+
+// describeSignature renders the signature check of a download, if it has one
+func describeSignature(method config.InstallMethod) []string {
+	switch {
+	case method.MinisignKey != "":
+		return []string{"verify minisign signature (key ID " + minisignKeyID(method.MinisignKey) + ")"}
+	case method.Cosign == nil:
+		return nil
+	case method.Cosign.Key != "":
+		return []string{"verify cosign signature (key " + cosignKeyFingerprint(method.Cosign.Key) + ")"}
+	}
+	return []string{fmt.Sprintf("verify cosign signature (identity %s, issuer %s)", method.Cosign.Identity, method.Cosign.Issuer)}
+}
+
+// describeAssetMatch renders the asset a github_release method without an asset pattern
+// would download and why, looking the release up without resolving secret headers
+func (i *Installer) describeAssetMatch(name string, method config.InstallMethod, vars map[string]string, bindir string) []string {
+	release, err := i.lookupRelease(name, method, vars, nil)
+	if err != nil {
+		return []string{fmt.Sprintf("download %s release asset matching %s/%s → %s", method.Repo, runtime.GOOS, runtime.GOARCH, bindir),
+			fmt.Sprintf("asset lookup failed: %v", err)}
+	}
+	chosen, scored, err := i.matchAsset(name, release.Assets)
+	if err != nil {
+		lines := []string{fmt.Sprintf("download %s release %s: %v", method.Repo, release.TagName, err)}
+		for _, s := range scored {
+			lines = append(lines, "  "+s.String())
+		}
+		return lines
+	}
+	return []string{fmt.Sprintf("download %s release asset %s → %s", method.Repo, chosen.asset.Name, bindir),
+		fmt.Sprintf("asset chosen for %s/%s: %s", runtime.GOOS, runtime.GOARCH, chosen)}
+}
+
+// PrintSelection prints the entries a selection resolved to and, for each tool_list entry it
+// left out, the rule that excluded it
+func (i *Installer) PrintSelection(patterns []string, sel config.Selection) {
+	fmt.Fprintf(i.display(), "\n%s╭─── Selection ───╮%s\n", colors.Blue+colors.Bold, colors.Reset)
+	fmt.Fprintf(i.display(), "%s│ %s%s%s\n", colors.Blue, colors.Gray, strings.Join(patterns, " "), colors.Reset)
+	for _, entry := range sel.Entries {
+		fmt.Fprintf(i.display(), "%s│ %s+ %-9s%s │ selected\n", colors.Blue, colors.Green, entry, colors.Reset)
+	}
+	for _, entry := range i.config.ToolList {
+		if slices.Contains(sel.Entries, entry) {
+			continue
+		}
+		rule, ok := sel.Excluded[entry]
+		if !ok {
+			rule = "not selected"
+		}
+		fmt.Fprintf(i.display(), "%s│ %s- %-9s%s │ %s\n", colors.Blue, colors.Gray, entry, colors.Reset, rule)
+	}
+	fmt.Fprintf(i.display(), "%s╰─── %s%d of %d tools %s───╯%s\n", colors.Blue, colors.Green, len(sel.Entries), len(i.config.ToolList), colors.Blue, colors.Reset)
+}
@@ -0,0 +1,44 @@
+package installer
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/config"
+)
+
+// writePorcelain writes one tab-separated line per entry: name, status, version and method,
+// with "-" for unknown fields. Fields are only ever appended to the end of a line.
+func (i *Installer) writePorcelain(out io.Writer, results []ToolReport) {
+	for _, result := range results {
+		status := result.Status
+		if status == statusDeferred {
+			status = statusSkipped
+		}
+		method := result.Method
+		if method == "" && (result.Status == statusOK || result.Status == statusDrift) {
+			name, _ := config.ParseToolEntry(result.Name)
+			if ts := i.loadedState().Tools[i.installName(name)]; ts != nil {
+				method = ts.Method
+			}
+		}
+		fields := []string{result.Name, status, orDash(result.Version), orDash(method)}
+		fmt.Fprintln(out, strings.Join(fields, "\t"))
+	}
+}
+
+// writeFailed writes the entries a run failed on, one per line: for installs those left
+// missing, failed or skipped because a dependency failed, for verify those missing, drifted
+// or, with Options.Integrity, changed since they were installed
+func (i *Installer) writeFailed(out io.Writer, results []ToolReport, install bool) {
+	for _, result := range results {
+		failed := result.Status == statusMissing || result.Drift || i.Options.Integrity && result.IntegrityChanged
+		if install {
+			failed = result.Status == statusMissing || result.Status == statusFailed || result.Status == statusSkipped
+		}
+		if failed {
+			fmt.Fprintln(out, result.Name)
+		}
+	}
+}
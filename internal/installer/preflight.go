@@ -0,0 +1,195 @@
+package installer
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/colors"
+	"github.com/Abhaythakor/dev-tools-installer/internal/config"
+)
+
+// preflightTimeout bounds each connectivity check
+const preflightTimeout = 3 * time.Second
+
+// registryHosts are the hosts typed methods download packages from
+var registryHosts = map[string][]string{
+	config.MethodCargo: {"index.crates.io"},
+	config.MethodPipx:  {"pypi.org"},
+	config.MethodNpm:   {"registry.npmjs.org"},
+}
+
+// commandURL matches URLs in method commands
+var commandURL = regexp.MustCompile(`https?://[^\s'"]+`)
+
+// preflight checks disk space, the download size and connectivity before an install run
+// touches anything, building the plan when it is nil
+func (i *Installer) preflight(plan Plan) error {
+	checkDisk := i.hasSizeEstimates()
+	limit := i.maxDownloadSize()
+	if !checkDisk && limit == 0 && i.Options.SkipPreflight {
+		return nil
+	}
+
+	if plan == nil {
+		plan = i.BuildPlan()
+	}
+	if checkDisk {
+		if err := i.checkDiskSpace(plan); err != nil {
+			return err
+		}
+	}
+	if limit > 0 {
+		i.EstimateDownloads(plan)
+		if err := i.checkDownloadSize(plan, limit); err != nil {
+			return err
+		}
+	}
+	if !i.Options.SkipPreflight {
+		i.checkConnectivity(plan)
+	}
+	return nil
+}
+
+// checkConnectivity checks that the hosts the plan's methods contact are reachable and
+// warns about the ones that are not
+func (i *Installer) checkConnectivity(plan []PlanItem) {
+	i.offline = probeHosts(i.planHosts(plan))
+	for _, host := range sortedKeys(i.offline) {
+		fmt.Fprintf(i.display(), "%s⚠ %s unreachable: %v; methods using it will likely fail%s\n", colors.Yellow, host, i.offline[host], colors.Reset)
+	}
+}
+
+// planHosts returns the hosts the methods of the plan's pending tools contact
+func (i *Installer) planHosts(plan []PlanItem) []string {
+	hosts := map[string]bool{}
+	for _, item := range plan {
+		if item.Action == actionSkip {
+			continue
+		}
+		name, version := config.ParseToolEntry(item.Entry)
+		toolConfig := i.config.Tools[name]
+		if toolConfig == nil {
+			continue
+		}
+		if version == "" {
+			version = toolConfig.Version
+		}
+		for _, method := range toolConfig.Methods {
+			for _, host := range i.methodHosts(name, version, method, i.toolBinDir(name)) {
+				hosts[host] = true
+			}
+		}
+	}
+	return sortedKeys(hosts)
+}
+
+// probeHosts checks hosts in parallel and returns the ones that could not be reached
+func probeHosts(hosts []string) map[string]error {
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	offline := map[string]error{}
+	for _, host := range hosts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := reachable(host); err != nil {
+				mu.Lock()
+				offline[host] = err
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return offline
+}
+
+// explainOffline replaces the error of a failed method with the connectivity problem when
+// the preflight found one of the method's hosts unreachable
+func (i *Installer) explainOffline(name string, toolConfig *config.ToolConfig, method config.InstallMethod, bindir string, err error) error {
+	if len(i.offline) == 0 {
+		return err
+	}
+	for _, host := range i.methodHosts(name, toolConfig.Version, method, bindir) {
+		if i.offline[host] != nil {
+			return fmt.Errorf("%s unreachable — check network/proxy (%v)", host, err)
+		}
+	}
+	return err
+}
+
+// methodHosts returns the hosts a method is expected to contact. Downloads are expected
+// to go to their first mirror.
+func (i *Installer) methodHosts(name, version string, method config.InstallMethod, bindir string) []string {
+	hosts := append([]string{}, registryHosts[method.Type]...)
+	vars := i.commandVars(name, version, bindir)
+	if method.Type == "" {
+		for _, command := range method.Runs() {
+			command, err := expandChecked(name, "command", command, vars)
+			if err != nil {
+				continue
+			}
+			if strings.Contains(command, "go install") || strings.Contains(command, "go get") {
+				if host := goProxyHost(); host != "" {
+					hosts = append(hosts, host)
+				}
+			}
+			for _, match := range commandURL.FindAllString(command, -1) {
+				if u, err := url.Parse(match); err == nil && u.Host != "" {
+					hosts = append(hosts, u.Host)
+				}
+			}
+		}
+	}
+	for _, download := range downloadURLs(method, vars) {
+		if u, err := url.Parse(i.mirrorURLs(download)[0]); err == nil && u.Host != "" {
+			hosts = append(hosts, u.Host)
+		}
+	}
+	return hosts
+}
+
+// goProxyHost returns the host of the first module proxy go install uses, if any
+func goProxyHost() string {
+	proxies := os.Getenv("GOPROXY")
+	if proxies == "" {
+		proxies = "https://proxy.golang.org,direct"
+	}
+	for _, proxy := range strings.FieldsFunc(proxies, func(r rune) bool { return r == ',' || r == '|' }) {
+		if proxy == "direct" || proxy == "off" {
+			return ""
+		}
+		if u, err := url.Parse(proxy); err == nil && u.Host != "" {
+			return u.Host
+		}
+	}
+	return ""
+}
+
+// reachable opens a TCP connection to host, or to the HTTP proxy configured for it
+func reachable(host string) error {
+	target := &url.URL{Scheme: "https", Host: host}
+	addr := host
+	if proxy, err := http.ProxyFromEnvironment(&http.Request{URL: target}); err == nil && proxy != nil {
+		addr = proxy.Host
+	}
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, "443")
+	}
+
+	conn, err := net.DialTimeout("tcp", addr, preflightTimeout)
+	if err != nil {
+		if addr != net.JoinHostPort(host, "443") && addr != host {
+			return fmt.Errorf("proxy %s: %v", addr, err)
+		}
+		return err
+	}
+	conn.Close()
+	return nil
+}
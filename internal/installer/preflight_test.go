@@ -0,0 +1,128 @@
+package installer
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/config"
+)
+
+// commandLines makes the commands of a method from command lines
+func commandLines(lines ...string) []config.Command {
+	var commands []config.Command
+	for _, line := range lines {
+		commands = append(commands, config.Command{Run: line})
+	}
+	return commands
+}
+
+func TestMethodHosts(t *testing.T) {
+	t.Setenv("GOPROXY", "https://goproxy.example.com,direct")
+	i := New(loadTestConfig(t, "tools: {}\n"))
+	for _, tc := range []struct {
+		name   string
+		method config.InstallMethod
+		want   []string
+	}{
+		{"command URLs", config.InstallMethod{Commands: commandLines("curl -fsSL https://get.example.com/install.sh | sh", "wget 'http://mirror.example.org/${TOOL_NAME}.tar.gz'")}, []string{"get.example.com", "mirror.example.org"}},
+		{"go install", config.InstallMethod{Commands: commandLines("go install example.com/evtool@latest")}, []string{"goproxy.example.com"}},
+		{"registry", config.InstallMethod{Type: config.MethodCargo, Package: "evtool"}, []string{"index.crates.io"}},
+		{"download", config.InstallMethod{Type: config.MethodDownload, URL: "https://dl.example.com/${TOOL_NAME}-${version}.tar.gz"}, []string{"dl.example.com"}},
+		{"release", config.InstallMethod{Type: config.MethodGithubRelease, Repo: "example/evtool"}, []string{"api.github.com", "github.com"}},
+		{"no network", config.InstallMethod{Commands: commandLines("make install")}, nil},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := i.methodHosts("evtool", "1.0.0", tc.method, "/opt/bin"); !slices.Equal(got, tc.want) {
+				t.Errorf("methodHosts = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestGoProxyHost(t *testing.T) {
+	for _, tc := range []struct {
+		goproxy string
+		want    string
+	}{
+		{"", "proxy.golang.org"},
+		{"https://goproxy.example.com|https://proxy.golang.org", "goproxy.example.com"},
+		{"direct", ""},
+		{"off", ""},
+	} {
+		t.Setenv("GOPROXY", tc.goproxy)
+		if got := goProxyHost(); got != tc.want {
+			t.Errorf("goProxyHost with GOPROXY=%q = %q, want %q", tc.goproxy, got, tc.want)
+		}
+	}
+}
+
+func TestReachable(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	if err := reachable(addr); err != nil {
+		t.Errorf("reachable(%s) = %v, want nil for a listening port", addr, err)
+	}
+	listener.Close()
+	if err := reachable(addr); err == nil {
+		t.Errorf("reachable(%s) = nil, want an error for a closed port", addr)
+	}
+}
+
+func TestExplainOffline(t *testing.T) {
+	i := New(loadTestConfig(t, "tools: {}\n"))
+	tool := &config.ToolConfig{Version: "1.0.0"}
+	method := config.InstallMethod{Commands: commandLines("curl -fsSL https://get.example.com/install.sh | sh")}
+	failed := errors.New("exit status 7")
+	if err := i.explainOffline("evtool", tool, method, "/opt/bin", failed); err != failed {
+		t.Errorf("explainOffline without offline hosts = %v, want %v", err, failed)
+	}
+
+	i.offline = map[string]error{"get.example.com": errors.New("connection refused")}
+	want := "get.example.com unreachable — check network/proxy (exit status 7)"
+	if err := i.explainOffline("evtool", tool, method, "/opt/bin", failed); err == nil || err.Error() != want {
+		t.Errorf("explainOffline = %v, want %q", err, want)
+	}
+	other := config.InstallMethod{Commands: commandLines("make install")}
+	if err := i.explainOffline("evtool", tool, other, "/opt/bin", failed); err != failed {
+		t.Errorf("explainOffline of a method without the host = %v, want %v", err, failed)
+	}
+}
+
+func TestRunWarnsAboutUnreachableHosts(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	runner := newFakeRunner(t)
+	command := "fetch http://" + addr + "/install.sh"
+	runner.fail[command] = true
+	i := newTestInstaller(t, `
+tool_list: [evtool]
+tools:
+  evtool:
+    methods: [{name: fake, commands: ["`+command+`"]}]
+`, runner)
+	i.Options.SkipPreflight = false
+	var out bytes.Buffer
+	if err := i.Apply(WithOutput(&out)); err != nil {
+		t.Fatal(err)
+	}
+	if err := i.Run(); err == nil {
+		t.Fatal("Run succeeded with the method failing")
+	}
+	for _, want := range []string{"⚠ " + addr + " unreachable", addr + " unreachable — check network/proxy (exit status 1)"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Run printed:\n%s\nwant %q", out.String(), want)
+		}
+	}
+}
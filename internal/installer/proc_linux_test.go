@@ -0,0 +1,139 @@
+package installer
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// treeScript starts a grandchild that outlives the shell unless its group is killed, and
+// prints its pid
+const treeScript = "sleep 30 & echo $!; wait"
+
+// runTree runs treeScript with localRunner, interrupts it once the grandchild started and
+// returns the pid of the grandchild. Run must return by the time the tree was killed, not
+// once the grandchild exited by itself.
+func runTree() (int, error) {
+	start := time.Now()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	r, w := io.Pipe()
+	pid := make(chan int, 1)
+	go func() {
+		line, _ := bufio.NewReader(r).ReadString('\n')
+		n, _ := strconv.Atoi(strings.TrimSpace(line))
+		pid <- n
+		cancel()
+		io.Copy(io.Discard, r)
+	}()
+	err := localRunner{}.Run(ctx, []string{"sh", "-c", treeScript}, os.Environ(), w)
+	w.Close()
+	if elapsed := time.Since(start); elapsed > 2*killGrace {
+		err = fmt.Errorf("Run returned after %v, once the tree exited by itself", elapsed.Round(time.Second))
+	}
+	return <-pid, err
+}
+
+// processGone reports whether pid exited, counting zombies no one reaped as exited
+func processGone(pid int) bool {
+	stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return true
+	}
+	fields := strings.Fields(string(stat[strings.LastIndexByte(string(stat), ')')+1:]))
+	return len(fields) > 0 && fields[0] == "Z"
+}
+
+// waitGone waits a little for pid to exit
+func waitGone(pid int) bool {
+	for deadline := time.Now().Add(2 * time.Second); time.Now().Before(deadline); time.Sleep(20 * time.Millisecond) {
+		if processGone(pid) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestRunKillsProcessTree(t *testing.T) {
+	pid, err := runTree()
+	if err != ErrInterrupted {
+		t.Fatalf("Run = %v, want ErrInterrupted", err)
+	}
+	if pid == 0 || !waitGone(pid) {
+		syscall.Kill(pid, syscall.SIGKILL)
+		t.Fatalf("the grandchild %d outlived the interrupted command", pid)
+	}
+}
+
+// TestRunKillsProcessTreeAtTerminal runs the test binary again as the session leader of a
+// pseudo-terminal, where commands take the foreground of the terminal
+func TestRunKillsProcessTreeAtTerminal(t *testing.T) {
+	if os.Getenv("EVTOOL_TERMINAL_HELPER") != "" {
+		terminalHelper()
+		return
+	}
+	master, err := os.OpenFile("/dev/ptmx", os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		t.Skipf("no pseudo-terminals: %v", err)
+	}
+	defer master.Close()
+	unlock := 0
+	if err := unix.IoctlSetPointerInt(int(master.Fd()), unix.TIOCSPTLCK, unlock); err != nil {
+		t.Fatal(err)
+	}
+	n, err := unix.IoctlGetUint32(int(master.Fd()), unix.TIOCGPTN)
+	if err != nil {
+		t.Fatal(err)
+	}
+	slave, err := os.OpenFile(fmt.Sprintf("/dev/pts/%d", n), os.O_RDWR|syscall.O_NOCTTY, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer slave.Close()
+	go io.Copy(io.Discard, master)
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestRunKillsProcessTreeAtTerminal$")
+	cmd.Env = append(os.Environ(), "EVTOOL_TERMINAL_HELPER=1")
+	cmd.Stdin = slave
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true, Setctty: true, Ctty: 0}
+	output, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("helper: %v\n%s", err, output)
+	}
+	line, _, _ := strings.Cut(string(output), "\n")
+	first, result, _ := strings.Cut(line, " ")
+	pid, err := strconv.Atoi(first)
+	if err != nil {
+		t.Fatalf("helper output %q: %v", output, err)
+	}
+	if result != "ok" {
+		t.Errorf("helper: %s", result)
+	}
+	if pid == 0 || !waitGone(pid) {
+		syscall.Kill(pid, syscall.SIGKILL)
+		t.Fatalf("the grandchild %d outlived the interrupted command", pid)
+	}
+}
+
+// terminalHelper runs treeScript in the foreground of its terminal and prints the pid of the
+// grandchild and whether the installer got the terminal back
+func terminalHelper() {
+	pid, err := runTree()
+	result := "ok"
+	if foreground, _ := unix.IoctlGetInt(0, unix.TIOCGPGRP); err != ErrInterrupted {
+		result = fmt.Sprintf("Run=%v", err)
+	} else if foreground != syscall.Getpgrp() {
+		result = fmt.Sprintf("foreground-group=%d,want=%d", foreground, syscall.Getpgrp())
+	}
+	fmt.Println(pid, result)
+}
@@ -0,0 +1,84 @@
+//go:build !windows
+
+package installer
+
+import (
+	"os"
+	"os/exec"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"golang.org/x/sys/unix"
+	"golang.org/x/term"
+)
+
+// foregroundTerminal is held by the command whose process group is in the foreground of the terminal
+var foregroundTerminal struct {
+	sync.Mutex
+	held bool
+}
+
+// setProcessGroup starts the command in its own process group so its whole tree can be
+// signalled. When the installer is in the foreground of a terminal, the group takes the
+// foreground while the command runs, so that sudo can read its password prompt and Ctrl-C
+// reaches the command; the commands of a parallel run take turns. The returned release gives
+// the terminal back, and reports whether the command was stopped with Ctrl-C, which the
+// installer then gets as well.
+func setProcessGroup(cmd *exec.Cmd) (release func() bool) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	tty := int(os.Stdin.Fd())
+	if !term.IsTerminal(tty) {
+		return func() bool { return false }
+	}
+	foreground, err := unix.IoctlGetInt(tty, unix.TIOCGPGRP)
+	if err != nil || foreground != syscall.Getpgrp() {
+		return func() bool { return false }
+	}
+	foregroundTerminal.Lock()
+	defer foregroundTerminal.Unlock()
+	if foregroundTerminal.held {
+		return func() bool { return false }
+	}
+	foregroundTerminal.held = true
+	cmd.SysProcAttr.Foreground, cmd.SysProcAttr.Ctty = true, tty
+
+	return func() bool {
+		foregroundTerminal.Lock()
+		defer foregroundTerminal.Unlock()
+		foregroundTerminal.held = false
+		// Taking the terminal back from the background would stop the installer with SIGTTOU
+		signal.Ignore(syscall.SIGTTOU)
+		unix.IoctlSetPointerInt(tty, unix.TIOCSPGRP, foreground)
+		signal.Reset(syscall.SIGTTOU)
+
+		if cmd.ProcessState == nil {
+			return false
+		}
+		if status, ok := cmd.ProcessState.Sys().(syscall.WaitStatus); !ok || !status.Signaled() || status.Signal() != syscall.SIGINT {
+			return false
+		}
+		syscall.Kill(os.Getpid(), syscall.SIGINT)
+		return true
+	}
+}
+
+// killProcessTree sends SIGTERM to the command's process group, waits up to killGrace for
+// it to exit and then sends SIGKILL to whatever is left
+func killProcessTree(cmd *exec.Cmd) {
+	if cmd.Process == nil {
+		return
+	}
+	pgid := -cmd.Process.Pid
+	if err := syscall.Kill(pgid, syscall.SIGTERM); err == syscall.ESRCH {
+		return
+	}
+	for deadline := time.Now().Add(killGrace); time.Now().Before(deadline); {
+		if err := syscall.Kill(pgid, 0); err == syscall.ESRCH {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	syscall.Kill(pgid, syscall.SIGKILL)
+}
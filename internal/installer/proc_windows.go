@@ -0,0 +1,24 @@
+//go:build windows
+
+package installer
+
+import (
+	"os/exec"
+	"strconv"
+	"syscall"
+)
+
+// setProcessGroup starts the command in its own process group. Consoles are shared by the
+// whole group, so there is nothing to release.
+func setProcessGroup(cmd *exec.Cmd) (release func() bool) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP}
+	return func() bool { return false }
+}
+
+// killProcessTree kills the command and every process it started
+func killProcessTree(cmd *exec.Cmd) {
+	if cmd.Process == nil {
+		return
+	}
+	exec.Command("taskkill", "/T", "/F", "/PID", strconv.Itoa(cmd.Process.Pid)).Run()
+}
@@ -0,0 +1,212 @@
+package installer
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/colors"
+)
+
+// Ways of showing the progress of a run
+const (
+	ProgressBox  = "box"  // The check table and a line per tool
+	ProgressLine = "line" // A single status line, then the failures and the summary
+)
+
+// lineProgressInterval is how often the status line is printed again without a terminal
+const lineProgressInterval = 10 * time.Second
+
+// lineProgress renders a run as one status line, e.g. "devtools: 7/20 done, installing
+// nuclei (go) 1m12s", for embedding the installer in the output of other programs. It sees
+// the run only through its events, like a program embedding the installer. On a terminal
+// the line is redrawn in place; otherwise it is printed every lineProgressInterval while
+// tools install. Close prints a line per failed tool and the summary.
+type lineProgress struct {
+	out      *terminalState
+	tty      bool
+	cols     func() int // Terminal width, 0 when unknown
+	now      func() time.Time
+	mu       sync.Mutex
+	total    int
+	done     int
+	inflight []*lineTask
+	failures []string
+	summary  string
+	drawn    bool // The status line is on screen
+	stop     chan struct{}
+	stopped  chan struct{}
+	release  func() // Shows the cursor again
+}
+
+// lineTask is a tool the status line shows as installing
+type lineTask struct {
+	tool    string
+	method  string
+	started time.Time
+}
+
+// newLineProgress creates a line progress writing to out, redrawing in place when out is
+// a terminal
+func newLineProgress(out *terminalState) *lineProgress {
+	tty, size := out.current()
+	cols := func() int {
+		width, _ := size()
+		return width
+	}
+	return &lineProgress{out: out, tty: tty, cols: cols, now: time.Now}
+}
+
+// Start redraws the status line every second until Close is called, or on a plain output
+// prints it every lineProgressInterval
+func (p *lineProgress) Start() {
+	interval := time.Second
+	if !p.tty {
+		interval = lineProgressInterval
+	}
+	// A terminal hides the cursor; errors and panics restoring it take the line off screen
+	p.release = p.out.acquire(func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		p.clear()
+	})
+	p.stop, p.stopped = make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(p.stopped)
+		defer recoverDrawing("status line")
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-p.stop:
+				return
+			case <-ticker.C:
+				p.tick()
+			}
+		}
+	}()
+}
+
+// tick redraws the status line, on a plain output only while tools install
+func (p *lineProgress) tick() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.tty || len(p.inflight) > 0 {
+		p.draw()
+	}
+}
+
+// Event updates the status line from an event of the run
+func (p *lineProgress) Event(e Event) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	switch e.Type {
+	case EventRunStarted:
+		p.total = e.Total
+	case EventToolStarted:
+		p.inflight = append(p.inflight, &lineTask{tool: e.Tool, started: e.Time})
+	case EventMethodStarted:
+		// Methods name the tool they install, which for same_as aliases is not the entry
+		if task := p.task(e.Tool); task != nil {
+			task.method = e.Method
+		}
+	case EventToolFinished:
+		p.done++
+		for n, task := range p.inflight {
+			if task.tool == e.Tool {
+				p.inflight = append(p.inflight[:n], p.inflight[n+1:]...)
+				break
+			}
+		}
+		if e.Status == statusFailed || e.Status == statusSkipped {
+			p.failures = append(p.failures, fmt.Sprintf("%s✗ %s: %s%s", colors.Red, e.Tool, e.Error, colors.Reset))
+		}
+	case EventRunFinished:
+		p.summary = e.Summary
+		return
+	default:
+		return
+	}
+	// A plain output gets a line per method tried rather than per event
+	if p.tty || e.Type == EventMethodStarted {
+		p.draw()
+	}
+}
+
+// task returns the installing tool a method started for: the one of that name, or else
+// the first one no method started for yet
+func (p *lineProgress) task(tool string) *lineTask {
+	for _, task := range p.inflight {
+		if task.tool == tool {
+			return task
+		}
+	}
+	for _, task := range p.inflight {
+		if task.method == "" {
+			return task
+		}
+	}
+	return nil
+}
+
+// line renders the status line
+func (p *lineProgress) line() string {
+	line := fmt.Sprintf("devtools: %d/%d done", p.done, p.total)
+	if len(p.inflight) > 0 {
+		task := p.inflight[0]
+		line += ", installing " + task.tool
+		if task.method != "" {
+			line += " (" + task.method + ")"
+		}
+		line += " " + p.now().Sub(task.started).Round(time.Second).String()
+		if len(p.inflight) > 1 {
+			line += fmt.Sprintf(", %d more", len(p.inflight)-1)
+		}
+	}
+	return line
+}
+
+// draw prints the status line, in place of the previous one on a terminal
+func (p *lineProgress) draw() {
+	line := p.line()
+	if !p.tty {
+		fmt.Fprintln(p.out, line)
+		return
+	}
+	if width := p.cols(); width > 0 && len([]rune(line)) >= width {
+		line = string([]rune(line)[:width-1])
+	}
+	fmt.Fprintf(p.out, "\r%s%s", line, clearLine)
+	p.drawn = true
+}
+
+// clear takes the status line off screen
+func (p *lineProgress) clear() {
+	if p.drawn {
+		fmt.Fprintf(p.out, "\r%s", clearLine)
+		p.drawn = false
+	}
+}
+
+// Close stops redrawing, takes the status line off screen and prints the failures and the
+// summary of the run
+func (p *lineProgress) Close() {
+	if p.stop != nil {
+		close(p.stop)
+		<-p.stopped
+		p.stop = nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.clear()
+	if p.release != nil {
+		p.release()
+		p.release = nil
+	}
+	for _, failure := range p.failures {
+		fmt.Fprintln(p.out, failure)
+	}
+	if p.summary != "" {
+		fmt.Fprintf(p.out, "%s%s%s\n", colors.Green, p.summary, colors.Reset)
+	}
+}
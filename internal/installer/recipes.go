@@ -0,0 +1,80 @@
+package installer
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/config"
+)
+
+// RecipeIndex downloads the recipe index configured in recipes.index
+func (i *Installer) RecipeIndex() (*config.RecipeIndex, error) {
+	if i.config.Recipes.Index == "" {
+		return nil, fmt.Errorf("no recipe index configured (set recipes.index)")
+	}
+	data, err := i.fetch("recipe index", i.config.Recipes.Index)
+	if err != nil {
+		return nil, err
+	}
+	return config.ParseRecipeIndex(data)
+}
+
+// Recipe returns the recipe for a tool from the recipe index
+func (i *Installer) Recipe(name string) (*config.ToolConfig, error) {
+	index, err := i.RecipeIndex()
+	if err != nil {
+		return nil, err
+	}
+	recipe := index.Tools[name]
+	if recipe == nil {
+		return nil, fmt.Errorf("no recipe for %s in %s", name, i.config.Recipes.Index)
+	}
+	return recipe, nil
+}
+
+// LoadRecipe reads a recipe file from a path or an http(s) URL. Recipes fetched from a URL
+// record it as their source.
+func (i *Installer) LoadRecipe(location string) (*config.Recipe, error) {
+	remote := strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://")
+	var data []byte
+	var err error
+	if remote {
+		data, err = i.fetch("recipe", location)
+	} else if data, err = os.ReadFile(location); err != nil {
+		err = fmt.Errorf("failed to read recipe: %v", err)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	recipe, err := config.ParseRecipe(data)
+	if err != nil {
+		return nil, err
+	}
+	recipe.Source = ""
+	if remote {
+		recipe.Source = location
+	}
+	return recipe, nil
+}
+
+// fetch downloads a small document, trying mirrors first
+func (i *Installer) fetch(what, url string) ([]byte, error) {
+	var data []byte
+	err := i.withMirrors(what, url, func(url string) error {
+		resp, err := httpGet(i.log(httpLog), url, nil)
+		if err != nil {
+			return &unavailableError{fmt.Errorf("failed to fetch %s %s: %v", what, url, err)}
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return statusError("failed to fetch "+what+" "+url, resp)
+		}
+		data, err = io.ReadAll(resp.Body)
+		return err
+	})
+	return data, err
+}
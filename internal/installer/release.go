@@ -0,0 +1,327 @@
+package installer
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/colors"
+	"github.com/Abhaythakor/dev-tools-installer/internal/config"
+)
+
+// githubRelease is the subset of the GitHub releases API response the installer uses
+type githubRelease struct {
+	TagName    string         `json:"tag_name"`
+	Draft      bool           `json:"draft"`
+	Prerelease bool           `json:"prerelease"`
+	Assets     []releaseAsset `json:"assets"`
+}
+
+// releaseAsset is a file attached to a GitHub release
+type releaseAsset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+	Size int64  `json:"size"`
+}
+
+// runReleaseMethod downloads an artifact and places its binary into bindir, returning the binary path
+func (i *Installer) runReleaseMethod(name string, toolConfig *config.ToolConfig, method config.InstallMethod, bindir string) (string, error) {
+	vars := i.commandVars(name, toolConfig.Version, bindir)
+
+	headers, err := i.resolveHeaders(method, vars)
+	if err != nil {
+		return "", err
+	}
+
+	url, err := expandChecked(name, "url", method.URL, vars)
+	if err != nil {
+		return "", err
+	}
+	if i.replaying() {
+		return "", fmt.Errorf("replay: %s methods are not recorded", method.Type)
+	}
+	if method.Type == config.MethodGithubRelease {
+		asset, err := i.resolveReleaseAsset(name, method, vars, headers)
+		if err != nil {
+			return "", err
+		}
+		url = asset.URL
+	}
+
+	var archive string
+	var resumed bool
+	if method.Type == config.MethodDownload {
+		archive, resumed, err = i.downloadCached(name, method.Name, url, headers)
+	} else {
+		archive, err = i.download(name, method.Name, url, headers)
+	}
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(archive)
+
+	if method.SHA256 != "" {
+		err := verifyChecksum(archive, method.SHA256)
+		if err != nil && resumed {
+			// The partial file may have been stale, so download it again from the start
+			i.printf("%s│%s ⚠ %v after resuming, downloading again%s\n", colors.Blue, colors.Yellow, err, colors.Reset)
+			i.tracer.event(name, "retry", map[string]interface{}{"installer.retry.reason": "checksum mismatch after resuming"})
+			os.Remove(archive)
+			if archive, err = i.download(name, method.Name, url, headers); err != nil {
+				return "", err
+			}
+			defer os.Remove(archive)
+			err = verifyChecksum(archive, method.SHA256)
+		}
+		if err != nil {
+			return "", err
+		}
+	}
+	if err := i.verifySignature(name, method, url, archive, vars); err != nil {
+		return "", err
+	}
+
+	binary := method.Binary
+	if binary == "" {
+		binary = name
+	}
+	dest := filepath.Join(bindir, binary)
+	backup, err := i.backupBinary(name, dest)
+	if err != nil {
+		return "", err
+	}
+	if err := installArtifact(archive, path.Base(url), binary, dest); err != nil {
+		if backup != nil {
+			os.Remove(backup.Path)
+		}
+		return "", err
+	}
+	i.recordBackup(name, backup)
+	return dest, nil
+}
+
+// releaseListSize is how many of the newest releases are searched for one on the channel
+const releaseListSize = 30
+
+// releaseAPI returns the GitHub API URL of the release a github_release method installs: the
+// pinned tag, the latest release on the stable channel, or otherwise the newest releases
+// to pick one from with pickRelease
+func releaseAPI(method config.InstallMethod, vars map[string]string, channel string) string {
+	if _, ok := vars["version"]; ok || method.Tag != "" {
+		tag := method.Tag
+		if tag == "" {
+			tag = "v${version}"
+		}
+		return fmt.Sprintf("https://api.github.com/repos/%s/releases/tags/%s", method.Repo, expandShown(tag, vars))
+	}
+	if channel == config.ChannelStable && method.TagPattern == "" {
+		return fmt.Sprintf("https://api.github.com/repos/%s/releases/latest", method.Repo)
+	}
+	return fmt.Sprintf("https://api.github.com/repos/%s/releases?per_page=%d", method.Repo, releaseListSize)
+}
+
+// lookupRelease fetches the release a github_release method installs
+func (i *Installer) lookupRelease(name string, method config.InstallMethod, vars, headers map[string]string) (githubRelease, error) {
+	var release githubRelease
+	if _, err := expandChecked(name, "tag", method.Tag, vars); err != nil {
+		return release, err
+	}
+	channel := i.toolChannel(i.config.Tools[name])
+	api := releaseAPI(method, vars, channel)
+	if !strings.Contains(api, "/releases?") {
+		err := i.withMirrors(name, api, func(api string) error {
+			return i.githubJSON(api, headers, &release)
+		})
+		return release, err
+	}
+	var releases []githubRelease
+	err := i.withMirrors(name, api, func(api string) error {
+		return i.githubJSON(api, headers, &releases)
+	})
+	if err != nil {
+		return release, err
+	}
+	return pickRelease(releases, method, channel)
+}
+
+// pickRelease returns the newest of the releases, which GitHub lists newest first, whose tag
+// matches the method's tag_pattern. Prereleases are only picked off the stable channel.
+func pickRelease(releases []githubRelease, method config.InstallMethod, channel string) (githubRelease, error) {
+	for _, release := range releases {
+		if release.Draft || release.Prerelease && channel == config.ChannelStable {
+			continue
+		}
+		if method.TagPattern != "" {
+			if ok, _ := path.Match(method.TagPattern, release.TagName); !ok {
+				continue
+			}
+		}
+		return release, nil
+	}
+	if method.TagPattern != "" {
+		return githubRelease{}, fmt.Errorf("none of the %d newest releases of %s on the %s channel has a tag matching %s", len(releases), method.Repo, channel, method.TagPattern)
+	}
+	return githubRelease{}, fmt.Errorf("%s has no release on the %s channel", method.Repo, channel)
+}
+
+// resolveReleaseAsset looks up the release asset matching the method's pattern, or best
+// matching this platform when the method has none
+func (i *Installer) resolveReleaseAsset(name string, method config.InstallMethod, vars, headers map[string]string) (releaseAsset, error) {
+	release, err := i.lookupRelease(name, method, vars, headers)
+	if err != nil {
+		return releaseAsset{}, err
+	}
+	chosen, scored, err := i.pickAsset(name, method, release, vars)
+	if err != nil {
+		if method.Asset == "" {
+			i.printf("%s│%s   assets of %s release %s:%s\n", colors.Blue, colors.Gray, method.Repo, release.TagName, colors.Reset)
+			for _, s := range scored {
+				i.printf("%s│%s     %s%s\n", colors.Blue, colors.Gray, s, colors.Reset)
+			}
+		}
+		return releaseAsset{}, err
+	}
+	if method.Asset == "" && i.verbose() {
+		i.printf("%s│   %sasset: %s%s\n", colors.Blue, colors.Gray, chosen, colors.Reset)
+	}
+	return chosen.asset, nil
+}
+
+// pickAsset picks the asset of a release a github_release method installs, returning the
+// scored assets when the method has no pattern
+func (i *Installer) pickAsset(name string, method config.InstallMethod, release githubRelease, vars map[string]string) (scoredAsset, []scoredAsset, error) {
+	if method.Asset == "" {
+		chosen, scored, err := i.matchAsset(name, release.Assets)
+		if err != nil {
+			return scoredAsset{}, scored, fmt.Errorf("%s release %s: %v", method.Repo, release.TagName, err)
+		}
+		return chosen, scored, nil
+	}
+
+	// Assets commonly embed the version without the tag's v prefix
+	if _, ok := vars["version"]; !ok {
+		vars["version"] = strings.TrimPrefix(release.TagName, "v")
+	}
+	pattern, err := expandChecked(name, "asset", method.Asset, vars)
+	if err != nil {
+		return scoredAsset{}, nil, err
+	}
+	for _, asset := range release.Assets {
+		if ok, _ := path.Match(pattern, asset.Name); ok {
+			return scoredAsset{asset: asset}, nil, nil
+		}
+	}
+	return scoredAsset{}, nil, fmt.Errorf("no asset matching %s in %s release %s", pattern, method.Repo, release.TagName)
+}
+
+// verifyChecksum compares the sha256 of file against the expected hex digest
+func verifyChecksum(file, expected string) error {
+	actual, err := hashFile(file)
+	if err != nil {
+		return err
+	}
+	if !strings.EqualFold(actual, expected) {
+		return fmt.Errorf("checksum mismatch: expected %s, got %s", expected, actual)
+	}
+	return nil
+}
+
+// installArtifact places binary from a downloaded artifact at dest
+func installArtifact(archive, artifactName, binary, dest string) error {
+	src := archive
+	if strings.HasSuffix(artifactName, ".tar.gz") || strings.HasSuffix(artifactName, ".tgz") {
+		dir, err := os.MkdirTemp(filepath.Dir(archive), "extract-*")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(dir)
+
+		if err := extractTarGz(archive, dir, 0); err != nil {
+			return err
+		}
+		if src, err = findFile(dir, binary); err != nil {
+			return err
+		}
+	} else if strings.HasSuffix(artifactName, ".zip") {
+		dir, err := os.MkdirTemp(filepath.Dir(archive), "extract-*")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(dir)
+
+		if err := extractZip(archive, dir); err != nil {
+			return err
+		}
+		if src, err = findFile(dir, binary); err != nil {
+			return err
+		}
+	}
+
+	return copyExecutable(src, dest)
+}
+
+// findFile returns the first regular file named name below dir
+func findFile(dir, name string) (string, error) {
+	var found string
+	filepath.WalkDir(dir, func(p string, d os.DirEntry, err error) error {
+		if err == nil && found == "" && d.Type().IsRegular() && d.Name() == name {
+			found = p
+		}
+		return nil
+	})
+	if found == "" {
+		return "", fmt.Errorf("%s not found in artifact", name)
+	}
+	return found, nil
+}
+
+// copyExecutable copies src to dest with executable permissions, replacing dest atomically.
+// The copy is written to a temp file next to dest and synced before it is renamed into
+// place, so an interrupted install never leaves a truncated binary behind.
+func copyExecutable(src, dest string) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.CreateTemp(filepath.Dir(dest), "."+filepath.Base(dest)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmp := out.Name()
+	_, err = io.Copy(out, in)
+	if err == nil {
+		err = out.Sync()
+	}
+	if err == nil {
+		err = out.Chmod(0755)
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp, dest)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	syncDir(filepath.Dir(dest))
+	return nil
+}
+
+// syncDir flushes a directory entry change such as a rename to disk, where supported
+func syncDir(dir string) {
+	if d, err := os.Open(dir); err == nil {
+		d.Sync()
+		d.Close()
+	}
+}
@@ -0,0 +1,295 @@
+package installer
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/colors"
+)
+
+// Renderer shows one status line per in-flight tool while installs run in parallel.
+// Status lines are redrawn in place below the regular output; finished tools and log
+// lines are printed above them. Without a terminal, or when the terminal is too short
+// for every status line, it falls back to plain sequential log lines. Status lines are
+// cut to the terminal width, which is queried again when the terminal is resized, and they
+// are taken off screen while the process is suspended with Ctrl-Z.
+type Renderer struct {
+	out    io.Writer
+	term   *terminalState // The terminal out is, whose cursor state the renderer shares
+	tty    bool
+	rows   func() int // Terminal height, 0 when unknown
+	cols   func() int // Terminal width, 0 when unknown
+	now    func() time.Time
+	mu     sync.Mutex
+	tasks  []*renderTask
+	drawn  int // Status lines currently on screen
+	frame  int
+	width  int  // Terminal width status lines are cut to, 0 for no limit
+	paused bool // The process is suspended; status lines are not drawn
+	stop   chan struct{}
+	done   chan struct{}
+
+	release     func() // Shows the cursor again
+	stopSignals func() // Stops handling job control and resize signals
+}
+
+// stepMarker starts the log line of a step when status lines are not drawn in place, where
+// a spinner frame would not move
+const stepMarker = "…"
+
+// renderTask is the status of one in-flight tool
+type renderTask struct {
+	name    string
+	method  string
+	detail  string
+	started time.Time
+}
+
+// NewRenderer creates a renderer writing to out. When tty is set, status lines are
+// redrawn in place as long as rows reports enough terminal height for them.
+func NewRenderer(out io.Writer, tty bool, rows func() int) *Renderer {
+	if rows == nil {
+		rows = func() int { return 0 }
+	}
+	return &Renderer{out: out, tty: tty, rows: rows, cols: func() int { return 0 }, now: time.Now}
+}
+
+// newTerminalRenderer creates a renderer drawing to t
+func newTerminalRenderer(t *terminalState) *Renderer {
+	tty, size := t.current()
+	r := NewRenderer(t, tty, func() int {
+		_, height := size()
+		return height
+	})
+	r.cols = func() int {
+		width, _ := size()
+		return width
+	}
+	r.term = t
+	return r
+}
+
+// Start animates the status lines until Close is called
+func (r *Renderer) Start() {
+	if !r.tty {
+		return
+	}
+	if r.term != nil {
+		r.release = r.term.acquire(r.Close)
+		r.stopSignals = r.watchSignals()
+	}
+	r.width = r.cols()
+	r.stop, r.done = make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(r.done)
+		defer recoverDrawing("status lines")
+		ticker := time.NewTicker(100 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-r.stop:
+				return
+			case <-ticker.C:
+				r.tick()
+			}
+		}
+	}()
+}
+
+// tick advances the spinners of the status lines and redraws them
+func (r *Renderer) tick() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.frame++
+	if r.live() {
+		r.redraw(nil)
+	}
+}
+
+// Close stops the animation and removes any status lines still on screen
+func (r *Renderer) Close() {
+	if r.stopSignals != nil {
+		r.stopSignals()
+		r.stopSignals = nil
+	}
+	if r.stop != nil {
+		close(r.stop)
+		<-r.done
+		r.stop = nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tasks = nil
+	r.redraw(nil)
+	if r.release != nil {
+		r.release()
+	}
+}
+
+// Begin adds a status line for a tool
+func (r *Renderer) Begin(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.tasks = append(r.tasks, &renderTask{name: name, started: r.now()})
+	if r.live() {
+		r.redraw(nil)
+	}
+}
+
+// Step updates the method and current step shown on a tool's status line
+func (r *Renderer) Step(name, method, detail string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	task := r.task(name)
+	if task == nil || (task.method == method && task.detail == detail) {
+		return
+	}
+	task.method, task.detail = method, detail
+	if r.live() {
+		r.redraw(nil)
+		return
+	}
+	r.redraw([]string{fmt.Sprintf("%s│ %s%s %s%s", colors.Blue, colors.Yellow, stepMarker, describeTask(task), colors.Reset)})
+}
+
+// Finish removes a tool's status line and prints its final line above the live region
+func (r *Renderer) Finish(name, line string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for n, task := range r.tasks {
+		if task.name == name {
+			r.tasks = append(r.tasks[:n], r.tasks[n+1:]...)
+			break
+		}
+	}
+	r.redraw([]string{line})
+}
+
+// Printf prints a log line above the live region
+func (r *Renderer) Printf(format string, args ...interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.redraw([]string{strings.TrimSuffix(fmt.Sprintf(format, args...), "\n")})
+}
+
+// Live reports whether status lines are currently redrawn in place
+func (r *Renderer) Live() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.live()
+}
+
+// task returns the in-flight task for name
+func (r *Renderer) task(name string) *renderTask {
+	for _, task := range r.tasks {
+		if task.name == name {
+			return task
+		}
+	}
+	return nil
+}
+
+// suspend takes the status lines off screen and shows the cursor before the process stops
+func (r *Renderer) suspend() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.paused = true
+	r.redraw(nil)
+	io.WriteString(r.out, showCursorSeq)
+}
+
+// resume hides the cursor and draws the status lines again once the process continues
+func (r *Renderer) resume() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.paused {
+		return
+	}
+	r.paused = false
+	r.width = r.cols()
+	io.WriteString(r.out, hideCursorSeq)
+	r.redraw(nil)
+}
+
+// resize cuts the status lines to the terminal's new width
+func (r *Renderer) resize() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.width = r.cols()
+	r.redraw(nil)
+}
+
+// live reports whether status lines can be drawn in place
+func (r *Renderer) live() bool {
+	if !r.tty || r.paused {
+		return false
+	}
+	rows := r.rows()
+	// Keep a spare row so the region never scrolls the first status line off screen
+	return rows == 0 || len(r.tasks) < rows
+}
+
+// redraw erases the status lines, prints lines above them and draws them again.
+// The caller must hold r.mu.
+func (r *Renderer) redraw(lines []string) {
+	var buf strings.Builder
+	if r.drawn > 0 {
+		fmt.Fprintf(&buf, "\033[%dA\r\033[J", r.drawn)
+		r.drawn = 0
+	}
+	for _, line := range lines {
+		buf.WriteString(line + "\n")
+	}
+	if r.live() {
+		for _, task := range r.tasks {
+			line := fmt.Sprintf("%s│ %s%s %s %s(%s)%s",
+				colors.Blue,
+				colors.Yellow,
+				spinnerChars[r.frame%len(spinnerChars)],
+				describeTask(task),
+				colors.Gray,
+				r.now().Sub(task.started).Round(time.Second),
+				colors.Reset)
+			// A wrapped line would throw off the count of lines to erase
+			buf.WriteString(truncateVisible(line, r.width-1) + "\n")
+		}
+		r.drawn = len(r.tasks)
+	}
+	io.WriteString(r.out, buf.String())
+}
+
+// describeTask renders the tool, method and step of a status line
+func describeTask(task *renderTask) string {
+	switch {
+	case task.method == "":
+		return fmt.Sprintf("Installing %s", task.name)
+	case task.detail == "":
+		return fmt.Sprintf("Installing %s (%s)", task.name, task.method)
+	}
+	return fmt.Sprintf("Installing %s (%s): %s", task.name, task.method, task.detail)
+}
+
+// truncateVisible cuts s to width visible characters, skipping ANSI escape sequences, and
+// resets colors when it cut anything. A width below one leaves s unchanged.
+func truncateVisible(s string, width int) string {
+	if width < 1 {
+		return s
+	}
+	visible, escape := 0, false
+	for n, c := range s {
+		switch {
+		case escape:
+			escape = !(c >= '@' && c <= '~' && c != '[')
+		case c == '\033':
+			escape = true
+		case visible == width:
+			return s[:n] + colors.Reset
+		default:
+			visible++
+		}
+	}
+	return s
+}
@@ -0,0 +1,87 @@
+package installer
+
+import (
+	"bytes"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+// ansiSequence matches the color and cursor sequences of terminal output
+var ansiSequence = regexp.MustCompile(`\033\[[0-9;?]*[A-Za-z]`)
+
+// visibleLines returns the lines of terminal output as they read without escape sequences,
+// each from the last carriage return in it
+func visibleLines(output string) []string {
+	lines := strings.Split(ansiSequence.ReplaceAllString(output, ""), "\n")
+	for n, line := range lines {
+		lines[n] = line[strings.LastIndex(line, "\r")+1:]
+	}
+	return lines
+}
+
+func TestRendererDrawsStatusLinesOnATerminal(t *testing.T) {
+	var out bytes.Buffer
+	r := newTerminalRenderer(newTerminal(&out, &virtualTerminal{width: 40, height: 24}))
+	start := time.Now()
+	r.now = func() time.Time { return start }
+	r.Start()
+	r.Begin("a")
+	r.Begin("b")
+	r.Step("a", "fake", "install a")
+	r.Step("b", "fake", "a step far too long for the terminal width")
+	r.Finish("a", "✓ a")
+	r.Close()
+
+	output, lines := out.String(), visibleLines(out.String())
+	if !strings.HasPrefix(output, hideCursorSeq) || !strings.HasSuffix(output, "\033[1A\r\033[J"+showCursorSeq) {
+		t.Errorf("output = %q, want the cursor hidden first and shown after the last status line is erased", output)
+	}
+	if !slices.Contains(lines, "│ ⠋ Installing a (fake): install a (0s)") {
+		t.Errorf("lines = %q, want a status line with the spinner, step and elapsed time", lines)
+	}
+	if !strings.Contains(output, "\033[2A\r\033[J✓ a\n") {
+		t.Errorf("output = %q, want both status lines erased before the final line of a", output)
+	}
+	for _, line := range lines {
+		if utf8.RuneCountInString(line) > 39 {
+			t.Errorf("line %q is wider than the terminal", line)
+		}
+	}
+}
+
+func TestRendererPrintsLogLinesWithoutALiveTerminal(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		new  func(*bytes.Buffer) *Renderer
+	}{
+		{"no terminal", func(out *bytes.Buffer) *Renderer { return newTerminalRenderer(newTerminal(out, nil)) }},
+		{"too few rows", func(out *bytes.Buffer) *Renderer {
+			return newTerminalRenderer(newTerminal(out, &virtualTerminal{width: 80, height: 1}))
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+			r := tc.new(&out)
+			r.Start()
+			r.Begin("a")
+			r.Begin("b")
+			r.Step("a", "fake", "install a")
+			r.Step("a", "fake", "install a")
+			r.Finish("a", "✓ a")
+			r.Finish("b", "✗ b")
+			r.Close()
+
+			lines := visibleLines(out.String())
+			if want := []string{"│ … Installing a (fake): install a", "✓ a", "✗ b", ""}; strings.Join(lines, "\n") != strings.Join(want, "\n") {
+				t.Errorf("lines = %q, want %q", lines, want)
+			}
+			if strings.ContainsAny(out.String(), strings.Join(spinnerChars, "")) || strings.Contains(out.String(), "\r") {
+				t.Errorf("output = %q, want no spinner frames or lines redrawn in place", out.String())
+			}
+		})
+	}
+}
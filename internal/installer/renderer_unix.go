@@ -0,0 +1,44 @@
+//go:build !windows
+
+package installer
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// watchSignals handles job control and resizes while the status lines are live: SIGTSTP
+// takes them off screen and shows the cursor before the process stops, SIGCONT draws them
+// again and SIGWINCH cuts them to the new width. It returns a function that stops it.
+func (r *Renderer) watchSignals() func() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTSTP, syscall.SIGCONT, syscall.SIGWINCH)
+	stop, done := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case <-stop:
+				return
+			case sig := <-signals:
+				switch sig {
+				case syscall.SIGTSTP:
+					r.suspend()
+					// Stop for real now that the terminal is clean; the Go runtime keeps
+					// catching SIGTSTP, so stop with SIGSTOP. SIGCONT resumes here.
+					syscall.Kill(os.Getpid(), syscall.SIGSTOP)
+				case syscall.SIGCONT:
+					r.resume()
+				case syscall.SIGWINCH:
+					r.resize()
+				}
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(signals)
+		close(stop)
+		<-done
+	}
+}
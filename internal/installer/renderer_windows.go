@@ -0,0 +1,8 @@
+//go:build windows
+
+package installer
+
+// watchSignals does nothing on Windows, which has no job control or resize signals
+func (r *Renderer) watchSignals() func() {
+	return func() {}
+}
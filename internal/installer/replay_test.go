@@ -0,0 +1,103 @@
+package installer
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// replayFixture copies testdata/replay into a temp directory, with @DIR@ replaced by it, and
+// returns the config and the recording
+func replayFixture(t *testing.T) (yaml, recording string) {
+	t.Helper()
+	dir := t.TempDir()
+	for _, name := range []string{"installer.yaml", "run.json"} {
+		data, err := os.ReadFile(filepath.Join("testdata", "replay", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		data = bytes.ReplaceAll(data, []byte("@DIR@"), []byte(dir))
+		if name == "installer.yaml" {
+			yaml = string(data)
+			continue
+		}
+		recording = filepath.Join(dir, name)
+		if err := os.WriteFile(recording, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return yaml, recording
+}
+
+func TestReplayRecordedRun(t *testing.T) {
+	for _, concurrency := range []int{1, 2} {
+		t.Run(fmt.Sprintf("concurrency %d", concurrency), func(t *testing.T) {
+			yaml, recording := replayFixture(t)
+			var out bytes.Buffer
+			i := New(loadTestConfig(t, yaml), WithOutput(&out))
+			i.Options.Replay = recording
+			i.Options.Concurrency = concurrency
+			i.Options.SkipPreflight = true
+
+			err := i.Run()
+			if !errors.Is(err, ErrInstallFailed) || !strings.Contains(err.Error(), "1 of 4 tools failed, 1 skipped because a dependency failed") {
+				t.Fatalf("Run error = %v, want the recorded failure of broken", err)
+			}
+			if output := ansiSequence.ReplaceAllString(out.String(), ""); !strings.Contains(output, "╰─── 2/4 tools installed, 1 skipped (dependency failed) ───╯") {
+				t.Errorf("output = %q, want the summary of the recorded run", out.String())
+			}
+			want := map[string]string{"sh": statusOK, "evtool": statusInstalled, "broken": statusFailed, "after-broken": statusSkipped}
+			for _, result := range i.report {
+				if result.Status != want[result.Name] {
+					t.Errorf("%s: status %s, want %s", result.Name, result.Status, want[result.Name])
+				}
+			}
+			if len(i.report) != len(want) {
+				t.Errorf("report has %d tools, want %d", len(i.report), len(want))
+			}
+		})
+	}
+}
+
+func TestReplayFailsOnUnrecordedCommands(t *testing.T) {
+	yaml, recording := replayFixture(t)
+	// A command the recording does not have
+	yaml = strings.Replace(yaml, `exit 3`, `exit 4`, 1)
+	i := New(loadTestConfig(t, yaml), WithOutput(&bytes.Buffer{}))
+	i.Options.Replay = recording
+	i.Options.SkipPreflight = true
+
+	err := i.Run()
+	if !errors.Is(err, ErrInstallFailed) || !strings.Contains(err.Error(), "replay: 1 invocations were not recorded, first: sh -c echo cannot reach the mirror >&2; exit 4") {
+		t.Fatalf("Run error = %v, want the unrecorded command reported", err)
+	}
+	if _, statErr := os.Stat(filepath.Join(filepath.Dir(recording), "bin", "evtool")); statErr == nil {
+		t.Error("the replay ran the recorded commands")
+	}
+}
+
+func TestPorcelainOutputOfRecordedRun(t *testing.T) {
+	for _, concurrency := range []int{1, 2} {
+		t.Run(fmt.Sprintf("concurrency %d", concurrency), func(t *testing.T) {
+			yaml, recording := replayFixture(t)
+			var out, diag bytes.Buffer
+			i := New(loadTestConfig(t, yaml), WithOutput(&out), WithDiagnostics(&diag))
+			i.Options.Replay = recording
+			i.Options.Concurrency = concurrency
+			i.Options.SkipPreflight = true
+			i.Options.Porcelain = true
+			if err := i.Run(); !errors.Is(err, ErrInstallFailed) {
+				t.Fatalf("Run error = %v, want the recorded failure", err)
+			}
+			// The lines are a documented format: fields may be appended, never changed
+			checkGolden(t, filepath.Join("testdata", "replay", "porcelain.golden"), out.Bytes())
+			if diag.Len() > 0 {
+				t.Errorf("diagnostics = %q, want nothing from a run without warnings", diag.String())
+			}
+		})
+	}
+}
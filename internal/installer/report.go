@@ -0,0 +1,75 @@
+package installer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Tool statuses recorded in the report
+const (
+	statusOK          = "ok"
+	statusMissing     = "missing"
+	statusDrift       = "drift"
+	statusInstalled   = "installed"
+	statusUpgraded    = "upgraded"
+	statusReinstalled = "reinstalled" // Installed again with --force although it was present
+	statusFailed      = "failed"
+	statusDeferred    = "deferred" // Not installed because the time budget ran out
+	statusSkipped     = "skipped"  // Not attempted because a dependency failed to install
+)
+
+// Report is the JSON report written with Options.ReportPath
+type Report struct {
+	GeneratedAt time.Time `json:"generated_at"`
+	ConfigSource
+	Tools []ToolReport `json:"tools"`
+}
+
+// ToolReport is the outcome for a single tool_list entry
+type ToolReport struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Version string `json:"version,omitempty"`
+	Pinned  string `json:"pinned,omitempty"`
+
+	PreviousVersion string `json:"previous_version,omitempty"`
+	PackageVersion  string `json:"package_version,omitempty"` // Version the package manager assigned, for package-manager methods
+
+	Drift    bool   `json:"drift"`
+	Method   string `json:"method,omitempty"`
+	Channel  string `json:"channel,omitempty"` // Channel the tool was installed from
+	Error    string `json:"error,omitempty"`
+	TimedOut bool   `json:"timed_out,omitempty"` // Failed because the tool's install_timeout ran out
+	ExitCode int    `json:"exit_code,omitempty"` // Exit code of the last command the tool's method ran
+	Output   string `json:"output,omitempty"`    // Output of the last failed command, sanitized and capped at output_limit
+	Stack    string `json:"stack,omitempty"`     // Stack trace of a panic that failed the tool, for bug reports
+
+	Hints []string `json:"hints,omitempty"` // Known fixes matching the output of the failed methods
+
+	SystemPackages []string `json:"system_packages,omitempty"` // System packages installed for the tool, as manager:package
+
+	DownloadedBytes int64 `json:"downloaded_bytes,omitempty"` // Bytes the installer downloaded for the tool
+	DownloadUnknown bool  `json:"download_unknown,omitempty"` // The method that installed the tool downloaded an amount the installer cannot see, such as go install
+
+	Signature *SignatureReport `json:"signature,omitempty"` // Signature the downloaded artifact was verified against
+
+	IntegrityChanged bool `json:"integrity_changed,omitempty"`
+}
+
+// writeReport writes the JSON report if one was requested
+func (i *Installer) writeReport() error {
+	if i.Options.ReportPath == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(Report{GeneratedAt: time.Now(), ConfigSource: i.source, Tools: i.report}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(i.Options.ReportPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write report: %v", err)
+	}
+	return nil
+}
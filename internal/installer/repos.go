@@ -0,0 +1,352 @@
+package installer
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/colors"
+	"github.com/Abhaythakor/dev-tools-installer/internal/config"
+)
+
+// aptSource is an apt repository of a method as the installer writes it
+type aptSource struct {
+	repo    config.AptRepo
+	file    string // Sources file, e.g. /etc/apt/sources.list.d/apt.releases.hashicorp.com.list
+	keyring string
+	keyURL  string
+	content string // The deb lines of the sources file
+}
+
+// aptSource renders the sources file of a method's apt repository, expanding vars in its URLs
+// and suites. Suites default to the codename of the distribution.
+func (i *Installer) aptSource(repo config.AptRepo, vars map[string]string) (aptSource, error) {
+	suites := repo.Suites
+	if len(suites) == 0 {
+		codename := i.osRelease("VERSION_CODENAME")
+		if codename == "" {
+			return aptSource{}, fmt.Errorf("the codename of the distribution is unknown; set the suites of apt_repo")
+		}
+		suites = []string{codename}
+	}
+	components := repo.Components
+	if len(components) == 0 {
+		components = []string{"main"}
+	}
+	name := vars["TOOL_NAME"]
+	keyURL, err := expandChecked(name, "apt_repo key_url", repo.KeyURL, vars)
+	if err != nil {
+		return aptSource{}, err
+	}
+	url, err := expandChecked(name, "apt_repo url", repo.URL, vars)
+	if err != nil {
+		return aptSource{}, err
+	}
+	source := aptSource{
+		repo:    repo,
+		file:    "/etc/apt/sources.list.d/" + repo.Name() + ".list",
+		keyring: repo.Keyring(),
+		keyURL:  keyURL,
+	}
+	for _, suite := range suites {
+		expanded, err := expandChecked(name, "apt_repo suite", suite, vars)
+		if err != nil {
+			return aptSource{}, err
+		}
+		line := fmt.Sprintf("deb [signed-by=%s] %s %s", source.keyring, url, expanded)
+		// Flat repositories have no components
+		if !strings.HasSuffix(suite, "/") {
+			line += " " + strings.Join(components, " ")
+		}
+		source.content += line + "\n"
+	}
+	return source, nil
+}
+
+// aptSourcePresent reports whether the repository is already set up as the installer would write it
+func (i *Installer) aptSourcePresent(source aptSource) bool {
+	data, err := os.ReadFile(i.inRoot(source.file))
+	if err != nil || string(data) != source.content {
+		return false
+	}
+	info, err := os.Stat(i.inRoot(source.keyring))
+	return err == nil && info.Size() > 0
+}
+
+// privileged returns argv run with root privileges: through sudo when the installer is not
+// root, unchanged with --root, whose target it writes as root
+func (i *Installer) privileged(argv []string) []string {
+	if i.Options.Root == "" && os.Geteuid() > 0 {
+		return append([]string{"sudo"}, argv...)
+	}
+	return argv
+}
+
+// setupRepositories adds the taps and apt repositories of the first methods of the plan's
+// pending tools before any of them installs, so that apt-get update runs once for all of
+// them, and for the default methods installing through apt-get, as fresh images have no
+// package lists. Failures are reported when the methods run.
+func (i *Installer) setupRepositories(plan Plan) {
+	i.repoMu.Lock()
+	defer i.repoMu.Unlock()
+	for _, item := range plan {
+		if item.Action == actionSkip {
+			continue
+		}
+		if i.context().Err() != nil {
+			return
+		}
+		name, version := config.ParseToolEntry(item.Entry)
+		name = i.installName(name)
+		toolConfig := i.config.Tools[name]
+		if toolConfig == nil {
+			continue
+		}
+		methods := i.orderedMethods(toolConfig)
+		if len(methods) == 0 || i.rootSkipReason(methods[0]) != "" {
+			continue
+		}
+		if version == "" {
+			version = toolConfig.Version
+		}
+		i.addRepositories(name, methods[0], i.commandVars(name, version, i.toolBinDir(name)))
+		if toolConfig.Default && usesAptGet(methods[0]) {
+			_ = i.once("apt-get update for default methods", func() error {
+				if i.aptStale == "" {
+					i.aptStale = "for the default methods"
+				}
+				return nil
+			})
+		}
+	}
+	// The update is not a tool; when it fails, the first method needing it tries again
+	const label = "apt-get update"
+	_ = i.updateApt(label, "apt repositories")
+	i.takeOutput(label)
+	i.takeExitCode(label)
+}
+
+// prepareRepositories adds the taps and apt repository a method needs before it runs,
+// running apt-get update when a repository was added since it last ran
+func (i *Installer) prepareRepositories(name string, method config.InstallMethod, vars map[string]string) error {
+	if len(method.Taps) == 0 && method.AptRepo == nil {
+		return nil
+	}
+	i.repoMu.Lock()
+	defer i.repoMu.Unlock()
+	if err := i.addRepositories(name, method, vars); err != nil {
+		return err
+	}
+	if method.AptRepo == nil {
+		return nil
+	}
+	if err := i.updateApt(name, method.Name); err != nil {
+		return fmt.Errorf("apt-get update failed: %v", err)
+	}
+	return nil
+}
+
+// addRepositories adds the taps and apt repository of a method that are not set up yet,
+// remembering the outcome so that each is only tried once per run
+func (i *Installer) addRepositories(name string, method config.InstallMethod, vars map[string]string) error {
+	for _, tap := range method.Taps {
+		if err := i.once("tap "+tap, func() error { return i.addTap(name, method.Name, tap) }); err != nil {
+			return fmt.Errorf("brew tap %s failed: %v", tap, err)
+		}
+	}
+	if method.AptRepo == nil {
+		return nil
+	}
+	source, err := i.aptSource(*method.AptRepo, vars)
+	if err != nil {
+		return err
+	}
+	if err := i.once("apt "+source.file, func() error { return i.addAptSource(name, method.Name, source) }); err != nil {
+		return fmt.Errorf("adding apt repository %s failed: %v", source.repo.Name(), err)
+	}
+	return nil
+}
+
+// once runs add unless the repository named key was set up this run, returning the error
+// its first attempt failed with
+func (i *Installer) once(key string, add func() error) error {
+	i.mu.Lock()
+	err, done := i.repos[key]
+	i.mu.Unlock()
+	if done {
+		return err
+	}
+	err = add()
+	i.mu.Lock()
+	if i.repos == nil {
+		i.repos = map[string]error{}
+	}
+	i.repos[key] = err
+	i.mu.Unlock()
+	return err
+}
+
+// addTap runs brew tap unless Homebrew already has the tap
+func (i *Installer) addTap(name, methodName, tap string) error {
+	if i.taps == nil {
+		if _, err := i.commands().LookPath("brew"); err != nil {
+			return fmt.Errorf("brew was not found on PATH")
+		}
+		i.taps = map[string]bool{}
+		output, err := i.commands().Output([]string{"brew", "tap"})
+		i.log(execLog).Debug("brew taps", "error", err, "output", string(output))
+		for _, line := range strings.Fields(string(output)) {
+			i.taps[strings.ToLower(line)] = true
+		}
+	}
+	if i.taps[strings.ToLower(tap)] {
+		return nil
+	}
+	argv := []string{"brew", "tap", tap}
+	i.printf("%s│%s 🍺 Adding Homebrew tap %s for %s%s\n", colors.Blue, colors.Yellow, tap, name, colors.Reset)
+	if err := i.runCommand(name, methodName, "tap", argv, nil); err != nil {
+		return err
+	}
+	i.taps[strings.ToLower(tap)] = true
+	return nil
+}
+
+// addAptSource stores the repository's key in its keyring and writes its sources file,
+// unless both are in place already
+func (i *Installer) addAptSource(name, methodName string, source aptSource) error {
+	if _, err := i.commands().LookPath("apt-get"); err != nil {
+		return fmt.Errorf("apt-get was not found on PATH")
+	}
+	if i.aptSourcePresent(source) {
+		return nil
+	}
+	i.printf("%s│%s 📦 Adding apt repository %s for %s%s\n", colors.Blue, colors.Yellow, source.repo.Name(), name, colors.Reset)
+	key, err := i.fetch("apt repository key", source.keyURL)
+	if err != nil {
+		return err
+	}
+	if !strings.HasSuffix(source.keyring, ".asc") {
+		if key, err = dearmor(key); err != nil {
+			return fmt.Errorf("key %s: %v", source.keyURL, err)
+		}
+	}
+	if err := i.installFile(name, methodName, "apt key", key, source.keyring); err != nil {
+		return err
+	}
+	if err := i.installFile(name, methodName, "apt source", []byte(source.content), source.file); err != nil {
+		return err
+	}
+	i.aptStale = "for the added repositories"
+	return nil
+}
+
+// installFile writes data to a root-owned file with mode 0644, creating its directory
+func (i *Installer) installFile(name, methodName, step string, data []byte, path string) error {
+	f, err := os.CreateTemp(i.tempDir, "repo-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	argv := i.privileged([]string{"install", "-D", "-m", "0644", f.Name(), i.inRoot(path)})
+	if i.verbose() {
+		i.printf("%s│   %s%s%s\n", colors.Blue, colors.Gray, strings.Join(argv, " "), colors.Reset)
+	}
+	return i.runCommand(name, methodName, step, argv, nil)
+}
+
+// updateApt runs apt-get update when an apt repository was added since it last ran, or
+// default methods are about to install through apt-get
+func (i *Installer) updateApt(name, methodName string) error {
+	if i.aptStale == "" {
+		return nil
+	}
+	argv := i.privileged([]string{"apt-get", "update"})
+	if i.Options.Root != "" {
+		argv = []string{"chroot", i.Options.Root, "apt-get", "update"}
+	}
+	i.printf("%s│%s 📦 Updating the apt package lists %s%s\n", colors.Blue, colors.Yellow, i.aptStale, colors.Reset)
+	if err := i.runCommand(name, methodName, "update", argv, nil); err != nil {
+		i.printf("%s│%s ❌ apt-get update failed: %v%s\n", colors.Blue, colors.Red, i.redact(err.Error()), colors.Reset)
+		return err
+	}
+	i.aptStale = ""
+	return nil
+}
+
+// dearmor returns the binary form of an ASCII-armored OpenPGP key, which apt only reads from
+// keyrings ending in .asc, or the key unchanged when it is binary already
+func dearmor(key []byte) ([]byte, error) {
+	begin := bytes.Index(key, []byte("-----BEGIN PGP"))
+	if begin < 0 {
+		return key, nil
+	}
+	var body strings.Builder
+	inHeaders := true
+	for _, line := range strings.Split(string(key[begin:]), "\n")[1:] {
+		line = strings.TrimSpace(line)
+		switch {
+		case strings.HasPrefix(line, "-----END PGP"):
+			return base64.StdEncoding.DecodeString(body.String())
+		case inHeaders && strings.Contains(line, ": "):
+			continue
+		case inHeaders && line == "":
+			inHeaders = false
+			continue
+		case strings.HasPrefix(line, "="):
+			// The checksum of the armor
+			continue
+		}
+		inHeaders = false
+		body.WriteString(line)
+	}
+	return nil, fmt.Errorf("the armored key has no end")
+}
+
+// describeRepositories renders what a method sets up before it runs, for display only
+func (i *Installer) describeRepositories(method config.InstallMethod, vars map[string]string) []string {
+	var lines []string
+	for _, tap := range method.Taps {
+		lines = append(lines, "brew tap "+tap)
+	}
+	if method.AptRepo == nil {
+		return lines
+	}
+	source, err := i.aptSource(*method.AptRepo, vars)
+	if err != nil {
+		return append(lines, "apt repository: "+err.Error())
+	}
+	if i.aptSourcePresent(source) {
+		return append(lines, "apt repository "+source.file+" (already added)")
+	}
+	lines = append(lines, fmt.Sprintf("fetch key %s → %s", source.keyURL, source.keyring))
+	for _, line := range strings.Split(strings.TrimSpace(source.content), "\n") {
+		lines = append(lines, fmt.Sprintf("add %s to %s", line, source.file))
+	}
+	return append(lines, "apt-get update (once for every repository the run adds)")
+}
+
+// repositoriesLikely reports whether the first methods of several entries add apt
+// repositories, going by the config alone since the entries are not checked yet
+func (i *Installer) repositoriesLikely(entries []string) bool {
+	count := 0
+	for _, entry := range entries {
+		name, _ := config.ParseToolEntry(entry)
+		toolConfig := i.config.Tools[i.installName(name)]
+		if toolConfig == nil {
+			continue
+		}
+		if methods := i.orderedMethods(toolConfig); len(methods) > 0 && methods[0].AptRepo != nil {
+			count++
+		}
+	}
+	return count > 1
+}
@@ -0,0 +1,112 @@
+package installer
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// resumeFile downloads url into path, continuing after the bytes already there when the
+// server supports range requests and still serves the same artifact. The response's
+// validator (a strong ETag or Last-Modified) is kept next to path so a changed artifact
+// restarts from zero, and the partial file is kept when the download fails. resumed is
+// called before the body is read when the download continues, and the offset it continued
+// at is returned. Reads go through limiter.
+func resumeFile(log *slog.Logger, path, url string, headers map[string]string, limiter *rateLimiter, resumed func(offset, total int64), progress func(done, total int64)) (int64, error) {
+	var offset int64
+	validator, _ := os.ReadFile(path + ".validator")
+	if info, err := os.Stat(path); err == nil && len(validator) > 0 {
+		offset = info.Size()
+	}
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return 0, err
+	}
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		req.Header.Set("If-Range", string(validator))
+	}
+
+	resp, err := doRequest(log, req)
+	if err != nil {
+		return 0, &unavailableError{fmt.Errorf("failed to download %s: %v", url, err)}
+	}
+	defer resp.Body.Close()
+
+	flags := os.O_CREATE | os.O_WRONLY
+	total := resp.ContentLength
+	switch {
+	case offset > 0 && resp.StatusCode == http.StatusPartialContent && contentRangeStart(resp) == offset:
+		flags |= os.O_APPEND
+		if total >= 0 {
+			total += offset
+		}
+	case resp.StatusCode == http.StatusOK:
+		offset = 0
+		flags |= os.O_TRUNC
+	case offset > 0:
+		// The server could not continue where the partial file ends, so start over
+		resp.Body.Close()
+		os.Remove(path)
+		os.Remove(path + ".validator")
+		return resumeFile(log, path, url, headers, limiter, resumed, progress)
+	default:
+		return 0, statusError("failed to download "+url, resp)
+	}
+
+	if err := saveValidator(path+".validator", resp); err != nil {
+		return 0, err
+	}
+	f, err := os.OpenFile(path, flags, 0644)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create download file: %v", err)
+	}
+	defer f.Close()
+
+	if offset > 0 && resumed != nil {
+		resumed(offset, total)
+	}
+	body := &countingReader{r: limiter.reader(resp.Body), n: offset, total: total, progress: progress}
+	if _, err := io.Copy(f, body); err != nil {
+		return offset, &unavailableError{fmt.Errorf("failed to download %s: %v", url, err)}
+	}
+
+	os.Remove(path + ".validator")
+	return offset, nil
+}
+
+// saveValidator records the validator a later range request sends in If-Range, removing
+// the file when the response has none that can be used
+func saveValidator(file string, resp *http.Response) error {
+	validator := resp.Header.Get("ETag")
+	if validator == "" || strings.HasPrefix(validator, "W/") {
+		validator = resp.Header.Get("Last-Modified")
+	}
+	if validator == "" || resp.Header.Get("Accept-Ranges") == "none" {
+		os.Remove(file)
+		return nil
+	}
+	return os.WriteFile(file, []byte(validator), 0644)
+}
+
+// contentRangeStart returns the first byte of a 206 response, or -1 when it is not reported
+func contentRangeStart(resp *http.Response) int64 {
+	value, ok := strings.CutPrefix(resp.Header.Get("Content-Range"), "bytes ")
+	if !ok {
+		return -1
+	}
+	start, _, _ := strings.Cut(value, "-")
+	n, err := strconv.ParseInt(start, 10, 64)
+	if err != nil {
+		return -1
+	}
+	return n
+}
@@ -0,0 +1,209 @@
+package installer
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// artifactServer serves an artifact with an ETag and range support, recording the Range
+// header of each request. With cut set, the next response ends after that many bytes.
+type artifactServer struct {
+	mu      sync.Mutex
+	content []byte
+	etag    string
+	cut     int
+	ranges  []string
+}
+
+func (s *artifactServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.ranges = append(s.ranges, r.Header.Get("Range"))
+	content, cut := s.content, s.cut
+	s.cut = 0
+	s.mu.Unlock()
+	w.Header().Set("ETag", s.etag)
+	if cut > 0 {
+		// Announce the whole artifact, then drop the connection partway through it
+		w.Header().Set("Content-Length", strconv.Itoa(len(content)))
+		w.Write(content[:cut])
+		w.(http.Flusher).Flush()
+		conn, _, _ := w.(http.Hijacker).Hijack()
+		conn.Close()
+		return
+	}
+	http.ServeContent(w, r, "artifact", time.Time{}, bytes.NewReader(content))
+}
+
+func TestResumeFile(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789abcdef"), 4096)
+	for _, tc := range []struct {
+		name      string
+		partial   int    // Bytes already downloaded
+		validator string // Validator kept with them
+		changed   bool   // The server now serves another artifact under another ETag
+		offset    int64  // Where the download must continue
+		ranges    []string
+	}{
+		{name: "fresh", offset: 0, ranges: []string{""}},
+		{name: "resume", partial: 1000, validator: `"v1"`, offset: 1000, ranges: []string{"bytes=1000-"}},
+		{name: "changed etag", partial: 1000, validator: `"v1"`, changed: true, offset: 0, ranges: []string{"bytes=1000-"}},
+		{name: "416 for a partial longer than the artifact", partial: len(content) + 10, validator: `"v1"`, offset: 0, ranges: []string{"bytes=65546-", ""}},
+		{name: "partial without validator", partial: 1000, offset: 0, ranges: []string{""}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			server := &artifactServer{content: content, etag: `"v1"`}
+			want := content
+			if tc.changed {
+				want = bytes.Repeat([]byte("fedcba9876543210"), 4096)
+				server.content, server.etag = want, `"v2"`
+			}
+			ts := httptest.NewServer(server)
+			defer ts.Close()
+
+			path := filepath.Join(t.TempDir(), "artifact")
+			if tc.partial > 0 {
+				if err := os.WriteFile(path, bytes.Repeat(content, 2)[:tc.partial], 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if tc.validator != "" {
+				if err := os.WriteFile(path+".validator", []byte(tc.validator), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			resumedAt := int64(-1)
+			offset, err := resumeFile(slog.Default(), path, ts.URL, nil, nil, func(offset, total int64) {
+				resumedAt = offset
+				if total != int64(len(want)) {
+					t.Errorf("resumed with total %d, want %d", total, len(want))
+				}
+			}, nil)
+			if err != nil {
+				t.Fatalf("resumeFile: %v", err)
+			}
+			if offset != tc.offset || (tc.offset > 0) != (resumedAt == tc.offset) {
+				t.Errorf("continued at %d (resumed callback at %d), want %d", offset, resumedAt, tc.offset)
+			}
+			if got, _ := os.ReadFile(path); !bytes.Equal(got, want) {
+				t.Errorf("downloaded %d bytes that differ from the %d bytes served", len(got), len(want))
+			}
+			if strings.Join(server.ranges, ",") != strings.Join(tc.ranges, ",") {
+				t.Errorf("requested ranges %q, want %q", server.ranges, tc.ranges)
+			}
+			if _, err := os.Stat(path + ".validator"); err == nil {
+				t.Error("the validator is left after a complete download")
+			}
+		})
+	}
+}
+
+func TestResumeFileContinuesAnInterruptedDownload(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789abcdef"), 4096)
+	server := &artifactServer{content: content, etag: `"v1"`, cut: 5000}
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+	path := filepath.Join(t.TempDir(), "artifact")
+
+	if _, err := resumeFile(slog.Default(), path, ts.URL, nil, nil, nil, nil); err == nil {
+		t.Fatal("resumeFile succeeded although the connection dropped")
+	}
+	if info, err := os.Stat(path); err != nil || info.Size() != 5000 {
+		t.Fatalf("partial file = %v, %v; want the 5000 bytes received", info, err)
+	}
+	if validator, err := os.ReadFile(path + ".validator"); err != nil || string(validator) != `"v1"` {
+		t.Fatalf("validator = %q, %v; want the ETag", validator, err)
+	}
+
+	offset, err := resumeFile(slog.Default(), path, ts.URL, nil, nil, nil, nil)
+	if err != nil || offset != 5000 {
+		t.Fatalf("resumeFile = %d, %v; want it to continue at 5000", offset, err)
+	}
+	if got, _ := os.ReadFile(path); !bytes.Equal(got, content) {
+		t.Error("the resumed file differs from the artifact")
+	}
+}
+
+func TestResumeFileRestartsWhenTheRangeDoesNotContinueThePartialFile(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789abcdef"), 4096)
+	var mu sync.Mutex
+	var requests []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r.Header.Get("Range")+" if-range "+r.Header.Get("If-Range"))
+		mu.Unlock()
+		w.Header().Set("ETag", `"v1"`)
+		if r.Header.Get("Range") != "" {
+			// A server that ignores the offset asked for and sends the artifact from its start
+			w.Header().Set("Content-Range", fmt.Sprintf("bytes 0-%d/%d", len(content)-1, len(content)))
+			w.WriteHeader(http.StatusPartialContent)
+			w.Write(content)
+			return
+		}
+		w.Write(content)
+	}))
+	defer ts.Close()
+
+	path := filepath.Join(t.TempDir(), "artifact")
+	if err := os.WriteFile(path, content[:1000], 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path+".validator", []byte(`"v1"`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	offset, err := resumeFile(slog.Default(), path, ts.URL, nil, nil, func(offset, total int64) {
+		t.Errorf("resumed at %d, want a restart", offset)
+	}, nil)
+	if err != nil || offset != 0 {
+		t.Fatalf("resumeFile = %d, %v; want a download from the start", offset, err)
+	}
+	if got, _ := os.ReadFile(path); !bytes.Equal(got, content) {
+		t.Errorf("downloaded %d bytes that differ from the %d bytes served", len(got), len(content))
+	}
+	if want := []string{`bytes=1000- if-range "v1"`, " if-range "}; strings.Join(requests, ",") != strings.Join(want, ",") {
+		t.Errorf("requests = %q, want %q", requests, want)
+	}
+}
+
+func TestSaveValidator(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		header http.Header
+		want   string
+	}{
+		{"strong etag", http.Header{"Etag": {`"v1"`}, "Last-Modified": {"Tue, 13 Oct 2026 10:00:00 GMT"}}, `"v1"`},
+		{"weak etag", http.Header{"Etag": {`W/"v1"`}, "Last-Modified": {"Tue, 13 Oct 2026 10:00:00 GMT"}}, "Tue, 13 Oct 2026 10:00:00 GMT"},
+		{"no validator", http.Header{}, ""},
+		{"no ranges", http.Header{"Etag": {`"v1"`}, "Accept-Ranges": {"none"}}, ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			file := filepath.Join(t.TempDir(), "artifact.validator")
+			if err := os.WriteFile(file, []byte(`"stale"`), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := saveValidator(file, &http.Response{Header: tc.header}); err != nil {
+				t.Fatal(err)
+			}
+			got, err := os.ReadFile(file)
+			if tc.want == "" {
+				if err == nil {
+					t.Errorf("validator = %q, want the file removed", got)
+				}
+				return
+			}
+			if string(got) != tc.want {
+				t.Errorf("validator = %q, %v; want %q", got, err, tc.want)
+			}
+		})
+	}
+}
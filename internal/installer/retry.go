@@ -0,0 +1,38 @@
+package installer
+
+import (
+	"slices"
+)
+
+// RetryRecord tracks the tool_list entries that failed to install, for --retry-failed
+type RetryRecord struct {
+	Entries []string `json:"entries"`          // Entries install runs covered so far
+	Failed  []string `json:"failed,omitempty"` // Entries whose last install failed, was skipped or was deferred
+}
+
+// recordFailures updates the failed entries of the state with the results of an install run.
+// Entries the run did not cover keep their previous outcome.
+func (i *Installer) recordFailures(results []ToolReport) {
+	state := i.loadedState()
+	if state.Retry == nil {
+		state.Retry = &RetryRecord{}
+	}
+	record := state.Retry
+	for _, result := range results {
+		if !slices.Contains(record.Entries, result.Name) {
+			record.Entries = append(record.Entries, result.Name)
+		}
+		record.Failed = slices.DeleteFunc(record.Failed, func(entry string) bool { return entry == result.Name })
+		switch result.Status {
+		case statusFailed, statusSkipped, statusDeferred:
+			record.Failed = append(record.Failed, result.Name)
+		}
+	}
+}
+
+// retryEntry reports whether Options.RetryFailed covers an entry: its last install failed,
+// or it was added to tool_list since the install runs recorded
+func (i *Installer) retryEntry(entry string) bool {
+	record := i.loadedState().Retry
+	return record != nil && (slices.Contains(record.Failed, entry) || !slices.Contains(record.Entries, entry))
+}
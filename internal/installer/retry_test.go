@@ -0,0 +1,63 @@
+package installer
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestRetryFailed(t *testing.T) {
+	runner := newFakeRunner(t)
+	runner.fail["install b"] = true
+	cfg := loadTestConfig(t, `
+tool_list: [a, b]
+tools:
+  a:
+    methods: [{name: fake, commands: ["install a"]}]
+  b:
+    methods: [{name: fake, commands: ["install b"]}]
+  c:
+    methods: [{name: fake, commands: ["install c"]}]
+`)
+	// run installs with a new Installer, as separate invocations do, returning the commands
+	// it ran and its output
+	run := func(retry bool) ([]string, string, error) {
+		t.Helper()
+		var out bytes.Buffer
+		i := New(cfg, WithOutput(&out), WithRunner(runner))
+		i.Options.SkipPreflight, i.Options.RetryFailed = true, retry
+		ran := len(runner.commands())
+		err := i.Run()
+		return runner.commands()[ran:], out.String(), err
+	}
+
+	if _, _, err := run(false); err == nil {
+		t.Fatal("Run succeeded with b failing")
+	}
+	delete(runner.fail, "install b")
+	if commands, _, err := run(true); err != nil || !slices.Equal(commands, []string{"install b"}) {
+		t.Errorf("retry ran %q (%v), want only the failed b", commands, err)
+	}
+	if commands, out, err := run(true); err != nil || len(commands) > 0 || !strings.Contains(out, "Nothing to retry: no tool failed at its last install") {
+		t.Errorf("retry without failures ran %q (%v) and printed:\n%s\nwant nothing to retry", commands, err, out)
+	}
+
+	// Entries added to tool_list since are retried as well
+	cfg.ToolList = append(cfg.ToolList, "c")
+	if commands, _, err := run(true); err != nil || !slices.Equal(commands, []string{"install c"}) {
+		t.Errorf("retry ran %q (%v), want only the new c", commands, err)
+	}
+
+	records, err := New(cfg).History()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var retries []bool
+	for _, record := range records {
+		retries = append(retries, record.Retry)
+	}
+	if !slices.Equal(retries, []bool{false, true, true}) {
+		t.Errorf("history marks retries %v, want the runs after the first", retries)
+	}
+}
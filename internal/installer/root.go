@@ -0,0 +1,111 @@
+package installer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/config"
+)
+
+// targetSystemPath is searched for commands inside a --root target, after the install
+// directories
+var targetSystemPath = []string{"/usr/local/sbin", "/usr/local/bin", "/usr/sbin", "/usr/bin", "/sbin", "/bin"}
+
+// inRoot maps a path of the target system to this machine's filesystem; without
+// Options.Root it is returned unchanged
+func (i *Installer) inRoot(path string) string {
+	if i.Options.Root == "" {
+		return path
+	}
+	return filepath.Join(i.Options.Root, path)
+}
+
+// inTarget maps a path under Options.Root to the path the target system sees it at
+func (i *Installer) inTarget(path string) string {
+	if i.Options.Root == "" {
+		return path
+	}
+	rel, err := filepath.Rel(i.Options.Root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return path
+	}
+	return filepath.Join("/", rel)
+}
+
+// targetPath returns the directories commands are looked up in inside the target
+func (i *Installer) targetPath() []string {
+	dirs := []string{i.inTarget(i.binDir())}
+	for _, name := range sortedKeys(i.config.Tools) {
+		if dir := i.inTarget(i.toolBinDir(name)); !slices.Contains(dirs, dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+	return append(dirs, targetSystemPath...)
+}
+
+// rootSkipReason explains why a method can't install into the --root target, or returns ""
+func (i *Installer) rootSkipReason(method config.InstallMethod) string {
+	if i.Options.Root == "" {
+		return ""
+	}
+	brew := method.Name == "brew" || len(method.Taps) > 0
+	for _, command := range method.Runs() {
+		brew = brew || strings.HasPrefix(strings.TrimSpace(command), "brew ")
+	}
+	switch {
+	case brew:
+		return "Homebrew cannot install into another root"
+	case method.InTarget:
+		return ""
+	case method.Type == config.MethodCargo || method.Type == config.MethodPipx || method.Type == config.MethodNpm || versionManager(method.Type):
+		return method.Type + " installs into the running system; set in_target: true to run it inside the target"
+	}
+	return ""
+}
+
+// rootRunner looks commands up inside a --root target and runs the target's binaries
+// through chroot, which needs root privileges; without them they run directly
+type rootRunner struct {
+	runner CommandRunner
+	root   string
+	path   []string
+}
+
+func (r rootRunner) LookPath(name string) (string, error) {
+	if strings.ContainsRune(name, filepath.Separator) {
+		return r.runner.LookPath(name)
+	}
+	for _, dir := range r.path {
+		if path := filepath.Join(r.root, dir, name); isExecutable(path) {
+			return path, nil
+		}
+	}
+	return "", &exec.Error{Name: name, Err: fmt.Errorf("not found in %s", r.root)}
+}
+
+// Output runs probes, such as version checks, against the target's binaries
+func (r rootRunner) Output(argv []string) ([]byte, error) {
+	if path, err := r.LookPath(argv[0]); err == nil {
+		argv = append([]string{path}, argv[1:]...)
+	}
+	return r.runner.Output(r.chroot(argv))
+}
+
+func (r rootRunner) Run(ctx context.Context, argv, env []string, out io.Writer) error {
+	return r.runner.Run(ctx, r.chroot(argv), env, out)
+}
+
+// chroot runs argv inside the root when its binary is the target's
+func (r rootRunner) chroot(argv []string) []string {
+	rel, err := filepath.Rel(r.root, argv[0])
+	if os.Geteuid() != 0 || !filepath.IsAbs(argv[0]) || err != nil || strings.HasPrefix(rel, "..") {
+		return argv
+	}
+	return append([]string{"chroot", r.root, filepath.Join("/", rel)}, argv[1:]...)
+}
@@ -0,0 +1,173 @@
+package installer
+
+import (
+	"os"
+	"os/user"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/colors"
+	"github.com/Abhaythakor/dev-tools-installer/internal/config"
+)
+
+// userScopedCommands install into the home directory of the user running them
+var userScopedCommands = []string{"go", "cargo", "pipx", "rustup"}
+
+// runAs is the user the commands of a tool's current method run as
+type runAs struct {
+	user string
+	keep []string // Configured env variables passed through sudo
+}
+
+// sudoInvoker returns the user who ran the installer through sudo, or nil when it was not
+// run through sudo by another user
+func sudoInvoker() *user.User {
+	name := os.Getenv("SUDO_USER")
+	if os.Geteuid() != 0 || name == "" || name == "root" {
+		return nil
+	}
+	u, err := user.Lookup(name)
+	if err != nil {
+		return nil
+	}
+	return u
+}
+
+// userScoped reports whether a method installs into the home directory of the user running it
+func userScoped(method config.InstallMethod) bool {
+	if method.Type == config.MethodCargo || method.Type == config.MethodPipx || versionManager(method.Type) {
+		return true
+	}
+	if method.Type != "" {
+		return false
+	}
+	for _, command := range method.Runs() {
+		for _, part := range strings.Fields(command) {
+			// Skip variable assignments such as GOBIN=${bindir}
+			if strings.Contains(part, "=") {
+				continue
+			}
+			if slices.Contains(userScopedCommands, filepath.Base(part)) {
+				return true
+			}
+			break
+		}
+	}
+	return false
+}
+
+// methodUser returns the user a method's commands run as: its run_as or the tool's, or under
+// sudo with sudo_user_methods the invoking user for user-scoped methods. It is empty when they
+// run as the installer's own user.
+func (i *Installer) methodUser(toolConfig *config.ToolConfig, method config.InstallMethod) string {
+	name := method.RunAs
+	if name == "" && toolConfig != nil {
+		name = toolConfig.RunAs
+	}
+	if name == "" && i.config.SudoUserMethods && i.sudo != nil && userScoped(method) && !method.InTarget {
+		name = i.sudo.Username
+	}
+	if current, err := user.Current(); err == nil && current.Username == name {
+		return ""
+	}
+	return name
+}
+
+// setRunAs records the user a tool's current method runs as, handing its ${tmpdir} to that user
+func (i *Installer) setRunAs(name string, toolConfig *config.ToolConfig, method config.InstallMethod) error {
+	username := i.methodUser(toolConfig, method)
+	i.clearRunAs(name)
+	i.mu.Lock()
+	if username != "" {
+		if i.users == nil {
+			i.users = map[string]runAs{}
+		}
+		keep := append(sortedKeys(i.config.Env), sortedKeys(method.Env)...)
+		slices.Sort(keep)
+		i.users[name] = runAs{user: username, keep: slices.Compact(keep)}
+	}
+	i.mu.Unlock()
+	if username == "" || i.tempDir == "" {
+		return nil
+	}
+	if i.verbose() {
+		i.printf("%s│   %srunning as %s%s\n", colors.Blue, colors.Gray, username, colors.Reset)
+	}
+	u, err := user.Lookup(username)
+	if err != nil {
+		return err
+	}
+	// The run's temp directory only needs to be traversable by the user
+	if err := os.Chmod(i.tempDir, 0711); err != nil {
+		return err
+	}
+	return chownUser(i.toolTempDir(name), u)
+}
+
+// clearRunAs makes a tool's commands run as the installer's user again
+func (i *Installer) clearRunAs(name string) {
+	i.mu.Lock()
+	delete(i.users, name)
+	i.mu.Unlock()
+}
+
+// asUser wraps the command of a tool's method in sudo when the method runs as another user,
+// with HOME set to that user's home and the configured env passed through
+func (i *Installer) asUser(name string, parts []string) []string {
+	i.mu.Lock()
+	as, ok := i.users[name]
+	i.mu.Unlock()
+	if !ok {
+		return parts
+	}
+	argv := []string{"sudo", "-u", as.user, "-H"}
+	if len(as.keep) > 0 {
+		argv = append(argv, "--preserve-env="+strings.Join(as.keep, ","))
+	}
+	return append(append(argv, "--"), parts...)
+}
+
+// warnSudo warns when the installer runs under sudo while user-scoped methods would install
+// into root's home directory
+func (i *Installer) warnSudo(entries []string) {
+	if i.sudo == nil || i.config.SudoUserMethods {
+		return
+	}
+	for _, entry := range entries {
+		name, _ := config.ParseToolEntry(entry)
+		toolConfig := i.config.Tools[i.installName(name)]
+		if toolConfig == nil || toolConfig.RunAs != "" {
+			continue
+		}
+		for _, method := range toolConfig.Methods {
+			if userScoped(method) && method.RunAs == "" && !method.InTarget {
+				i.printf("%s│%s ⚠ Running under sudo: go, cargo and pipx methods install for root; set sudo_user_methods: true to run them as %s%s\n",
+					colors.Blue, colors.Yellow, i.sudo.Username, colors.Reset)
+				return
+			}
+		}
+	}
+}
+
+// restoreOwnership hands the files the installer wrote into the home directory of the user who
+// invoked sudo back to that user
+func (i *Installer) restoreOwnership() {
+	if i.sudo == nil || i.sudo.HomeDir == "" {
+		return
+	}
+	dirs := []string{i.binDir(), i.stateDir(), i.github.dir}
+	for _, name := range sortedKeys(i.config.Tools) {
+		dirs = append(dirs, i.toolBinDir(name))
+	}
+	home := filepath.Clean(i.sudo.HomeDir)
+	for _, dir := range dirs {
+		dir = filepath.Clean(dir)
+		if rel, err := filepath.Rel(home, dir); err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		if err := chownTree(dir, home, i.sudo); err != nil {
+			i.printf("%s⚠ Failed to hand %s back to %s: %v%s\n", colors.Yellow, dir, i.sudo.Username, err, colors.Reset)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package installer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/colors"
+)
+
+// runLockName is the lockfile in the state directory held by runs that change state
+const runLockName = "run.lock"
+
+// runLockPoll is how often --wait-lock checks whether the lock was released
+const runLockPoll = time.Second
+
+// runLockHolder identifies the run holding the lock; it is the content of the lockfile
+type runLockHolder struct {
+	PID     int       `json:"pid"`
+	Started time.Time `json:"started"`
+	Command string    `json:"command"`
+}
+
+func (h runLockHolder) String() string {
+	if h.PID == 0 {
+		return "another installer run"
+	}
+	return fmt.Sprintf("installer run %q (pid %d, started %s)", h.Command, h.PID, h.Started.Format("2006-01-02 15:04:05"))
+}
+
+// lockRun takes the advisory lock on the state directory so that concurrent runs don't race
+// on the state file, the logs and package managers. When another run holds it, lockRun fails
+// naming that run, or with Options.WaitLock waits for it to finish. The lock is released when
+// its holder exits, however it ends, so a lockfile is never removed: a holder that looks gone,
+// as across PID namespaces, may still hold it. The returned function releases the lock.
+func (i *Installer) lockRun() (func(), error) {
+	path := filepath.Join(i.stateDir(), runLockName)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create state directory: %v", err)
+	}
+
+	waiting := false
+	for {
+		f, holder, err := tryRunLock(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to lock %s: %v", path, err)
+		}
+		if f != nil {
+			return func() { f.Close() }, nil
+		}
+		if !i.Options.WaitLock {
+			return nil, fmt.Errorf("%s holds %s; wait for it to finish or pass --wait-lock", holder, path)
+		}
+		if !waiting {
+			fmt.Fprintf(i.display(), "%sWaiting for %s to finish...%s\n", colors.Gray, holder, colors.Reset)
+			waiting = true
+		}
+		time.Sleep(runLockPoll)
+	}
+}
+
+// tryRunLock opens and locks the lockfile, recording this run in it. When another process
+// holds the lock it returns a nil file and the holder recorded in the lockfile.
+func tryRunLock(path string) (*os.File, runLockHolder, error) {
+	var holder runLockHolder
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, holder, err
+	}
+	locked, err := lockFile(f)
+	if err != nil || !locked {
+		f.Close()
+		if data, readErr := os.ReadFile(path); readErr == nil {
+			json.Unmarshal(data, &holder)
+		}
+		return nil, holder, err
+	}
+
+	holder = runLockHolder{PID: os.Getpid(), Started: time.Now(), Command: runCommandLine()}
+	data, _ := json.Marshal(holder)
+	if err := f.Truncate(0); err == nil {
+		f.WriteAt(append(data, '\n'), 0)
+	}
+	return f, holder, nil
+}
+
+// runCommandLine returns the command line of this process, naming the binary by its base name
+func runCommandLine() string {
+	args := append([]string{filepath.Base(os.Args[0])}, os.Args[1:]...)
+	return strings.Join(args, " ")
+}
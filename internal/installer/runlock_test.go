@@ -0,0 +1,54 @@
+//go:build !windows
+
+package installer
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/config"
+)
+
+func TestLockRunKeepsLockOfHolderThatLooksGone(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, runLockName)
+	held, _, err := tryRunLock(path)
+	if err != nil || held == nil {
+		t.Fatalf("tryRunLock = %v, %v", held, err)
+	}
+	defer held.Close()
+	// A holder whose pid is not visible here, as from another PID namespace
+	data, _ := json.Marshal(runLockHolder{PID: 1 << 30, Started: time.Now(), Command: "installer install"})
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	before, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	i := New(&config.InstallerConfig{StateDir: dir})
+	unlock, err := i.lockRun()
+	if err == nil {
+		unlock()
+		t.Fatal("lockRun took a lock another file description holds")
+	}
+	if !strings.Contains(err.Error(), "pid 1073741824") {
+		t.Errorf("lockRun error = %v, want it to name the holder", err)
+	}
+	after, err := os.Stat(path)
+	if err != nil || !os.SameFile(before, after) {
+		t.Fatalf("the lockfile was removed or replaced (%v)", err)
+	}
+
+	held.Close()
+	unlock, err = i.lockRun()
+	if err != nil {
+		t.Fatalf("lockRun after the holder released the lock: %v", err)
+	}
+	unlock()
+}
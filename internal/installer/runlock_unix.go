@@ -0,0 +1,18 @@
+//go:build !windows
+
+package installer
+
+import (
+	"os"
+	"syscall"
+)
+
+// lockFile takes an exclusive flock on f without blocking, reporting false when another
+// process holds it
+func lockFile(f *os.File) (bool, error) {
+	err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if err == syscall.EWOULDBLOCK {
+		return false, nil
+	}
+	return err == nil, err
+}
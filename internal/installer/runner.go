@@ -0,0 +1,370 @@
+package installer
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/colors"
+)
+
+// CommandRunner spawns the processes of a run: PATH lookups, short probes such as version
+// checks, and method commands. Runs execute on this machine unless they are recorded with
+// Options.Record or replayed with Options.Replay, or Options.Runner replaces the runner.
+type CommandRunner interface {
+	// LookPath resolves a command name like exec.LookPath
+	LookPath(name string) (string, error)
+	// Output runs argv and returns its combined output
+	Output(argv []string) ([]byte, error)
+	// Run runs argv with env, writing its output to out, until it exits or ctx is done
+	Run(ctx context.Context, argv, env []string, out io.Writer) error
+}
+
+// startError reports a command that could not be started
+type startError struct {
+	err error
+}
+
+func (e *startError) Error() string {
+	return e.err.Error()
+}
+
+// localRunner runs commands on this machine
+type localRunner struct{}
+
+func (localRunner) LookPath(name string) (string, error) {
+	return exec.LookPath(name)
+}
+
+// Output runs probes in the C locale, so that the output parsed from them isn't translated
+func (localRunner) Output(argv []string) ([]byte, error) {
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Env = probeEnv(os.Environ())
+	return cmd.CombinedOutput()
+}
+
+// probeEnv returns env with the locale forced to C
+func probeEnv(env []string) []string {
+	probe := make([]string, 0, len(env)+2)
+	for _, kv := range env {
+		name, _, _ := strings.Cut(kv, "=")
+		if name != "LANG" && name != "LANGUAGE" && !strings.HasPrefix(name, "LC_") {
+			probe = append(probe, kv)
+		}
+	}
+	return append(probe, "LANG=C", "LC_ALL=C")
+}
+
+// Run starts the command in its own process group so failures and interrupts can stop
+// its whole tree
+func (localRunner) Run(ctx context.Context, argv, env []string, out io.Writer) error {
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Env = env
+	release := setProcessGroup(cmd)
+	cmd.Stdout, cmd.Stderr = out, out
+	// Background processes left holding the output open must not block Wait forever
+	cmd.WaitDelay = killGrace
+
+	if err := cmd.Start(); err != nil {
+		release()
+		return &startError{err}
+	}
+
+	// Wait for the command to complete, stopping it when the run is interrupted
+	exited := make(chan error, 1)
+	go func() { exited <- cmd.Wait() }()
+	select {
+	case err := <-exited:
+		if err != nil {
+			// Grandchildren of a failed command may still hold locks the next method needs
+			killProcessTree(cmd)
+		}
+		if release() {
+			return ErrInterrupted
+		}
+		return err
+	case <-ctx.Done():
+		killProcessTree(cmd)
+		<-exited
+		release()
+		return ErrInterrupted
+	}
+}
+
+// exitCode returns the exit code reported by a command error, 0 for success and -1 when
+// the command did not exit normally
+func exitCode(err error) int {
+	var coded interface{ ExitCode() int }
+	switch {
+	case err == nil:
+		return 0
+	case errors.As(err, &coded):
+		return coded.ExitCode()
+	}
+	return -1
+}
+
+// fixture is a recorded run, as written by --record and read by --replay
+type fixture struct {
+	Commands []recordedCommand `json:"commands"`
+}
+
+// recordedCommand is one recorded lookup, probe or command
+type recordedCommand struct {
+	Kind     string   `json:"kind"` // lookpath, output or run
+	Argv     []string `json:"argv"`
+	Path     string   `json:"path,omitempty"`   // Result of a lookup
+	Output   string   `json:"output,omitempty"` // Combined output
+	Error    string   `json:"error,omitempty"`
+	ExitCode int      `json:"exit_code,omitempty"`
+	Duration int64    `json:"duration_ms,omitempty"`
+}
+
+// key identifies the invocation a recorded command answers
+func (c recordedCommand) key() string {
+	return c.Kind + "\x00" + strings.Join(c.Argv, "\x00")
+}
+
+// replayError reproduces a recorded command error, including its exit code
+type replayError struct {
+	msg  string
+	code int
+}
+
+func (e *replayError) Error() string { return e.msg }
+func (e *replayError) ExitCode() int { return e.code }
+
+// recordingRunner runs commands with the runner returned by commands and records their
+// results. normalize rewrites run-specific values, such as the temp directory, and redacts
+// secrets.
+type recordingRunner struct {
+	commands  func() CommandRunner
+	normalize func(string) string
+	mu        sync.Mutex
+	fixture   fixture
+}
+
+func (r *recordingRunner) add(c recordedCommand, err error) {
+	for n, arg := range c.Argv {
+		c.Argv[n] = r.normalize(arg)
+	}
+	c.Path, c.Output = r.normalize(c.Path), r.normalize(c.Output)
+	if err != nil {
+		c.Error, c.ExitCode = err.Error(), exitCode(err)
+	}
+	r.mu.Lock()
+	r.fixture.Commands = append(r.fixture.Commands, c)
+	r.mu.Unlock()
+}
+
+func (r *recordingRunner) LookPath(name string) (string, error) {
+	path, err := r.commands().LookPath(name)
+	r.add(recordedCommand{Kind: "lookpath", Argv: []string{name}, Path: path}, err)
+	return path, err
+}
+
+func (r *recordingRunner) Output(argv []string) ([]byte, error) {
+	started := time.Now()
+	output, err := r.commands().Output(argv)
+	r.add(recordedCommand{Kind: "output", Argv: append([]string{}, argv...), Output: string(output),
+		Duration: time.Since(started).Milliseconds()}, err)
+	return output, err
+}
+
+func (r *recordingRunner) Run(ctx context.Context, argv, env []string, out io.Writer) error {
+	started := time.Now()
+	var captured strings.Builder
+	err := r.commands().Run(ctx, argv, env, io.MultiWriter(out, &captured))
+	r.add(recordedCommand{Kind: "run", Argv: append([]string{}, argv...), Output: captured.String(),
+		Duration: time.Since(started).Milliseconds()}, err)
+	return err
+}
+
+// save writes the recording to path
+func (r *recordingRunner) save(path string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	data, err := json.MarshalIndent(r.fixture, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// replayRunner serves results from a recording instead of running anything. Invocations
+// are matched by kind and argv; repeated ones get the recorded results in order and then
+// the last one again. Unrecorded invocations fail and are collected for the run's error.
+type replayRunner struct {
+	normalize  func(string) string
+	mu         sync.Mutex
+	recorded   map[string][]recordedCommand
+	unrecorded []string
+}
+
+// loadReplay reads a recording written by --record
+func loadReplay(path string, normalize func(string) string) (*replayRunner, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read replay file: %v", err)
+	}
+	var f fixture
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("failed to parse replay file %s: %v", path, err)
+	}
+	r := &replayRunner{normalize: normalize, recorded: map[string][]recordedCommand{}}
+	for _, c := range f.Commands {
+		r.recorded[c.key()] = append(r.recorded[c.key()], c)
+	}
+	return r, nil
+}
+
+// next returns the recorded result of an invocation
+func (r *replayRunner) next(kind string, argv []string) (recordedCommand, error) {
+	c := recordedCommand{Kind: kind}
+	for _, arg := range argv {
+		c.Argv = append(c.Argv, r.normalize(arg))
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	queue := r.recorded[c.key()]
+	if len(queue) == 0 {
+		r.unrecorded = append(r.unrecorded, strings.Join(c.Argv, " "))
+		return c, fmt.Errorf("replay: %s %q was not recorded", kind, strings.Join(c.Argv, " "))
+	}
+	c = queue[0]
+	if len(queue) > 1 {
+		r.recorded[c.key()] = queue[1:]
+	}
+	return c, nil
+}
+
+// err reports the unrecorded invocations of the run
+func (r *replayRunner) err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.unrecorded) == 0 {
+		return nil
+	}
+	return fmt.Errorf("replay: %d invocations were not recorded, first: %s", len(r.unrecorded), r.unrecorded[0])
+}
+
+// result converts a recorded error back into an error
+func (c recordedCommand) result() error {
+	if c.Error == "" {
+		return nil
+	}
+	return &replayError{msg: c.Error, code: c.ExitCode}
+}
+
+func (r *replayRunner) LookPath(name string) (string, error) {
+	c, err := r.next("lookpath", []string{name})
+	if err != nil {
+		return "", err
+	}
+	return c.Path, c.result()
+}
+
+func (r *replayRunner) Output(argv []string) ([]byte, error) {
+	c, err := r.next("output", argv)
+	if err != nil {
+		return nil, err
+	}
+	return []byte(c.Output), c.result()
+}
+
+// Run writes the recorded output and takes the recorded time, so replays look like the
+// original run
+func (r *replayRunner) Run(ctx context.Context, argv, env []string, out io.Writer) error {
+	c, err := r.next("run", argv)
+	if err != nil {
+		return err
+	}
+	io.WriteString(out, c.Output)
+	select {
+	case <-time.After(time.Duration(c.Duration) * time.Millisecond):
+	case <-ctx.Done():
+		return ErrInterrupted
+	}
+	return c.result()
+}
+
+// commands returns the runner of the current run, looking commands up inside the target
+// with Options.Root, or in the PATH overlay before PATH. Recordings take those lookups
+// with everything else, so replays answer them without looking at the filesystem.
+func (i *Installer) commands() CommandRunner {
+	if i.runner != nil {
+		return i.runner
+	}
+	return i.targetRunner(i.baseRunner())
+}
+
+// targetRunner wraps runner to look commands up inside the target or the PATH overlay
+func (i *Installer) targetRunner(runner CommandRunner) CommandRunner {
+	if i.Options.Root != "" {
+		runner = rootRunner{runner: runner, root: i.Options.Root, path: i.targetPath()}
+	} else if overlay := i.overlayDirs(); len(overlay) > 0 {
+		runner = overlayRunner{runner: runner, dirs: overlay}
+	}
+	return runner
+}
+
+// baseRunner returns the runner of the current run as recorded or replayed
+func (i *Installer) baseRunner() CommandRunner {
+	switch {
+	case i.runner != nil:
+		return i.runner
+	case i.Options.Runner != nil:
+		return i.Options.Runner
+	}
+	return localRunner{}
+}
+
+// startRunner sets up recording or replaying for a run
+func (i *Installer) startRunner() error {
+	normalize := func(s string) string {
+		if i.tempDir != "" {
+			s = strings.ReplaceAll(s, i.tempDir, "${run_tmpdir}")
+		}
+		return i.redact(s)
+	}
+	switch {
+	case i.Options.Replay != "":
+		replay, err := loadReplay(i.Options.Replay, normalize)
+		if err != nil {
+			return err
+		}
+		i.runner = replay
+	case i.Options.Record != "":
+		base := i.baseRunner()
+		i.runner = &recordingRunner{commands: func() CommandRunner { return i.targetRunner(base) }, normalize: normalize}
+	}
+	return nil
+}
+
+// finishRunner saves a recording, or fails a replay that hit unrecorded invocations
+func (i *Installer) finishRunner() error {
+	defer func() { i.runner = nil }()
+	switch runner := i.runner.(type) {
+	case *recordingRunner:
+		if err := runner.save(i.Options.Record); err != nil {
+			return fmt.Errorf("failed to save recording: %v", err)
+		}
+		fmt.Fprintf(i.display(), "%sRecorded %d commands to %s%s\n", colors.Gray, len(runner.fixture.Commands), i.Options.Record, colors.Reset)
+	case *replayRunner:
+		return runner.err()
+	}
+	return nil
+}
+
+// replaying reports whether the run replays a recording
+func (i *Installer) replaying() bool {
+	_, ok := i.runner.(*replayRunner)
+	return ok
+}
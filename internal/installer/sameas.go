@@ -0,0 +1,73 @@
+package installer
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/colors"
+)
+
+// installName returns the tool that installs name: the tool it is the same as, or itself
+func (i *Installer) installName(name string) string {
+	if toolConfig := i.config.Tools[name]; toolConfig != nil && toolConfig.SameAs != "" {
+		return toolConfig.SameAs
+	}
+	return name
+}
+
+// attempt returns the outcome of an earlier install of the tool a plan item installs with,
+// so that a tool and its same_as aliases are only ever attempted once per run
+func (i *Installer) attempt(item PlanItem) (ToolReport, bool) {
+	if item.status.sideBySide() {
+		return ToolReport{}, false
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	prev, ok := i.attempts[i.installName(item.status.Name)]
+	return prev, ok
+}
+
+// recordAttempt records the outcome of installing a plan item for its aliases
+func (i *Installer) recordAttempt(item PlanItem, result ToolReport) {
+	if item.status.sideBySide() {
+		return
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.attempts == nil {
+		i.attempts = map[string]ToolReport{}
+	}
+	i.attempts[i.installName(item.status.Name)] = result
+}
+
+// sameAsResult reports an entry whose tool another entry of the run already installed or
+// failed to install, without attempting it again
+func (i *Installer) sameAsResult(item PlanItem, result, prev ToolReport) ToolReport {
+	result.Status, result.Version, result.Method, result.Channel, result.Error = prev.Status, prev.Version, prev.Method, prev.Channel, prev.Error
+	if prev.Status == statusFailed {
+		i.printf("%s│%s   %s installs with %s, which failed%s\n", colors.Blue, colors.Red, item.Entry, prev.Name, colors.Reset)
+	} else {
+		i.printf("%s│%s   %s installed along with %s%s\n", colors.Blue, colors.Gray, item.Entry, prev.Name, colors.Reset)
+	}
+	i.emit(Event{Type: EventToolFinished, Tool: item.Entry, Method: result.Method, Status: result.Status, Error: result.Error})
+	return result
+}
+
+// noteSameBinary points out a tool resolving to the same binary as a tool checked before it
+// when neither declares same_as. binaries maps resolved binaries to the tools checked so far.
+func (i *Installer) noteSameBinary(s ToolStatus, binaries map[string]string) {
+	if !s.Present || s.sideBySide() || s.SameAs != "" {
+		return
+	}
+	resolved, err := filepath.EvalSymlinks(s.Path)
+	if err != nil {
+		return
+	}
+	other, ok := binaries[resolved]
+	if !ok {
+		binaries[resolved] = s.Name
+		return
+	}
+	fmt.Fprintf(i.display(), "%s│%s   %s is the same binary as %s (%s); set same_as: %s on it to install only once%s\n",
+		colors.Blue, colors.Gray, s.Name, other, resolved, other, colors.Reset)
+}
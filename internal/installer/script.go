@@ -0,0 +1,102 @@
+package installer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/colors"
+	"github.com/Abhaythakor/dev-tools-installer/internal/config"
+)
+
+// defaultInterpreter runs the scripts of script methods that set no interpreter
+const defaultInterpreter = "sh"
+
+// runScript runs the script of a script method with the installer variables in its
+// environment. Inline content and scripts fetched from a url, once their checksum matches,
+// are written to the tool's temp directory first.
+func (i *Installer) runScript(name string, toolConfig *config.ToolConfig, method config.InstallMethod, bindir string) error {
+	vars := i.commandVars(name, toolConfig.Version, bindir)
+	script := i.config.ScriptPath(method)
+	content := []byte(method.Content)
+	if method.URL != "" {
+		url, err := expandChecked(name, "url", method.URL, vars)
+		if err != nil {
+			return err
+		}
+		data, err := i.fetch("script", url)
+		if err != nil {
+			return err
+		}
+		digest := sha256.Sum256(data)
+		if actual := hex.EncodeToString(digest[:]); !strings.EqualFold(actual, method.SHA256) {
+			return fmt.Errorf("script %s: checksum mismatch: expected %s, got %s", url, method.SHA256, actual)
+		}
+		content = data
+	}
+	if len(content) > 0 {
+		script = filepath.Join(i.toolTempDir(name), "install-"+method.Name+".sh")
+		if err := os.WriteFile(script, content, 0700); err != nil {
+			return fmt.Errorf("failed to write script: %v", err)
+		}
+	}
+
+	env, err := i.commandEnv(method, vars)
+	if err != nil {
+		return err
+	}
+	env = append(env, scriptEnv(vars)...)
+	i.printEnv(method, vars)
+
+	parts := append(strings.Fields(interpreter(method)), script)
+	if i.verbose() {
+		i.printf("%s│   %s%s%s\n", colors.Blue, colors.Gray, strings.Join(parts, " "), colors.Reset)
+	}
+	return i.runCommand(name, method.Name, "", parts, env)
+}
+
+// scriptEnv returns the installer variables exported to scripts
+func scriptEnv(vars map[string]string) []string {
+	return []string{
+		"TOOL_NAME=" + vars["TOOL_NAME"],
+		"VERSION=" + vars["version"],
+		"OS=" + runtime.GOOS,
+		"ARCH=" + runtime.GOARCH,
+		"BINDIR=" + vars["bindir"],
+	}
+}
+
+// interpreter returns the command running a script method's script
+func interpreter(method config.InstallMethod) string {
+	if strings.TrimSpace(method.Interpreter) == "" {
+		return defaultInterpreter
+	}
+	return method.Interpreter
+}
+
+// describeScript renders the script a script method would run: the url it is fetched from,
+// or its path or size and digest followed by its lines with Options.ShowScripts
+func (i *Installer) describeScript(method config.InstallMethod, vars map[string]string) []string {
+	if method.URL != "" {
+		return []string{fmt.Sprintf("fetch %s, check sha256 %s and run it with %s", expandShown(method.URL, vars), shortHash(method.SHA256), interpreter(method))}
+	}
+	content := []byte(method.Content)
+	source := "inline script"
+	if method.File != "" {
+		source = i.config.ScriptPath(method)
+		content, _ = os.ReadFile(source)
+	}
+	lines := strings.Split(strings.TrimRight(string(content), "\n"), "\n")
+	digest := sha256.Sum256(content)
+	described := []string{fmt.Sprintf("%s %s (%d lines, sha256 %s)", interpreter(method), source, len(lines), hex.EncodeToString(digest[:])[:12])}
+	if i.Options.ShowScripts {
+		for _, line := range lines {
+			described = append(described, "  "+line)
+		}
+	}
+	return described
+}
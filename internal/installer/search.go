@@ -0,0 +1,132 @@
+package installer
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/colors"
+	"github.com/Abhaythakor/dev-tools-installer/internal/config"
+)
+
+// SearchMatch is a tool matching a search query
+type SearchMatch struct {
+	Name        string
+	Description string
+	Field       string // What matched: name, provides, description or fuzzy
+	score       int
+}
+
+// Match quality, best first
+const (
+	matchExact = iota
+	matchName
+	matchProvides
+	matchDescription
+	matchFuzzy
+)
+
+// Search returns the tools whose name, provided commands or description contain query,
+// ignoring case, followed by tools with a command containing its letters in order
+func (i *Installer) Search(query string) []SearchMatch {
+	return searchTools(i.config.Tools, query)
+}
+
+// searchTools matches query against tools, best matches first
+func searchTools(tools map[string]*config.ToolConfig, query string) []SearchMatch {
+	query = strings.ToLower(query)
+	var matches []SearchMatch
+	for name, tool := range tools {
+		match := SearchMatch{Name: name, score: -1}
+		if tool != nil {
+			match.Description = tool.Description
+		}
+		lower := strings.ToLower(name)
+		switch {
+		case lower == query:
+			match.Field, match.score = "name", matchExact
+		case strings.Contains(lower, query):
+			match.Field, match.score = "name", matchName
+		case slices.ContainsFunc(tool.Commands(name), func(c string) bool { return strings.Contains(strings.ToLower(c), query) }):
+			match.Field, match.score = "provides", matchProvides
+		case strings.Contains(strings.ToLower(match.Description), query):
+			match.Field, match.score = "description", matchDescription
+		case slices.ContainsFunc(tool.Commands(name), func(c string) bool { return isSubsequence(query, strings.ToLower(c)) }):
+			match.Field, match.score = "fuzzy", matchFuzzy
+		}
+		if match.score >= 0 {
+			matches = append(matches, match)
+		}
+	}
+	slices.SortFunc(matches, func(a, b SearchMatch) int {
+		if a.score != b.score {
+			return a.score - b.score
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	return matches
+}
+
+// isSubsequence reports whether the letters of query appear in s in order
+func isSubsequence(query, s string) bool {
+	for _, r := range query {
+		n := strings.IndexRune(s, r)
+		if n < 0 {
+			return false
+		}
+		s = s[n+len(string(r)):]
+	}
+	return query != ""
+}
+
+// PrintSearch prints the configured tools matching query with their status and, when a
+// recipe index is configured, the matching recipes not in the config
+func (i *Installer) PrintSearch(query string) error {
+	matches := i.Search(query)
+	if len(matches) == 0 {
+		fmt.Fprintf(i.display(), "%sNo configured tool matches %q%s\n", colors.Gray, query, colors.Reset)
+	}
+	for _, match := range matches {
+		color, symbol, status := colors.Red, "✗", "missing"
+		switch {
+		case i.isDisabled(match.Name):
+			color, symbol, status = colors.Gray, "-", "disabled"
+		case i.probeTool(match.Name).installed:
+			color, symbol, status = colors.Green, "✓", "installed"
+		}
+		fmt.Fprintf(i.display(), "%s%s %-9s%s %s%-16s%s %s\n", color, symbol, status, colors.Reset, colors.Blue, match.Name, colors.Reset, searchDetail(match))
+	}
+
+	if i.config.Recipes.Index == "" {
+		return nil
+	}
+	index, err := i.RecipeIndex()
+	if err != nil {
+		return err
+	}
+	var remote []SearchMatch
+	for _, match := range searchTools(index.Tools, query) {
+		if i.config.Tools[match.Name] == nil {
+			remote = append(remote, match)
+		}
+	}
+	fmt.Fprintf(i.display(), "\n%sRecipes in %s:%s\n", colors.Gray, i.config.Recipes.Index, colors.Reset)
+	if len(remote) == 0 {
+		fmt.Fprintf(i.display(), "%sNo recipe matches %q%s\n", colors.Gray, query, colors.Reset)
+		return nil
+	}
+	for _, match := range remote {
+		fmt.Fprintf(i.display(), "  %s%-16s%s %s\n", colors.Blue, match.Name, colors.Reset, searchDetail(match))
+	}
+	fmt.Fprintf(i.display(), "%sAdd one with: installer add --from-recipe %s%s\n", colors.Gray, remote[0].Name, colors.Reset)
+	return nil
+}
+
+// searchDetail describes a match by its description, noting fuzzy and provides matches
+func searchDetail(match SearchMatch) string {
+	detail := match.Description
+	if match.Field == "provides" || match.Field == "fuzzy" {
+		detail = strings.TrimSpace(detail + " " + colors.Gray + "(" + match.Field + " match)" + colors.Reset)
+	}
+	return detail
+}
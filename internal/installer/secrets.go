@@ -0,0 +1,95 @@
+package installer
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/config"
+	"github.com/Abhaythakor/dev-tools-installer/internal/shellwords"
+)
+
+// secretStore holds the secrets resolved during a run
+type secretStore struct {
+	mu     sync.Mutex
+	values map[string]string
+}
+
+// secret returns the value of a named secret, resolving it on first use
+func (i *Installer) secret(name string) (string, error) {
+	i.secrets.mu.Lock()
+	defer i.secrets.mu.Unlock()
+	if value, ok := i.secrets.values[name]; ok {
+		return value, nil
+	}
+
+	secret := i.config.Secrets[name]
+	if secret == nil {
+		return "", fmt.Errorf("secret %s is not defined", name)
+	}
+
+	var value string
+	if secret.Env != "" {
+		value = os.Getenv(secret.Env)
+		if value == "" {
+			return "", fmt.Errorf("secret %s: environment variable %s is not set", name, secret.Env)
+		}
+	} else {
+		parts, err := shellwords.Split(secret.Command)
+		if err != nil || len(parts) == 0 {
+			return "", fmt.Errorf("secret %s: command: %v", name, err)
+		}
+		// The command's output is the secret, so it is never included in errors
+		output, err := exec.Command(parts[0], parts[1:]...).Output()
+		if err != nil {
+			return "", fmt.Errorf("secret %s: %s failed: %v", name, parts[0], err)
+		}
+		value = strings.TrimRight(string(output), "\r\n")
+		if value == "" {
+			return "", fmt.Errorf("secret %s: %s printed nothing", name, parts[0])
+		}
+	}
+
+	if i.secrets.values == nil {
+		i.secrets.values = map[string]string{}
+	}
+	i.secrets.values[name] = value
+	return value, nil
+}
+
+// expandSecrets replaces ${secret:name} references in s with their values
+func (i *Installer) expandSecrets(s string) (string, error) {
+	return config.ExpandSecrets(s, i.secret)
+}
+
+// redact replaces every resolved secret value in s with ***
+func (i *Installer) redact(s string) string {
+	i.secrets.mu.Lock()
+	defer i.secrets.mu.Unlock()
+	for _, value := range i.secrets.values {
+		s = strings.ReplaceAll(s, value, "***")
+	}
+	return s
+}
+
+// resolveHeaders expands installer variables and secrets in a method's HTTP headers
+func (i *Installer) resolveHeaders(method config.InstallMethod, vars map[string]string) (map[string]string, error) {
+	if len(method.Headers) == 0 {
+		return nil, nil
+	}
+	headers := map[string]string{}
+	for name, value := range method.Headers {
+		value, err := expandChecked(vars["TOOL_NAME"], "header "+name, value, vars)
+		if err != nil {
+			return nil, err
+		}
+		value, err = i.expandSecrets(value)
+		if err != nil {
+			return nil, fmt.Errorf("header %s: %v", name, err)
+		}
+		headers[name] = value
+	}
+	return headers, nil
+}
@@ -0,0 +1,70 @@
+package installer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// leakingRunner prints the environment of every command it runs, then fails it
+type leakingRunner struct {
+	*fakeRunner
+}
+
+func (r leakingRunner) Run(ctx context.Context, argv, env []string, out io.Writer) error {
+	r.fakeRunner.Run(ctx, argv, env, out)
+	for _, kv := range env {
+		fmt.Fprintln(out, kv)
+	}
+	return errors.New("exit status 1")
+}
+
+func TestSecretsAreRedactedFromOutputAndLogs(t *testing.T) {
+	const token = "s3cr3t-7c1f0d"
+	t.Setenv("EVTOOL_TEST_TOKEN", token)
+	var output, logs bytes.Buffer
+	i := newTestInstaller(t, `
+tool_list: [evtool]
+secrets:
+  token:
+    env: EVTOOL_TEST_TOKEN
+tools:
+  evtool:
+    methods:
+      - name: fake
+        env:
+          AUTH: Bearer ${secret:token}
+        commands: ["install evtool"]
+`, leakingRunner{newFakeRunner(t)})
+	if err := i.Apply(WithOutput(&output), WithLogger(slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug})))); err != nil {
+		t.Fatal(err)
+	}
+	i.Options.ReportPath = filepath.Join(t.TempDir(), "report.json")
+	if err := i.Run(); err == nil {
+		t.Fatal("Run succeeded, want the failing command to fail it")
+	}
+	report, err := os.ReadFile(i.Options.ReportPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The output of the failed command is logged and reported with the secret redacted
+	if !strings.Contains(logs.String(), "AUTH=Bearer ***") {
+		t.Errorf("logs = %q, want the logged output with the secret redacted", logs.String())
+	}
+	if !strings.Contains(string(report), "AUTH=Bearer ***") {
+		t.Errorf("report = %q, want the reported output with the secret redacted", report)
+	}
+	for name, text := range map[string]string{"output": output.String(), "logs": logs.String(), "report": string(report)} {
+		if strings.Contains(text, token) {
+			t.Errorf("the %s show the secret: %q", name, text)
+		}
+	}
+}
@@ -0,0 +1,213 @@
+package installer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/config"
+)
+
+// Markers delimiting the block shellenv adds to rc files
+const (
+	shellBlockStart = "# >>> dev-tools-installer >>>"
+	shellBlockEnd   = "# <<< dev-tools-installer <<<"
+)
+
+// Shells shellenv supports
+var shells = []string{"bash", "zsh", "fish"}
+
+// DefaultShell returns the name of the user's login shell, falling back to bash
+func DefaultShell() string {
+	shell := filepath.Base(os.Getenv("SHELL"))
+	for _, known := range shells {
+		if shell == known {
+			return shell
+		}
+	}
+	return "bash"
+}
+
+// ShellEnv returns the shell code adding the directories of installed tools to PATH,
+// followed by the shell_init lines of the installed tools
+func (i *Installer) ShellEnv(shell string) (string, error) {
+	if err := checkShell(shell); err != nil {
+		return "", err
+	}
+
+	var names []string
+	seen := map[string]bool{}
+	for _, entry := range i.selectedEntries() {
+		name, _ := config.ParseToolEntry(entry)
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Generated by dev-tools-installer; rerun `installer shellenv --apply` to update\n")
+	home := homeDir()
+	for _, dir := range i.toolPathDirs(names) {
+		if home != "" && strings.HasPrefix(dir, home+string(filepath.Separator)) {
+			dir = "$HOME" + strings.TrimPrefix(dir, home)
+		}
+		if shell == "fish" {
+			fmt.Fprintf(&b, "fish_add_path %s\n", dir)
+		} else {
+			fmt.Fprintf(&b, "case \":$PATH:\" in *\":%s:\"*) ;; *) export PATH=\"%s:$PATH\" ;; esac\n", dir, dir)
+		}
+	}
+	for _, name := range names {
+		toolConfig := i.config.Tools[name]
+		if toolConfig == nil || toolConfig.ShellInit == "" || !i.probeTool(name).installed {
+			continue
+		}
+		fmt.Fprintf(&b, "# %s\n%s\n", name, strings.TrimSpace(strings.ReplaceAll(toolConfig.ShellInit, "${shell}", shell)))
+	}
+	return b.String(), nil
+}
+
+// checkShell fails for shells shellenv doesn't support
+func checkShell(shell string) error {
+	for _, known := range shells {
+		if shell == known {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported shell %q (choose from %s)", shell, strings.Join(shells, ", "))
+}
+
+// shellEnvFile returns the file shellenv --apply writes the environment of shell into
+func shellEnvFile(shell string) string {
+	dir := expandHome("~/.config")
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); xdg != "" {
+		dir = xdg
+	}
+	return filepath.Join(dir, "dev-tools-installer", "env."+shell)
+}
+
+// shellRCFile returns the rc file of shell that sources the environment file
+func shellRCFile(shell string) string {
+	switch shell {
+	case "zsh":
+		if dir := os.Getenv("ZDOTDIR"); dir != "" {
+			return filepath.Join(dir, ".zshrc")
+		}
+		return expandHome("~/.zshrc")
+	case "fish":
+		return filepath.Join(filepath.Dir(filepath.Dir(shellEnvFile(shell))), "fish", "config.fish")
+	}
+	return expandHome("~/.bashrc")
+}
+
+// ApplyShellEnv writes the environment file of shell and adds the block sourcing it to the
+// shell's rc file, replacing the block when it is already there. It returns the rc file.
+func (i *Installer) ApplyShellEnv(shell string) (string, error) {
+	env, err := i.ShellEnv(shell)
+	if err != nil {
+		return "", err
+	}
+	envFile := shellEnvFile(shell)
+	if err := os.MkdirAll(filepath.Dir(envFile), 0755); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(envFile, []byte(env), 0644); err != nil {
+		return "", err
+	}
+
+	source := fmt.Sprintf("[ -f %q ] && . %q", envFile, envFile)
+	if shell == "fish" {
+		source = fmt.Sprintf("test -f %q; and source %q", envFile, envFile)
+	}
+	block := shellBlockStart + "\n" + source + "\n" + shellBlockEnd + "\n"
+
+	rc := shellRCFile(shell)
+	data, err := os.ReadFile(rc)
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+	content, at := cutShellBlock(string(data))
+	if at >= 0 {
+		content = content[:at] + block + content[at:]
+	} else {
+		if content != "" && !strings.HasSuffix(content, "\n") {
+			content += "\n"
+		}
+		if content != "" {
+			content += "\n"
+		}
+		content += block
+	}
+	return rc, writeRCFile(rc, content)
+}
+
+// RemoveShellEnv takes the block added by ApplyShellEnv back out of the rc file of shell
+// and deletes the environment file. It returns the rc file, or "" when it had no block.
+func (i *Installer) RemoveShellEnv(shell string) (string, error) {
+	if err := checkShell(shell); err != nil {
+		return "", err
+	}
+	os.Remove(shellEnvFile(shell))
+
+	rc := shellRCFile(shell)
+	data, err := os.ReadFile(rc)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	content, at := cutShellBlock(string(data))
+	if at < 0 {
+		return "", nil
+	}
+	// Drop the blank line ApplyShellEnv put in front of the block
+	if strings.HasSuffix(content[:at], "\n\n") {
+		content = content[:at-1] + content[at:]
+	}
+	return rc, writeRCFile(rc, content)
+}
+
+// cutShellBlock removes the installer's block from an rc file's content, returning where it
+// was or -1
+func cutShellBlock(content string) (string, int) {
+	start := strings.Index(content, shellBlockStart+"\n")
+	if start < 0 {
+		return content, -1
+	}
+	end := strings.Index(content[start:], shellBlockEnd)
+	if end < 0 {
+		return content, -1
+	}
+	end += start + len(shellBlockEnd)
+	if end < len(content) && content[end] == '\n' {
+		end++
+	}
+	return content[:start] + content[end:], start
+}
+
+// writeRCFile replaces an rc file atomically, keeping its permissions. A symlinked rc file,
+// as dotfile managers create, is written through the link.
+func writeRCFile(path, content string) error {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp := path + ".dev-tools-installer.tmp"
+	if err := os.WriteFile(tmp, []byte(content), mode); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
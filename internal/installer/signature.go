@@ -0,0 +1,189 @@
+package installer
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/colors"
+	"github.com/Abhaythakor/dev-tools-installer/internal/config"
+)
+
+// SignatureReport records the signature an artifact was verified against before it was
+// installed
+type SignatureReport struct {
+	Artifact string `json:"artifact"`           // File name of the artifact
+	Method   string `json:"method"`             // minisign or cosign
+	Key      string `json:"key,omitempty"`      // minisign key ID, or sha256 fingerprint of the cosign key
+	Identity string `json:"identity,omitempty"` // Signer identity and issuer of keyless cosign verification
+}
+
+// signer describes whose signature was checked, for messages
+func (r SignatureReport) signer() string {
+	switch {
+	case r.Identity != "":
+		return "identity " + r.Identity
+	case r.Method == "minisign":
+		return "key ID " + r.Key
+	}
+	return "key " + r.Key
+}
+
+// verifySignature checks the minisign or cosign signature of a downloaded artifact, after
+// its checksum and before it is installed. Methods without a signature configured pass.
+func (i *Installer) verifySignature(name string, method config.InstallMethod, url, archive string, vars map[string]string) error {
+	if method.MinisignKey == "" && method.Cosign == nil {
+		return nil
+	}
+	report := SignatureReport{Artifact: path.Base(url)}
+	urlVars := map[string]string{"url": url}
+	for k, v := range vars {
+		urlVars[k] = v
+	}
+
+	sigURL, err := expandChecked(name, "signature", method.Signature, urlVars)
+	if err != nil {
+		return err
+	}
+	suffix := ".sig"
+	if method.MinisignKey != "" {
+		suffix = ".minisig"
+	}
+	if sigURL == "" {
+		sigURL = url + suffix
+	}
+	sigFile, err := i.fetchBeside(archive, "signature", sigURL)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(sigFile)
+
+	var argv []string
+	switch {
+	case method.MinisignKey != "":
+		report.Method, report.Key = "minisign", minisignKeyID(method.MinisignKey)
+		argv = []string{"minisign", "-V", "-q", "-P", method.MinisignKey, "-x", sigFile, "-m", archive}
+	case method.Cosign.Key != "":
+		key, err := expandChecked(name, "cosign key", method.Cosign.Key, urlVars)
+		if err != nil {
+			return err
+		}
+		report.Method, report.Key = "cosign", cosignKeyFingerprint(key)
+		argv = []string{"cosign", "verify-blob", "--key", key, "--signature", sigFile, archive}
+	default:
+		report.Method = "cosign"
+		report.Identity = fmt.Sprintf("%s (issuer %s)", method.Cosign.Identity, method.Cosign.Issuer)
+		certURL, err := expandChecked(name, "cosign certificate", method.Cosign.Certificate, urlVars)
+		if err != nil {
+			return err
+		}
+		if certURL == "" {
+			certURL = url + ".pem"
+		}
+		certFile, err := i.fetchBeside(archive, "certificate", certURL)
+		if err != nil {
+			return err
+		}
+		defer os.Remove(certFile)
+		argv = []string{"cosign", "verify-blob", "--certificate", certFile, "--certificate-identity", method.Cosign.Identity,
+			"--certificate-oidc-issuer", method.Cosign.Issuer, "--signature", sigFile, archive}
+	}
+
+	// Verifiers run on this machine, also when installing into another root
+	runner := i.baseRunner()
+	if _, err := runner.LookPath(argv[0]); err != nil {
+		return fmt.Errorf("%s is not installed; it is needed to verify the signature of %s", argv[0], report.Artifact)
+	}
+	output, err := runner.Output(argv)
+	if err != nil {
+		detail := strings.TrimSpace(string(output))
+		if lines := strings.Split(detail, "\n"); detail != "" {
+			detail = ": " + lines[len(lines)-1]
+		}
+		return fmt.Errorf("signature verification of %s failed (%s, %s)%s", report.Artifact, report.Method, report.signer(), detail)
+	}
+	if i.verbose() {
+		i.printf("%s│   %s%s signature of %s verified (%s)%s\n", colors.Blue, colors.Gray, report.Method, report.Artifact, report.signer(), colors.Reset)
+	}
+	i.setSignature(name, report)
+	return nil
+}
+
+// fetchBeside downloads a small file belonging to an artifact into a temp file next to it
+func (i *Installer) fetchBeside(archive, what, url string) (string, error) {
+	data, err := i.fetch(what, url)
+	if err != nil {
+		return "", err
+	}
+	f, err := os.CreateTemp(filepath.Dir(archive), what+"-*")
+	if err != nil {
+		return "", err
+	}
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
+
+// minisignKeyID returns the key ID of a minisign public key as minisign prints it, or the
+// key itself when it cannot be decoded
+func minisignKeyID(pubkey string) string {
+	data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(pubkey))
+	if err != nil || len(data) != 42 {
+		return pubkey
+	}
+	// The 8 byte ID is stored little-endian after the 2 byte algorithm
+	id := make([]byte, 8)
+	for n := range id {
+		id[n] = data[9-n]
+	}
+	return strings.ToUpper(hex.EncodeToString(id))
+}
+
+// cosignKeyFingerprint returns the sha256 fingerprint of a PEM public key file, or the key
+// reference itself when it is not a readable file, such as a URL or KMS URI
+func cosignKeyFingerprint(key string) string {
+	data, err := os.ReadFile(key)
+	if err != nil {
+		return key
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return key
+	}
+	sum := sha256.Sum256(block.Bytes)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// setSignature stores the verified signature of a tool's artifact for the report
+func (i *Installer) setSignature(name string, report SignatureReport) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if i.signatures == nil {
+		i.signatures = map[string]SignatureReport{}
+	}
+	i.signatures[name] = report
+}
+
+// takeSignature returns and forgets the verified signature stored for a tool
+func (i *Installer) takeSignature(name string) *SignatureReport {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	report, ok := i.signatures[name]
+	if !ok {
+		return nil
+	}
+	delete(i.signatures, name)
+	return &report
+}
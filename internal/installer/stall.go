@@ -0,0 +1,99 @@
+package installer
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/colors"
+)
+
+// Thresholds for commands that stop producing output
+const (
+	stallNotice = time.Minute     // The progress line shows how long the command has been silent
+	stallWarn   = 5 * time.Minute // A warning shows the silent command
+)
+
+// stallCheckInterval is how often a running command's silence is checked
+const stallCheckInterval = time.Second
+
+// stallMonitor tracks how long a running command has gone without printing a line. It owns
+// the command's progress line so that output handling and the monitor don't redraw it at
+// the same time.
+type stallMonitor struct {
+	mu       sync.Mutex
+	progress *stepProgress
+	detail   string // Progress detail without the silence notice
+	last     time.Time
+	warned   bool
+	stalled  time.Duration // Silence after which the command was killed, zero while it runs
+}
+
+// activity records a line of output
+func (s *stallMonitor) activity() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.last = time.Now()
+}
+
+// update replaces the progress detail
+func (s *stallMonitor) update(detail string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.detail = detail
+	s.progress.update(detail)
+}
+
+// replace swaps the progress line, calling between with none shown
+func (s *stallMonitor) replace(between func(), start func() *stepProgress) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.progress.stop()
+	between()
+	s.progress = start()
+}
+
+// stop clears the progress line
+func (s *stallMonitor) stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.progress.stop()
+}
+
+// watchStall checks the command's silence until done is closed. Past stallNotice the
+// progress line shows it, past stallWarn a warning shows the command, and past the method's
+// stall_timeout the command is cancelled.
+func (i *Installer) watchStall(s *stallMonitor, command string, timeout time.Duration, cancel context.CancelFunc, done <-chan struct{}) {
+	ticker := time.NewTicker(stallCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+
+		s.mu.Lock()
+		silent := time.Since(s.last).Round(time.Second)
+		if silent >= stallNotice {
+			s.progress.update(fmt.Sprintf("%s, no output for %s", s.detail, silent))
+		}
+		if silent >= stallWarn && !s.warned {
+			s.warned = true
+			i.printf("\r%s%s│%s ⚠ No output for %s from: %s%s\n", clearLine, colors.Blue, colors.Yellow, silent, command, colors.Reset)
+		}
+		if timeout > 0 && silent >= timeout && s.stalled == 0 {
+			s.stalled = timeout
+			cancel()
+		}
+		s.mu.Unlock()
+	}
+}
+
+// stallTimeout returns the stall_timeout of a tool's method, or zero when it has none
+func (i *Installer) stallTimeout(name, methodName string) time.Duration {
+	method, _ := i.methodConfig(name, methodName)
+	timeout, _ := time.ParseDuration(method.StallTimeout)
+	return timeout
+}
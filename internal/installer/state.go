@@ -0,0 +1,135 @@
+package installer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/colors"
+	"github.com/Abhaythakor/dev-tools-installer/internal/config"
+)
+
+// stateFileName is the name of the state file inside the state directory
+const stateFileName = "state.json"
+
+// State records what the installer has installed on this machine
+type State struct {
+	Tools       map[string]*ToolState `json:"tools"`
+	LastCheck   *CheckRecord          `json:"last_check,omitempty"`   // Last run checking every tool_list entry
+	LastInstall *CheckRecord          `json:"last_install,omitempty"` // Last install of every tool_list entry that succeeded
+	Retry       *RetryRecord          `json:"retry,omitempty"`        // Entries that failed to install
+
+	DownloadSizes map[string]int64 `json:"download_sizes,omitempty"` // Size of the last complete download of each URL, for estimates
+
+	path string
+}
+
+// ToolState records a tool installed by the installer
+type ToolState struct {
+	Version        string    `json:"version,omitempty"`
+	Method         string    `json:"method,omitempty"`
+	Path           string    `json:"path,omitempty"`            // Binary placed by a managed method
+	Managed        bool      `json:"managed,omitempty"`         // Path is owned by the installer and safe to remove
+	SHA256         string    `json:"sha256,omitempty"`          // Digest of the binary when it was installed
+	Package        string    `json:"package,omitempty"`         // Package a package-manager method installed, as manager:name
+	PackageVersion string    `json:"package_version,omitempty"` // Version the package manager assigned the package
+	InstalledAt    time.Time `json:"installed_at"`
+	Action         string    `json:"action,omitempty"`  // How the last install came about: installed, upgraded or reinstalled
+	Channel        string    `json:"channel,omitempty"` // Channel the tool was installed from
+	Backups        []Backup  `json:"backups,omitempty"` // Binaries replaced by later installs, oldest first
+
+	// Versioned installs from name@version tool_list entries
+	Versions map[string]*VersionState `json:"versions,omitempty"`
+	Active   string                   `json:"active,omitempty"`
+}
+
+// VersionState records one side-by-side version of a tool
+type VersionState struct {
+	Path        string    `json:"path"` // Binary inside the versioned directory
+	SHA256      string    `json:"sha256,omitempty"`
+	Method      string    `json:"method,omitempty"`
+	InstalledAt time.Time `json:"installed_at"`
+}
+
+// LoadState reads the state file from dir, returning an empty state when none exists
+func LoadState(dir string) (*State, error) {
+	state := &State{Tools: map[string]*ToolState{}, path: filepath.Join(dir, stateFileName)}
+
+	data, err := os.ReadFile(state.path)
+	if os.IsNotExist(err) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read state file: %v", err)
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("failed to parse state file %s: %v", state.path, err)
+	}
+	if state.Tools == nil {
+		state.Tools = map[string]*ToolState{}
+	}
+	return state, nil
+}
+
+// Save writes the state file atomically
+func (s *State) Save() error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %v", err)
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to write state file: %v", err)
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// Tool returns the state entry for name, creating it if needed
+func (s *State) Tool(name string) *ToolState {
+	if s.Tools[name] == nil {
+		s.Tools[name] = &ToolState{}
+	}
+	return s.Tools[name]
+}
+
+// recordInstall stores a successful installation in the state file
+func (i *Installer) recordInstall(name string, toolConfig *config.ToolConfig, method config.InstallMethod, path string, pkg installedPackage) {
+	ts := i.loadedState().Tool(name)
+	ts.Method = method.Name
+	ts.Channel = i.recordedChannel(toolConfig)
+	ts.Path = path
+	ts.Managed = path != ""
+	ts.InstalledAt = time.Now()
+
+	bin := path
+	if toolConfig.DetectKind() != config.DetectCommand {
+		check := i.probeDetected(name, toolConfig, toolCheck{})
+		bin, ts.Version = check.path, check.version
+	} else {
+		if bin == "" {
+			bin, _ = i.commands().LookPath(i.toolCommand(name))
+		}
+		if bin != "" {
+			ts.Version = i.detectVersion(bin, toolConfig.VersionFlag)
+		}
+	}
+
+	ts.SHA256 = ""
+	if bin != "" && i.integrityApplies(bin) {
+		ts.SHA256, _ = hashFile(bin)
+	}
+
+	ts.Package, ts.PackageVersion = "", pkg.version
+	if pkg.name != "" {
+		ts.Package = pkg.manager + ":" + pkg.name
+	}
+	if i.verbose() && ts.Version != "" && pkg.version != "" && !i.packageVersionMatches(ts.Version, pkg.version) {
+		i.printf("%s│%s ⚠ %s reports version %s, but %s installed %s %s%s\n", colors.Blue, colors.Yellow, name, ts.Version, pkg.manager, pkg.name, pkg.version, colors.Reset)
+	}
+}
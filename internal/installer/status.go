@@ -0,0 +1,111 @@
+package installer
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/config"
+)
+
+// CheckRecord summarizes the last run that checked every tool_list entry, so that Status can
+// report on the tools without checking them again
+type CheckRecord struct {
+	Time    time.Time `json:"time"`
+	Command string    `json:"command"` // verify or install
+	ConfigSource
+	Missing []string `json:"missing,omitempty"` // Entries that were not installed when the run ended
+	Drifted []string `json:"drifted,omitempty"` // Entries whose version did not match the pin
+}
+
+// Status is the state of the tools as Status reports it for shell prompts
+type Status struct {
+	Missing []string   // Entries missing at the last check, or whose managed binary is gone since
+	Drifted []string   // Entries drifted at the last check
+	Stale   string     // Why the last check cannot be trusted; empty when it can
+	Checked *time.Time // When the last check ran, nil when none was recorded
+
+	Check       *CheckRecord // Last check, nil when none was recorded
+	LastInstall *CheckRecord // Last successful install of every tool_list entry, nil when none was recorded
+}
+
+// Token returns the status as a single word for prompts: devtools:ok, devtools:stale,
+// devtools:3-missing or devtools:2-drifted
+func (s Status) Token() string {
+	switch {
+	case s.Stale != "":
+		return "devtools:stale"
+	case len(s.Missing) > 0:
+		return fmt.Sprintf("devtools:%d-missing", len(s.Missing))
+	case len(s.Drifted) > 0:
+		return fmt.Sprintf("devtools:%d-drifted", len(s.Drifted))
+	}
+	return "devtools:ok"
+}
+
+// recordCheck stores the outcome of a run in the state when it covered every tool_list entry
+func (i *Installer) recordCheck(command string, results []ToolReport) {
+	if len(i.Options.Only) > 0 || len(i.Options.Selected) > 0 || i.Options.RetryFailed || i.context().Err() != nil {
+		return
+	}
+	record := &CheckRecord{Time: time.Now(), Command: command, ConfigSource: i.source}
+	for _, result := range results {
+		switch {
+		case result.Status == statusMissing || result.Status == statusFailed || result.Status == statusDeferred || result.Status == statusSkipped:
+			record.Missing = append(record.Missing, result.Name)
+		case result.Drift:
+			record.Drifted = append(record.Drifted, result.Name)
+		}
+	}
+	i.loadedState().LastCheck = record
+	if command == "install" && len(record.Missing) == 0 {
+		i.loadedState().LastInstall = record
+	}
+}
+
+// Status reports on the tools from the state file alone, without running any command: what
+// the last full verify or install found, plus managed binaries removed since. The status is
+// stale when no check was recorded, the config changed since, or the check is older than
+// maxAge when it is positive.
+func (i *Installer) Status(maxAge time.Duration) Status {
+	state := i.loadedState()
+	record := state.LastCheck
+	if record == nil {
+		return Status{Stale: "no verify or install run was recorded", LastInstall: state.LastInstall}
+	}
+	status := Status{Checked: &record.Time, Drifted: record.Drifted, Check: record, LastInstall: state.LastInstall}
+	switch {
+	case record.ConfigSHA256 != i.config.SHA256:
+		status.Stale = "the config changed since the last check"
+	case maxAge > 0 && time.Since(record.Time) > maxAge:
+		status.Stale = fmt.Sprintf("the last check is older than %s", maxAge)
+	}
+
+	status.Missing = append([]string{}, record.Missing...)
+	for _, entry := range i.config.ToolList {
+		if slices.Contains(status.Missing, entry) {
+			continue
+		}
+		name, version := config.ParseToolEntry(entry)
+		if i.isDisabled(name) {
+			continue
+		}
+		ts := state.Tools[i.installName(name)]
+		path := ""
+		switch {
+		case ts == nil:
+		case version != "" && ts.Versions[version] != nil:
+			path = ts.Versions[version].Path
+		case version == "" && ts.Managed:
+			path = ts.Path
+		}
+		if path == "" {
+			continue
+		}
+		if _, err := os.Stat(path); err != nil {
+			status.Missing = append(status.Missing, entry)
+		}
+	}
+	return status
+}
@@ -0,0 +1,192 @@
+package installer
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/colors"
+	"github.com/Abhaythakor/dev-tools-installer/internal/config"
+)
+
+// systemPackageSet is the system_packages of a tool for one package manager on this machine
+type systemPackageSet struct {
+	manager string        // The system_packages key, e.g. apt
+	native  nativeManager // How the manager installs packages
+	missing []string      // Packages not installed yet; all of them when the manager cannot tell
+}
+
+// argv returns the command installing the missing packages, through sudo for package managers
+// other than Homebrew, which need root, as the default method does
+func (s systemPackageSet) argv(root string) []string {
+	argv := append(strings.Fields(s.native.install), s.missing...)
+	if root != "" {
+		return append([]string{"chroot", root}, argv...)
+	}
+	if s.native.command != "brew" && os.Geteuid() > 0 {
+		argv = append([]string{"sudo"}, argv...)
+	}
+	return argv
+}
+
+// packages returns the missing packages as manager:package
+func (s systemPackageSet) packages() []string {
+	var packages []string
+	for _, pkg := range s.missing {
+		packages = append(packages, s.manager+":"+pkg)
+	}
+	return packages
+}
+
+// systemManager returns the package manager a system_packages key names
+func systemManager(manager string) nativeManager {
+	command := manager
+	if manager == "apt" {
+		command = "apt-get"
+	}
+	for _, native := range nativeManagers {
+		if native.command == command {
+			return native
+		}
+	}
+	return nativeManager{}
+}
+
+// systemPackages sorts the system_packages of a tool into the sets of the package managers
+// found on this machine, which have packages missing or not, and the managers not found,
+// as "manager: packages"
+func (i *Installer) systemPackages(toolConfig *config.ToolConfig) (found []systemPackageSet, absent []string) {
+	if toolConfig == nil {
+		return nil, nil
+	}
+	for _, manager := range slices.Sorted(maps.Keys(toolConfig.SystemPackages)) {
+		packages := toolConfig.SystemPackages[manager]
+		native := systemManager(manager)
+		if _, err := i.commands().LookPath(native.command); err != nil {
+			absent = append(absent, manager+": "+strings.Join(packages, " "))
+			continue
+		}
+		set := systemPackageSet{manager: manager, native: native}
+		for _, pkg := range packages {
+			if !i.systemPackageInstalled(native.command, pkg) {
+				set.missing = append(set.missing, pkg)
+			}
+		}
+		found = append(found, set)
+	}
+	return found, absent
+}
+
+// systemPackageInstalled asks a package manager whether a package is installed, reporting
+// false when the manager cannot tell
+func (i *Installer) systemPackageInstalled(manager, pkg string) bool {
+	query := packageQueries[manager]
+	if query == nil {
+		return false
+	}
+	argv := query(pkg)
+	if i.Options.Root != "" {
+		argv = append([]string{"chroot", i.Options.Root}, argv...)
+	}
+	output, err := i.commands().Output(argv)
+	i.log(versionLog).Debug("system package query", "argv", argv, "error", err, "output", string(output))
+	return err == nil && parsePackageVersion(manager, pkg, string(output)) != ""
+}
+
+// installSystemPackages installs the missing system_packages of a tool before its methods
+// run, one command per package manager. Packages of managers this machine lacks are skipped
+// with a warning, unless another of the tool's managers is found. A failed install does not
+// stop the methods, which may not need the packages.
+func (i *Installer) installSystemPackages(name string, toolConfig *config.ToolConfig) {
+	found, absent := i.systemPackages(toolConfig)
+	switch {
+	case len(absent) > 0 && len(found) == 0:
+		i.printf("%s│%s ⚠ Skipping system packages of %s, no package manager for them was found: %s%s\n", colors.Blue, colors.Yellow, name, strings.Join(absent, "; "), colors.Reset)
+	case len(absent) > 0 && i.verbose():
+		i.printf("%s│   %sskipping system packages for package managers not found: %s%s\n", colors.Blue, colors.Gray, strings.Join(absent, "; "), colors.Reset)
+	}
+	for _, set := range found {
+		if len(set.missing) == 0 {
+			continue
+		}
+		if i.context().Err() != nil {
+			return
+		}
+		argv := set.argv(i.Options.Root)
+		i.printf("%s│%s 📦 Installing system packages of %s: %s (%s)%s\n", colors.Blue, colors.Yellow, name, strings.Join(set.missing, ", "), set.manager, colors.Reset)
+		if i.verbose() {
+			i.printf("%s│   %s%s%s\n", colors.Blue, colors.Gray, strings.Join(argv, " "), colors.Reset)
+		}
+		if err := i.runCommand(name, "system packages", "", argv, nil); err != nil {
+			i.printf("%s│%s ❌ System packages of %s failed: %v; trying its methods anyway%s\n", colors.Blue, colors.Red, name, i.redact(err.Error()), colors.Reset)
+			continue
+		}
+		i.mu.Lock()
+		if i.pulled == nil {
+			i.pulled = map[string][]string{}
+		}
+		i.pulled[name] = append(i.pulled[name], set.packages()...)
+		i.mu.Unlock()
+	}
+}
+
+// takeSystemPackages returns and forgets the system packages installed for a tool
+func (i *Installer) takeSystemPackages(name string) []string {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	packages := i.pulled[name]
+	delete(i.pulled, name)
+	return packages
+}
+
+// noteSystemPackages adds the system packages the items of a plan would install, or skip, to
+// their reasons
+func (i *Installer) noteSystemPackages(plan Plan) {
+	for n := range plan {
+		item := &plan[n]
+		if item.Action == actionSkip {
+			continue
+		}
+		found, absent := i.systemPackages(i.config.Tools[i.installName(item.status.Name)])
+		for _, set := range found {
+			item.SystemPackages = append(item.SystemPackages, set.packages()...)
+		}
+		if len(item.SystemPackages) > 0 {
+			item.reason("system packages %s are installed before the methods run", strings.Join(item.SystemPackages, ", "))
+		}
+		if len(absent) > 0 && len(found) == 0 {
+			item.systemSkipped = absent
+			item.reason("system packages are skipped, no package manager for them was found: %s", strings.Join(absent, "; "))
+		}
+	}
+}
+
+// systemPackageLine describes the system packages of a plan item for PrintPlan, or returns ""
+// when it has none to install or skip
+func (item PlanItem) systemPackageLine() string {
+	switch {
+	case len(item.SystemPackages) > 0:
+		return "system packages: " + strings.Join(item.SystemPackages, " ")
+	case len(item.systemSkipped) > 0:
+		return "system packages skipped, no package manager found: " + strings.Join(item.systemSkipped, "; ")
+	}
+	return ""
+}
+
+// checkSystemPackages prints the system packages the pending tools still need, and those no
+// package manager on this machine can install
+func (i *Installer) checkSystemPackages(plan Plan) {
+	for _, item := range plan {
+		if item.Action == actionSkip {
+			continue
+		}
+		if len(item.SystemPackages) > 0 {
+			fmt.Fprintf(i.display(), "%s│ %s! %-9s%s │ %s needs %s, which the run installs first\n", colors.Blue, colors.Yellow, "packages", colors.Reset, item.Entry, strings.Join(item.SystemPackages, ", "))
+		}
+		if len(item.systemSkipped) > 0 {
+			fmt.Fprintf(i.display(), "%s│ %s! %-9s%s │ %s: no package manager found for %s\n", colors.Blue, colors.Yellow, "packages", colors.Reset, item.Entry, strings.Join(item.systemSkipped, "; "))
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package installer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/colors"
+)
+
+// tempParent returns the directory the per-run temp directory is created in
+func (i *Installer) tempParent() string {
+	if i.config.TempDir != "" {
+		return expandHome(i.config.TempDir)
+	}
+	return os.TempDir()
+}
+
+// createTempDir creates the per-run temp directory holding downloads and ${tmpdir}
+func (i *Installer) createTempDir() error {
+	parent := i.tempParent()
+	if err := os.MkdirAll(parent, 0755); err != nil {
+		return fmt.Errorf("failed to create temp directory: %v", err)
+	}
+	dir, err := os.MkdirTemp(parent, "dev-tools-installer-")
+	if err != nil {
+		return fmt.Errorf("failed to create temp directory: %v", err)
+	}
+	i.tempDir = dir
+	return nil
+}
+
+// removeTempDir removes the per-run temp directory, or reports where it is with --keep-temp
+func (i *Installer) removeTempDir() {
+	if i.tempDir == "" {
+		return
+	}
+	if i.Options.KeepTemp {
+		fmt.Fprintf(i.display(), "%sTemporary files kept in %s%s\n", colors.Gray, i.tempDir, colors.Reset)
+	} else if err := os.RemoveAll(i.tempDir); err != nil {
+		fmt.Fprintf(i.display(), "%s⚠ Failed to remove %s: %v%s\n", colors.Yellow, i.tempDir, err, colors.Reset)
+	}
+	i.tempDir = ""
+}
+
+// toolTempDir returns a tool's subdirectory of the per-run temp directory, exposed to its
+// commands as ${tmpdir}. Tools installing in parallel each get their own.
+func (i *Installer) toolTempDir(name string) string {
+	if i.tempDir == "" {
+		// Outside install runs, e.g. for --dry-run, show where it would be
+		return filepath.Join(i.tempParent(), "dev-tools-installer-*", name)
+	}
+	return filepath.Join(i.tempDir, name)
+}
+
+// resetToolTempDir empties a tool's temp subdirectory before an install
+func (i *Installer) resetToolTempDir(name string) error {
+	if i.tempDir == "" {
+		return nil
+	}
+	dir := i.toolTempDir(name)
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	return os.MkdirAll(dir, 0755)
+}
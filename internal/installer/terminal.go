@@ -0,0 +1,167 @@
+package installer
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"golang.org/x/term"
+)
+
+// Cursor visibility sequences
+const (
+	hideCursorSeq = "\033[?25l"
+	showCursorSeq = "\033[?25h"
+)
+
+// The terminals of stdout and stderr, which installers print to unless given other writers
+var (
+	stdoutTerminal = newTerminal(os.Stdout, nil)
+	stderrTerminal = newTerminal(os.Stderr, nil)
+)
+
+// discardTerminal takes the usual output of porcelain and quiet runs, which is not shown
+var discardTerminal = newTerminal(io.Discard, nil)
+
+// terminalState tracks the state spinners and status lines put an output in, so that every
+// exit path can tear them down and show the cursor again before printing. Writes go to out.
+type terminalState struct {
+	out    io.Writer
+	write  sync.Mutex // Serializes writes to out, which spinners make from their own goroutines
+	mu     sync.Mutex
+	tty    bool
+	size   func() (width, height int) // Size of the terminal, zeros when unknown
+	hidden int                        // Active spinners and renderers keeping the cursor hidden
+	active map[int]func()             // Teardowns of the active ones
+	next   int
+}
+
+// activeTerminals holds the terminals with active spinners or status lines, for RestoreTerminal
+var activeTerminals = struct {
+	sync.Mutex
+	set map[*terminalState]bool
+}{set: map[*terminalState]bool{}}
+
+// virtualTerminal is the size of the terminal output given WithTerminal is rendered for
+type virtualTerminal struct {
+	width, height int
+}
+
+// newTerminal returns the terminal state of output to w. Output is drawn as for a terminal
+// when w is one, or as for a terminal of the size of screen when it is set.
+func newTerminal(w io.Writer, screen *virtualTerminal) *terminalState {
+	t := &terminalState{out: w, size: func() (int, int) { return 0, 0 }}
+	switch f, ok := w.(*os.File); {
+	case screen != nil:
+		t.tty, t.size = true, func() (int, int) { return screen.width, screen.height }
+	case ok:
+		t.tty, t.size = term.IsTerminal(int(f.Fd())), fileSize(f)
+	}
+	return t
+}
+
+// Write writes to the output of the terminal
+func (t *terminalState) Write(p []byte) (int, error) {
+	t.write.Lock()
+	defer t.write.Unlock()
+	return t.out.Write(p)
+}
+
+// fileSize returns the size of the terminal f is, or zeros when it is none
+func fileSize(f *os.File) func() (int, int) {
+	return func() (int, int) {
+		width, height, err := term.GetSize(int(f.Fd()))
+		if err != nil {
+			return 0, 0
+		}
+		return width, height
+	}
+}
+
+// current reports whether output goes to a terminal, and the size of that terminal
+func (t *terminalState) current() (bool, func() (int, int)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.tty, t.size
+}
+
+// acquire hides the cursor while a spinner or renderer is active and registers its
+// teardown. The returned release shows the cursor again once no other one is active.
+func (t *terminalState) acquire(teardown func()) (release func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.active == nil {
+		t.active = map[int]func(){}
+	}
+	id := t.next
+	t.next++
+	t.active[id] = teardown
+	activeTerminals.Lock()
+	activeTerminals.set[t] = true
+	activeTerminals.Unlock()
+	if t.hidden == 0 && t.tty {
+		fmt.Fprint(t, hideCursorSeq)
+	}
+	t.hidden++
+
+	return func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		if _, ok := t.active[id]; !ok {
+			return
+		}
+		delete(t.active, id)
+		if len(t.active) == 0 {
+			activeTerminals.Lock()
+			delete(activeTerminals.set, t)
+			activeTerminals.Unlock()
+		}
+		t.hidden--
+		if t.hidden == 0 && t.tty {
+			fmt.Fprint(t, showCursorSeq)
+		}
+	}
+}
+
+// restore tears down every active spinner and renderer and shows the cursor
+func (t *terminalState) restore() {
+	t.mu.Lock()
+	teardowns := make([]func(), 0, len(t.active))
+	for _, teardown := range t.active {
+		teardowns = append(teardowns, teardown)
+	}
+	t.mu.Unlock()
+
+	// Teardowns release their registration, which takes the lock
+	for _, teardown := range teardowns {
+		teardown()
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.tty {
+		fmt.Fprint(t, showCursorSeq)
+	}
+	t.active, t.hidden = nil, 0
+	activeTerminals.Lock()
+	delete(activeTerminals.set, t)
+	activeTerminals.Unlock()
+}
+
+// RestoreTerminal stops any spinner or status lines still drawing, on stdout and on every
+// other output of an installer, and shows the cursor. Call it before printing an error or
+// exiting, including when recovering from a panic.
+func RestoreTerminal() {
+	activeTerminals.Lock()
+	terminals := []*terminalState{stdoutTerminal}
+	for t := range activeTerminals.set {
+		if t != stdoutTerminal {
+			terminals = append(terminals, t)
+		}
+	}
+	activeTerminals.Unlock()
+	for _, t := range terminals {
+		t.restore()
+	}
+}
@@ -0,0 +1,47 @@
+package installer
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRestoreTerminalAfterPanic(t *testing.T) {
+	var out bytes.Buffer
+	term := newTerminal(&out, &virtualTerminal{width: 80, height: 24})
+	func() {
+		// As main does when a panic unwinds it
+		defer func() {
+			if r := recover(); r == nil {
+				t.Fatal("no panic")
+			}
+			RestoreTerminal()
+		}()
+		newProgress(term, "Checking evtool").Start()
+		renderer := newTerminalRenderer(term)
+		renderer.Start()
+		renderer.Begin("evtool")
+		time.Sleep(150 * time.Millisecond)
+		panic("boom")
+	}()
+
+	// Let a spinner that saw the stop finish clearing its line
+	time.Sleep(200 * time.Millisecond)
+	term.write.Lock()
+	output := out.String()
+	term.write.Unlock()
+	shown := strings.LastIndex(output, showCursorSeq)
+	if shown < 0 || shown < strings.LastIndex(output, hideCursorSeq) {
+		t.Fatalf("output = %q, want the cursor shown after it was hidden", output)
+	}
+	if after := strings.NewReplacer("\r", "", clearLine, "", "\033[J", "").Replace(output[shown+len(showCursorSeq):]); after != "" {
+		t.Errorf("drawn after the cursor was restored: %q", after)
+	}
+	activeTerminals.Lock()
+	active := activeTerminals.set[term]
+	activeTerminals.Unlock()
+	if active {
+		t.Error("the terminal is still registered as drawing")
+	}
+}
@@ -0,0 +1,84 @@
+package installer
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/colors"
+)
+
+// defaultDownloadSlots is the number of downloads that run at once by default
+const defaultDownloadSlots = 3
+
+// acquireDownload waits for a free download slot, showing the tool as waiting until one
+// frees up. The returned function releases the slot.
+func (i *Installer) acquireDownload(name, methodName string) (func(), error) {
+	select {
+	case i.slots <- struct{}{}:
+	default:
+		if i.renderer != nil {
+			i.renderer.Step(name, methodName, "waiting for a download slot")
+		} else {
+			i.printf("%s│   %swaiting for a download slot%s\n", colors.Blue, colors.Gray, colors.Reset)
+		}
+		select {
+		case i.slots <- struct{}{}:
+		case <-i.toolContext(name).Done():
+			return nil, ErrInterrupted
+		}
+	}
+	return func() { <-i.slots }, nil
+}
+
+// rateLimiter spreads the reads of all downloads over a shared transfer rate
+type rateLimiter struct {
+	mu   sync.Mutex
+	rate float64   // Bytes per second
+	next time.Time // When the bytes read so far are paid for
+}
+
+// newRateLimiter creates a limiter for rate bytes per second, or nil when rate is 0
+func newRateLimiter(rate int64) *rateLimiter {
+	if rate <= 0 {
+		return nil
+	}
+	return &rateLimiter{rate: float64(rate)}
+}
+
+// reader limits reads from r, returning r unchanged when l is nil
+func (l *rateLimiter) reader(r io.Reader) io.Reader {
+	if l == nil {
+		return r
+	}
+	return &limitedReader{r: r, l: l}
+}
+
+// wait sleeps until n more bytes fit into the rate
+func (l *rateLimiter) wait(n int) {
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	delay := l.next.Sub(now)
+	l.next = l.next.Add(time.Duration(float64(n) / l.rate * float64(time.Second)))
+	l.mu.Unlock()
+	time.Sleep(delay)
+}
+
+// limitedReader reads through a rateLimiter
+type limitedReader struct {
+	r io.Reader
+	l *rateLimiter
+}
+
+// Read reads at most a tenth of a second's worth of bytes and waits for them
+func (r *limitedReader) Read(p []byte) (int, error) {
+	if limit := max(int(r.l.rate/10), 1024); len(p) > limit {
+		p = p[:limit]
+	}
+	n, err := r.r.Read(p)
+	r.l.wait(n)
+	return n, err
+}
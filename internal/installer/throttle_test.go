@@ -0,0 +1,90 @@
+package installer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestNewRateLimiterWithoutARate(t *testing.T) {
+	l := newRateLimiter(0)
+	if l != nil {
+		t.Fatalf("newRateLimiter(0) = %+v, want nil", l)
+	}
+	r := strings.NewReader("evtool")
+	if got := l.reader(r); got != r {
+		t.Errorf("reader of a nil limiter = %T, want the reader unchanged", got)
+	}
+}
+
+func TestRateLimiterSharesTheRateBetweenDownloads(t *testing.T) {
+	const rate = 100_000
+	l := newRateLimiter(rate)
+	// Reads are cut to a tenth of a second's worth of bytes
+	if n, _ := l.reader(bytes.NewReader(make([]byte, rate))).Read(make([]byte, rate)); n != rate/10 {
+		t.Errorf("read %d bytes at once, want %d", n, rate/10)
+	}
+
+	// Two downloads of 20KB share 100KB/s with the 10KB read before, taking half a second
+	started := time.Now()
+	var wg sync.WaitGroup
+	for range 2 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			io.Copy(io.Discard, l.reader(bytes.NewReader(make([]byte, 20_000))))
+		}()
+	}
+	wg.Wait()
+	if elapsed := time.Since(started); elapsed < 300*time.Millisecond || elapsed > 3*time.Second {
+		t.Errorf("the downloads took %s, want about half a second", elapsed)
+	}
+}
+
+func TestAcquireDownloadWaitsForAFreeSlot(t *testing.T) {
+	var out bytes.Buffer
+	i := New(loadTestConfig(t, "downloads: {concurrency: 1}\ntools: {}\n"), WithOutput(&out))
+	release, err := i.acquireDownload("first", "download")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	acquired := make(chan error)
+	go func() {
+		release, err := i.acquireDownload("second", "download")
+		if err == nil {
+			release()
+		}
+		acquired <- err
+	}()
+	select {
+	case err := <-acquired:
+		t.Fatalf("second download got a slot while the only one was taken (%v)", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	release()
+	if err := <-acquired; err != nil {
+		t.Fatalf("second download: %v", err)
+	}
+	if !strings.Contains(out.String(), "waiting for a download slot") {
+		t.Errorf("printed %q, want the second download shown as waiting", out.String())
+	}
+
+	// An interrupt ends the wait
+	release, err = i.acquireDownload("first", "download")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer release()
+	ctx, cancel := context.WithCancel(context.Background())
+	i.ctx = ctx
+	cancel()
+	if _, err := i.acquireDownload("second", "download"); !errors.Is(err, ErrInterrupted) {
+		t.Errorf("acquireDownload after an interrupt = %v, want %v", err, ErrInterrupted)
+	}
+}
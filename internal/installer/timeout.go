@@ -0,0 +1,86 @@
+package installer
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/config"
+)
+
+// toolTimeout tracks the install_timeout of a tool while its methods run
+type toolTimeout struct {
+	ctx    context.Context
+	limit  string // install_timeout as configured
+	method string // Method running, for the error once the timeout hits
+	step   string // Step of the method running, empty for single-command methods
+}
+
+// toolTimeoutError reports a tool whose methods ran past its install_timeout
+type toolTimeoutError struct {
+	limit  string
+	method string
+	step   string
+}
+
+func (e *toolTimeoutError) Error() string {
+	where := fmt.Sprintf("method '%s'", e.method)
+	if e.step != "" {
+		where += ", " + e.step
+	}
+	return fmt.Sprintf("tool timeout after %s (was on %s)", e.limit, where)
+}
+
+// startToolTimeout starts the install_timeout of a tool, after which the commands of its
+// methods are cancelled. The returned function stops it.
+func (i *Installer) startToolTimeout(name string, toolConfig *config.ToolConfig) func() {
+	limit, _ := time.ParseDuration(toolConfig.InstallTimeout)
+	if limit <= 0 {
+		return func() {}
+	}
+	ctx, cancel := context.WithTimeout(i.context(), limit)
+	i.mu.Lock()
+	if i.timeouts == nil {
+		i.timeouts = map[string]*toolTimeout{}
+	}
+	i.timeouts[name] = &toolTimeout{ctx: ctx, limit: toolConfig.InstallTimeout}
+	i.mu.Unlock()
+	return func() {
+		cancel()
+		i.mu.Lock()
+		delete(i.timeouts, name)
+		i.mu.Unlock()
+	}
+}
+
+// toolContext returns the context the commands of a tool run under: the run's context,
+// bounded by the tool's install_timeout when it has one
+func (i *Installer) toolContext(name string) context.Context {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if t := i.timeouts[name]; t != nil {
+		return t.ctx
+	}
+	return i.context()
+}
+
+// setToolPosition records the method and step a tool is on, for the install_timeout error
+func (i *Installer) setToolPosition(name, methodName, step string) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if t := i.timeouts[name]; t != nil {
+		t.method, t.step = methodName, step
+	}
+}
+
+// toolTimedOut returns the error of a tool whose install_timeout ran out, or nil while it
+// has time left or has no timeout
+func (i *Installer) toolTimedOut(name string) error {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	t := i.timeouts[name]
+	if t == nil || t.ctx.Err() != context.DeadlineExceeded || i.context().Err() != nil {
+		return nil
+	}
+	return &toolTimeoutError{limit: t.limit, method: t.method, step: t.step}
+}
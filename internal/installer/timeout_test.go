@@ -0,0 +1,72 @@
+package installer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// hangingRunner is a fakeRunner whose "hang" commands run until they are cancelled
+type hangingRunner struct {
+	*fakeRunner
+}
+
+func (r hangingRunner) Run(ctx context.Context, argv, env []string, out io.Writer) error {
+	if err := r.fakeRunner.Run(ctx, argv, env, out); err != nil || argv[0] != "hang" {
+		return err
+	}
+	io.WriteString(out, "compiling...\n")
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestInstallTimeoutFailsTheToolWithoutFurtherMethods(t *testing.T) {
+	runner := hangingRunner{newFakeRunner(t)}
+	i := newTestInstaller(t, `
+tool_list: [evtool, other]
+tools:
+  evtool:
+    install_timeout: 100ms
+    methods:
+      - {name: source, commands: ["install helper", "hang evtool"]}
+      - {name: fake, commands: ["install evtool"]}
+  other:
+    methods: [{name: fake, commands: ["install other"]}]
+`, runner)
+	var out bytes.Buffer
+	if err := i.Apply(WithOutput(&out)); err != nil {
+		t.Fatal(err)
+	}
+	i.Options.ReportPath = filepath.Join(t.TempDir(), "report.json")
+	if err := i.Run(); err == nil {
+		t.Fatal("Run succeeded with a tool past its install_timeout")
+	}
+	if slices.Contains(runner.commands(), "install evtool") || !slices.Contains(runner.commands(), "install other") {
+		t.Errorf("commands = %q, want the next method skipped and the run moving on", runner.commands())
+	}
+	if !strings.Contains(out.String(), "1 timed out") {
+		t.Errorf("summary does not count the timed out tool:\n%s", out.String())
+	}
+
+	data, err := os.ReadFile(i.Options.ReportPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var report Report
+	if err := json.Unmarshal(data, &report); err != nil {
+		t.Fatal(err)
+	}
+	want := "tool timeout after 100ms (was on method 'source', step 2/2)"
+	if got := report.Tools[0]; got.Status != statusFailed || !got.TimedOut || got.Error != want {
+		t.Errorf("report of evtool = %+v, want it timed out with %q", got, want)
+	}
+	if report.Tools[1].TimedOut {
+		t.Errorf("report of other = %+v, want it not timed out", report.Tools[1])
+	}
+}
@@ -0,0 +1,338 @@
+package installer
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/colors"
+	"github.com/Abhaythakor/dev-tools-installer/internal/config"
+)
+
+// tracer records the spans of a run and exports them to an OTLP/HTTP collector as JSON.
+// A nil tracer records nothing, so runs without an endpoint pay for a nil check only.
+type tracer struct {
+	endpoint string
+	headers  map[string]string
+	service  string
+	traceID  string
+	parentID string // Span of a calling process, from TRACEPARENT
+
+	mu    sync.Mutex
+	spans []*span
+	open  map[string][]*span // Open spans per tool, innermost last
+	root  *span
+}
+
+// span is one timed operation of a run
+type span struct {
+	id     string
+	parent string
+	name   string
+	start  time.Time
+	end    time.Time
+	attrs  map[string]interface{}
+	events []spanEvent
+	err    string
+}
+
+// spanEvent is a point in time within a span, such as a retry
+type spanEvent struct {
+	name  string
+	time  time.Time
+	attrs map[string]interface{}
+}
+
+// newTracer creates a tracer when an OTLP endpoint is configured in the config or the
+// standard OTEL_* environment variables, and returns nil otherwise. headers are the
+// configured headers with their secrets resolved.
+func newTracer(cfg config.Tracing, headers map[string]string) *tracer {
+	if strings.EqualFold(os.Getenv("OTEL_SDK_DISABLED"), "true") {
+		return nil
+	}
+	endpoint := tracesEndpoint(cfg.Endpoint)
+	if endpoint == "" {
+		endpoint = os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT")
+	}
+	if endpoint == "" {
+		endpoint = tracesEndpoint(os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"))
+	}
+	if endpoint == "" {
+		return nil
+	}
+
+	all := map[string]string{}
+	for _, variable := range []string{"OTEL_EXPORTER_OTLP_HEADERS", "OTEL_EXPORTER_OTLP_TRACES_HEADERS"} {
+		for _, pair := range strings.Split(os.Getenv(variable), ",") {
+			if name, value, ok := strings.Cut(pair, "="); ok {
+				all[strings.TrimSpace(name)] = strings.TrimSpace(value)
+			}
+		}
+	}
+	for name, value := range headers {
+		all[name] = value
+	}
+
+	t := &tracer{endpoint: endpoint, headers: all, service: os.Getenv("OTEL_SERVICE_NAME"), open: map[string][]*span{}}
+	if t.service == "" {
+		t.service = "dev-tools-installer"
+	}
+	// Join the trace of a calling pipeline: TRACEPARENT=00-<trace id>-<span id>-<flags>
+	if parts := strings.Split(os.Getenv("TRACEPARENT"), "-"); len(parts) == 4 && len(parts[1]) == 32 && len(parts[2]) == 16 {
+		t.traceID, t.parentID = parts[1], parts[2]
+	} else {
+		t.traceID = randomID(16)
+	}
+	return t
+}
+
+// startTrace starts tracing a run when an endpoint is configured
+func (i *Installer) startTrace(command string) {
+	headers := map[string]string{}
+	for name, value := range i.config.Tracing.Headers {
+		expanded, err := i.expandSecrets(value)
+		if err != nil {
+			fmt.Fprintf(i.display(), "%s⚠ Tracing disabled: tracing.headers %s: %v%s\n", colors.Yellow, name, err, colors.Reset)
+			return
+		}
+		headers[name] = expanded
+	}
+	i.tracer = newTracer(i.config.Tracing, headers)
+	i.tracer.startRoot("installer "+command, map[string]interface{}{"installer.command": command, "installer.config": i.config.Path})
+}
+
+// finishTrace exports the run's trace and prints its ID
+func (i *Installer) finishTrace(err error) {
+	if i.tracer == nil {
+		return
+	}
+	if exportErr := i.tracer.export(err); exportErr != nil {
+		fmt.Fprintf(i.display(), "%s⚠ Failed to export trace: %v%s\n", colors.Yellow, exportErr, colors.Reset)
+		return
+	}
+	fmt.Fprintf(i.display(), "%sTrace ID: %s%s\n", colors.Gray, i.tracer.traceID, colors.Reset)
+}
+
+// tracesEndpoint appends the OTLP traces path to a base endpoint
+func tracesEndpoint(base string) string {
+	if base == "" || strings.HasSuffix(base, "/v1/traces") {
+		return base
+	}
+	return strings.TrimSuffix(base, "/") + "/v1/traces"
+}
+
+// randomID returns n random bytes as hex
+func randomID(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// startRoot starts the span covering the whole run
+func (t *tracer) startRoot(name string, attrs map[string]interface{}) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.root = &span{id: randomID(8), parent: t.parentID, name: name, start: time.Now(), attrs: attrs}
+}
+
+// setRoot records an attribute on the run's span
+func (t *tracer) setRoot(key string, value interface{}) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.root != nil {
+		t.root.attrs[key] = value
+	}
+}
+
+// start opens a span for a tool, nested in the tool's innermost open span or the root
+func (t *tracer) start(tool, name string, attrs map[string]interface{}) *span {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s := &span{id: randomID(8), name: name, start: time.Now(), attrs: attrs}
+	if s.attrs == nil {
+		s.attrs = map[string]interface{}{}
+	}
+	if open := t.open[tool]; len(open) > 0 {
+		s.parent = open[len(open)-1].id
+	} else if t.root != nil {
+		s.parent = t.root.id
+	}
+	t.open[tool] = append(t.open[tool], s)
+	return s
+}
+
+// finish closes a span of tool, recording err as its status
+func (t *tracer) finish(tool string, s *span, err error) {
+	if t == nil || s == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	s.end = time.Now()
+	if err != nil {
+		s.err = err.Error()
+	}
+	open := t.open[tool]
+	for n := len(open) - 1; n >= 0; n-- {
+		if open[n] == s {
+			t.open[tool] = append(open[:n], open[n+1:]...)
+			break
+		}
+	}
+	t.spans = append(t.spans, s)
+}
+
+// set records an attribute on the innermost open span of tool
+func (t *tracer) set(tool, key string, value interface{}) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if open := t.open[tool]; len(open) > 0 {
+		s := open[len(open)-1]
+		if n, ok := value.(int64); ok {
+			// Counters such as downloaded bytes add up over the span
+			if current, ok := s.attrs[key].(int64); ok {
+				n += current
+			}
+			value = n
+		}
+		s.attrs[key] = value
+	}
+}
+
+// event records an event on the innermost open span of tool
+func (t *tracer) event(tool, name string, attrs map[string]interface{}) {
+	if t == nil {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if open := t.open[tool]; len(open) > 0 {
+		s := open[len(open)-1]
+		s.events = append(s.events, spanEvent{name: name, time: time.Now(), attrs: attrs})
+	}
+}
+
+// export closes the root span and sends all spans to the collector
+func (t *tracer) export(err error) error {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	if t.root != nil {
+		t.root.end = time.Now()
+		if err != nil {
+			t.root.err = err.Error()
+		}
+		t.spans = append(t.spans, t.root)
+	}
+	spans := make([]interface{}, 0, len(t.spans))
+	for _, s := range t.spans {
+		spans = append(spans, t.otlpSpan(s))
+	}
+	t.spans = nil
+	t.mu.Unlock()
+
+	body, marshalErr := json.Marshal(map[string]interface{}{
+		"resourceSpans": []interface{}{map[string]interface{}{
+			"resource": map[string]interface{}{"attributes": otlpAttributes(map[string]interface{}{"service.name": t.service})},
+			"scopeSpans": []interface{}{map[string]interface{}{
+				"scope": map[string]interface{}{"name": "dev-tools-installer"},
+				"spans": spans,
+			}},
+		}},
+	})
+	if marshalErr != nil {
+		return marshalErr
+	}
+
+	req, reqErr := http.NewRequest(http.MethodPost, t.endpoint, bytes.NewReader(body))
+	if reqErr != nil {
+		return reqErr
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range t.headers {
+		req.Header.Set(name, value)
+	}
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, sendErr := client.Do(req)
+	if sendErr != nil {
+		return sendErr
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("%s: %s", t.endpoint, resp.Status)
+	}
+	return nil
+}
+
+// otlpSpan renders a span in the OTLP JSON encoding
+func (t *tracer) otlpSpan(s *span) map[string]interface{} {
+	out := map[string]interface{}{
+		"traceId":           t.traceID,
+		"spanId":            s.id,
+		"name":              s.name,
+		"kind":              1, // SPAN_KIND_INTERNAL
+		"startTimeUnixNano": strconv.FormatInt(s.start.UnixNano(), 10),
+		"endTimeUnixNano":   strconv.FormatInt(s.end.UnixNano(), 10),
+		"attributes":        otlpAttributes(s.attrs),
+		"status":            map[string]interface{}{"code": 1}, // STATUS_CODE_OK
+	}
+	if s.parent != "" {
+		out["parentSpanId"] = s.parent
+	}
+	if s.err != "" {
+		out["status"] = map[string]interface{}{"code": 2, "message": s.err} // STATUS_CODE_ERROR
+	}
+	var events []interface{}
+	for _, e := range s.events {
+		events = append(events, map[string]interface{}{
+			"name":         e.name,
+			"timeUnixNano": strconv.FormatInt(e.time.UnixNano(), 10),
+			"attributes":   otlpAttributes(e.attrs),
+		})
+	}
+	if len(events) > 0 {
+		out["events"] = events
+	}
+	return out
+}
+
+// otlpAttributes renders attributes as OTLP key/value pairs in key order
+func otlpAttributes(attrs map[string]interface{}) []interface{} {
+	result := []interface{}{}
+	for _, key := range sortedKeys(attrs) {
+		var value map[string]interface{}
+		switch v := attrs[key].(type) {
+		case int:
+			value = map[string]interface{}{"intValue": strconv.Itoa(v)}
+		case int64:
+			value = map[string]interface{}{"intValue": strconv.FormatInt(v, 10)}
+		case bool:
+			value = map[string]interface{}{"boolValue": v}
+		default:
+			value = map[string]interface{}{"stringValue": fmt.Sprint(v)}
+		}
+		result = append(result, map[string]interface{}{"key": key, "value": value})
+	}
+	return result
+}
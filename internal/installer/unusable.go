@@ -0,0 +1,38 @@
+package installer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// findUnusable looks for a file named command in the directories commands are looked up in
+// when the lookup failed, returning the first one found that the current user can't run and
+// a description of why, or "". Recorded and replayed runs skip it, as their lookups don't
+// use this machine.
+func (i *Installer) findUnusable(command string) (path, description string) {
+	if _, local := i.baseRunner().(localRunner); !local {
+		return "", ""
+	}
+	dirs := filepath.SplitList(os.Getenv("PATH"))
+	if i.Options.Root != "" {
+		dirs = nil
+		for _, dir := range i.targetPath() {
+			dirs = append(dirs, i.inRoot(dir))
+		}
+	}
+	for _, dir := range dirs {
+		if dir == "" {
+			continue
+		}
+		path := filepath.Join(dir, command)
+		info, err := os.Stat(path)
+		if err != nil || info.IsDir() {
+			continue
+		}
+		if reason := unusableReason(path, info); reason != "" {
+			return path, fmt.Sprintf("found %s but it is %s", path, reason)
+		}
+	}
+	return "", ""
+}
@@ -0,0 +1,28 @@
+//go:build !windows
+
+package installer
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"strconv"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// unusableReason explains why the current user can't run the file at path, or returns ""
+func unusableReason(path string, info os.FileInfo) string {
+	if unix.Faccessat(unix.AT_FDCWD, path, unix.X_OK, unix.AT_EACCESS) == nil {
+		return ""
+	}
+	owner := "unknown"
+	if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+		owner = strconv.Itoa(int(stat.Uid))
+		if u, err := user.LookupId(owner); err == nil {
+			owner = u.Username
+		}
+	}
+	return fmt.Sprintf("not executable (mode %04o, owner %s)", info.Mode().Perm(), owner)
+}
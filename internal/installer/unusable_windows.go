@@ -0,0 +1,8 @@
+package installer
+
+import "os"
+
+// unusableReason returns "": Windows runs files by their extension, not their permissions
+func unusableReason(path string, info os.FileInfo) string {
+	return ""
+}
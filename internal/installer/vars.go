@@ -0,0 +1,225 @@
+package installer
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"regexp"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/config"
+	"github.com/Abhaythakor/dev-tools-installer/internal/shellwords"
+)
+
+// Sources of the variables of the expansion context
+const (
+	VarDetected    = "detected"    // Found on this machine, such as os and distro
+	VarConfig      = "config"      // Set in the vars of the config
+	VarCLI         = "cli"         // Set with --var
+	VarTool        = "tool"        // Set for each tool, such as bindir and version
+	VarEnvironment = "environment" // Not a variable: references fall back to the environment
+	VarUnset       = "unset"       // Referenced, but neither a variable nor in the environment
+)
+
+// ContextVar is a value ${name} references in method commands, URLs and detect blocks
+// expand to, and where it comes from
+type ContextVar struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+}
+
+// expansionContext returns the installer variables of a tool, each source overriding the
+// ones before it: values detected on this machine, the config's vars, --var, and the
+// variables of the tool itself. commandVars and the env command both use it, so that what
+// env prints is what commands expand with. An empty name leaves out the tool's variables.
+func (i *Installer) expansionContext(name, version, bindir string) []ContextVar {
+	context := []ContextVar{
+		{Name: "os", Value: runtime.GOOS, Source: VarDetected},
+		{Name: "arch", Value: runtime.GOARCH, Source: VarDetected},
+		{Name: "distro", Value: i.distro(), Source: VarDetected},
+		{Name: "home", Value: homeDir(), Source: VarDetected},
+	}
+	set := func(name, value, source string) {
+		for n := range context {
+			if context[n].Name == name {
+				context[n].Value, context[n].Source = value, source
+				return
+			}
+		}
+		context = append(context, ContextVar{Name: name, Value: value, Source: source})
+	}
+	for _, key := range slices.Sorted(maps.Keys(i.config.Vars)) {
+		set(key, i.config.Vars[key], VarConfig)
+	}
+	for _, key := range slices.Sorted(maps.Keys(i.Options.Vars)) {
+		set(key, i.Options.Vars[key], VarCLI)
+	}
+	if name == "" {
+		return context
+	}
+	set("TOOL_NAME", name, VarTool)
+	set("bindir", bindir, VarTool)
+	set("tmpdir", i.toolTempDir(name), VarTool)
+	if version != "" {
+		set("version", version, VarTool)
+	}
+	return context
+}
+
+// commandVars returns the installer variables available to method commands and URLs
+func (i *Installer) commandVars(name, version, bindir string) map[string]string {
+	vars := map[string]string{}
+	for _, v := range i.expansionContext(name, version, bindir) {
+		vars[v.Name] = v.Value
+	}
+	return vars
+}
+
+// distro returns the ID of the Linux distribution from os-release, e.g. ubuntu, or of the
+// distribution in the target root with Options.Root, or "" when there is none
+func (i *Installer) distro() string {
+	return i.osRelease("ID")
+}
+
+// osRelease returns a field of the os-release file of this system, or of the target root
+// with Options.Root, or "" when it has none
+func (i *Installer) osRelease(field string) string {
+	for _, file := range []string{"/etc/os-release", "/usr/lib/os-release"} {
+		data, err := os.ReadFile(i.inRoot(file))
+		if err != nil {
+			continue
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			if value, ok := strings.CutPrefix(line, field+"="); ok {
+				return strings.Trim(strings.TrimSpace(value), `"'`)
+			}
+		}
+		return ""
+	}
+	return ""
+}
+
+// keepCaptures returns vars with the ${capture:name} references of a method's commands left
+// as they are, for showing commands before any of them ran
+func keepCaptures(method config.InstallMethod, vars map[string]string) map[string]string {
+	for _, command := range method.Commands {
+		if command.Capture != "" {
+			vars["capture:"+command.Capture] = "${capture:" + command.Capture + "}"
+		}
+	}
+	return vars
+}
+
+// bracedVar matches ${name} references
+var bracedVar = regexp.MustCompile(`\$\{([^}]*)\}`)
+
+// expandChecked expands s like expandVars, but fails when a ${name} reference in it is
+// neither an installer variable nor set in the environment, instead of leaving a broken
+// command or URL. what describes s in the error, e.g. the command.
+func expandChecked(name, what, s string, vars map[string]string) (string, error) {
+	for _, match := range bracedVar.FindAllStringSubmatch(s, -1) {
+		key := match[1]
+		if _, ok := vars[key]; ok || strings.HasPrefix(key, "secret:") {
+			continue
+		}
+		if _, ok := os.LookupEnv(key); ok {
+			continue
+		}
+		if key == "version" {
+			return "", fmt.Errorf("%s references ${version}, but no version of %s is pinned", what, name)
+		}
+		return "", fmt.Errorf("%s references ${%s}, which is neither an installer variable nor set in the environment", what, key)
+	}
+	return expandVars(s, vars), nil
+}
+
+// expandVars replaces ${name} references with installer variables, falling back to the environment.
+// ${secret:name} references are kept for the installer to resolve where secrets are allowed.
+func expandVars(s string, vars map[string]string) string {
+	return os.Expand(s, func(key string) string {
+		if value, ok := vars[key]; ok {
+			return value
+		}
+		if strings.HasPrefix(key, "secret:") {
+			return "${" + key + "}"
+		}
+		return os.Getenv(key)
+	})
+}
+
+// expandShown expands s for display like expandChecked, but shows it as written when one of
+// its references is broken, which checkMethodVars reports, rather than expanding it to nothing
+func expandShown(s string, vars map[string]string) string {
+	if expanded, err := expandChecked(vars["TOOL_NAME"], "", s, vars); err == nil {
+		return expanded
+	}
+	return s
+}
+
+// splitCommand splits a command line into argv like a shell, expanding vars in each word, so
+// that quoted values stay one argument however many spaces they hold. It fails, like
+// expandChecked, on a reference that is neither a variable nor in the environment.
+func splitCommand(command string, vars map[string]string) ([]string, error) {
+	if _, err := expandChecked(vars["TOOL_NAME"], fmt.Sprintf("command %q", command), command, vars); err != nil {
+		return nil, err
+	}
+	return shellwords.SplitExpand(command, func(s string) string { return expandVars(s, vars) })
+}
+
+// checkMethodVars checks every reference a method expands: its commands, cleanup, env,
+// headers, URLs and apt repository, and the detect block of its tool. Runs check it before
+// the method does anything and dry runs show its error, so that both fail the same methods.
+func (i *Installer) checkMethodVars(name string, toolConfig *config.ToolConfig, method config.InstallMethod, bindir string) error {
+	type field struct{ what, s string }
+	var fields []field
+	for _, command := range method.Commands {
+		fields = append(fields, field{fmt.Sprintf("command %q", command.Run), command.Run})
+	}
+	for _, command := range method.Cleanup {
+		fields = append(fields, field{fmt.Sprintf("cleanup command %q", command), command})
+	}
+	for _, env := range []map[string]string{i.config.Env, method.Env} {
+		for _, key := range sortedKeys(env) {
+			fields = append(fields, field{"env " + key, env[key]})
+		}
+	}
+	for _, key := range sortedKeys(method.Headers) {
+		fields = append(fields, field{"header " + key, method.Headers[key]})
+	}
+	fields = append(fields, field{"url", method.URL}, field{"asset", method.Asset}, field{"tag", method.Tag})
+	if method.BatchCommand != "" {
+		fields = append(fields, field{"batch_command", strings.ReplaceAll(method.BatchCommand, packagesVar, "")}, field{"package", method.Package})
+	}
+	if repo := method.AptRepo; repo != nil {
+		fields = append(fields, field{"apt_repo url", repo.URL}, field{"apt_repo key_url", repo.KeyURL})
+		for _, suite := range repo.Suites {
+			fields = append(fields, field{"apt_repo suite", suite})
+		}
+	}
+	if detect := toolConfig.Detect; detect != nil {
+		fields = append(fields, field{"detect path", detect.Path}, field{fmt.Sprintf("detect command %q", detect.Command), detect.Command})
+	}
+
+	vars := keepCaptures(method, i.commandVars(name, toolConfig.Version, bindir))
+	for _, f := range fields {
+		if _, err := expandChecked(name, f.what, f.s, vars); err != nil {
+			return err
+		}
+	}
+
+	// Signature URLs may also reference the url of the artifact
+	vars["url"] = ""
+	signature := []field{{"signature", method.Signature}}
+	if method.Cosign != nil {
+		signature = append(signature, field{"cosign key", method.Cosign.Key}, field{"cosign certificate", method.Cosign.Certificate})
+	}
+	for _, f := range signature {
+		if _, err := expandChecked(name, f.what, f.s, vars); err != nil {
+			return err
+		}
+	}
+	return nil
+}
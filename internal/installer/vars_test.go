@@ -0,0 +1,45 @@
+package installer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBrokenReferencesFailDryRunsAndRunsAlike(t *testing.T) {
+	for _, tc := range []struct {
+		name, method, err string
+	}{
+		{"command", `commands: ["install a ${EVTOOL_NOPE}"]`, `command "install a ${EVTOOL_NOPE}" references ${EVTOOL_NOPE}`},
+		{"env", `commands: ["install a"], env: {CC: "${EVTOOL_NOPE}"}`, "env CC references ${EVTOOL_NOPE}"},
+		{"cleanup", `commands: ["install a"], cleanup: ["rm ${EVTOOL_NOPE}"]`, `cleanup command "rm ${EVTOOL_NOPE}" references ${EVTOOL_NOPE}`},
+		{"url", `type: script, url: "https://example.com/${EVTOOL_NOPE}.sh", sha256: "00"`, "url references ${EVTOOL_NOPE}"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			config := "tool_list: [a]\ntools:\n  a:\n    methods: [{name: fake, " + tc.method + "}]\n"
+
+			runner := newFakeRunner(t)
+			i := newTestInstaller(t, config, runner)
+			i.Options.DryRun = true
+			var output strings.Builder
+			i.Apply(WithOutput(&output))
+			if err := i.Run(); err != nil {
+				t.Errorf("dry run: %v", err)
+			}
+			if !strings.Contains(output.String(), tc.err) {
+				t.Errorf("dry run output does not show %q:\n%s", tc.err, output.String())
+			}
+
+			runner = newFakeRunner(t)
+			i = newTestInstaller(t, config, runner)
+			if err := i.Run(); err == nil {
+				t.Error("run succeeded, want the broken reference to fail it")
+			}
+			if len(i.report) != 1 || !strings.Contains(i.report[0].Error, tc.err) {
+				t.Errorf("report = %+v, want an error with %q", i.report, tc.err)
+			}
+			if ran := runner.commands(); len(ran) > 0 {
+				t.Errorf("commands ran before the broken reference was found: %q", ran)
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package installer
+
+import (
+	"fmt"
+)
+
+// Verbosity is how much a run prints, from nothing at all to every command and debug log
+type Verbosity int
+
+const (
+	VerbositySilent  Verbosity = -2 // Nothing; the exit code tells how the run went
+	VerbosityQuiet   Verbosity = -1 // Failures as they happen and the final summary line
+	VerbosityNormal  Verbosity = 0  // The check table and a line per tool
+	VerbosityVerbose Verbosity = 1  // Also each command of a method as it runs
+	VerbosityDebug   Verbosity = 2  // Verbose output, with debug logs enabled
+)
+
+// verbose reports whether the run prints each command of a method as it runs
+func (i *Installer) verbose() bool {
+	return i.Options.Verbosity >= VerbosityVerbose
+}
+
+// quietf prints a line of a quiet run, whose other output is discarded. It prints nothing
+// in runs that are not quiet, since their own output already says the same.
+func (i *Installer) quietf(format string, args ...interface{}) {
+	if i.quiet != nil {
+		fmt.Fprintf(i.quiet, format, args...)
+	}
+}
@@ -0,0 +1,70 @@
+package installer
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/shellwords"
+)
+
+// versionFromTimeout bounds how long a version_from command may run
+const versionFromTimeout = 30 * time.Second
+
+// resolveVersion runs the version_from command of a tool once per run and makes its output
+// the tool's pinned version. A failing command leaves the tool unpinned and is returned as
+// the tool's config error on every call of the run.
+func (i *Installer) resolveVersion(name string) error {
+	toolConfig := i.config.Tools[name]
+	if toolConfig == nil || toolConfig.VersionFrom == "" {
+		return nil
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	if err, ok := i.versions[name]; ok {
+		return err
+	}
+	version, err := i.runVersionFrom(toolConfig.VersionFrom)
+	if err != nil {
+		err = fmt.Errorf("tool %s: version_from: %v", name, err)
+	}
+	toolConfig.Version = version
+	i.log(versionLog).Debug("version_from", "tool", name, "command", toolConfig.VersionFrom, "version", version, "error", err)
+
+	if i.versions == nil {
+		i.versions = map[string]error{}
+	}
+	i.versions[name] = err
+	return err
+}
+
+// runVersionFrom runs a version_from command and returns its trimmed standard output
+func (i *Installer) runVersionFrom(command string) (string, error) {
+	parts, err := shellwords.Split(command)
+	if err != nil {
+		return "", err
+	}
+	if len(parts) == 0 {
+		return "", fmt.Errorf("the command is empty")
+	}
+	ctx, cancel := context.WithTimeout(i.context(), versionFromTimeout)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, parts[0], parts[1:]...).Output()
+	switch {
+	case ctx.Err() == context.DeadlineExceeded:
+		return "", fmt.Errorf("%s timed out after %s", parts[0], versionFromTimeout)
+	case err != nil:
+		return "", fmt.Errorf("%s failed: %v", parts[0], err)
+	}
+	version := strings.TrimSpace(string(output))
+	if version == "" {
+		return "", fmt.Errorf("%s printed nothing", parts[0])
+	}
+	if strings.ContainsAny(version, "\r\n") {
+		return "", fmt.Errorf("%s printed more than one line", parts[0])
+	}
+	return version, nil
+}
@@ -0,0 +1,258 @@
+package installer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/colors"
+	"github.com/Abhaythakor/dev-tools-installer/internal/config"
+)
+
+// probeVersion reports whether a side-by-side version of a tool is installed
+func (i *Installer) probeVersion(name, version string) bool {
+	ts := i.loadedState().Tools[name]
+	if ts == nil || ts.Versions[version] == nil {
+		return false
+	}
+	_, err := os.Stat(ts.Versions[version].Path)
+	return err == nil
+}
+
+// sideBySide reports whether a method can install a version side by side. Package manager
+// methods install into their own prefix and cannot.
+func sideBySide(method config.InstallMethod) bool {
+	switch method.Type {
+	case "", config.MethodDownload, config.MethodGithubRelease, config.MethodScript:
+		return true
+	}
+	return false
+}
+
+// installVersion installs a side-by-side version of a tool into its versioned directory
+func (i *Installer) installVersion(name, version string) error {
+	base := i.config.Tools[name]
+	toolConfig := *base
+	toolConfig.Version = version
+
+	toolConfig.Methods = nil
+	for _, method := range base.Methods {
+		if sideBySide(method) {
+			toolConfig.Methods = append(toolConfig.Methods, method)
+		}
+	}
+	if len(toolConfig.Methods) == 0 {
+		return fmt.Errorf("%s has no download, github_release or command methods to install side by side", name)
+	}
+
+	dir := i.versionDir(name, version)
+	method, path, err := i.install(name, &toolConfig, dir)
+	if err != nil {
+		return err
+	}
+	if path == "" {
+		path = filepath.Join(dir, binaryName(name, method))
+		if _, err := os.Stat(path); err != nil {
+			os.RemoveAll(dir)
+			return fmt.Errorf("method %s did not place %s in ${bindir}", method.Name, filepath.Base(path))
+		}
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	ts := i.loadedState().Tool(name)
+	if ts.Versions == nil {
+		ts.Versions = map[string]*VersionState{}
+	}
+	digest, _ := hashFile(path)
+	ts.Versions[version] = &VersionState{Path: path, Method: method.Name, SHA256: digest, InstalledAt: time.Now()}
+
+	if ts.Active == "" {
+		return i.activate(name, version)
+	}
+	return nil
+}
+
+// Use makes an installed version of a tool the active one
+func (i *Installer) Use(name, version string) error {
+	unlock, err := i.lockRun()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if err := i.activate(name, version); err != nil {
+		return err
+	}
+	return i.saveState()
+}
+
+// activate points the tool's symlink in its install directory at the given version
+func (i *Installer) activate(name, version string) error {
+	ts := i.loadedState().Tools[name]
+	if ts == nil || ts.Versions[version] == nil {
+		return fmt.Errorf("%s@%s is not installed", name, version)
+	}
+
+	link := filepath.Join(i.toolBinDir(name), filepath.Base(ts.Versions[version].Path))
+	if info, err := os.Lstat(link); err == nil && info.Mode()&os.ModeSymlink == 0 {
+		return fmt.Errorf("%s exists and is not managed by the installer", link)
+	}
+	if err := os.MkdirAll(i.toolBinDir(name), 0755); err != nil {
+		return err
+	}
+	os.Remove(link)
+	if err := os.Symlink(ts.Versions[version].Path, link); err != nil {
+		return fmt.Errorf("failed to activate %s@%s: %v", name, version, err)
+	}
+
+	ts.Active = version
+	i.printf("%s│%s ✓ %s now points to %s@%s%s\n", colors.Blue, colors.Green, link, name, version, colors.Reset)
+	return nil
+}
+
+// Uninstall removes a tool, or one side-by-side version of it when entry is name@version
+func (i *Installer) Uninstall(entry string) error {
+	unlock, err := i.lockRun()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	name, version := config.ParseToolEntry(entry)
+	state := i.loadedState()
+	ts := state.Tools[name]
+
+	if version != "" {
+		if ts == nil || ts.Versions[version] == nil {
+			return fmt.Errorf("%s is not installed", entry)
+		}
+		i.removeVersion(name, version)
+		if len(ts.Versions) == 0 && ts.Path == "" {
+			delete(state.Tools, name)
+		}
+		return i.saveState()
+	}
+
+	switch {
+	case ts != nil && len(ts.Versions) > 0:
+		return fmt.Errorf("%s has side-by-side versions (%s); uninstall them as %s@<version>",
+			name, strings.Join(installedVersions(ts), ", "), name)
+	case ts != nil && ts.Managed:
+		if err := os.Remove(ts.Path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s: %v", ts.Path, err)
+		}
+		i.removeBackups(name)
+		fmt.Fprintf(i.display(), "%s│%s ✓ Removed %s%s\n", colors.Blue, colors.Green, ts.Path, colors.Reset)
+	case i.config.Tools[name] != nil && len(i.config.Tools[name].Uninstall) > 0:
+		if err := i.runUninstallCommands(name); err != nil {
+			return err
+		}
+		fmt.Fprintf(i.display(), "%s│%s ✓ Uninstalled %s%s\n", colors.Blue, colors.Green, name, colors.Reset)
+	default:
+		return fmt.Errorf("%s was not installed by a managed method and has no uninstall_commands", name)
+	}
+
+	delete(state.Tools, name)
+	return i.saveState()
+}
+
+// runUninstallCommands runs the uninstall_commands of a tool
+func (i *Installer) runUninstallCommands(name string) error {
+	toolConfig := i.config.Tools[name]
+	method := config.InstallMethod{Name: "uninstall", Commands: config.RunCommands(toolConfig.Uninstall...)}
+	if err := i.runCommands(name, toolConfig, method, i.toolBinDir(name)); err != nil {
+		return fmt.Errorf("failed to uninstall %s: %v", name, err)
+	}
+	return nil
+}
+
+// uninstallFirst runs the uninstall_commands of a tool about to be installed again. Binaries
+// of managed methods are replaced in place and need no uninstall.
+func (i *Installer) uninstallFirst(name string) error {
+	name = i.installName(name)
+	toolConfig := i.config.Tools[name]
+	i.mu.Lock()
+	ts := i.loadedState().Tools[name]
+	i.mu.Unlock()
+	if (ts != nil && ts.Managed) || toolConfig == nil || len(toolConfig.Uninstall) == 0 {
+		return nil
+	}
+	i.printf("%s│%s 🗑 Uninstalling %s before reinstalling it...%s\n", colors.Blue, colors.Yellow, name, colors.Reset)
+	return i.runUninstallCommands(name)
+}
+
+// Prune removes side-by-side versions and managed binaries no longer referenced by tool_list
+func (i *Installer) Prune() ([]string, error) {
+	unlock, err := i.lockRun()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	referenced := map[string]bool{}
+	for _, entry := range i.config.ToolList {
+		name, _ := config.ParseToolEntry(entry)
+		referenced[name] = true
+		referenced[entry] = true
+	}
+
+	var pruned []string
+	state := i.loadedState()
+	for _, name := range sortedKeys(state.Tools) {
+		ts := state.Tools[name]
+		for _, version := range installedVersions(ts) {
+			if !referenced[name+"@"+version] && ts.Active != version {
+				i.removeVersion(name, version)
+				pruned = append(pruned, name+"@"+version)
+			}
+		}
+		if !referenced[name] && ts.Managed {
+			os.Remove(ts.Path)
+			i.removeBackups(name)
+			ts.Path, ts.Managed = "", false
+			pruned = append(pruned, name)
+		}
+		if len(ts.Versions) == 0 && ts.Path == "" && !referenced[name] {
+			delete(state.Tools, name)
+		}
+	}
+
+	return pruned, i.saveState()
+}
+
+// removeVersion deletes a side-by-side version and its symlink when it was active
+func (i *Installer) removeVersion(name, version string) {
+	ts := i.loadedState().Tools[name]
+	vs := ts.Versions[version]
+	if ts.Active == version {
+		os.Remove(filepath.Join(i.toolBinDir(name), filepath.Base(vs.Path)))
+		ts.Active = ""
+	}
+	os.RemoveAll(filepath.Dir(vs.Path))
+	delete(ts.Versions, version)
+	fmt.Fprintf(i.display(), "%s│%s ✓ Removed %s@%s%s\n", colors.Blue, colors.Green, name, version, colors.Reset)
+}
+
+// binaryName returns the name of the binary a method installs
+func binaryName(name string, method config.InstallMethod) string {
+	if method.Binary != "" {
+		return method.Binary
+	}
+	return name
+}
+
+// installedVersions returns the tool's side-by-side versions in sorted order
+func installedVersions(ts *ToolState) []string {
+	return sortedKeys(ts.Versions)
+}
+
+// sortedKeys returns the keys of m in sorted order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,203 @@
+package installer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/colors"
+	"github.com/Abhaythakor/dev-tools-installer/internal/config"
+)
+
+// watchLogFileName is the name of the watch cycle log inside the state directory
+const watchLogFileName = "watch.jsonl"
+
+// metricsFileName is the Prometheus textfile the last watch cycle is exposed in
+const metricsFileName = "metrics.prom"
+
+// WatchBackoff is the first delay before retrying an invalid config; it doubles up to the interval
+const WatchBackoff = 30 * time.Second
+
+// WatchCycle is one verification cycle of watch mode
+type WatchCycle struct {
+	Time     time.Time     `json:"time"`
+	Duration time.Duration `json:"duration_ns"`
+	Tools    int           `json:"tools"`
+	Missing  int           `json:"missing"`
+	Drifted  int           `json:"drifted"`
+	Repaired int           `json:"repaired,omitempty"`
+	Failed   int           `json:"failed,omitempty"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// Watch verifies the toolset every interval until interrupted, repairing drift when autoFix
+// is set. Each cycle is logged to the state directory and exposed in the metrics file.
+// SIGHUP reloads the config through reload; an invalid config keeps the previous one and is
+// retried with backoff. Cycles never overlap: one that outlasts the interval delays the next
+// to the following interval boundary.
+func (i *Installer) Watch(interval time.Duration, autoFix bool, reload func() (*config.InstallerConfig, error)) error {
+	if interval <= 0 {
+		return fmt.Errorf("watch interval must be positive")
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	mode := "reporting drift"
+	if autoFix {
+		mode = "repairing drift"
+	}
+	fmt.Fprintf(i.display(), "%sWatching %d tools every %s, %s%s\n", colors.Gray, len(i.config.ToolList), interval, mode, colors.Reset)
+
+	next := time.NewTimer(0)
+	defer next.Stop()
+	var retry <-chan time.Time
+	backoff := WatchBackoff
+	configErr := ""
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-hup:
+			retry = nil
+			backoff = WatchBackoff
+			if !i.reloadConfig(reload, &configErr) {
+				retry = time.After(backoff)
+			}
+		case <-retry:
+			retry = nil
+			if !i.reloadConfig(reload, &configErr) {
+				backoff = min(backoff*2, interval)
+				retry = time.After(backoff)
+			}
+		case <-next.C:
+			start := time.Now()
+			cycle := i.watchCycle(autoFix)
+			if ctx.Err() != nil {
+				return nil
+			}
+			i.recordCycle(cycle, configErr)
+
+			elapsed := time.Since(start)
+			wait := interval - elapsed%interval
+			if elapsed > interval {
+				fmt.Fprintf(i.display(), "%s⚠ Cycle took %s, longer than the %s interval; next cycle in %s%s\n",
+					colors.Yellow, elapsed.Round(time.Second), interval, wait.Round(time.Second), colors.Reset)
+			}
+			next.Reset(wait)
+		}
+	}
+}
+
+// reloadConfig replaces the config with a freshly loaded one, keeping the current config
+// when the new one is invalid. configErr holds the last load error for the metrics file.
+func (i *Installer) reloadConfig(reload func() (*config.InstallerConfig, error), configErr *string) bool {
+	cfg, err := reload()
+	if err != nil {
+		*configErr = err.Error()
+		fmt.Fprintf(i.display(), "%s⚠ Keeping the previous config: %v%s\n", colors.Yellow, err, colors.Reset)
+		return false
+	}
+	*configErr = ""
+	i.configure(cfg)
+	i.applyDefaultTools()
+	fmt.Fprintf(i.display(), "%sReloaded config: %d tools%s\n", colors.Gray, len(cfg.ToolList), colors.Reset)
+	return true
+}
+
+// watchCycle verifies the toolset and, with autoFix, installs missing tools and reinstalls
+// drifted ones
+func (i *Installer) watchCycle(autoFix bool) WatchCycle {
+	i.report, i.state = nil, nil
+	cycle := WatchCycle{Time: time.Now()}
+	err := i.run(false)
+	for _, result := range i.report {
+		cycle.Tools++
+		switch {
+		case result.Status == statusMissing:
+			cycle.Missing++
+		case result.Drift:
+			cycle.Drifted++
+		}
+	}
+
+	if autoFix && cycle.Missing+cycle.Drifted > 0 {
+		i.report, i.state = nil, nil
+		fix := i.Options.Fix
+		i.Options.Fix = true
+		err = i.run(true)
+		i.Options.Fix = fix
+		for _, result := range i.report {
+			switch result.Status {
+			case statusInstalled, statusUpgraded, statusReinstalled:
+				cycle.Repaired++
+			case statusFailed:
+				cycle.Failed++
+			}
+		}
+	}
+
+	cycle.Duration = time.Since(cycle.Time)
+	if err != nil {
+		cycle.Error = err.Error()
+	}
+	return cycle
+}
+
+// recordCycle appends a cycle to the watch log and rewrites the metrics file
+func (i *Installer) recordCycle(cycle WatchCycle, configErr string) {
+	line, err := json.Marshal(cycle)
+	if err == nil {
+		err = appendBounded(filepath.Join(i.stateDir(), watchLogFileName), append(line, '\n'), defaultHistoryLimit)
+	}
+	if err != nil {
+		fmt.Fprintf(i.display(), "%s⚠ Failed to log watch cycle: %v%s\n", colors.Yellow, err, colors.Reset)
+	}
+	if err := i.writeMetrics(cycle, configErr); err != nil {
+		fmt.Fprintf(i.display(), "%s⚠ Failed to write metrics: %v%s\n", colors.Yellow, err, colors.Reset)
+	}
+}
+
+// writeMetrics writes the last cycle in the Prometheus textfile format, atomically so a
+// collector never reads a partial file
+func (i *Installer) writeMetrics(cycle WatchCycle, configErr string) error {
+	ok, valid := 0, 1
+	if cycle.Error == "" {
+		ok = 1
+	}
+	if configErr != "" {
+		valid = 0
+	}
+
+	var b strings.Builder
+	metric := func(name, help string, value any) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s gauge\n%s %v\n", name, help, name, name, value)
+	}
+	metric("installer_watch_last_cycle_timestamp_seconds", "Unix time the last watch cycle started.", cycle.Time.Unix())
+	metric("installer_watch_last_cycle_duration_seconds", "Duration of the last watch cycle.", cycle.Duration.Seconds())
+	metric("installer_watch_last_cycle_success", "Whether the last watch cycle ended without missing, drifted or failed tools.", ok)
+	metric("installer_watch_tools", "Tools in tool_list.", cycle.Tools)
+	metric("installer_watch_tools_missing", "Tools missing at the start of the last cycle.", cycle.Missing)
+	metric("installer_watch_tools_drifted", "Tools whose version drifted from the pin at the start of the last cycle.", cycle.Drifted)
+	metric("installer_watch_tools_repaired", "Tools the last cycle repaired.", cycle.Repaired)
+	metric("installer_watch_tools_repair_failed", "Tools the last cycle failed to repair.", cycle.Failed)
+	metric("installer_watch_config_valid", "Whether the last config reload succeeded.", valid)
+
+	path := filepath.Join(i.stateDir(), metricsFileName)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(b.String()), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
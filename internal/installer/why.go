@@ -0,0 +1,100 @@
+package installer
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/Abhaythakor/dev-tools-installer/internal/colors"
+	"github.com/Abhaythakor/dev-tools-installer/internal/config"
+)
+
+// Why explains a tool's status: whether tool_list selects it, what the planner found and
+// decided, which methods a run would try and what earlier runs did with it
+func (i *Installer) Why(tool string) error {
+	name, _ := config.ParseToolEntry(tool)
+	fmt.Fprintf(i.display(), "\n%s╭─── Why %s ───╮%s\n", colors.Blue+colors.Bold, tool, colors.Reset)
+
+	// A bare name explains every entry of the tool, name@version only that entry
+	var entries []string
+	for _, entry := range i.config.ToolList {
+		if entryName, _ := config.ParseToolEntry(entry); entry == tool || (tool == name && entryName == name) {
+			entries = append(entries, entry)
+		}
+	}
+	selected := len(entries) > 0
+	if !selected {
+		whyRow(i.display(), colors.Yellow, "selected", fmt.Sprintf("%s is not in tool_list, so runs ignore it", tool))
+		entries = []string{tool}
+	}
+
+	for _, entry := range entries {
+		item := i.planEntry(entry)
+		switch {
+		case selected && i.isDisabled(name) && !i.Options.IncludeDisabled:
+			whyRow(i.display(), colors.Yellow, "selected", fmt.Sprintf("tool_list entry %s, but the tool is disabled, so runs skip it", entry))
+		case selected:
+			whyRow(i.display(), colors.Green, "selected", fmt.Sprintf("tool_list entry %s", entry))
+		}
+
+		color := colors.Green
+		if item.Action != actionSkip {
+			color = colors.Yellow
+		}
+		whyRow(i.display(), color, "decision", item.Action)
+		for _, reason := range item.Reasons {
+			fmt.Fprintf(i.display(), "%s│   %s%s%s\n", colors.Blue, colors.Gray, reason, colors.Reset)
+		}
+
+		if len(item.Methods) > 0 {
+			methods := append([]string{item.Methods[0] + " (next)"}, item.Methods[1:]...)
+			whyRow(i.display(), colors.Blue, "methods", strings.Join(methods, ", "))
+		}
+		i.whyHistory(entry)
+	}
+
+	fmt.Fprintf(i.display(), "%s╰───────╯%s\n\n", colors.Blue, colors.Reset)
+	return nil
+}
+
+// whyHistory prints what the state file and the last run touching entry recorded
+func (i *Installer) whyHistory(entry string) {
+	name, version := config.ParseToolEntry(entry)
+	if ts := i.loadedState().Tools[name]; ts != nil {
+		switch {
+		case version != "" && ts.Versions[version] != nil:
+			vs := ts.Versions[version]
+			whyRow(i.display(), colors.Blue, "state", fmt.Sprintf("installed via %s on %s", vs.Method, vs.InstalledAt.Format("2006-01-02 15:04")))
+		case version == "" && ts.Method != "":
+			whyRow(i.display(), colors.Blue, "state", fmt.Sprintf("%s installed via %s on %s", orDash(ts.Version), ts.Method, ts.InstalledAt.Format("2006-01-02 15:04")))
+		}
+	}
+
+	records, err := i.History()
+	if err != nil {
+		return
+	}
+	for n := len(records) - 1; n >= 0; n-- {
+		for _, tool := range records[n].Tools {
+			if tool.Name != entry {
+				continue
+			}
+			line := fmt.Sprintf("%s: %s", records[n].Time.Format("2006-01-02 15:04"), tool.Action)
+			if tool.Method != "" {
+				line += " via " + tool.Method
+			}
+			color := colors.Blue
+			if tool.Error != "" {
+				color = colors.Red
+				line += ": " + tool.Error
+			}
+			whyRow(i.display(), color, "last run", line)
+			return
+		}
+	}
+}
+
+// whyRow prints a labelled row of the why box to w
+func whyRow(w io.Writer, color, label, text string) {
+	fmt.Fprintf(w, "%s│ %s%-9s%s │ %s\n", colors.Blue, color, label, colors.Reset, text)
+}
@@ -0,0 +1,166 @@
+// Package shellwords splits command lines into argv the way a POSIX shell splits words,
+// without running a shell: quotes and backslashes group and escape, nothing else is special.
+package shellwords
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxLength is the longest command line Split accepts
+const MaxLength = 64 << 10
+
+// Split splits a command line into words. Words are separated by unquoted spaces, tabs and
+// newlines; single quotes keep everything up to the next single quote, double quotes keep
+// everything up to the next unescaped double quote with \\, \", \$ and \` unescaped, and a
+// backslash outside quotes keeps the next character. Pipes, redirections and $ are not
+// special, so commands needing them run through sh -c '...'.
+func Split(line string) ([]string, error) {
+	return SplitExpand(line, nil)
+}
+
+// SplitExpand splits a command line like Split, passing the unquoted and quoted parts of
+// each word between escapes through expand first when it is not nil. What an unquoted part
+// expands to is split into words at whitespace, like the unquoted variables of a shell; what
+// a quoted part expands to stays in its word.
+func SplitExpand(line string, expand func(string) string) ([]string, error) {
+	if err := Check(line); err != nil {
+		return nil, err
+	}
+	if expand == nil {
+		expand = func(s string) string { return s }
+	}
+
+	var words []string
+	var word, plain strings.Builder
+	inWord := false
+	end := func() {
+		if inWord {
+			words = append(words, word.String())
+		}
+		word.Reset()
+		inWord = false
+	}
+	// flush expands the unquoted text read since the last quote, escape or separator
+	flush := func() {
+		if plain.Len() == 0 {
+			return
+		}
+		expanded := expand(plain.String())
+		plain.Reset()
+		if expanded != "" && isSeparator(rune(expanded[0])) {
+			end()
+		}
+		fields := strings.FieldsFunc(expanded, isSeparator)
+		for n, field := range fields {
+			if n > 0 {
+				end()
+			}
+			word.WriteString(field)
+			inWord = true
+		}
+		if len(fields) > 0 && isSeparator(rune(expanded[len(expanded)-1])) {
+			end()
+		}
+	}
+
+	for pos := 0; pos < len(line); {
+		c := line[pos]
+		switch {
+		case isSeparator(rune(c)):
+			flush()
+			end()
+			pos++
+		case c == '\\':
+			flush()
+			if pos+1 == len(line) {
+				return nil, fmt.Errorf("the command ends with an unescaped backslash")
+			}
+			r, size := utf8.DecodeRuneInString(line[pos+1:])
+			// A backslash before a newline continues the line
+			if r != '\n' {
+				word.WriteRune(r)
+				inWord = true
+			}
+			pos += 1 + size
+		case c == '\'':
+			flush()
+			closing := strings.IndexByte(line[pos+1:], '\'')
+			if closing < 0 {
+				return nil, fmt.Errorf("unterminated single quote at byte %d", pos)
+			}
+			word.WriteString(expand(line[pos+1 : pos+1+closing]))
+			inWord = true
+			pos += closing + 2
+		case c == '"':
+			flush()
+			quoted, next, err := doubleQuoted(line, pos, expand)
+			if err != nil {
+				return nil, err
+			}
+			word.WriteString(quoted)
+			inWord = true
+			pos = next
+		default:
+			plain.WriteByte(c)
+			pos++
+		}
+	}
+	flush()
+	end()
+	return words, nil
+}
+
+// doubleQuoted reads the double-quoted string starting at line[start], returning its content
+// with the text between escapes expanded and the escapes removed, and the position after the
+// closing quote
+func doubleQuoted(line string, start int, expand func(string) string) (string, int, error) {
+	var quoted, run strings.Builder
+	for pos := start + 1; pos < len(line); pos++ {
+		switch c := line[pos]; {
+		case c == '"':
+			quoted.WriteString(expand(run.String()))
+			return quoted.String(), pos + 1, nil
+		case c == '\\' && pos+1 < len(line) && strings.IndexByte("\\\"$`\n", line[pos+1]) >= 0:
+			quoted.WriteString(expand(run.String()))
+			run.Reset()
+			if line[pos+1] != '\n' {
+				quoted.WriteByte(line[pos+1])
+			}
+			pos++
+		default:
+			run.WriteByte(c)
+		}
+	}
+	return "", 0, fmt.Errorf("unterminated double quote at byte %d", start)
+}
+
+// isSeparator reports whether r separates words: only ASCII whitespace does, as in a shell
+func isSeparator(r rune) bool {
+	return r == ' ' || r == '\t' || r == '\n' || r == '\r'
+}
+
+// Check rejects command lines that would split into argv other than what they look like:
+// lines longer than MaxLength, invalid UTF-8, and control, invisible formatting and non-ASCII
+// space characters, which are usually pasted by accident and are not word separators
+func Check(line string) error {
+	if len(line) > MaxLength {
+		return fmt.Errorf("the command is longer than %d bytes", MaxLength)
+	}
+	for pos, r := range line {
+		switch {
+		case r == utf8.RuneError && !strings.HasPrefix(line[pos:], string(utf8.RuneError)):
+			return fmt.Errorf("invalid UTF-8 at byte %d", pos)
+		case isSeparator(r):
+		case unicode.IsControl(r):
+			return fmt.Errorf("control character %U at byte %d", r, pos)
+		case unicode.Is(unicode.Cf, r):
+			return fmt.Errorf("invisible formatting character %U at byte %d; retype the command around it", r, pos)
+		case unicode.IsSpace(r):
+			return fmt.Errorf("non-ASCII space %U at byte %d; use a plain space", r, pos)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package shellwords
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	for _, tc := range []struct {
+		line  string
+		words []string
+		err   string
+	}{
+		{line: "go install ./cmd/...", words: []string{"go", "install", "./cmd/..."}},
+		{line: "  a \t b\n", words: []string{"a", "b"}},
+		{line: `echo 'a  b' "c \"d\" \$e" f\ g`, words: []string{"echo", "a  b", `c "d" $e`, "f g"}},
+		{line: `a''b ""`, words: []string{"ab", ""}},
+		{line: "a \\\nb", words: []string{"a", "b"}},
+		{line: "sh -c 'curl -fsSL https://example.com | sh'", words: []string{"sh", "-c", "curl -fsSL https://example.com | sh"}},
+		{line: "echo 'héllo wörld' 日本", words: []string{"echo", "héllo wörld", "日本"}},
+		{line: "", words: nil},
+		{line: "echo 'a", err: "unterminated single quote at byte 5"},
+		{line: `echo "a`, err: "unterminated double quote at byte 5"},
+		{line: `echo a\`, err: "the command ends with an unescaped backslash"},
+		{line: "echo a", err: "non-ASCII space U+00A0 at byte 4; use a plain space"},
+		{line: "echo a​b", err: "invisible formatting character U+200B at byte 6"},
+		{line: "echo \x1b[31m", err: "control character U+001B at byte 5"},
+		{line: "echo \xff", err: "invalid UTF-8 at byte 5"},
+		{line: "echo " + strings.Repeat("a", MaxLength), err: "the command is longer than 65536 bytes"},
+	} {
+		words, err := Split(tc.line)
+		switch {
+		case tc.err != "" && (err == nil || !strings.Contains(err.Error(), tc.err)):
+			t.Errorf("Split(%.40q) error = %v, want %q", tc.line, err, tc.err)
+		case tc.err == "" && (err != nil || !slices.Equal(words, tc.words)):
+			t.Errorf("Split(%q) = %q, %v; want %q", tc.line, words, err, tc.words)
+		}
+	}
+}
+
+func TestSplitExpand(t *testing.T) {
+	vars := map[string]string{"$list": "a  b", "$empty": ""}
+	expand := func(s string) string {
+		for name, value := range vars {
+			s = strings.ReplaceAll(s, name, value)
+		}
+		return s
+	}
+	for line, want := range map[string][]string{
+		`echo $list`:      {"echo", "a", "b"},
+		`echo "$list"`:    {"echo", "a  b"},
+		`echo x$list'y'`:  {"echo", "xa", "by"},
+		`echo $empty end`: {"echo", "end"},
+		`echo '$empty'`:   {"echo", ""},
+		`echo \$list`:     {"echo", "$list"},
+	} {
+		if words, err := SplitExpand(line, expand); err != nil || !slices.Equal(words, want) {
+			t.Errorf("SplitExpand(%q) = %q, %v; want %q", line, words, err, want)
+		}
+	}
+}
+
+// quote quotes words for Split with single quotes
+func quote(words []string) string {
+	quoted := make([]string, len(words))
+	for n, word := range words {
+		quoted[n] = "'" + strings.ReplaceAll(word, "'", `'\''`) + "'"
+	}
+	return strings.Join(quoted, " ")
+}
+
+// FuzzSplit checks that Split never panics, rejects every line Check rejects, and returns
+// words that split back into themselves once quoted. Its seed corpus is in
+// testdata/fuzz/FuzzSplit.
+func FuzzSplit(f *testing.F) {
+	f.Fuzz(func(t *testing.T, line string) {
+		words, err := Split(line)
+		if checkErr := Check(line); checkErr != nil {
+			if err == nil {
+				t.Fatalf("Split(%q) accepted a line Check rejects: %v", line, checkErr)
+			}
+			return
+		}
+		if err != nil {
+			return
+		}
+		again, err := Split(quote(words))
+		if err != nil || !slices.Equal(again, words) {
+			t.Fatalf("Split(%q) = %q, which split again as %q, %v", line, words, again, err)
+		}
+		if expanded, err := SplitExpand(line, func(s string) string { return s }); err != nil || !slices.Equal(expanded, words) {
+			t.Fatalf("SplitExpand(%q) without expansion = %q, %v; want %q", line, expanded, err, words)
+		}
+	})
+}
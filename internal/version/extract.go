@@ -0,0 +1,92 @@
+package version
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Limits on the output Extract looks at, so that a tool printing megabytes or binary data
+// where a version banner was expected cannot stall a check
+const (
+	maxOutput    = 16 << 10 // Bytes of output matched against the patterns
+	maxFirstLine = 200      // Bytes of the first line returned when no pattern matches
+)
+
+// commonPatterns match the versions in the output of common version flags, tried in order
+var commonPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)version\s+(v\d+\.\d+\.\d+)`), // version v1.2.3 (case insensitive)
+	regexp.MustCompile(`(?i)amass\s+-\s+v\d+\.\d+\.\d+`), // amass - v1.2.3
+	regexp.MustCompile(`v\d+\.\d+\.\d+`),                 // v1.2.3
+	regexp.MustCompile(`\d+\.\d+\.\d+`),                  // 1.2.3
+	regexp.MustCompile(`go\d+\.\d+\.\d+`),                // go1.2.3
+	regexp.MustCompile(`Version: (v\d+\.\d+\.\d+)`),      // Version: v1.2.3
+}
+
+// Extract finds the version in the output of a version command, trying the configured
+// regular expressions first. A pattern's first capture group is the version when it has
+// one. When nothing matches, the first line of the output is returned. Only the first 16 KiB
+// of the output are looked at.
+func Extract(output string, configured []string) string {
+	output = strings.TrimSpace(strings.ToValidUTF8(truncate(output, maxOutput), "\uFFFD"))
+	for _, pattern := range configured {
+		re := compiled(pattern)
+		if re == nil {
+			continue
+		}
+		if match := re.FindStringSubmatch(output); len(match) > 1 {
+			return match[1]
+		} else if len(match) == 1 {
+			return match[0]
+		}
+	}
+
+	for _, re := range commonPatterns {
+		match := re.FindStringSubmatch(output)
+		switch {
+		case len(match) > 1:
+			return match[1]
+		case len(match) == 1:
+			return Clean(match[0])
+		}
+	}
+	first, _, _ := strings.Cut(output, "\n")
+	return strings.TrimSpace(truncate(first, maxFirstLine))
+}
+
+// truncate cuts s to at most n bytes without splitting a character
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// patterns caches the configured patterns, which Extract is called with for every check
+var patterns sync.Map
+
+// compiled returns the compiled form of a configured pattern, or nil when it does not compile
+func compiled(pattern string) *regexp.Regexp {
+	if re, ok := patterns.Load(pattern); ok {
+		return re.(*regexp.Regexp)
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil
+	}
+	patterns.Store(pattern, re)
+	return re
+}
+
+// Clean strips the tool-specific decorations common patterns match along with a version,
+// such as the "amass - v" of amass or the "go" of go1.22.1
+func Clean(match string) string {
+	if rest, ok := strings.CutPrefix(match, "amass - "); ok {
+		return strings.TrimPrefix(rest, "v")
+	}
+	return strings.TrimPrefix(match, "go")
+}
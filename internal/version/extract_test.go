@@ -0,0 +1,82 @@
+package version
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestExtract(t *testing.T) {
+	for _, tc := range []struct {
+		name       string
+		output     string
+		configured []string
+		want       string
+	}{
+		{"go", "go version go1.22.1 linux/amd64\n", nil, "1.22.1"},
+		{"git", "git version 2.43.0\n", nil, "2.43.0"},
+		{"node", "v20.11.1\n", nil, "v20.11.1"},
+		{"rustc", "rustc 1.76.0 (07dca489a 2024-02-04)\n", nil, "1.76.0"},
+		{"python", "Python 3.12.2\n", nil, "3.12.2"},
+		{"docker", "Docker version 25.0.3, build 4debf41\n", nil, "25.0.3"},
+		{"kubectl", "Client Version: v1.29.2\nKustomize Version: v5.0.4-0.20230601165947-6ce0bf390ce3\n", nil, "v1.29.2"},
+		{"nuclei", "[INF] Nuclei Engine Version: v3.1.10\n", nil, "v3.1.10"},
+		{"amass", "amass - v4.2.0\n", nil, "4.2.0"},
+		{"jq", "jq-1.7.1\n", nil, "1.7.1"},
+		{"configured capture group", "ffuf version: 2.1.0-dev\n", []string{`version: (\S+)`}, "2.1.0-dev"},
+		{"configured without a group", "tool build 2024.06.01\n", []string{`\d{4}\.\d{2}\.\d{2}`}, "2024.06.01"},
+		{"configured patterns in order", "a 1.0 b 2.0", []string{`b (\S+)`, `a (\S+)`}, "2.0"},
+		{"invalid configured pattern", "tool 1.2.3", []string{`(`}, "1.2.3"},
+		{"no version", "  usage: tool [flags]\nflags:\n", nil, "usage: tool [flags]"},
+		{"empty", "", nil, ""},
+		{"invalid UTF-8", "tool \xff\xfe build\n", nil, "tool � build"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Extract(tc.output, tc.configured); got != tc.want {
+				t.Errorf("Extract(%q, %q) = %q, want %q", tc.output, tc.configured, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestExtractLimitsTheOutputItLooksAt(t *testing.T) {
+	if got := Extract(strings.Repeat("x", maxOutput)+" 1.2.3", nil); got != strings.Repeat("x", maxFirstLine) {
+		t.Errorf("Extract = %q, want the version past the limit ignored and the first line cut", got)
+	}
+	if got := Extract(strings.Repeat("é", maxFirstLine), nil); got != strings.Repeat("é", maxFirstLine/2) {
+		t.Errorf("Extract = %q, want the first line cut without splitting a character", got)
+	}
+}
+
+func TestClean(t *testing.T) {
+	for match, want := range map[string]string{
+		"amass - v4.2.0": "4.2.0",
+		"go1.22.1":       "1.22.1",
+		"v1.2.3":         "v1.2.3",
+		"1.2.3":          "1.2.3",
+	} {
+		if got := Clean(match); got != want {
+			t.Errorf("Clean(%q) = %q, want %q", match, got, want)
+		}
+	}
+}
+
+// FuzzExtract checks that Extract never panics, returns valid UTF-8 no longer than the
+// output it looks at, and stays fast when the output is padded past 16 KiB. Its seed corpus
+// is in testdata/fuzz/FuzzExtract.
+func FuzzExtract(f *testing.F) {
+	configured := []string{`(?i)version:?\s+(\S+)`, `\d{4}\.\d{2}\.\d{2}`}
+	f.Fuzz(func(t *testing.T, output string) {
+		for _, output := range []string{output, strings.Repeat(output+"\n", maxOutput/(len(output)+1)+1)} {
+			start := time.Now()
+			got := Extract(output, configured)
+			if elapsed := time.Since(start); elapsed > time.Second {
+				t.Fatalf("Extract of %d bytes took %v", len(output), elapsed)
+			}
+			if !utf8.ValidString(got) || len(got) > maxOutput {
+				t.Fatalf("Extract(%q) = %q, want valid UTF-8 of at most %d bytes", output, got, maxOutput)
+			}
+		}
+	})
+}
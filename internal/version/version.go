@@ -0,0 +1,210 @@
+// Package version parses and compares the versions tools report, which are often not semver:
+// "1.2", "v3.2.0-dev", "2024.06.01" or "1.2.3-ubuntu2".
+package version
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is a parsed version. Release components missing from the end count as zero, so
+// 1.2 equals 1.2.0.
+type Version struct {
+	Release    []int  // Numeric components, e.g. [1 2 3] for 1.2.3 or [2024 6 1] for 2024.06.01
+	Prerelease string // Suffix marking a version before the release, e.g. rc1 or dev
+	Revision   string // Other suffix, such as a distribution revision; ranks after the release
+	Build      string // Metadata after +, ignored when comparing
+
+	raw string
+}
+
+// ParseError reports a string that is not a version. Callers comparing such strings can
+// fall back to comparing them as text.
+type ParseError struct {
+	Input string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("%q is not a version", e.Input)
+}
+
+// prereleaseRanks orders the words that mark a prerelease suffix
+var prereleaseRanks = map[string]int{
+	"dev": 0, "snapshot": 0, "nightly": 0,
+	"alpha": 1, "a": 1,
+	"beta": 2, "b": 2,
+	"pre": 3, "preview": 3,
+	"rc": 4,
+}
+
+// Parse parses a version with an optional v prefix, numeric components separated by dots
+// and an optional suffix after -, ~, _ or directly after the last component
+func Parse(s string) (Version, error) {
+	v := Version{raw: strings.TrimSpace(s)}
+	rest := strings.TrimPrefix(strings.TrimPrefix(v.raw, "v"), "V")
+	rest, v.Build, _ = strings.Cut(rest, "+")
+
+	for {
+		n := 0
+		for n < len(rest) && rest[n] >= '0' && rest[n] <= '9' {
+			n++
+		}
+		if n == 0 {
+			return Version{}, &ParseError{Input: s}
+		}
+		component, err := strconv.Atoi(rest[:n])
+		if err != nil {
+			return Version{}, &ParseError{Input: s}
+		}
+		v.Release = append(v.Release, component)
+		rest = rest[n:]
+		if len(rest) < 2 || rest[0] != '.' || rest[1] < '0' || rest[1] > '9' {
+			break
+		}
+		rest = rest[1:]
+	}
+
+	suffix := strings.TrimLeft(rest, "-~_.")
+	if suffix == "" {
+		if rest != "" {
+			return Version{}, &ParseError{Input: s}
+		}
+		return v, nil
+	}
+	if _, ok := prereleaseRanks[strings.ToLower(leadingWord(suffix))]; ok {
+		v.Prerelease = suffix
+	} else {
+		v.Revision = suffix
+	}
+	return v, nil
+}
+
+// String returns the version as it was parsed
+func (v Version) String() string {
+	return v.raw
+}
+
+// Compare returns -1, 0 or +1 depending on whether v is older than, equal to or newer than w.
+// Prereleases rank before their release and revisions after it; build metadata is ignored.
+func (v Version) Compare(w Version) int {
+	for n := 0; n < max(len(v.Release), len(w.Release)); n++ {
+		if c := compareInts(component(v.Release, n), component(w.Release, n)); c != 0 {
+			return c
+		}
+	}
+	if c := compareInts(v.stage(), w.stage()); c != 0 {
+		return c
+	}
+	if v.Prerelease != "" {
+		return compareSuffixes(v.Prerelease, w.Prerelease)
+	}
+	return compareSuffixes(v.Revision, w.Revision)
+}
+
+// stage ranks prereleases before releases before revisions
+func (v Version) stage() int {
+	switch {
+	case v.Prerelease != "":
+		return -1
+	case v.Revision != "":
+		return 1
+	}
+	return 0
+}
+
+// Compare parses and compares two versions as Version.Compare does, returning a *ParseError
+// when either is not a version
+func Compare(a, b string) (int, error) {
+	va, err := Parse(a)
+	if err != nil {
+		return 0, err
+	}
+	vb, err := Parse(b)
+	if err != nil {
+		return 0, err
+	}
+	return va.Compare(vb), nil
+}
+
+// component returns the nth release component, zero when it is missing
+func component(release []int, n int) int {
+	if n < len(release) {
+		return release[n]
+	}
+	return 0
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// compareSuffixes compares suffixes such as rc1 and beta.2 identifier by identifier: words by
+// their prerelease rank or else alphabetically, numbers numerically and before words
+func compareSuffixes(a, b string) int {
+	ia, ib := identifiers(a), identifiers(b)
+	for n := 0; n < min(len(ia), len(ib)); n++ {
+		if c := compareIdentifiers(ia[n], ib[n]); c != 0 {
+			return c
+		}
+	}
+	return compareInts(len(ia), len(ib))
+}
+
+func compareIdentifiers(a, b string) int {
+	na, errA := strconv.Atoi(a)
+	nb, errB := strconv.Atoi(b)
+	switch {
+	case errA == nil && errB == nil:
+		return compareInts(na, nb)
+	case errA == nil:
+		return -1
+	case errB == nil:
+		return 1
+	}
+	ra, okA := prereleaseRanks[strings.ToLower(a)]
+	rb, okB := prereleaseRanks[strings.ToLower(b)]
+	if okA && okB && ra != rb {
+		return compareInts(ra, rb)
+	}
+	return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+}
+
+// identifiers splits a suffix at separators and where letters and digits meet, so that rc10
+// becomes [rc 10]
+func identifiers(s string) []string {
+	var ids []string
+	start := 0
+	for n := 0; n <= len(s); n++ {
+		switch {
+		case n == len(s) || strings.ContainsRune(".-_~", rune(s[n])):
+			if n > start {
+				ids = append(ids, s[start:n])
+			}
+			start = n + 1
+		case n > start && isDigit(s[n]) != isDigit(s[n-1]):
+			ids = append(ids, s[start:n])
+			start = n
+		}
+	}
+	return ids
+}
+
+// leadingWord returns the letters a suffix starts with
+func leadingWord(s string) string {
+	n := 0
+	for n < len(s) && !isDigit(s[n]) && !strings.ContainsRune(".-_~", rune(s[n])) {
+		n++
+	}
+	return s[:n]
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
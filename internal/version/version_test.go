@@ -0,0 +1,107 @@
+package version
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	for _, tc := range []struct {
+		input                       string
+		release                     []int
+		prerelease, revision, build string
+	}{
+		{input: "1.2.3", release: []int{1, 2, 3}},
+		{input: "v3.2.0-dev", release: []int{3, 2, 0}, prerelease: "dev"},
+		{input: "V2.0", release: []int{2, 0}},
+		{input: "1.2", release: []int{1, 2}},
+		{input: "2024.06.01", release: []int{2024, 6, 1}},
+		{input: "1.2.3-ubuntu2", release: []int{1, 2, 3}, revision: "ubuntu2"},
+		{input: "3.12.0rc1", release: []int{3, 12, 0}, prerelease: "rc1"},
+		{input: "1.0.0-beta.2", release: []int{1, 0, 0}, prerelease: "beta.2"},
+		{input: "0.9.0~alpha1", release: []int{0, 9, 0}, prerelease: "alpha1"},
+		{input: "1.9.2+b1", release: []int{1, 9, 2}, build: "b1"},
+		{input: "2.0.0-rc.1+build.5", release: []int{2, 0, 0}, prerelease: "rc.1", build: "build.5"},
+		{input: "1.76.0-nightly", release: []int{1, 76, 0}, prerelease: "nightly"},
+		{input: "  20.11.1\n", release: []int{20, 11, 1}},
+		{input: "9.4p1", release: []int{9, 4}, revision: "p1"},
+	} {
+		t.Run(tc.input, func(t *testing.T) {
+			v, err := Parse(tc.input)
+			if tc.release == nil {
+				var parseErr *ParseError
+				if !errors.As(err, &parseErr) || parseErr.Input != tc.input {
+					t.Fatalf("Parse error = %v, want a *ParseError for %q", err, tc.input)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(v.Release, tc.release) || v.Prerelease != tc.prerelease || v.Revision != tc.revision || v.Build != tc.build {
+				t.Errorf("Parse = %+v, want release %v, prerelease %q, revision %q and build %q", v, tc.release, tc.prerelease, tc.revision, tc.build)
+			}
+		})
+	}
+}
+
+func TestParseRejectsNonVersions(t *testing.T) {
+	for _, input := range []string{"", "latest", "stable", "v", "1.", "1.2.", "unknown option --version", "go version devel"} {
+		var parseErr *ParseError
+		if _, err := Parse(input); !errors.As(err, &parseErr) {
+			t.Errorf("Parse(%q) error = %v, want a *ParseError", input, err)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	for _, tc := range []struct {
+		a, b string
+		want int
+	}{
+		{"1.2.3", "1.2.3", 0},
+		{"v1.2.3", "1.2.3", 0},
+		{"1.2", "1.2.0", 0},
+		{"1.2.0.0", "1.2", 0},
+		{"1.2.3", "1.2.4", -1},
+		{"1.10.0", "1.9.9", 1},
+		{"2.0", "1.99.99", 1},
+		{"2024.06.01", "2024.6.1", 0},
+		{"2024.06.01", "2024.10.01", -1},
+		{"1.22.1", "1.22.1+build.7", 0},
+		{"3.2.0-dev", "3.2.0", -1},
+		{"3.2.0-dev", "3.1.9", 1},
+		{"1.0.0-alpha", "1.0.0-beta", -1},
+		{"1.0.0-beta.2", "1.0.0-beta.11", -1},
+		{"1.0.0-beta", "1.0.0-beta.2", -1},
+		{"1.0.0-rc1", "1.0.0-beta9", 1},
+		{"3.12.0rc1", "3.12.0", -1},
+		{"1.76.0-nightly", "1.76.0-alpha", -1},
+		{"1.2.3-ubuntu2", "1.2.3", 1},
+		{"1.2.3-ubuntu2", "1.2.3-ubuntu10", -1},
+		{"1.2.3-ubuntu2", "1.2.4", -1},
+		{"1.2.3-rc1", "1.2.3-ubuntu1", -1},
+		{"9.4p1", "9.6p1", -1},
+	} {
+		got, err := Compare(tc.a, tc.b)
+		if err != nil || got != tc.want {
+			t.Errorf("Compare(%q, %q) = %d, %v; want %d", tc.a, tc.b, got, err, tc.want)
+		}
+		if back, err := Compare(tc.b, tc.a); err != nil || back != -tc.want {
+			t.Errorf("Compare(%q, %q) = %d, %v; want %d", tc.b, tc.a, back, err, -tc.want)
+		}
+	}
+}
+
+func TestCompareReportsTheStringThatIsNotAVersion(t *testing.T) {
+	for _, tc := range []struct{ a, b, bad string }{
+		{"latest", "1.2.3", "latest"},
+		{"1.2.3", "nightly", "nightly"},
+	} {
+		var parseErr *ParseError
+		if _, err := Compare(tc.a, tc.b); !errors.As(err, &parseErr) || parseErr.Input != tc.bad {
+			t.Errorf("Compare(%q, %q) error = %v, want a *ParseError for %q", tc.a, tc.b, err, tc.bad)
+		}
+	}
+}